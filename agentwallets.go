@@ -0,0 +1,128 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"oaw/openclaw"
+	worktracker "oaw/tracker"
+)
+
+// ============ Agent 钱包映射 ============
+
+// config.json 的 agent_wallets 将 OpenClaw Agent 映射到各自的 OAW 钱包，
+// 该 Agent 的记录归属此钱包并用其私钥签名；未映射的 Agent 使用默认钱包
+
+// setTrackerSigners 设置追踪器的签名私钥: 默认钱包与 agent_wallets 中各 Agent 的钱包
+func setTrackerSigners(tracker *worktracker.Tracker) error {
+	if w, err := LoadWallet(dataDir+"/wallets", "default"); err == nil && w.Private != "" {
+		if err := tracker.SetSigner(w.Private); err != nil {
+			return err
+		}
+	}
+	for agent, name := range openclaw.AgentWallets {
+		w, err := LoadWallet(dataDir+"/wallets", name)
+		if err != nil || w.Private == "" {
+			return fmt.Errorf("Agent %s 的钱包 %s 不存在 (oaw wallet create %s)", agent, name, name)
+		}
+		if err := tracker.SetAgentSigner(agent, w.Private); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walletKeys 按钱包名称加载并缓存私钥 ("" 为默认钱包)，钱包不存在时返回 nil
+type walletKeys map[string]*ecdsa.PrivateKey
+
+func (k walletKeys) get(name string) *ecdsa.PrivateKey {
+	if name == "" {
+		name = "default"
+	}
+	if key, ok := k[name]; ok {
+		return key
+	}
+	var key *ecdsa.PrivateKey
+	if w, err := LoadWallet(dataDir+"/wallets", name); err == nil && w.Private != "" {
+		key, _ = crypto.HexToECDSA(strings.TrimPrefix(w.Private, "0x"))
+	}
+	k[name] = key
+	return key
+}
+
+// recordKey 同步记录归属钱包的私钥 (记录未标注钱包时按当前 agent_wallets 映射)
+func (k walletKeys) recordKey(r openclaw.WorkRecord) *ecdsa.PrivateKey {
+	name := r.Wallet
+	if name == "" {
+		name = openclaw.WalletFor(r.AgentID, r.Agent)
+	}
+	return k.get(name)
+}
+
+// recordWallet 记录归属的钱包名称 (默认钱包为 "default")
+func recordWallet(agentID, agent, wallet string) string {
+	if wallet == "" {
+		wallet = openclaw.WalletFor(agentID, agent)
+	}
+	if wallet == "" {
+		wallet = "default"
+	}
+	return wallet
+}
+
+// walletShare 归属某个钱包的工作量
+type walletShare struct {
+	Wallet  string
+	Agents  map[string]bool
+	Records int
+	Tokens  int64
+	Value   float64
+}
+
+// walletShares 按归属钱包汇总同步记录与追踪器记录的工作量，按价值降序
+func walletShares(tracker *worktracker.Tracker) []*walletShare {
+	shares := map[string]*walletShare{}
+	add := func(wallet, agent string, records int, tokens int64, value float64) {
+		s, ok := shares[wallet]
+		if !ok {
+			s = &walletShare{Wallet: wallet, Agents: map[string]bool{}}
+			shares[wallet] = s
+		}
+		if agent != "" {
+			s.Agents[agent] = true
+		}
+		s.Records += records
+		s.Tokens += tokens
+		s.Value += value
+	}
+
+	records, _ := openclaw.LoadRecords(dataDir + "/records")
+	for _, r := range records {
+		agent := r.AgentID
+		if agent == "" {
+			agent = r.Agent
+		}
+		add(recordWallet(r.AgentID, r.Agent, r.Wallet), agent, 1, int64(r.TotalTokens), r.Value)
+	}
+	if tracker != nil {
+		for _, a := range tracker.AgentBreakdown() {
+			add(recordWallet(a.AgentID, "", ""), a.AgentID, a.Tasks, a.TotalTokens, a.TotalValue)
+		}
+	}
+
+	list := make([]*walletShare, 0, len(shares))
+	for _, s := range shares {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Value != list[j].Value {
+			return list[i].Value > list[j].Value
+		}
+		return list[i].Wallet < list[j].Wallet
+	})
+	return list
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"oaw/mining"
+)
+
+// DefaultConfirmations 奖励视为已确认所需的确认数
+const DefaultConfirmations = 6
+
+// 区块时间戳校验
+const (
+	MaxFutureBlockTime = 2 * 60 // 允许区块时间超前本地时间的最大秒数
+	MedianTimeSpan     = 11     // 计算中位时间的最近区块数
+)
+
+// PoWWorkProof PoW 区块的 WorkProof
+const PoWWorkProof = "pow"
+
+// DifficultyWindow 难度调整时计算平均出块间隔的最近区块数
+const DifficultyWindow = 10
+
+// blockPoWHash PoW 区块哈希: 只覆盖确定的区块头字段与随机数，任何节点都可由区块重算
+func blockPoWHash(b Block) string {
+	data := fmt.Sprintf("%d|%d|%s|%s|%f|%s|%d",
+		b.Index,
+		b.Timestamp,
+		b.Previous,
+		b.Miner,
+		b.Value,
+		b.WorkProof,
+		b.Nonce)
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
+
+// validateBlockWork 校验区块的出块凭证: 版本 2 起区块哈希须可由区块头重算 (权益区块为区块时间戳所在时隙的抽签哈希)，
+// PoW 区块的哈希须满足区块难度的目标，难度不得低于网络最小难度 (旧区块未记录难度，按最小难度校验)。
+// recompute 为 false 时 (已裁剪的区块头) 只校验难度。依赖链历史的校验见 validateBlockDifficulty 与 validateStakeBlock
+func validateBlockWork(b Block, recompute bool) error {
+	if slot, ok := strings.CutPrefix(b.WorkProof, StakeWorkProofPrefix); ok {
+		if !recompute || b.effectiveVersion() < PoWHashVersion {
+			return nil
+		}
+		n, err := strconv.ParseInt(slot, 10, 64)
+		if err != nil || n != stakeSlot(b.Timestamp) {
+			return fmt.Errorf("区块 #%d 权益时隙 %q 与区块时间戳不符", b.Index, slot)
+		}
+		if stakeKernelHash(b.Previous, b.Miner, n) != b.Hash {
+			return fmt.Errorf("区块 #%d 权益抽签哈希不匹配", b.Index)
+		}
+		return nil
+	}
+
+	difficulty := b.Difficulty
+	if difficulty == 0 && b.effectiveVersion() < PoWHashVersion {
+		difficulty = activeNetwork.MinDifficulty
+	}
+	if difficulty < activeNetwork.MinDifficulty {
+		return fmt.Errorf("区块 #%d 难度 %d 低于网络最小难度 %d", b.Index, difficulty, activeNetwork.MinDifficulty)
+	}
+	if recompute && b.effectiveVersion() >= PoWHashVersion && blockPoWHash(b) != b.Hash {
+		return fmt.Errorf("区块 #%d 哈希与区块头不匹配", b.Index)
+	}
+	if !mining.MeetsTarget(b.Hash, mining.DifficultyTarget(difficulty)) {
+		return fmt.Errorf("区块 #%d 哈希未达到难度 %d 的目标", b.Index, difficulty)
+	}
+	return nil
+}
+
+// expectedDifficulty 接在 prior 之后、时间戳为 timestamp 的 PoW 区块应使用的难度，只由链历史决定，各节点结果一致:
+// 以最近一个记录难度的区块为基准 (没有时为初始难度)，最近 DifficultyWindow 个区块到本区块的平均出块间隔
+// 小于出块间隔一半时加 1，超过两倍时减 1，限制在网络难度范围内
+func expectedDifficulty(prior []Block, timestamp int64) int {
+	difficulty := activeNetwork.InitialDifficulty
+	for i := len(prior) - 1; i >= 0; i-- {
+		if prior[i].Difficulty > 0 {
+			difficulty = prior[i].Difficulty
+			break
+		}
+	}
+
+	if len(prior) > 0 {
+		start := len(prior) - DifficultyWindow
+		if start < 0 {
+			start = 0
+		}
+		avg := (timestamp - prior[start].Timestamp) / int64(len(prior)-start)
+		interval := int64(activeNetwork.BlockInterval / time.Second)
+		switch {
+		case avg < interval/2:
+			difficulty++
+		case avg > interval*2:
+			difficulty--
+		}
+	}
+
+	if difficulty < activeNetwork.MinDifficulty {
+		difficulty = activeNetwork.MinDifficulty
+	}
+	if difficulty > activeNetwork.MaxDifficulty {
+		difficulty = activeNetwork.MaxDifficulty
+	}
+	return difficulty
+}
+
+// validateBlockDifficulty 版本 2 起的 PoW 区块难度须等于由前序区块推出的难度 (权益区块与旧区块不校验)
+func validateBlockDifficulty(b Block, prior []Block) error {
+	if strings.HasPrefix(b.WorkProof, StakeWorkProofPrefix) || b.effectiveVersion() < PoWHashVersion {
+		return nil
+	}
+	if want := expectedDifficulty(prior, b.Timestamp); b.Difficulty != want {
+		return fmt.Errorf("区块 #%d 难度 %d 与链历史推出的难度 %d 不符", b.Index, b.Difficulty, want)
+	}
+	return nil
+}
+
+// blockSigningHash 计算区块签名摘要 (覆盖矿工地址与奖励，防止篡改)
+func blockSigningHash(b Block) []byte {
+	data := fmt.Sprintf("%d|%d|%s|%s|%s|%f|%s",
+		b.Index,
+		b.Timestamp,
+		b.WorkProof,
+		b.Previous,
+		b.Miner,
+		b.Value,
+		b.Hash)
+	if b.RewardTo != "" {
+		data += "|" + b.RewardTo // 兼容旧区块: 仅在指定收款地址时纳入签名
+	}
+	for _, tx := range b.Transactions {
+		data += "|" + tx.Hash // 交易纳入签名，防止篡改
+	}
+	if v := b.effectiveVersion(); v > 1 {
+		data += fmt.Sprintf("|v%d", v) // 新版本区块头的版本号纳入签名
+	}
+	return crypto.Keccak256([]byte(data))
+}
+
+// Payee 区块奖励的收款地址 (未指定时为矿工地址)
+func (b Block) Payee() string {
+	if b.RewardTo != "" {
+		return b.RewardTo
+	}
+	return b.Miner
+}
+
+// SignBlock 使用矿工钱包私钥签名区块
+func SignBlock(b *Block, privateKeyHex string) error {
+	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	if err != nil {
+		return fmt.Errorf("私钥解析失败: %w", err)
+	}
+
+	sig, err := crypto.Sign(blockSigningHash(*b), privateKey)
+	if err != nil {
+		return fmt.Errorf("签名失败: %w", err)
+	}
+
+	b.Signature = "0x" + hex.EncodeToString(sig)
+	return nil
+}
+
+// VerifyBlockSignature 验证区块签名者与 Miner 地址一致
+func VerifyBlockSignature(b Block) error {
+	if b.Signature == "" {
+		return fmt.Errorf("区块 #%d 缺少签名", b.Index)
+	}
+
+	sig, err := hex.DecodeString(strings.TrimPrefix(b.Signature, "0x"))
+	if err != nil {
+		return fmt.Errorf("区块 #%d 签名格式错误: %w", b.Index, err)
+	}
+
+	pub, err := crypto.SigToPub(blockSigningHash(b), sig)
+	if err != nil {
+		return fmt.Errorf("区块 #%d 签名恢复失败: %w", b.Index, err)
+	}
+
+	signer := crypto.PubkeyToAddress(*pub).Hex()
+	if !strings.EqualFold(signer, b.Miner) {
+		return fmt.Errorf("区块 #%d 签名者 %s 与矿工 %s 不一致", b.Index, signer, b.Miner)
+	}
+	return nil
+}
+
+// VerifyChain 校验区块链接关系、时间戳、工作量与每个区块的签名
+func (m *Miner) VerifyChain() error {
+	now := time.Now()
+	for i, b := range m.blocks {
+		if b.Index != i {
+			return fmt.Errorf("区块 #%d 高度错误: %d", i, b.Index)
+		}
+		if i > 0 && b.Previous != m.blocks[i-1].Hash {
+			return fmt.Errorf("区块 #%d 前序哈希不匹配", b.Index)
+		}
+		if err := validateBlockVersion(b); err != nil {
+			return err
+		}
+		if err := validateTimestamp(b, m.blocks[:i], now); err != nil {
+			return err
+		}
+		pruned := m.snapshot != nil && b.Index < m.snapshot.Height
+		if err := validateBlockWork(b, !pruned); err != nil {
+			return err
+		}
+		if err := validateBlockDifficulty(b, m.blocks[:i]); err != nil {
+			return err
+		}
+		if pruned {
+			continue // 已裁剪，仅保留区块头
+		}
+		if err := validateStakeBlock(b, m.blocks[:i]); err != nil {
+			return err
+		}
+		if err := VerifyBlockSignature(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// medianTimePast 最近 MedianTimeSpan 个区块时间戳的中位数
+func medianTimePast(blocks []Block) int64 {
+	start := len(blocks) - MedianTimeSpan
+	if start < 0 {
+		start = 0
+	}
+	times := make([]int64, 0, len(blocks)-start)
+	for _, b := range blocks[start:] {
+		times = append(times, b.Timestamp)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return times[len(times)/2]
+}
+
+// validateTimestamp 拒绝时间超前过多或早于最近区块中位时间的区块
+func validateTimestamp(b Block, prior []Block, now time.Time) error {
+	if b.Timestamp > now.Unix()+MaxFutureBlockTime {
+		return fmt.Errorf("区块 #%d 时间戳超前: %s", b.Index, time.Unix(b.Timestamp, 0).Format(time.RFC3339))
+	}
+	if len(prior) == 0 {
+		return nil
+	}
+	if median := medianTimePast(prior); b.Timestamp < median {
+		return fmt.Errorf("区块 #%d 时间戳早于最近区块中位时间 (%d < %d)", b.Index, b.Timestamp, median)
+	}
+	return nil
+}
+
+// GetBalance 获取至少有 confirmations 个确认的奖励余额
+// 区块自身算 1 个确认，confirmations <= 0 时包含全部区块
+func (m *Miner) GetBalance(confirmations int) float64 {
+	var total float64
+	if m.snapshot != nil {
+		total = m.snapshot.Balances[m.payee()]
+	}
+	height := len(m.blocks)
+	for _, b := range m.blocks {
+		if b.Payee() != m.payee() || m.snapshot.covers(b) {
+			continue
+		}
+		if height-b.Index >= confirmations {
+			total += b.Value
+		}
+	}
+	return total
+}
+
+// PendingBalance 获取未达到确认数的奖励余额
+func (m *Miner) PendingBalance(confirmations int) float64 {
+	return m.GetBalance(0) - m.GetBalance(confirmations)
+}
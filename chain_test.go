@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"oaw/mining"
+)
+
+// testSigner 测试用矿工钱包 (地址与十六进制私钥)
+type testSigner struct {
+	Address string
+	Private string
+}
+
+func newTestSigner(t *testing.T) testSigner {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testSigner{Address: crypto.PubkeyToAddress(key.PublicKey).Hex(), Private: hex.EncodeToString(crypto.FromECDSA(key))}
+}
+
+// minePoWBlock 在 prior 之后按链历史推出的难度挖出并签名一个 PoW 区块
+func minePoWBlock(t *testing.T, prior []Block, timestamp int64, s testSigner) Block {
+	t.Helper()
+	b := Block{Version: CurrentBlockVersion, Index: len(prior), Timestamp: timestamp, Miner: s.Address,
+		Value: BaseBlockReward, WorkProof: PoWWorkProof, Difficulty: expectedDifficulty(prior, timestamp)}
+	if len(prior) > 0 {
+		b.Previous = prior[len(prior)-1].Hash
+	}
+	solveTestBlock(t, &b)
+	if err := SignBlock(&b, s.Private); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// solveTestBlock 搜索满足 b.Difficulty 的随机数并写入哈希
+func solveTestBlock(t *testing.T, b *Block) {
+	t.Helper()
+	target := mining.DifficultyTarget(b.Difficulty)
+	for b.Nonce = 0; b.Nonce < 1<<24; b.Nonce++ {
+		if b.Hash = blockPoWHash(*b); mining.MeetsTarget(b.Hash, target) {
+			return
+		}
+	}
+	t.Fatalf("难度 %d 未找到随机数", b.Difficulty)
+}
+
+// testChain 以 gap 秒的间隔挖出 n 个区块
+func testChain(t *testing.T, s testSigner, start int64, gap int64, n int) []Block {
+	t.Helper()
+	var blocks []Block
+	for i := 0; i < n; i++ {
+		blocks = append(blocks, minePoWBlock(t, blocks, start+int64(i)*gap, s))
+	}
+	return blocks
+}
+
+func TestExpectedDifficulty(t *testing.T) {
+	const start = 1_700_000_000
+	interval := int64(activeNetwork.BlockInterval.Seconds())
+	withDifficulty := func(d int, times ...int64) []Block {
+		var blocks []Block
+		for i, ts := range times {
+			blocks = append(blocks, Block{Index: i, Timestamp: ts, Difficulty: d})
+		}
+		return blocks
+	}
+
+	tests := []struct {
+		name      string
+		prior     []Block
+		timestamp int64
+		want      int
+	}{
+		{"创世区块使用初始难度", nil, start, activeNetwork.InitialDifficulty},
+		{"出块间隔正常保持难度", withDifficulty(5, start, start+interval), start + 2*interval, 5},
+		{"出块过快加 1", withDifficulty(5, start, start+1), start + 2, 6},
+		{"出块过慢减 1", withDifficulty(5, start, start+3*interval), start + 6*interval, 4},
+		{"不超过最大难度", withDifficulty(activeNetwork.MaxDifficulty, start, start+1), start + 2, activeNetwork.MaxDifficulty},
+		{"不低于最小难度", withDifficulty(activeNetwork.MinDifficulty, start), start + 10*interval, activeNetwork.MinDifficulty},
+		{"旧区块未记录难度时以初始难度为基准", withDifficulty(0, start), start + interval, activeNetwork.InitialDifficulty},
+		{"跳过未记录难度的权益区块",
+			append(withDifficulty(6, start), Block{Index: 1, Timestamp: start + interval, WorkProof: StakeWorkProofPrefix + "1"}),
+			start + 2*interval, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expectedDifficulty(tt.prior, tt.timestamp); got != tt.want {
+				t.Fatalf("难度 %d，期望 %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateBlockWork(t *testing.T) {
+	s := newTestSigner(t)
+	chain := testChain(t, s, 1_700_000_000, 10, 2)
+	good := minePoWBlock(t, chain, 1_700_000_020, s)
+
+	tests := []struct {
+		name    string
+		block   func() Block
+		wantErr string
+	}{
+		{"有效区块", func() Block { return good }, ""},
+		{"篡改奖励后哈希不匹配", func() Block {
+			b := good
+			b.Value = MaxBlockValue
+			return b
+		}, "哈希与区块头不匹配"},
+		{"伪造哈希未达到目标", func() Block {
+			b := good
+			b.Nonce++
+			b.Hash = blockPoWHash(b)
+			for mining.MeetsTarget(b.Hash, mining.DifficultyTarget(b.Difficulty)) {
+				b.Nonce++
+				b.Hash = blockPoWHash(b)
+			}
+			return b
+		}, "未达到难度"},
+		{"难度低于网络最小难度", func() Block {
+			b := good
+			b.Difficulty = activeNetwork.MinDifficulty - 1
+			return b
+		}, "低于网络最小难度"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBlockWork(tt.block(), true)
+			checkErr(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateBlockDifficulty(t *testing.T) {
+	s := newTestSigner(t)
+	chain := testChain(t, s, 1_700_000_000, 10, 3)
+	next := int64(1_700_000_030)
+
+	easy := Block{Version: CurrentBlockVersion, Index: len(chain), Timestamp: next, Previous: chain[len(chain)-1].Hash,
+		Miner: s.Address, Value: BaseBlockReward, WorkProof: PoWWorkProof, Difficulty: activeNetwork.MinDifficulty}
+	solveTestBlock(t, &easy)
+
+	tests := []struct {
+		name    string
+		block   Block
+		wantErr string
+	}{
+		{"按链历史难度挖出", minePoWBlock(t, chain, next, s), ""},
+		{"自定的低难度", easy, "与链历史推出的难度"},
+		{"旧版本区块不校验", Block{Version: 1, Index: len(chain), Timestamp: next, WorkProof: PoWWorkProof}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkErr(t, validateBlockDifficulty(tt.block, chain), tt.wantErr)
+		})
+	}
+
+	// 对等节点以自定难度挖出的区块被拒绝
+	m := &Miner{blocks: append([]Block(nil), chain...), dataDir: t.TempDir()}
+	if err := SignBlock(&easy, s.Private); err != nil {
+		t.Fatal(err)
+	}
+	checkErr(t, m.AcceptBlock(easy), "与链历史推出的难度")
+}
+
+func TestVerifyBlockSignature(t *testing.T) {
+	s, other := newTestSigner(t), newTestSigner(t)
+	signed := func(edit func(b *Block)) Block {
+		b := Block{Version: CurrentBlockVersion, Index: 3, Timestamp: 1_700_000_000, WorkProof: PoWWorkProof,
+			Previous: "00ab", Miner: s.Address, Value: BaseBlockReward, Hash: "00cd",
+			Transactions: []Transaction{{Hash: "0x01", From: s.Address, To: other.Address, Amount: 1}}}
+		if err := SignBlock(&b, s.Private); err != nil {
+			t.Fatal(err)
+		}
+		if edit != nil {
+			edit(&b)
+		}
+		return b
+	}
+
+	tests := []struct {
+		name    string
+		block   Block
+		wantErr string
+	}{
+		{"有效签名", signed(nil), ""},
+		{"缺少签名", signed(func(b *Block) { b.Signature = "" }), "缺少签名"},
+		{"签名不是十六进制", signed(func(b *Block) { b.Signature = "0xzz" }), "签名格式错误"},
+		{"签名长度错误", signed(func(b *Block) { b.Signature = b.Signature[:20] }), "签名恢复失败"},
+		{"篡改奖励", signed(func(b *Block) { b.Value = MaxBlockValue }), "与矿工"},
+		{"篡改收款地址", signed(func(b *Block) { b.RewardTo = other.Address }), "与矿工"},
+		{"篡改区块哈希", signed(func(b *Block) { b.Hash = "00ef" }), "与矿工"},
+		{"篡改交易", signed(func(b *Block) { b.Transactions[0].Hash = "0x02" }), "与矿工"},
+		{"篡改版本", signed(func(b *Block) { b.Version = CurrentBlockVersion + 1 }), "与矿工"},
+		{"冒用矿工地址", signed(func(b *Block) { SignBlock(b, other.Private) }), "与矿工"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkErr(t, VerifyBlockSignature(tt.block), tt.wantErr)
+		})
+	}
+
+	if err := SignBlock(&Block{}, "not-a-key"); err == nil {
+		t.Fatal("无效私钥应签名失败")
+	}
+}
+
+// checkErr wantErr 为空时要求无错误，否则要求错误包含 wantErr
+func checkErr(t *testing.T, err error, wantErr string) {
+	t.Helper()
+	switch {
+	case wantErr == "" && err != nil:
+		t.Fatalf("意外错误: %v", err)
+	case wantErr != "" && err == nil:
+		t.Fatalf("期望错误包含 %q，实际无错误", wantErr)
+	case wantErr != "" && !strings.Contains(err.Error(), wantErr):
+		t.Fatalf("错误 %q 不包含 %q", err, wantErr)
+	}
+}
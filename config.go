@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	integrator "oaw/integrator"
+	"oaw/openclaw"
+	worktracker "oaw/tracker"
+)
+
+// Config 节点配置 (data/config.json)
+type Config struct {
+	Peers []string `json:"peers"`           // 对等节点地址 (如 http://10.0.0.2:8091)
+	Hooks []Hook   `json:"hooks,omitempty"` // 新区块事件钩子
+
+	RetentionMonths int      `json:"retention_months,omitempty"` // 原始工作记录保留月数 (0 为默认 12)
+	Valuator        string   `json:"valuator,omitempty"`         // 追踪器记录的价值策略 (如 token+lines+duration:0.5)
+	QualityWeight   *float64 `json:"quality_weight,omitempty"`   // 质量评分对价值的影响 (默认 1，0 为不影响)
+	Timezone        string   `json:"timezone,omitempty"`         // 按日/周/月统计与报告的时区 (如 Asia/Shanghai 或 +08:00，默认本地时区)
+
+	TaskTypes       []worktracker.TaskTypeDef         `json:"task_types,omitempty"`       // 自定义任务类型 (名称、权重、分类关键词)
+	Classifier      *worktracker.ClassifierConfig     `json:"classifier,omitempty"`       // 任务分类器 (规则文件、可选的大模型分类)
+	Pricing         map[string]worktracker.ModelPrice `json:"pricing,omitempty"`          // 模型 token 单价 (美元/百万 token，"*" 为默认)，优先于 OpenClaw 配置
+	OpenClawPricing *bool                             `json:"openclaw_pricing,omitempty"` // 是否读取 OpenClaw 配置中的模型单价 (默认是)
+	RateLimits      map[string]worktracker.RateLimit  `json:"rate_limits,omitempty"`      // 按 Agent 的记录限流 ("*" 为默认)，超出部分合并为聚合记录
+
+	EncryptRecords string `json:"encrypt_records,omitempty"` // 记录敏感字段加密: "wallet" (由默认钱包派生密钥) 或数据密钥文件路径
+
+	OpenClawHome   string                  `json:"openclaw_home,omitempty"`   // OpenClaw 数据目录 (为空时自动检测)
+	OpenClawLayout string                  `json:"openclaw_layout,omitempty"` // 会话文件路径模板，如 agents/{agent}/sessions/sessions.json
+	OpenClawAgents *openclaw.AgentFilter   `json:"openclaw_agents,omitempty"` // 同步的 Agent (include/exclude 通配符)
+	OpenClawToken  string                  `json:"openclaw_token,omitempty"`  // OpenClaw API 令牌 (为空时读取 $OPENCLAW_TOKEN 或 OpenClaw 配置)
+	AgentWallets   map[string]string       `json:"agent_wallets,omitempty"`   // OpenClaw Agent → 钱包名称 (记录归属与签名，支持通配符)
+	SessionFilter  *openclaw.SessionFilter `json:"session_filter,omitempty"`  // 同步时的会话过滤规则 (Agent、模型、会话类型、最少 token)
+
+	OpenClawInstances []integrator.Instance `json:"openclaw_instances,omitempty"` // oaw poll 轮询的 OpenClaw 实例 (地址、接口、令牌、间隔)
+	IngestToken       string                `json:"ingest_token,omitempty"`       // oaw poll --source push 的推送令牌 (为空时只接受本机请求)
+
+	ModelValues map[string]openclaw.ModelValue `json:"model_values,omitempty"` // OpenClaw 会话按模型的价值倍数与 token 单价 ("*" 为默认)
+	KindBonuses map[string]float64             `json:"kind_bonuses,omitempty"` // OpenClaw 会话按类型 (direct/interactive/cron/subagent/webhook) 的价值加成 (默认 cron 1.5)
+
+	PoleNode     string           `json:"pole_node,omitempty"`     // PoLE 节点地址 (oaw pole config 设置，为空时为 http://127.0.0.1:9090)
+	PoleContract string           `json:"pole_contract,omitempty"` // PoLE 工作记录合约地址 (oaw pole config 设置)
+	PoleNodes    []string         `json:"pole_nodes,omitempty"`    // PoLE 备用节点地址 (主节点不可用时依次切换)
+	PoleLocal    *PoleLocalConfig `json:"pole_local,omitempty"`    // 本机 PoLE 节点与钱包 (安装目录、节点程序与启动参数、钱包文件)
+	PoleABI      string           `json:"pole_abi,omitempty"`      // PoLE 工作记录合约的 ABI JSON 文件 (相对数据目录，为空时使用默认 ABI)
+	PoleGas      *GasConfig       `json:"pole_gas,omitempty"`      // 链上提交的 gas 策略 (估算倍数、上限、gas 价格倍数与上限)
+	PoleSymbol   string           `json:"pole_symbol,omitempty"`   // 链上货币单位 (默认 POLE)
+	PoleDecimals *int             `json:"pole_decimals,omitempty"` // 链上货币的小数位数 (默认 18)
+}
+
+// LoadConfig 加载配置，文件不存在时返回空配置
+func LoadConfig(dir string) (*Config, error) {
+	cfg := &Config{}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadRecordKey 按 encrypt_records 配置取得记录加密密钥
+func loadRecordKey(spec string) ([]byte, error) {
+	if spec == "wallet" {
+		w, err := LoadWallet(dataDir+"/wallets", "default")
+		if err != nil || w.Private == "" {
+			return nil, fmt.Errorf("由钱包派生密钥需要默认钱包 (oaw wallet create)")
+		}
+		return worktracker.DeriveRecordKey(w.Private)
+	}
+	return worktracker.LoadRecordKeyFile(spec)
+}
+
+// Save 保存配置
+func (c *Config) Save(dir string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "config.json"), data, 0644)
+}
+
+// SetPole 设置 PoLE 节点地址与合约地址 (contract 为空时不修改合约地址)
+func (c *Config) SetPole(node, contract string) error {
+	u, err := url.Parse(node)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("无效的节点地址: %q (如 http://127.0.0.1:9090)", node)
+	}
+	if contract != "" && !common.IsHexAddress(contract) {
+		return fmt.Errorf("无效的合约地址: %q", contract)
+	}
+	c.PoleNode = strings.TrimRight(node, "/")
+	if contract != "" {
+		c.PoleContract = common.HexToAddress(contract).Hex()
+	}
+	return nil
+}
+
+// AddPeer 添加对等节点 (已存在时忽略)
+func (c *Config) AddPeer(url string) bool {
+	for _, p := range c.Peers {
+		if p == url {
+			return false
+		}
+	}
+	c.Peers = append(c.Peers, url)
+	return true
+}
+
+// RemovePeer 移除对等节点
+func (c *Config) RemovePeer(url string) bool {
+	for i, p := range c.Peers {
+		if p == url {
+			c.Peers = append(c.Peers[:i], c.Peers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// MinerControl 挖矿守护进程控制文件 (data/miner.json)
+type MinerControl struct {
+	PID       int    `json:"pid"`
+	Addr      string `json:"addr"` // 本地控制端点 (127.0.0.1:port)
+	Address   string `json:"address"`
+	Mode      string `json:"mode"`
+	StartedAt int64  `json:"started_at"`
+}
+
+func controlFilePath(dir string) string {
+	return filepath.Join(dir, "miner.json")
+}
+
+// ReadMinerControl 读取控制文件，守护进程未运行时返回 os.ErrNotExist
+func ReadMinerControl(dir string) (*MinerControl, error) {
+	data, err := os.ReadFile(controlFilePath(dir))
+	if err != nil {
+		return nil, err
+	}
+	var c MinerControl
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (c *MinerControl) save(dir string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(controlFilePath(dir), data, 0600)
+}
+
+func removeMinerControl(dir string) {
+	os.Remove(controlFilePath(dir))
+}
+
+// Status 通过控制端点查询运行中矿工的状态
+func (c *MinerControl) Status() (MineStatus, error) {
+	var st MineStatus
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get("http://" + c.Addr + "/control/status")
+	if err != nil {
+		return st, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	return st, json.NewDecoder(resp.Body).Decode(&st)
+}
+
+// ErrStaleControl 控制文件中的端点不可达，矿工进程已不存在 (崩溃后残留的控制文件)
+var ErrStaleControl = errors.New("控制端点不可达，控制文件已过期")
+
+// Stop 通过控制端点停止运行中的矿工。端点不可达时返回 ErrStaleControl，
+// 不按控制文件中的 PID 结束进程 (PID 可能已被其他进程复用)
+func (c *MinerControl) Stop() error {
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Post("http://"+c.Addr+"/control/stop", "application/json", nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrStaleControl, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: HTTP %d", ErrStaleControl, resp.StatusCode)
+	}
+	return nil
+}
+
+// RunDaemon 在前台运行矿工：写入控制文件并提供本地控制端点，
+// 直到收到 stop 请求或 ctx 取消
+func (m *Miner) RunDaemon(ctx context.Context, cancel context.CancelFunc) error {
+	if c, err := ReadMinerControl(m.dataDir); err == nil {
+		if _, err := c.Status(); err == nil {
+			return fmt.Errorf("挖矿已在运行 (PID: %d)", c.PID)
+		}
+		removeMinerControl(m.dataDir) // 残留的控制文件
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("启动控制端点失败: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/control/status", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(m.Status())
+	})
+	mux.HandleFunc("/control/stop", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		m.Stop()
+		cancel()
+		json.NewEncoder(w).Encode(map[string]bool{"stopped": true})
+	})
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(ln)
+
+	c := &MinerControl{
+		PID:       os.Getpid(),
+		Addr:      ln.Addr().String(),
+		Address:   m.wallet.Address,
+		Mode:      m.mode,
+		StartedAt: time.Now().Unix(),
+	}
+	if err := c.save(m.dataDir); err != nil {
+		srv.Close()
+		return fmt.Errorf("写入控制文件失败: %w", err)
+	}
+	defer removeMinerControl(m.dataDir)
+
+	m.Start(ctx)
+	<-ctx.Done()
+	m.Stop()
+
+	shutdownCtx, done := context.WithTimeout(context.Background(), 2*time.Second)
+	defer done()
+	return srv.Shutdown(shutdownCtx)
+}
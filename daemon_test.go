@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMinerControlStop(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := ln.Addr().String()
+	ln.Close()
+
+	stopped := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"stopped":true}`))
+	}))
+	defer stopped.Close()
+	other := httptest.NewServer(http.NotFoundHandler())
+	defer other.Close()
+
+	tests := []struct {
+		name  string
+		addr  string
+		stale bool
+	}{
+		{"控制端点停止矿工", strings.TrimPrefix(stopped.URL, "http://"), false},
+		{"端点不可达", closedAddr, true},
+		{"端口被其他服务占用", strings.TrimPrefix(other.URL, "http://"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// PID 为测试进程自身: 过期控制文件不得结束该进程
+			c := &MinerControl{PID: os.Getpid(), Addr: tt.addr}
+			err := c.Stop()
+			if got := errors.Is(err, ErrStaleControl); got != tt.stale {
+				t.Fatalf("Stop() = %v，期望过期 %v", err, tt.stale)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// 区块事件
+const (
+	BlockEventMined    = "mined"    // 本地挖出
+	BlockEventReceived = "received" // 从对等节点接收
+)
+
+// Hook 新区块事件钩子：执行 shell 命令或 POST 到 webhook
+type Hook struct {
+	Command string `json:"command,omitempty"` // 区块事件 JSON 通过 stdin 传入，事件类型见 OAW_EVENT
+	URL     string `json:"url,omitempty"`     // 以 POST 发送区块事件 JSON
+}
+
+// BlockEvent 传给钩子的事件内容
+type BlockEvent struct {
+	Event string `json:"event"`
+	Block Block  `json:"block"`
+}
+
+// fireBlockHooks 异步触发配置中的全部钩子
+func (m *Miner) fireBlockHooks(event string, b Block) {
+	cfg, err := LoadConfig(m.dataDir)
+	if err != nil || len(cfg.Hooks) == 0 {
+		return
+	}
+
+	data, _ := json.Marshal(BlockEvent{Event: event, Block: b})
+	for _, h := range cfg.Hooks {
+		go func(h Hook) {
+			if err := h.run(event, data); err != nil {
+				fmt.Printf("  ⚠️ 钩子执行失败: %v\n", err)
+			}
+		}(h)
+	}
+}
+
+func (h Hook) run(event string, data []byte) error {
+	if h.URL != "" {
+		client := &http.Client{Timeout: 10 * time.Second}
+		resp, err := client.Post(h.URL, "application/json", bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("webhook %s: %w", h.URL, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook %s: HTTP %d", h.URL, resp.StatusCode)
+		}
+	}
+
+	if h.Command != "" {
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.Command("cmd", "/C", h.Command)
+		} else {
+			cmd = exec.Command("sh", "-c", h.Command)
+		}
+		cmd.Stdin = bytes.NewReader(data)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Env = append(os.Environ(), "OAW_EVENT="+event)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("命令 %q: %w", h.Command, err)
+		}
+	}
+	return nil
+}
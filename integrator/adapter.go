@@ -0,0 +1,125 @@
+package openclaw
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ============ 工作来源适配器 ============
+
+// SourceAdapter 工作来源适配器: 从某个 Agent 框架读取新的工作事件 (OpenClaw API、Claude Code 会话文件、
+// OpenAI 用量导出等)，由集成器统一分类、计价并记录
+type SourceAdapter interface {
+	// Name 来源名称 (唯一，用于读取位置与状态)
+	Name() string
+	// Collect 读取 since 之后的事件，返回事件与新的读取位置
+	Collect(since time.Time) (events []*Event, next time.Time, err error)
+}
+
+// AdapterPositionsFile 各适配器的读取位置 (位于 SetDataDir 设置的目录)
+const AdapterPositionsFile = "adapter_positions.json"
+
+// StartAdapter 按间隔轮询适配器 (立即读取一次)，新事件交给 StartListener 启动的处理协程
+func (o *OpenClawIntegrator) StartAdapter(a SourceAdapter, interval time.Duration) {
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+
+		delay := interval
+		for {
+			active := o.collectWithRetry(a)
+			delay = o.nextInterval(delay, interval, active)
+			timer := time.NewTimer(delay)
+			select {
+			case <-o.stopChan:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+}
+
+// collectWithRetry 读取失败时按指数退避重试，并更新轮询状态；停止时放弃重试。返回是否读取到新事件
+func (o *OpenClawIntegrator) collectWithRetry(a SourceAdapter) bool {
+	since := o.position(a.Name())
+	events, next, err := a.Collect(since)
+	backoff := pollRetryBackoff
+	for attempt := 1; err != nil && attempt < pollRetries; attempt++ {
+		select {
+		case <-o.stopChan:
+			return false
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		events, next, err = a.Collect(since)
+	}
+	o.observe(a.Name(), err)
+	if err != nil {
+		return false
+	}
+
+	for _, event := range events {
+		if o.enqueue(event, true) != nil {
+			return false // 已停止
+		}
+	}
+	if next.After(since) {
+		o.setPosition(a.Name(), next)
+	}
+	return len(events) > 0
+}
+
+// observe 记录轮询结果，来源持续不可达超过 sessions.UnreachableWarn 时警告
+func (o *OpenClawIntegrator) observe(source string, err error) {
+	o.healthMu.Lock()
+	now := time.Now()
+	wasDown := !o.health.Healthy && !o.health.DownSince.IsZero()
+	if o.health.Observe(err, now) {
+		fmt.Printf("⚠️ %s 已持续 %s 不可达: %v\n", source, o.health.Downtime(now).Round(time.Second), err)
+	} else if wasDown && err == nil {
+		fmt.Printf("✅ %s 已恢复\n", source)
+	}
+	o.health.Source = source
+	h := o.health
+	o.healthMu.Unlock()
+
+	if o.dataDir != "" {
+		if err := h.Save(o.dataDir); err != nil {
+			fmt.Printf("⚠️ 保存轮询状态失败: %v\n", err)
+		}
+	}
+}
+
+// position 适配器上次读取到的位置 (首次读取时为零值)
+func (o *OpenClawIntegrator) position(name string) time.Time {
+	o.positionsMu.Lock()
+	defer o.positionsMu.Unlock()
+	if o.positions == nil && o.dataDir != "" {
+		data, err := os.ReadFile(filepath.Join(o.dataDir, AdapterPositionsFile))
+		if err == nil {
+			json.Unmarshal(data, &o.positions)
+		}
+	}
+	return o.positions[name]
+}
+
+// setPosition 前移读取位置并保存
+func (o *OpenClawIntegrator) setPosition(name string, at time.Time) {
+	o.positionsMu.Lock()
+	defer o.positionsMu.Unlock()
+	if o.positions == nil {
+		o.positions = map[string]time.Time{}
+	}
+	o.positions[name] = at
+	if o.dataDir == "" {
+		return
+	}
+	data, _ := json.MarshalIndent(o.positions, "", "  ")
+	if err := os.WriteFile(filepath.Join(o.dataDir, AdapterPositionsFile), data, 0644); err != nil {
+		fmt.Printf("⚠️ 保存读取位置失败: %v\n", err)
+	}
+}
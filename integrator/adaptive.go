@@ -0,0 +1,66 @@
+package openclaw
+
+import (
+	"fmt"
+	"time"
+)
+
+// ============ 自适应轮询 ============
+
+// 设置了间隔范围 (SetAdaptive 或 Instance.MinInterval/MaxInterval) 时，轮询间隔随会话活动调整:
+// 读取到新事件后立即缩短到下限，连续空闲 (或读取失败) 时逐次加倍直到上限。
+// 会话活跃时接近实时记录，空闲时减少对 OpenClaw 的请求
+
+// 只启用自适应轮询时的默认范围: 下限为间隔的 1/AdaptiveSpeedup (不低于 MinAdaptiveInterval)，上限为间隔的 AdaptiveSlowdown 倍
+const (
+	AdaptiveSpeedup     = 6
+	AdaptiveSlowdown    = 4
+	MinAdaptiveInterval = time.Second
+)
+
+// DefaultAdaptiveRange 以 interval 为基准的默认间隔范围
+func DefaultAdaptiveRange(interval time.Duration) (min, max time.Duration) {
+	min = interval / AdaptiveSpeedup
+	if min < MinAdaptiveInterval {
+		min = MinAdaptiveInterval
+	}
+	max = interval * AdaptiveSlowdown
+	if max < min {
+		max = min
+	}
+	return min, max
+}
+
+// SetAdaptive 设置自适应轮询的间隔范围 (均为 0 时按固定间隔轮询)，需在 StartPolling / StartAdapter 之前调用
+func (o *OpenClawIntegrator) SetAdaptive(min, max time.Duration) error {
+	if min < 0 || max < 0 || (max > 0 && min > max) {
+		return fmt.Errorf("无效的轮询间隔范围: %s ~ %s", min, max)
+	}
+	o.minInterval, o.maxInterval = min, max
+	return nil
+}
+
+// nextInterval 下一次轮询前的等待时间: active 为本次是否读取到新事件
+func (o *OpenClawIntegrator) nextInterval(cur, base time.Duration, active bool) time.Duration {
+	if o.minInterval == 0 && o.maxInterval == 0 {
+		return base
+	}
+	min, max := o.minInterval, o.maxInterval
+	if min == 0 {
+		min = base
+	}
+	if max == 0 {
+		max = base
+	}
+	if active {
+		return min
+	}
+	next := cur * 2
+	if next < min {
+		next = min
+	}
+	if next > max {
+		next = max
+	}
+	return next
+}
@@ -0,0 +1,186 @@
+package openclaw
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ============ Claude Code 会话文件 ============
+
+// ClaudeCodeAdapter 读取 Claude Code 的会话文件 (~/.claude/projects/<项目>/<会话>.jsonl)，
+// 每条带用量的助手消息生成一个事件，工具结果 (tool_result) 回填到对应的工具调用
+type ClaudeCodeAdapter struct {
+	Dir     string // 会话目录 (默认 ~/.claude/projects)
+	AgentID string // 记录使用的 Agent ID (默认 claude-code)
+
+	seen map[string]bool // 已生成事件的消息 ID (同一消息可能分多行写入)
+}
+
+// NewClaudeCodeAdapter 创建 Claude Code 适配器，dir 为空时使用 ~/.claude/projects
+func NewClaudeCodeAdapter(dir string) *ClaudeCodeAdapter {
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".claude", "projects")
+	}
+	return &ClaudeCodeAdapter{Dir: dir, AgentID: "claude-code", seen: map[string]bool{}}
+}
+
+func (a *ClaudeCodeAdapter) Name() string { return "claude-code" }
+
+// claudeLine 会话文件的一行
+type claudeLine struct {
+	Type      string    `json:"type"` // user/assistant/summary...
+	SessionID string    `json:"sessionId"`
+	Timestamp time.Time `json:"timestamp"`
+	Message   struct {
+		ID      string          `json:"id"`
+		Model   string          `json:"model"`
+		Content json.RawMessage `json:"content"` // 字符串或内容块数组
+		Usage   *struct {
+			InputTokens         int64 `json:"input_tokens"`
+			OutputTokens        int64 `json:"output_tokens"`
+			CacheReadTokens     int64 `json:"cache_read_input_tokens"`
+			CacheCreationTokens int64 `json:"cache_creation_input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// claudeBlock 消息内容块
+type claudeBlock struct {
+	Type      string          `json:"type"` // text/tool_use/tool_result
+	Text      string          `json:"text"`
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+	ToolUseID string          `json:"tool_use_id"`
+	Content   json.RawMessage `json:"content"`
+	IsError   bool            `json:"is_error"`
+}
+
+// Collect 读取修改时间晚于 since 的会话文件中 since 之后的助手消息
+func (a *ClaudeCodeAdapter) Collect(since time.Time) ([]*Event, time.Time, error) {
+	files, err := filepath.Glob(filepath.Join(a.Dir, "*", "*.jsonl"))
+	if err != nil {
+		return nil, since, err
+	}
+	if _, err := os.Stat(a.Dir); err != nil {
+		return nil, since, err
+	}
+	if a.seen == nil {
+		a.seen = map[string]bool{}
+	}
+
+	var events []*Event
+	next := since
+	for _, path := range files {
+		if info, err := os.Stat(path); err != nil || !info.ModTime().After(since) {
+			continue
+		}
+		fileEvents, latest := a.readFile(path, since)
+		events = append(events, fileEvents...)
+		if latest.After(next) {
+			next = latest
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp < events[j].Timestamp })
+	return events, next, nil
+}
+
+// readFile 解析一个会话文件，返回新事件与其中最晚的消息时间
+func (a *ClaudeCodeAdapter) readFile(path string, since time.Time) ([]*Event, time.Time) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, since
+	}
+	defer f.Close()
+
+	var events []*Event
+	tools := map[string]*Tool{} // tool_use id -> 工具调用，用于回填结果
+	byID := map[string]*Event{} // 消息 ID -> 事件，合并同一消息的多行
+	latest := since
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLine)
+	for scanner.Scan() {
+		var line claudeLine
+		if json.Unmarshal(scanner.Bytes(), &line) != nil || !line.Timestamp.After(since) {
+			continue
+		}
+		var blocks []claudeBlock
+		json.Unmarshal(line.Message.Content, &blocks) // 字符串内容没有工具调用
+
+		switch line.Type {
+		case "user":
+			for _, b := range blocks {
+				if t, ok := tools[b.ToolUseID]; ok && b.Type == "tool_result" {
+					t.Output = claudeText(b.Content)
+					t.Success = !b.IsError
+				}
+			}
+		case "assistant":
+			id := line.Message.ID
+			event, merging := byID[id]
+			if line.Message.Usage == nil || (!merging && a.seen[id]) {
+				continue
+			}
+			if !merging {
+				event = &Event{
+					Type:      "message",
+					Timestamp: line.Timestamp.UnixMilli(),
+					AgentID:   a.AgentID,
+					SessionID: line.SessionID,
+					Model:     line.Message.Model,
+				}
+				events = append(events, event)
+				if id != "" {
+					a.seen[id], byID[id] = true, event
+				}
+			}
+			// 同一消息的多行中用量以最后一行为准
+			u := line.Message.Usage
+			event.Tokens = &Tokens{
+				Input:  u.InputTokens,
+				Output: u.OutputTokens,
+				Cache:  u.CacheReadTokens + u.CacheCreationTokens,
+			}
+			for _, b := range blocks {
+				switch b.Type {
+				case "text":
+					if event.Content != "" {
+						event.Content += "\n"
+					}
+					event.Content += b.Text
+				case "tool_use":
+					t := &Tool{Name: b.Name, Input: string(b.Input), Success: true}
+					tools[b.ID] = t
+					event.Tools = append(event.Tools, t)
+				}
+			}
+			if line.Timestamp.After(latest) {
+				latest = line.Timestamp
+			}
+		}
+	}
+	return events, latest
+}
+
+// claudeText 工具结果内容 (字符串或文本块数组)
+func claudeText(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var blocks []claudeBlock
+	json.Unmarshal(raw, &blocks)
+	var text []string
+	for _, b := range blocks {
+		if b.Text != "" {
+			text = append(text, b.Text)
+		}
+	}
+	return strings.Join(text, "\n")
+}
@@ -0,0 +1,90 @@
+package openclaw
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ============ 轮询 token 增量 ============
+
+// /api/stats 返回的会话 token 数是累计值，轮询时记住各会话上次的累计值，只把增量记为新的工作，
+// 避免每次轮询重复计入同一会话的全部 token。游标文件只保存增量已记录 (或已暂存到溢出文件) 的累计值，
+// 事件在内存队列中丢失时重启后重新计入
+
+// PollCursorFile 轮询游标文件 (位于 SetDataDir 设置的目录)
+const PollCursorFile = "poll_cursor.json"
+
+// sessionDelta 计算会话自上次轮询以来的 token 增量并记住当前累计值，ok=false 表示没有新增。
+// 累计值回落 (会话被重置) 时以当前值作为增量。仅由轮询协程调用
+func (o *OpenClawIntegrator) sessionDelta(s Session) (delta TokenInfo, ok bool) {
+	if o.lastTokens == nil {
+		o.lastTokens = map[string]TokenInfo{}
+	}
+	prev, seen := o.lastTokens[s.ID]
+	delta = s.Tokens
+	if seen && s.Tokens.Input >= prev.Input && s.Tokens.Output >= prev.Output && s.Tokens.Cache >= prev.Cache {
+		delta.Input -= prev.Input
+		delta.Output -= prev.Output
+		delta.Cache -= prev.Cache
+	}
+	o.lastTokens[s.ID] = s.Tokens
+	return delta, delta.Input > 0 || delta.Output > 0 || delta.Cache > 0
+}
+
+// loadPollCursor 读取上次保存的各会话累计值 (重启后不重复计入)
+func (o *OpenClawIntegrator) loadPollCursor() error {
+	data, err := os.ReadFile(filepath.Join(o.dataDir, PollCursorFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := json.Unmarshal(data, &o.lastTokens); err != nil {
+		return err
+	}
+	o.cursorMu.Lock()
+	defer o.cursorMu.Unlock()
+	o.recorded = make(map[string]TokenInfo, len(o.lastTokens))
+	for id, tokens := range o.lastTokens {
+		o.recorded[id] = tokens
+	}
+	return nil
+}
+
+// commitPollCursor 轮询事件已记录或已暂存后，把其会话累计值写入游标
+func (o *OpenClawIntegrator) commitPollCursor(event *Event) {
+	if event.cursor == nil {
+		return
+	}
+	o.cursorMu.Lock()
+	if o.recorded == nil {
+		o.recorded = map[string]TokenInfo{}
+	}
+	o.recorded[event.SessionID] = *event.cursor
+	o.cursorMu.Unlock()
+	if err := o.savePollCursor(); err != nil {
+		fmt.Printf("⚠️ 保存轮询游标失败: %v\n", err)
+	}
+}
+
+// savePollCursor 保存增量已记录的各会话累计值 (先写临时文件再重命名)
+func (o *OpenClawIntegrator) savePollCursor() error {
+	if o.dataDir == "" {
+		return nil
+	}
+	o.cursorMu.Lock()
+	defer o.cursorMu.Unlock()
+	data, err := json.MarshalIndent(o.recorded, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(o.dataDir, PollCursorFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,184 @@
+package openclaw
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	worktracker "oaw/tracker"
+)
+
+// ============ git 提交归属 ============
+
+// 设置了工作区 (Instance.Workspace 或 SetWorkspace) 时，代码行不再由工具输出估算，
+// 而是统计会话时间窗口内工作区 git 仓库新增提交的增删行数，并把提交 SHA 写入记录。
+// 每个提交只归属一次，已归属的提交保存在数据目录的 GitCommitsFile
+
+// GitCommitsFile 已归属的提交 (SHA → 会话 ID，位于集成器数据目录)
+const GitCommitsFile = "git_commits.json"
+
+// GitWindow 会话在本进程中的首个事件向前追溯提交的时间
+var GitWindow = time.Hour
+
+// GitAttribution 归属于一个事件的提交与增删行数 (写入事件日志，重放时沿用)
+type GitAttribution struct {
+	Repo    string   `json:"repo,omitempty"` // 仓库远程地址 (无远程时为本地路径)，见 gitRepoURL
+	Commits []string `json:"commits"`
+	Added   int      `json:"added"`
+	Removed int      `json:"removed"`
+	Files   int      `json:"files"`
+}
+
+// gitCommit git log 中的一个提交
+type gitCommit struct {
+	SHA     string
+	Time    time.Time
+	Added   int
+	Removed int
+	Files   []string
+}
+
+// gitLog 列出仓库在 (since, until] 内提交的增删行数 (按提交时间，不含合并提交与二进制文件)
+func gitLog(repo string, since, until time.Time) ([]gitCommit, error) {
+	cmd := exec.Command("git", "-C", repo, "log", "--no-merges", "--numstat", "--format=@%H %ct",
+		"--since="+since.Format(time.RFC3339), "--until="+until.Add(time.Second).Format(time.RFC3339))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log 失败: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var commits []gitCommit
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if header, ok := strings.CutPrefix(line, "@"); ok {
+			sha, ts, _ := strings.Cut(header, " ")
+			sec, _ := strconv.ParseInt(ts, 10, 64)
+			commits = append(commits, gitCommit{SHA: sha, Time: time.Unix(sec, 0)})
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 || len(commits) == 0 {
+			continue
+		}
+		c := &commits[len(commits)-1]
+		added, err1 := strconv.Atoi(fields[0])
+		removed, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil {
+			continue // 二进制文件显示为 "-"
+		}
+		c.Added += added
+		c.Removed += removed
+		c.Files = append(c.Files, fields[2])
+	}
+
+	// --since/--until 只精确到秒且包含边界，这里按 (since, until] 过滤
+	var inWindow []gitCommit
+	for _, c := range commits {
+		if c.Time.After(since.Truncate(time.Second)) && !c.Time.After(until) {
+			inWindow = append(inWindow, c)
+		}
+	}
+	sort.Slice(inWindow, func(i, j int) bool { return inWindow[i].Time.Before(inWindow[j].Time) })
+	return inWindow, nil
+}
+
+// gitRepoURL 仓库的 origin 远程地址 (去掉其中的用户名与令牌)，没有远程时为仓库的绝对路径
+func gitRepoURL(repo string) string {
+	out, err := exec.Command("git", "-C", repo, "remote", "get-url", "origin").Output()
+	if remote := strings.TrimSpace(string(out)); err == nil && remote != "" {
+		if u, err := url.Parse(remote); err == nil && u.User != nil && u.Scheme != "" {
+			u.User = nil
+			return u.String()
+		}
+		return remote
+	}
+	if abs, err := filepath.Abs(repo); err == nil {
+		return abs
+	}
+	return repo
+}
+
+// SetWorkspace 设置工作区 git 仓库，之后按会话窗口内的提交统计代码行
+func (o *OpenClawIntegrator) SetWorkspace(dir string) {
+	o.workspace = dir
+}
+
+// attributeCommits 归属会话窗口内尚未归属的提交。窗口从该会话上次归属时间
+// (本进程中首次为 at-GitWindow) 到 at
+func (o *OpenClawIntegrator) attributeCommits(sessionID string, at time.Time) (*GitAttribution, error) {
+	o.gitMu.Lock()
+	defer o.gitMu.Unlock()
+
+	if o.gitCommits == nil {
+		o.gitCommits = map[string]string{}
+		o.gitSeen = map[string]time.Time{}
+		if o.dataDir != "" {
+			if data, err := os.ReadFile(filepath.Join(o.dataDir, GitCommitsFile)); err == nil {
+				json.Unmarshal(data, &o.gitCommits)
+			}
+		}
+	}
+
+	since, ok := o.gitSeen[sessionID]
+	if !ok {
+		since = at.Add(-GitWindow)
+	}
+	commits, err := gitLog(o.workspace, since, at)
+	if err != nil {
+		return nil, err
+	}
+	o.gitSeen[sessionID] = at
+	if o.gitRepo == "" {
+		o.gitRepo = gitRepoURL(o.workspace)
+	}
+
+	g := &GitAttribution{Repo: o.gitRepo}
+	files := map[string]bool{}
+	for _, c := range commits {
+		if _, done := o.gitCommits[c.SHA]; done {
+			continue
+		}
+		o.gitCommits[c.SHA] = sessionID
+		g.Commits = append(g.Commits, c.SHA)
+		g.Added += c.Added
+		g.Removed += c.Removed
+		for _, f := range c.Files {
+			files[f] = true
+		}
+	}
+	g.Files = len(files)
+
+	if len(g.Commits) > 0 && o.dataDir != "" {
+		data, _ := json.MarshalIndent(o.gitCommits, "", "  ")
+		if err := os.WriteFile(filepath.Join(o.dataDir, GitCommitsFile), data, 0644); err != nil {
+			fmt.Printf("⚠️ 保存提交归属失败: %v\n", err)
+		}
+	}
+	return g, nil
+}
+
+// apply 以提交的增删行数代替工具输出的估算
+func (g *GitAttribution) apply(result *worktracker.TaskResult) {
+	if g == nil {
+		return
+	}
+	result.CodeLines = g.Added
+	result.LinesRemoved = g.Removed
+	result.CodeFiles = g.Files
+	result.Commits = append([]string(nil), g.Commits...)
+	if len(g.Commits) > 0 {
+		result.Repo = g.Repo
+	}
+}
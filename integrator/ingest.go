@@ -0,0 +1,121 @@
+package openclaw
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ============ 事件推送 ============
+
+// OpenClaw 钩子/插件可将事件直接 POST 到 IngestPath，与轮询共用事件处理 (分类、记录与事件日志)，
+// 可以补充或代替轮询
+
+const (
+	IngestPath        = "/api/ingest/event"
+	IngestTokenEnv    = "OAW_INGEST_TOKEN" // 推送令牌的环境变量
+	DefaultIngestAddr = "127.0.0.1:18790"  // 默认监听地址 (仅本机)
+	maxClockSkew      = 5 * time.Minute    // 事件时间允许超前的范围
+)
+
+// ErrQueueFull 事件队列已满
+var ErrQueueFull = errors.New("事件队列已满，请稍后重试")
+
+// eventTypes 可推送的事件类型
+var eventTypes = map[string]bool{"message": true, "tool": true, "exec": true, "done": true, "session": true}
+
+// Validate 校验推送的事件: 类型与会话 ID 必填，时间不能超前，token 数不能为负，工具调用需有名称
+func (e *Event) Validate(now time.Time) error {
+	if !eventTypes[e.Type] {
+		return fmt.Errorf("type 无效: %q (可选: message/tool/exec/done/session)", e.Type)
+	}
+	if e.SessionID == "" {
+		return fmt.Errorf("缺少 session_id")
+	}
+	if e.ParentSessionID == e.SessionID {
+		return fmt.Errorf("parent_session_id 不能是会话自身")
+	}
+	if e.Timestamp < 0 || time.UnixMilli(e.Timestamp).After(now.Add(maxClockSkew)) {
+		return fmt.Errorf("timestamp 无效: %d (毫秒时间戳，不能晚于当前时间)", e.Timestamp)
+	}
+	if t := e.Tokens; t != nil && (t.Input < 0 || t.Output < 0 || t.Cache < 0) {
+		return fmt.Errorf("tokens 不能为负数")
+	}
+	for i, tool := range e.Tools {
+		if tool == nil || tool.Name == "" {
+			return fmt.Errorf("tools[%d] 缺少 name", i)
+		}
+		if tool.Duration < 0 {
+			return fmt.Errorf("tools[%d].duration_ms 不能为负数", i)
+		}
+	}
+	return nil
+}
+
+// Ingest 校验事件并加入处理队列 (需已调用 StartListener)，未填写时间时使用当前时间；
+// 队列满且无法写入溢出文件时返回 ErrQueueFull，见 queue.go
+func (o *OpenClawIntegrator) Ingest(event *Event) error {
+	now := time.Now()
+	if err := event.Validate(now); err != nil {
+		return err
+	}
+	if event.Timestamp == 0 {
+		event.Timestamp = now.UnixMilli()
+	}
+	return o.enqueue(event, false)
+}
+
+// handleIngest 接收推送的事件。设置了 IngestToken 时需 Authorization: Bearer <token>，
+// 否则只接受本机请求；请求体为单个事件 (字段同事件日志，不接受未知字段)
+func (a *APIServer) handleIngest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if a.Ingest == nil {
+		http.Error(w, "未启用事件推送", http.StatusNotFound)
+		return
+	}
+	if a.IngestToken != "" {
+		if r.Header.Get("Authorization") != "Bearer "+a.IngestToken {
+			http.Error(w, "未授权", http.StatusUnauthorized)
+			return
+		}
+	} else if !loopback(r.RemoteAddr) {
+		http.Error(w, "未设置推送令牌时只接受本机请求", http.StatusForbidden)
+		return
+	}
+
+	var event Event
+	dec := json.NewDecoder(io.LimitReader(r.Body, maxLogLine))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&event); err != nil {
+		http.Error(w, "事件格式错误: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := a.Ingest.Ingest(&event); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrQueueFull) {
+			w.Header().Set("Retry-After", "1")
+			status = http.StatusServiceUnavailable
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{"accepted": true, "session_id": event.SessionID})
+}
+
+// loopback 请求是否来自本机
+func loopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,124 @@
+package openclaw
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	worktracker "oaw/tracker"
+)
+
+// ============ OpenClaw 实例 ============
+
+// 默认的 OpenClaw 地址与接口
+const (
+	DefaultBaseURL      = "http://localhost:18789"
+	DefaultStatsPath    = "/api/stats"
+	DefaultPollInterval = 30 * time.Second
+)
+
+// Instance 一个 OpenClaw 实例的连接配置 (config.json 的 openclaw_instances，可同时配置多个)
+type Instance struct {
+	Name        string `json:"name,omitempty"`         // 实例名称 (默认取 URL 的主机名)，多实例时区分各自的游标与状态
+	URL         string `json:"url,omitempty"`          // 基础地址 (默认 http://localhost:18789)
+	Stats       string `json:"stats,omitempty"`        // 会话统计接口路径 (默认 /api/stats)，也可为完整 URL
+	Token       string `json:"token,omitempty"`        // API 令牌 (为空时见 openclaw.ResolveToken)
+	Interval    string `json:"interval,omitempty"`     // 轮询间隔 (默认 30s)
+	MinInterval string `json:"min_interval,omitempty"` // 自适应轮询: 会话活跃时的间隔 (可选)，见 adaptive.go
+	MaxInterval string `json:"max_interval,omitempty"` // 自适应轮询: 空闲时逐次加倍的上限 (可选)
+	AgentID     string `json:"agent_id,omitempty"`     // 记录使用的 Agent ID (默认为实例名称)
+	LogFile     string `json:"log_file,omitempty"`     // 跟踪的事件日志文件 (可选)，见 tail.go
+
+	Workspace string `json:"workspace,omitempty"` // 工作区 git 仓库 (可选)，按会话窗口内的提交统计代码行，见 git.go
+}
+
+// Normalize 补全默认值并校验配置
+func (i Instance) Normalize() (Instance, error) {
+	if i.URL == "" {
+		i.URL = DefaultBaseURL
+	}
+	u, err := url.Parse(i.URL)
+	if err != nil || u.Host == "" {
+		return i, fmt.Errorf("无效的 OpenClaw 地址: %q", i.URL)
+	}
+	i.URL = strings.TrimRight(i.URL, "/")
+	if i.Stats == "" {
+		i.Stats = DefaultStatsPath
+	}
+	if i.Name == "" {
+		i.Name = u.Hostname()
+		if p := u.Port(); p != "" {
+			i.Name += "-" + p
+		}
+	}
+	if i.AgentID == "" {
+		i.AgentID = i.Name
+	}
+	if _, err := i.PollInterval(); err != nil {
+		return i, err
+	}
+	if _, _, err := i.AdaptiveRange(); err != nil {
+		return i, err
+	}
+	return i, nil
+}
+
+// StatsURL 会话统计接口的完整地址
+func (i Instance) StatsURL() string {
+	if strings.HasPrefix(i.Stats, "http://") || strings.HasPrefix(i.Stats, "https://") {
+		return i.Stats
+	}
+	return i.URL + "/" + strings.TrimLeft(i.Stats, "/")
+}
+
+// PollInterval 轮询间隔
+func (i Instance) PollInterval() (time.Duration, error) {
+	if i.Interval == "" {
+		return DefaultPollInterval, nil
+	}
+	d, err := time.ParseDuration(i.Interval)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("实例 %s 的轮询间隔无效: %q", i.Name, i.Interval)
+	}
+	return d, nil
+}
+
+// AdaptiveRange 自适应轮询的间隔范围，未设置时均为 0 (固定间隔)；只设置一端时另一端取轮询间隔
+func (i Instance) AdaptiveRange() (min, max time.Duration, err error) {
+	if i.MinInterval == "" && i.MaxInterval == "" {
+		return 0, 0, nil
+	}
+	interval, err := i.PollInterval()
+	if err != nil {
+		return 0, 0, err
+	}
+	min, max = interval, interval
+	for _, f := range []struct {
+		s string
+		d *time.Duration
+	}{{i.MinInterval, &min}, {i.MaxInterval, &max}} {
+		if f.s == "" {
+			continue
+		}
+		if *f.d, err = time.ParseDuration(f.s); err != nil || *f.d <= 0 {
+			return 0, 0, fmt.Errorf("实例 %s 的轮询间隔无效: %q", i.Name, f.s)
+		}
+	}
+	if min > max {
+		return 0, 0, fmt.Errorf("实例 %s 的自适应轮询间隔范围无效: %s ~ %s", i.Name, min, max)
+	}
+	return min, max, nil
+}
+
+// NewInstanceIntegrator 按实例配置创建集成器 (inst 需已 Normalize)
+func NewInstanceIntegrator(tracker *worktracker.Tracker, inst Instance) *OpenClawIntegrator {
+	o := NewOpenClawIntegrator(tracker, inst.AgentID)
+	o.statsURL = inst.StatsURL()
+	if inst.Token != "" {
+		o.token = inst.Token
+	}
+	o.workspace = inst.Workspace
+	o.minInterval, o.maxInterval, _ = inst.AdaptiveRange()
+	return o
+}
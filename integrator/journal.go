@@ -0,0 +1,155 @@
+package openclaw
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	worktracker "oaw/tracker"
+)
+
+// ============ 事件日志与重放 ============
+
+// 集成器收到的每个事件都追加到数据目录的事件日志，连同其生成的记录 ID。
+// 分类规则、价格或价值策略变化后可用 Replay 按当前配置重新推导记录，
+// 结果不同的记录以修正记录 (见 worktracker.Tracker.AmendAs) 更新，原记录与工作证明保持不变
+
+// EventJournalFile 事件日志文件 (位于集成器数据目录，JSONL，只追加)
+const EventJournalFile = "events.jsonl"
+
+// JournalEntry 事件日志中的一行
+type JournalEntry struct {
+	ReceivedAt int64           `json:"received_at"`
+	RecordID   string          `json:"record_id,omitempty"` // 生成的记录 (未分类、重复或被聚合时为空)
+	Event      *Event          `json:"event"`
+	Git        *GitAttribution `json:"git,omitempty"` // 归属的 git 提交 (设置了工作区时)，见 git.go
+}
+
+// journal 追加事件日志，未设置数据目录时不记录
+func (o *OpenClawIntegrator) journal(event *Event, recordID string, git *GitAttribution) {
+	if o.dataDir == "" {
+		return
+	}
+	entry := JournalEntry{ReceivedAt: time.Now().UnixMilli(), RecordID: recordID, Event: event, Git: git}
+	if err := AppendJournal(filepath.Join(o.dataDir, EventJournalFile), entry); err != nil {
+		fmt.Printf("⚠️ 写入事件日志失败: %v\n", err)
+	}
+}
+
+// AppendJournal 追加一条事件日志
+func AppendJournal(path string, entry JournalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// ReadJournal 按顺序读取事件日志，无法解析的行被跳过
+func ReadJournal(path string, fn func(JournalEntry) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLine)
+	for scanner.Scan() {
+		var entry JournalEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil || entry.Event == nil {
+			continue
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// ReplayResult 重放结果
+type ReplayResult struct {
+	Events     int // 读取的事件
+	Unrecorded int // 未生成记录的事件 (跳过)
+	Missing    int // 记录已不存在
+	Unchanged  int
+	Amended    int
+}
+
+// ReplayOptions 重放参数
+type ReplayOptions struct {
+	Since  time.Time // 只重放此后收到的事件
+	All    bool      // 结果相同的记录也重新生成 (价值策略变化时重算统计)
+	DryRun bool      // 只统计，不写入修正记录
+}
+
+// Replay 按当前分类器与价格表重新推导事件日志中的记录，结果变化的记录写入修正记录
+func Replay(tracker *worktracker.Tracker, path string, opts ReplayOptions) (ReplayResult, error) {
+	var res ReplayResult
+	o := &OpenClawIntegrator{tracker: tracker}
+	err := ReadJournal(path, func(entry JournalEntry) error {
+		if !opts.Since.IsZero() && entry.ReceivedAt < opts.Since.UnixMilli() {
+			return nil
+		}
+		res.Events++
+		if entry.RecordID == "" {
+			res.Unrecorded++
+			return nil
+		}
+		chain := tracker.Amendments(entry.RecordID)
+		if len(chain) == 0 {
+			res.Missing++
+			return nil
+		}
+		latest := chain[len(chain)-1]
+
+		taskType, result := o.deriveTask(entry.Event)
+		entry.Git.apply(&result) // 沿用记录时归属的提交
+		if taskType == "" {
+			taskType = latest.TaskType
+		}
+		if !opts.All && !replayChanged(latest, taskType, result) {
+			res.Unchanged++
+			return nil
+		}
+		if opts.DryRun {
+			res.Amended++
+			return nil
+		}
+		if _, err := tracker.AmendAs(latest.ID, taskType, result, "事件重放"); err != nil {
+			return fmt.Errorf("修正记录 %s 失败: %w", latest.ID, err)
+		}
+		res.Amended++
+		return nil
+	})
+	return res, err
+}
+
+// replayChanged 重新推导的结果与记录是否不同 (任务类型、工作量指标或按当前价格表估算的成本)
+func replayChanged(r *worktracker.WorkRecord, taskType worktracker.TaskType, result worktracker.TaskResult) bool {
+	if r.TaskType != taskType || r.TokensInput != result.TokensInput || r.TokensOutput != result.TokensOutput ||
+		r.TokensCache != result.TokensCache || r.CodeLines != result.CodeLines || r.CodeFiles != result.CodeFiles ||
+		r.BugsFixed != result.BugsFixed || r.ErrorsFixed != result.ErrorsFixed || r.LinesRemoved != result.LinesRemoved ||
+		!slices.Equal(r.Commits, result.Commits) {
+		return true
+	}
+	model := r.Model
+	if result.Model != "" {
+		model = result.Model
+	}
+	cost, _ := worktracker.EstimateCost(model, result.TokensInput, result.TokensOutput)
+	if p, ok := worktracker.LookupPrice(model); ok {
+		cost += float64(result.TokensCache) * p.Cache / 1e6
+	}
+	return fmt.Sprintf("%.6f", cost) != fmt.Sprintf("%.6f", r.CostUSD)
+}
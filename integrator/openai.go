@@ -0,0 +1,188 @@
+package openclaw
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============ OpenAI 用量导出 ============
+
+// OpenAIUsageAdapter 读取 OpenAI 用量导出文件: Usage API (organization/usage/completions) 的 JSON 响应，
+// 或带表头的 CSV。每个时间段、每组结果 (模型/项目/Assistant) 生成一个事件
+type OpenAIUsageAdapter struct {
+	Path    string // 导出文件 (.json 或 .csv)
+	AgentID string // 记录使用的 Agent ID (默认 openai，结果带项目 ID 时为 openai/<项目>)
+}
+
+// NewOpenAIUsageAdapter 创建 OpenAI 用量适配器
+func NewOpenAIUsageAdapter(path string) *OpenAIUsageAdapter {
+	return &OpenAIUsageAdapter{Path: path, AgentID: "openai"}
+}
+
+func (a *OpenAIUsageAdapter) Name() string { return "openai-usage:" + filepath.Base(a.Path) }
+
+// openAIUsage 一组用量
+type openAIUsage struct {
+	Start        time.Time
+	Model        string
+	Project      string // 项目 ID 或 Assistant ID
+	InputTokens  int64
+	OutputTokens int64
+	CachedTokens int64
+	Requests     int64
+}
+
+// Collect 读取开始时间晚于 since 的用量
+func (a *OpenAIUsageAdapter) Collect(since time.Time) ([]*Event, time.Time, error) {
+	data, err := os.ReadFile(a.Path)
+	if err != nil {
+		return nil, since, err
+	}
+	var usage []openAIUsage
+	if strings.EqualFold(filepath.Ext(a.Path), ".csv") {
+		usage, err = parseOpenAICSV(data)
+	} else {
+		usage, err = parseOpenAIJSON(data)
+	}
+	if err != nil {
+		return nil, since, fmt.Errorf("解析 %s 失败: %w", a.Path, err)
+	}
+	sort.SliceStable(usage, func(i, j int) bool { return usage[i].Start.Before(usage[j].Start) })
+
+	var events []*Event
+	next := since
+	for _, u := range usage {
+		if !u.Start.After(since) || u.InputTokens+u.OutputTokens == 0 {
+			continue
+		}
+		input := u.InputTokens - u.CachedTokens // Usage API 的 input_tokens 含缓存命中部分
+		if input < 0 {
+			input = 0
+		}
+		agentID := a.AgentID
+		if u.Project != "" {
+			agentID += "/" + u.Project
+		}
+		events = append(events, &Event{
+			Type:      "usage",
+			Timestamp: u.Start.UnixMilli(),
+			AgentID:   agentID,
+			SessionID: fmt.Sprintf("%s-%d", firstNonEmpty(u.Project, "openai"), u.Start.Unix()),
+			Model:     u.Model,
+			Tokens: &Tokens{
+				Input:  input,
+				Output: u.OutputTokens,
+				Cache:  u.CachedTokens,
+			},
+		})
+		if u.Start.After(next) {
+			next = u.Start
+		}
+	}
+	return events, next, nil
+}
+
+// parseOpenAIJSON 解析 Usage API 响应 ({"data":[{"start_time":...,"results":[...]}]}) 或时间段数组
+func parseOpenAIJSON(data []byte) ([]openAIUsage, error) {
+	type result struct {
+		Model        string `json:"model"`
+		ProjectID    string `json:"project_id"`
+		InputTokens  int64  `json:"input_tokens"`
+		OutputTokens int64  `json:"output_tokens"`
+		CachedTokens int64  `json:"input_cached_tokens"`
+		Requests     int64  `json:"num_model_requests"`
+	}
+	type bucket struct {
+		StartTime int64    `json:"start_time"`
+		Results   []result `json:"results"`
+	}
+	var page struct {
+		Data []bucket `json:"data"`
+	}
+	if err := json.Unmarshal(data, &page); err != nil || page.Data == nil {
+		if err := json.Unmarshal(data, &page.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	var usage []openAIUsage
+	for _, b := range page.Data {
+		for _, r := range b.Results {
+			usage = append(usage, openAIUsage{
+				Start:        time.Unix(b.StartTime, 0),
+				Model:        r.Model,
+				Project:      r.ProjectID,
+				InputTokens:  r.InputTokens,
+				OutputTokens: r.OutputTokens,
+				CachedTokens: r.CachedTokens,
+				Requests:     r.Requests,
+			})
+		}
+	}
+	return usage, nil
+}
+
+// parseOpenAICSV 解析带表头的 CSV，兼容 Usage API 与旧版用量导出的列名
+func parseOpenAICSV(data []byte) ([]openAIUsage, error) {
+	rows, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	col := map[string]int{}
+	for i, name := range rows[0] {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	get := func(row []string, names ...string) string {
+		for _, n := range names {
+			if i, ok := col[n]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+		}
+		return ""
+	}
+	num := func(row []string, names ...string) int64 {
+		n, _ := strconv.ParseInt(get(row, names...), 10, 64)
+		return n
+	}
+
+	var usage []openAIUsage
+	for _, row := range rows[1:] {
+		start, err := parseUsageTime(get(row, "start_time", "timestamp", "date"))
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, openAIUsage{
+			Start:        start,
+			Model:        get(row, "model", "snapshot_id"),
+			Project:      get(row, "project_id", "assistant_id"),
+			InputTokens:  num(row, "input_tokens", "n_context_tokens_total"),
+			OutputTokens: num(row, "output_tokens", "n_generated_tokens_total"),
+			CachedTokens: num(row, "input_cached_tokens", "n_cached_context_tokens_total"),
+			Requests:     num(row, "num_model_requests", "n_requests"),
+		})
+	}
+	return usage, nil
+}
+
+// parseUsageTime 解析 Unix 秒、RFC3339 或日期
+func parseUsageTime(s string) (time.Time, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(n, 0), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析时间 %q", s)
+}
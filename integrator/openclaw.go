@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"regexp"
+	"net/url"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
-	"worktracker"
+	sessions "oaw/openclaw"
+	worktracker "oaw/tracker"
 )
 
 // OpenClawIntegrator OpenClaw 集成器
@@ -18,6 +21,29 @@ type OpenClawIntegrator struct {
 	tracker    *worktracker.Tracker
 	agentID    string
 	statsURL   string
+	token      string       // OpenClaw API 令牌 (Authorization: Bearer)，为空时不认证
+	client     *http.Client
+	healthMu   sync.Mutex
+	health     sessions.Health // 轮询状态，见 Health
+	dataDir    string          // 设置后轮询状态与游标写入该目录，见 SetDataDir
+	lastTokens map[string]TokenInfo // 各会话上次轮询的累计 token，见 cursor.go
+	cursorMu   sync.Mutex
+	recorded   map[string]TokenInfo // 增量已记录或已暂存的累计 token，即写入游标文件的值
+
+	positionsMu sync.Mutex
+	positions   map[string]time.Time // 各适配器的读取位置，见 adapter.go
+
+	workspace  string               // 工作区 git 仓库，设置后按提交统计代码行，见 git.go
+	gitMu      sync.Mutex
+	gitCommits map[string]string    // 已归属的提交 → 会话 ID
+	gitSeen    map[string]time.Time // 各会话上次归属提交的时间
+	gitRepo    string               // 工作区仓库地址，见 gitRepoURL
+
+	minInterval time.Duration // 自适应轮询的间隔范围 (均为 0 时固定间隔)，见 adaptive.go
+	maxInterval time.Duration
+
+	sessionRecords map[string]string // 各会话最近生成的记录 ID (子 Agent 会话据此挂到父会话下)
+	queue      eventQueue // 队列计数与溢出状态，见 queue.go
 	eventChan  chan *Event
 	stopChan   chan bool
 	wg         sync.WaitGroup
@@ -30,9 +56,12 @@ type Event struct {
 	Content   string    `json:"content"`
 	AgentID   string    `json:"agent_id"`
 	SessionID string    `json:"session_id"`
+	ParentSessionID string `json:"parent_session_id,omitempty"` // 子 Agent 会话的父会话，记录为父会话记录的子任务
 	Model     string    `json:"model"`
 	Tokens    *Tokens   `json:"tokens"`
 	Tools     []*Tool   `json:"tools"`
+
+	cursor *TokenInfo // 轮询事件对应的会话累计 token，事件记录或暂存后写入游标，见 cursor.go
 }
 
 // Tokens Token 统计
@@ -56,182 +85,249 @@ func NewOpenClawIntegrator(tracker *worktracker.Tracker, agentID string) *OpenCl
 	return &OpenClawIntegrator{
 		tracker:   tracker,
 		agentID:   agentID,
-		statsURL:  "http://localhost:18789/api/stats",
-		eventChan: make(chan *Event, 1000),
+		statsURL:  DefaultBaseURL + DefaultStatsPath,
+		token:     sessions.ResolveToken(),
+		client:    &http.Client{Timeout: 10 * time.Second},
+		eventChan: make(chan *Event, EventQueueSize),
 		stopChan:  make(chan bool),
 	}
 }
 
-// StartPolling 开始轮询
+// SetToken 设置 OpenClaw API 令牌 (默认见 openclaw.ResolveToken: config.json、$OPENCLAW_TOKEN 或 OpenClaw 配置)
+func (o *OpenClawIntegrator) SetToken(token string) {
+	o.token = token
+}
+
+// get 发送 GET 请求，设置了令牌时附带 Authorization 头
+func (o *OpenClawIntegrator) get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if o.token != "" {
+		req.Header.Set("Authorization", "Bearer "+o.token)
+	}
+	return o.client.Do(req)
+}
+
+// SetDataDir 设置数据目录: 轮询状态写入 openclaw_health.json (供 oaw mine status 显示)，
+// 各会话的累计 token 写入 poll_cursor.json (重启后只记录增量)。需在 StartPolling 之前调用
+func (o *OpenClawIntegrator) SetDataDir(dir string) error {
+	o.dataDir = dir
+	return o.loadPollCursor()
+}
+
+// Health 返回最近一次轮询的结果
+func (o *OpenClawIntegrator) Health() sessions.Health {
+	o.healthMu.Lock()
+	defer o.healthMu.Unlock()
+	return o.health
+}
+
+// 单次轮询失败后的重试参数
+const (
+	pollRetries      = 3
+	pollRetryBackoff = time.Second // 首次重试前的等待，之后逐次翻倍
+)
+
+// StartPolling 开始轮询 OpenClaw 会话统计 (见 StartAdapter)
 func (o *OpenClawIntegrator) StartPolling(interval time.Duration) {
-	o.wg.Add(1)
-	go func() {
-		defer o.wg.Done()
-		
-		ticker := time.NewTicker(interval)
-		defer ticker.Stop()
-		
-		for {
-			select {
-			case <-o.stopChan:
-				return
-			case <-ticker.C:
-				o.poll()
-			}
-		}
-	}()
+	o.StartAdapter(&statsAdapter{o: o}, interval)
+}
+
+// statsAdapter 轮询 OpenClaw /api/stats 的适配器
+type statsAdapter struct {
+	o *OpenClawIntegrator
 }
 
-// poll 轮询获取数据
-func (o *OpenClawIntegrator) poll() {
-	// 获取会话统计
-	resp, err := http.Get(o.statsURL)
+func (a *statsAdapter) Name() string { return "openclaw" }
+
+// Collect 获取会话统计，只返回各会话自上次轮询以来的 token 增量 (读取位置见 cursor.go，忽略 since)
+func (a *statsAdapter) Collect(since time.Time) ([]*Event, time.Time, error) {
+	o := a.o
+	resp, err := o.get(o.statsURL)
 	if err != nil {
-		return
+		return nil, since, err
 	}
 	defer resp.Body.Close()
 	
 	if resp.StatusCode != 200 {
-		return
+		return nil, since, fmt.Errorf("%s 返回 %s", o.statsURL, resp.Status)
 	}
 	
 	var stats StatsResponse
 	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
-		return
+		return nil, since, fmt.Errorf("解析统计失败: %w", err)
 	}
 	
-	// 处理每个会话
+	// 处理每个会话: 只记录自上次轮询以来的增量
+	var events []*Event
+	now := time.Now()
 	for _, session := range stats.Sessions {
-		event := &Event{
+		delta, ok := o.sessionDelta(session)
+		if !ok {
+			continue
+		}
+		cumulative := session.Tokens
+		events = append(events, &Event{
 			Type:      "session",
-			Timestamp: time.Now().UnixMilli(),
+			Timestamp: now.UnixMilli(),
 			AgentID:   o.agentID,
 			SessionID: session.ID,
 			Model:     session.Model,
 			Tokens: &Tokens{
-				Input:  session.Tokens.Input,
-				Output: session.Tokens.Output,
+				Input:  delta.Input,
+				Output: delta.Output,
+				Cache:  delta.Cache,
 			},
-		}
-		o.eventChan <- event
+			cursor: &cumulative,
+		})
 	}
+	return events, now, nil
 }
 
-// StartListener 启动日志监听
+// StartListener 启动事件处理；logFile 不为空时跟踪该日志文件 (每行一个 JSON 事件，支持轮转与截断)，
+// 从启动时的末尾开始读取
 func (o *OpenClawIntegrator) StartListener(logFile string) {
+	if logFile != "" {
+		o.wg.Add(1)
+		go o.tailLog(logFile)
+	}
+
+	o.loadSpill()
 	o.wg.Add(1)
 	go func() {
 		defer o.wg.Done()
 		
-		// 简化版：读取日志文件
-		// 实际实现应该用 tail -f
-		
+		// 内存队列为空时取回溢出的事件，见 queue.go
+		spillTicker := time.NewTicker(spillCheckInterval)
+		defer spillTicker.Stop()
 		for {
 			select {
 			case <-o.stopChan:
 				return
 			case event := <-o.eventChan:
 				o.processEvent(event)
+				o.commitPollCursor(event)
+				o.queue.processed(false)
+			case <-spillTicker.C:
+				if len(o.eventChan) == 0 {
+					o.drainSpill()
+				}
 			}
 		}
 	}()
 }
 
-// processEvent 处理事件
+// processEvent 处理事件，设置了数据目录时写入事件日志 (见 journal.go)
 func (o *OpenClawIntegrator) processEvent(event *Event) {
 	// 根据事件类型判断任务
-	taskType := o.detectTaskType(event)
+	taskType, result := o.deriveTask(event)
 	
 	if taskType == "" {
+		o.journal(event, "", nil)
 		return
 	}
 	
-	// 开始任务
-	record := o.tracker.StartTask(
-		o.agentID,
-		fmt.Sprintf("Session: %s", event.SessionID),
-		taskType,
-	)
+	// 工作区 git 仓库: 代码行取会话窗口内的提交
+	var git *GitAttribution
+	if o.workspace != "" {
+		at := time.Now()
+		if event.Timestamp > 0 && event.Timestamp < at.UnixMilli() {
+			at = time.UnixMilli(event.Timestamp)
+		}
+		g, err := o.attributeCommits(event.SessionID, at)
+		if err != nil {
+			fmt.Printf("⚠️ 统计工作区提交失败，按工具输出估算代码行: %v\n", err)
+		}
+		git = g
+	}
+	git.apply(&result)
+	result.EventAt = event.Timestamp
 	
-	// 等待任务完成（简化版：直接标记完成）
-	time.Sleep(100 * time.Millisecond)
+	// 开始任务 (适配器事件带有各自的 Agent ID)
+	agentID := event.AgentID
+	if agentID == "" {
+		agentID = o.agentID
+	}
+	desc := fmt.Sprintf("Session: %s", event.SessionID)
+	var record *worktracker.WorkRecord
+	if parentID := o.parentRecord(event.ParentSessionID); parentID != "" {
+		// 子 Agent 会话: 作为父会话记录的子任务，汇总见 Tracker.Rollup
+		record, _ = o.tracker.StartSubtask(parentID, desc, taskType)
+	}
+	if record == nil {
+		record = o.tracker.StartTask(agentID, desc, taskType)
+	}
 	
-	result := worktracker.TaskResult{
-		TokensInput:  event.Tokens.Input,
-		TokensOutput: event.Tokens.Output,
+	// 等待任务完成（简化版：直接标记完成），停止时不再等待
+	if !o.stopping() {
+		time.Sleep(100 * time.Millisecond)
 	}
 	
-	// 解析工具调用获取更多信息
-	for _, tool := range event.Tools {
-		if tool.Success {
-			switch tool.Name {
-			case "exec", "bash", "powershell":
-				result.CodeLines += estimateCodeLines(tool.Output)
-			case "write", "edit":
-				result.CodeFiles++
-				result.CodeLines += estimateCodeLines(tool.Output)
-			}
+	if err := o.tracker.CompleteTask(record, result); err != nil {
+		fmt.Printf("⚠️ 记录任务完成失败: %v\n", err)
+	}
+	recordID := ""
+	if record.Status == "completed" {
+		recordID = record.ID
+		if o.sessionRecords == nil {
+			o.sessionRecords = map[string]string{}
 		}
+		o.sessionRecords[event.SessionID] = recordID
 	}
-	
-	o.tracker.CompleteTask(record, result)
+	o.journal(event, recordID, git)
 }
 
-// detectTaskType 检测任务类型
-func (o *OpenClawIntegrator) detectTaskType(event *Event) worktracker.TaskType {
-	content := event.Content
-	tools := event.Tools
-	
-	// 根据内容关键词判断
-	lower := strings.ToLower(content)
-	
-	// 代码相关
-	codeKeywords := []string{"func ", "def ", "class ", "const ", "let ", "import ", "package "}
-	for _, kw := range codeKeywords {
-		if strings.Contains(lower, kw) {
-			return worktracker.TaskCoding
-		}
+// parentRecord 父会话最近的记录 ID: 先查本集成器处理过的会话，再查追踪器中该会话的最新记录
+func (o *OpenClawIntegrator) parentRecord(sessionID string) string {
+	if sessionID == "" {
+		return ""
 	}
-	
-	// 调试相关
-	debugKeywords := []string{"error", "bug", "fix", "debug", "exception", "traceback"}
-	for _, kw := range debugKeywords {
-		if strings.Contains(lower, kw) {
-			return worktracker.TaskDebug
-		}
+	if id := o.sessionRecords[sessionID]; id != "" {
+		return id
 	}
-	
-	// 部署相关
-	deployKeywords := []string{"deploy", "docker", "kubernetes", "kubectl", "npm run", "build", "serve"}
-	for _, kw := range deployKeywords {
-		if strings.Contains(lower, kw) {
-			return worktracker.TaskDeploy
+	var latest *worktracker.WorkRecord
+	for _, r := range o.tracker.Query(worktracker.RecordFilter{Status: "completed", Latest: true}) {
+		if r.TaskDesc == "Session: "+sessionID && (latest == nil || r.CompletedAt > latest.CompletedAt) {
+			latest = r
 		}
 	}
-	
-	// 文档相关
-	docKeywords := []string{"readme", "document", "comment", "explain", "describe"}
-	for _, kw := range docKeywords {
-		if strings.Contains(lower, kw) {
-			return worktracker.TaskDoc
-		}
+	if latest == nil {
+		return ""
 	}
-	
-	// 工具判断
-	for _, tool := range tools {
-		if strings.HasPrefix(tool.Name, "exec") || strings.HasPrefix(tool.Name, "bash") {
-			return worktracker.TaskCoding
-		}
-		if strings.HasPrefix(tool.Name, "write") || strings.HasPrefix(tool.Name, "edit") {
-			if isCodeFile(tool.Name) {
-				return worktracker.TaskCoding
-			}
-			return worktracker.TaskWriting
-		}
+	return latest.ID
+}
+
+// deriveTask 由事件得出任务类型与结果 (实时处理与 Replay 共用)
+func (o *OpenClawIntegrator) deriveTask(event *Event) (worktracker.TaskType, worktracker.TaskResult) {
+	taskType := o.detectTaskType(event)
+	result := worktracker.TaskResult{Model: event.Model}
+	if event.Tokens != nil {
+		result.TokensInput = event.Tokens.Input
+		result.TokensOutput = event.Tokens.Output
+		result.TokensCache = event.Tokens.Cache
 	}
 	
-	// 默认
-	return worktracker.TaskResearch
+	// 解析工具调用: 写入的文件与代码行、应用的补丁、测试与命令的退出码，见 tools.go
+	m := parseTools(event.Tools)
+	result.CodeLines = m.CodeLines
+	result.CodeFiles = m.CodeFiles
+	result.BugsFixed = m.BugsFixed
+	result.ErrorsFixed = m.ErrorsFixed
+	if m.TestsRun > 0 {
+		result.Tags = append(result.Tags, "tests")
+	}
+	return taskType, result
+}
+
+// detectTaskType 检测任务类型，见 worktracker.Classify (分类器可在 config.json 的 classifier 中配置)
+func (o *OpenClawIntegrator) detectTaskType(event *Event) worktracker.TaskType {
+	in := worktracker.ClassifyInput{Content: event.Content}
+	for _, tool := range event.Tools {
+		in.Tools = append(in.Tools, tool.Name)
+	}
+	return worktracker.Classify(in)
 }
 
 func estimateCodeLines(output string) int {
@@ -247,16 +343,6 @@ func estimateCodeLines(output string) int {
 	return count
 }
 
-func isCodeFile(name string) bool {
-	codeExts := []string{".go", ".js", ".ts", ".py", ".rs", ".java", ".cpp", ".c", ".h", ".cs"}
-	for _, ext := range codeExts {
-		if strings.HasSuffix(name, ext) {
-			return true
-		}
-	}
-	return false
-}
-
 // StatsResponse 统计响应
 type StatsResponse struct {
 	Sessions []Session `json:"sessions"`
@@ -272,12 +358,55 @@ type Session struct {
 type TokenInfo struct {
 	Input  int64 `json:"input"`
 	Output int64 `json:"output"`
+	Cache  int64 `json:"cache"`
 }
 
-// Stop 停止
+// StopTimeout Stop 处理队列中剩余事件的时限，超时后剩余事件暂存到溢出文件 (未设置数据目录时继续处理)
+var StopTimeout = 10 * time.Second
+
+// Stop 停止轮询与日志跟踪，处理完队列中已收到的事件并保存轮询游标后返回
 func (o *OpenClawIntegrator) Stop() {
 	close(o.stopChan)
 	o.wg.Wait()
+	o.flush()
+	if o.dataDir != "" {
+		if err := o.savePollCursor(); err != nil {
+			fmt.Printf("⚠️ 保存轮询游标失败: %v\n", err)
+		}
+	}
+}
+
+// stopping 是否已调用 Stop
+func (o *OpenClawIntegrator) stopping() bool {
+	select {
+	case <-o.stopChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// flush 处理内存队列中剩余的事件 (处理协程已退出)，超过 StopTimeout 后剩余事件写入溢出文件
+func (o *OpenClawIntegrator) flush() {
+	if len(o.eventChan) == 0 {
+		return
+	}
+	fmt.Printf("⏳ 处理剩余的 %d 个事件...\n", len(o.eventChan))
+	deadline := time.Now().Add(StopTimeout)
+	for {
+		select {
+		case event := <-o.eventChan:
+			if o.dataDir != "" && time.Now().After(deadline) {
+				o.spill(event)
+				continue
+			}
+			o.processEvent(event)
+			o.commitPollCursor(event)
+			o.queue.processed(false)
+		default:
+			return
+		}
+	}
 }
 
 // ============ HTTP API ============
@@ -285,44 +414,331 @@ func (o *OpenClawIntegrator) Stop() {
 type APIServer struct {
 	tracker *worktracker.Tracker
 	port    string
+
+	ProofsDir   string // Merkle 批次目录 (默认 data/proofs)
+	ReviewToken string // 设置后评审接口需 Authorization: Bearer <token>
+
+	Ingest      *OpenClawIntegrator // 设置后接受推送的事件，见 ingest.go
+	IngestToken string              // 设置后推送接口需 Authorization: Bearer <token>，否则只接受本机请求
 }
 
 func NewAPIServer(tracker *worktracker.Tracker, port string) *APIServer {
 	return &APIServer{
-		tracker: tracker,
-		port:    port,
+		tracker:   tracker,
+		port:      port,
+		ProofsDir: filepath.Join("data", "proofs"),
 	}
 }
 
 func (a *APIServer) Start() {
 	http.HandleFunc("/api/stats", a.handleStats)
+	http.HandleFunc("/api/stats/daily", a.handlePeriodStats(worktracker.PeriodDay))
+	http.HandleFunc("/api/stats/weekly", a.handlePeriodStats(worktracker.PeriodWeek))
+	http.HandleFunc("/api/stats/monthly", a.handlePeriodStats(worktracker.PeriodMonth))
+	http.HandleFunc("/api/stats/agents", a.handleAgentStats)
+	http.HandleFunc("/api/leaderboard", a.handleLeaderboard)
 	http.HandleFunc("/api/records", a.handleRecords)
+	http.HandleFunc("/api/records/rollup", a.handleRollup)
+	http.HandleFunc("/api/records/", a.handleRecord)
 	http.HandleFunc("/api/proof", a.handleProof)
+	http.HandleFunc("/api/proof/verify", a.handleProofVerify)
+	http.HandleFunc("/api/events", a.handleEvents)
+	http.HandleFunc(IngestPath, a.handleIngest)
+	http.HandleFunc("/metrics", a.handleMetrics)
 	
 	go http.ListenAndServe(a.port, nil)
 }
 
+// handleMetrics Prometheus 指标
+func (a *APIServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := a.tracker.WriteMetrics(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if a.Ingest != nil {
+		a.Ingest.WriteQueueMetrics(w, "push")
+	}
+}
+
 func (a *APIServer) handleStats(w http.ResponseWriter, r *http.Request) {
 	stats := a.tracker.GetStats()
 	json.NewEncoder(w).Encode(stats)
 }
 
+// handleAgentStats 按 Agent 统计 (按价值降序)
+func (a *APIServer) handleAgentStats(w http.ResponseWriter, r *http.Request) {
+	type agentStats struct {
+		worktracker.AgentStats
+		SuccessRate float64 `json:"success_rate"`
+	}
+	list := []agentStats{}
+	for _, s := range a.tracker.AgentBreakdown() {
+		list = append(list, agentStats{AgentStats: s, SuccessRate: s.SuccessRate()})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// handleLeaderboard Agent 排行榜 (?period=30d&limit=N，period 默认 30d，all 为全部)
+func (a *APIServer) handleLeaderboard(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	period := q.Get("period")
+	if period == "" {
+		period = "30d"
+	}
+	lookback, err := worktracker.ParseLookback(period)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit := 0
+	if s := q.Get("limit"); s != "" {
+		if limit, err = strconv.Atoi(s); err != nil || limit < 0 {
+			http.Error(w, "无效的 limit: "+s, http.StatusBadRequest)
+			return
+		}
+	}
+
+	var since time.Time
+	if lookback > 0 {
+		since = time.Now().Add(-lookback)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a.tracker.Leaderboard(since, limit))
+}
+
+// handlePeriodStats 按周期统计 (?from=&to=，格式 2006-01-02 或 RFC3339)
+func (a *APIServer) handlePeriodStats(period worktracker.Period) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		from, err := worktracker.ParseFilterTime(q.Get("from"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		to, err := worktracker.ParseFilterTime(q.Get("to"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		series, err := a.tracker.Aggregate(period, from, to)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(series)
+	}
+}
+
+// handleRecords 查询记录
+// 参数: agent, type, status, parent, tag (可重复), since, until (2006-01-02 或 RFC3339), limit (默认 50),
+// cursor, offset; 总数与下一页游标通过 X-Total-Count / X-Next-Cursor 响应头返回
+// 只返回 Agent 钱包签名过的记录
 func (a *APIServer) handleRecords(w http.ResponseWriter, r *http.Request) {
-	records := a.tracker.GetRecords(50)
-	json.NewEncoder(w).Encode(records)
+	filter, err := parseRecordFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := a.tracker.QueryPage(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if page.NextCursor != "" {
+		w.Header().Set("X-Next-Cursor", page.NextCursor)
+	}
+	json.NewEncoder(w).Encode(page.Records)
+}
+
+// handleRecord 单条记录详情 (/api/records/{id})，只返回已签名记录；
+// POST /api/records/{id}/quality 设置质量评分
+func (a *APIServer) handleRecord(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/records/")
+	if rest, ok := strings.CutSuffix(id, "/quality"); ok {
+		a.handleQuality(w, r, rest)
+		return
+	}
+	if id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	detail, err := a.tracker.GetRecordDetail(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if !detail.Record.IsSigned() {
+		http.Error(w, "记录未签名", http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
 }
 
+// handleQuality 外部评审设置质量评分: {"score": 0~1, "reviewer": "..."}
+func (a *APIServer) handleQuality(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "请使用 POST", http.StatusMethodNotAllowed)
+		return
+	}
+	if a.ReviewToken != "" && r.Header.Get("Authorization") != "Bearer "+a.ReviewToken {
+		http.Error(w, "未授权", http.StatusUnauthorized)
+		return
+	}
+	var req struct {
+		Score    *float64 `json:"score"`
+		Reviewer string   `json:"reviewer"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(&req); err != nil || req.Score == nil {
+		http.Error(w, "请求格式: {\"score\": 0~1, \"reviewer\": \"...\"}", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := a.tracker.GetRecord(id); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	record, err := a.tracker.SetQuality(id, *req.Score, req.Reviewer)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":            record.ID,
+		"quality_score": *record.QualityScore,
+		"value":         record.CalculateValue(),
+	})
+}
+
+// handleRollup 任务及子任务汇总 (?id=)
+func (a *APIServer) handleRollup(w http.ResponseWriter, r *http.Request) {
+	rollup, err := a.tracker.Rollup(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if !rollup.Record.IsSigned() {
+		http.Error(w, "记录未签名", http.StatusForbidden)
+		return
+	}
+	json.NewEncoder(w).Encode(rollup)
+}
+
+func parseRecordFilter(q url.Values) (worktracker.RecordFilter, error) {
+	filter := worktracker.RecordFilter{
+		AgentID:  q.Get("agent"),
+		TaskType: worktracker.TaskType(q.Get("type")),
+		Status:   q.Get("status"),
+		ParentID: q.Get("parent"),
+		Tags:     q["tag"],
+		Latest:   q.Get("latest") == "true",
+		Commit:   q.Get("commit"),
+		Cursor:   q.Get("cursor"),
+		Limit:    50,
+		Signed:   true, // 未签名记录不对外提供
+	}
+
+	var err error
+	if filter.Since, err = worktracker.ParseFilterTime(q.Get("since")); err != nil {
+		return filter, err
+	}
+	if filter.Until, err = worktracker.ParseFilterTime(q.Get("until")); err != nil {
+		return filter, err
+	}
+	if s := q.Get("limit"); s != "" {
+		if filter.Limit, err = strconv.Atoi(s); err != nil || filter.Limit < 0 {
+			return filter, fmt.Errorf("无效的 limit: %s", s)
+		}
+	}
+	if s := q.Get("offset"); s != "" {
+		if filter.Offset, err = strconv.Atoi(s); err != nil || filter.Offset < 0 {
+			return filter, fmt.Errorf("无效的 offset: %s", s)
+		}
+	}
+	return filter, nil
+}
+
+// handleProof 最新 Merkle 批次的根；?record=<id> 返回该记录的包含证明
 func (a *APIServer) handleProof(w http.ResponseWriter, r *http.Request) {
-	records := a.tracker.GetRecords(100)
-	
-	// 生成汇总证明
-	combined := ""
-	for _, r := range records {
-		combined += r.ProofHash
+	if id := r.URL.Query().Get("record"); id != "" {
+		proof, err := worktracker.FindInclusionProof(a.ProofsDir, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(proof)
+		return
 	}
-	
-	json.NewEncoder(w).Encode(map[string]string{
-		"combined_hash": fmt.Sprintf("%x", combined),
-		"record_count": fmt.Sprintf("%d", len(records)),
+
+	batches, err := worktracker.LoadProofBatches(a.ProofsDir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(batches) == 0 {
+		http.Error(w, "尚无工作证明批次", http.StatusNotFound)
+		return
+	}
+	latest := batches[len(batches)-1]
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"batch":        latest.Index,
+		"root":         latest.Root,
+		"record_count": len(latest.Leaves),
+		"created_at":   latest.CreatedAt,
+		"batches":      len(batches),
 	})
 }
+
+// handleEvents 以 Server-Sent Events 推送记录事件 (结束类事件只推送已签名记录)
+func (a *APIServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher.Flush()
+
+	events, cancel := a.tracker.Subscribe()
+	defer cancel()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if event.Type != worktracker.RecordStarted && !event.Record.IsSigned() {
+				continue
+			}
+			data, _ := json.Marshal(event)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+			flusher.Flush()
+		}
+	}
+}
+
+// handleProofVerify 校验 POST 提交的工作记录 (重算工作证明并校验签名)
+func (a *APIServer) handleProofVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var record worktracker.WorkRecord
+	if err := json.NewDecoder(r.Body).Decode(&record); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := map[string]interface{}{"valid": true, "signer": record.Signer}
+	if err := worktracker.VerifyRecord(&record); err != nil {
+		result = map[string]interface{}{"valid": false, "error": err.Error()}
+	}
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,268 @@
+// Package openclawtest 提供基于 httptest 的 OpenClaw 模拟服务，用于在没有安装 OpenClaw 的环境中
+// 对集成器 (oaw/integrator) 与会话同步 (oaw/openclaw) 做集成测试。
+//
+// 会话由测试脚本增减 token，集成器轮询 /api/stats 得到累计用量；WriteHome 按默认目录结构写出
+// sessions.json，供 openclaw.Home 指向的同步使用:
+//
+//	srv := openclawtest.NewServer()
+//	defer srv.Close()
+//	srv.AddSession(openclawtest.Session{Key: "agent:main:main", ID: "s1", Model: "claude-sonnet-4"})
+//	srv.AddTokens("agent:main:main", 1200, 300)
+//
+//	ig := integrator.NewInstanceIntegrator(tracker, srv.Instance())
+//	ig.StartListener("")
+//	ig.StartPolling(100 * time.Millisecond)
+package openclawtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	integrator "oaw/integrator"
+	sessions "oaw/openclaw"
+)
+
+// 模拟的接口路径
+const (
+	StatsPath    = integrator.DefaultStatsPath // 会话统计 (集成器轮询)
+	SessionsPath = "/api/sessions"             // 会话列表 (sessions.json 格式，键为会话键)
+)
+
+// Session 模拟的会话 (token 为累计值)
+type Session struct {
+	Key        string // 会话键 (如 agent:main:main、cron:daily)，为空时使用 ID
+	ID         string // sessionId
+	Agent      string // 所在 Agent (默认 main)
+	Model      string
+	Kind       string
+	SpawnedBy  string // 子 Agent 会话的父会话键
+	Input      int64
+	Output     int64
+	CacheRead  int64
+	CacheWrite int64
+	Started    time.Time
+	Updated    time.Time
+}
+
+// Server 模拟的 OpenClaw 服务
+type Server struct {
+	*httptest.Server
+
+	Token string // 设置后要求 Authorization: Bearer <Token>，否则返回 401
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+	order    []string // 会话键的添加顺序
+	requests map[string]int
+	failures int // 接下来返回错误的请求数
+	status   int // 错误请求的状态码
+}
+
+// NewServer 启动模拟服务，使用后需调用 Close
+func NewServer() *Server {
+	s := &Server{sessions: map[string]*Session{}, requests: map[string]int{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc(StatsPath, s.handleStats)
+	mux.HandleFunc(SessionsPath, s.handleSessions)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Instance 连接模拟服务的实例配置 (可传给 integrator.NewInstanceIntegrator)
+func (s *Server) Instance() integrator.Instance {
+	inst, _ := integrator.Instance{Name: "openclawtest", URL: s.URL, Token: s.Token, AgentID: "main"}.Normalize()
+	return inst
+}
+
+// AddSession 添加或替换会话，未设置的时间取当前时间
+func (s *Server) AddSession(sess Session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess.Key == "" {
+		sess.Key = sess.ID
+	}
+	if sess.ID == "" {
+		sess.ID = sess.Key
+	}
+	if sess.Agent == "" {
+		sess.Agent = sessions.DefaultAgent
+	}
+	now := time.Now()
+	if sess.Started.IsZero() {
+		sess.Started = now
+	}
+	if sess.Updated.IsZero() {
+		sess.Updated = now
+	}
+	if _, ok := s.sessions[sess.Key]; !ok {
+		s.order = append(s.order, sess.Key)
+	}
+	s.sessions[sess.Key] = &sess
+}
+
+// AddTokens 模拟会话的一轮活动: 累计 token 增加并更新时间，会话不存在时以 key 为 ID 创建
+func (s *Server) AddTokens(key string, input, output int64) {
+	s.mu.Lock()
+	_, ok := s.sessions[key]
+	s.mu.Unlock()
+	if !ok {
+		s.AddSession(Session{Key: key})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess := s.sessions[key]
+	sess.Input += input
+	sess.Output += output
+	sess.Updated = time.Now()
+}
+
+// RemoveSession 删除会话 (模拟会话被清理)
+func (s *Server) RemoveSession(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, key)
+	for i, k := range s.order {
+		if k == key {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// Sessions 当前的会话 (按添加顺序)
+func (s *Server) Sessions() []Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Session, 0, len(s.order))
+	for _, key := range s.order {
+		list = append(list, *s.sessions[key])
+	}
+	return list
+}
+
+// FailNext 接下来 n 个请求返回 status (如 503，模拟 OpenClaw 不可达或重启)
+func (s *Server) FailNext(n, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures, s.status = n, status
+}
+
+// Requests 收到的请求数 (path 为空时为全部接口)
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if path != "" {
+		return s.requests[path]
+	}
+	total := 0
+	for _, n := range s.requests {
+		total += n
+	}
+	return total
+}
+
+// WriteHome 在 dir 下按默认目录结构 (openclaw.DefaultLayout) 写出各 Agent 的 sessions.json，
+// 之后将 openclaw.Home 设为 dir 即可同步模拟的会话
+func (s *Server) WriteHome(dir string) error {
+	byAgent := map[string]map[string]sessions.Session{}
+	for _, sess := range s.Sessions() {
+		if byAgent[sess.Agent] == nil {
+			byAgent[sess.Agent] = map[string]sessions.Session{}
+		}
+		byAgent[sess.Agent][sess.Key] = sess.openclaw()
+	}
+	for agent, list := range byAgent {
+		path := filepath.Join(dir, filepath.FromSlash(strings.ReplaceAll(sessions.DefaultLayout, "{agent}", agent)))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		data, _ := json.MarshalIndent(list, "", "  ")
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openclaw 转换为 sessions.json 中的会话
+func (sess Session) openclaw() sessions.Session {
+	return sessions.Session{
+		SessionID:    sess.ID,
+		UpdatedAt:    sess.Updated.UnixMilli(),
+		InputTokens:  int(sess.Input),
+		OutputTokens: int(sess.Output),
+		TotalTokens:  int(sess.Input + sess.Output + sess.CacheRead + sess.CacheWrite),
+		CacheRead:    int(sess.CacheRead),
+		CacheWrite:   int(sess.CacheWrite),
+		Model:        sess.Model,
+		AgentID:      sess.Agent,
+		Kind:         sess.Kind,
+		SpawnedBy:    sess.SpawnedBy,
+	}
+}
+
+// begin 计数请求并检查认证与注入的错误，返回 false 时已写入错误响应
+func (s *Server) begin(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[r.URL.Path]++
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	if s.Token != "" && r.Header.Get("Authorization") != "Bearer "+s.Token {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	if s.failures > 0 {
+		s.failures--
+		http.Error(w, fmt.Sprintf("openclawtest: injected failure (%d left)", s.failures), s.status)
+		return false
+	}
+	return true
+}
+
+// handleStats 会话统计 (integrator.StatsResponse)，按会话开始时间排序
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if !s.begin(w, r) {
+		return
+	}
+	list := s.Sessions()
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Started.Before(list[j].Started) })
+	resp := integrator.StatsResponse{Sessions: []integrator.Session{}}
+	for _, sess := range list {
+		resp.Sessions = append(resp.Sessions, integrator.Session{
+			ID:      sess.ID,
+			Model:   sess.Model,
+			Tokens:  integrator.TokenInfo{Input: sess.Input, Output: sess.Output, Cache: sess.CacheRead + sess.CacheWrite},
+			Started: sess.Started.UnixMilli(),
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleSessions 会话列表 (sessions.json 格式，?agent= 只返回该 Agent 的会话)
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	if !s.begin(w, r) {
+		return
+	}
+	agent := r.URL.Query().Get("agent")
+	resp := map[string]sessions.Session{}
+	for _, sess := range s.Sessions() {
+		if agent == "" || sess.Agent == agent {
+			resp[sess.Key] = sess.openclaw()
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
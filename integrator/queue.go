@@ -0,0 +1,314 @@
+package openclaw
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ============ 事件队列与溢出 ============
+
+// 事件先进入内存队列 (EventQueueSize)。队列满时，设置了数据目录的集成器把事件追加到溢出文件，
+// 不阻塞轮询与推送；处理协程在队列空闲时按顺序取回溢出的事件。溢出文件未处理完之前，
+// 新事件也写入溢出文件以保持顺序。未设置数据目录时轮询与日志跟踪等待队列空出，推送返回 ErrQueueFull
+
+// EventQueueSize 内存事件队列的容量
+const EventQueueSize = 1000
+
+// EventSpillFile 溢出的事件 (位于集成器数据目录，JSONL，处理后删除)
+const EventSpillFile = "event_spill.jsonl"
+
+// 溢出文件检查间隔与队列满警告的最小间隔
+const (
+	spillCheckInterval = time.Second
+	overflowWarnEvery  = time.Minute
+)
+
+// QueueStats 事件队列统计 (计数为本进程启动以来)
+type QueueStats struct {
+	Capacity     int       `json:"capacity"`
+	Queued       int       `json:"queued"`        // 内存队列中待处理的事件
+	Spilled      int       `json:"spilled"`       // 溢出文件中待处理的事件
+	Enqueued     int64     `json:"enqueued"`      // 进入内存队列的事件
+	Processed    int64     `json:"processed"`     // 处理完的事件 (含取回的溢出事件)
+	Overflows    int64     `json:"overflows"`     // 内存队列已满的次数
+	SpilledTotal int64     `json:"spilled_total"` // 写入溢出文件的事件
+	Restored     int64     `json:"restored"`      // 从溢出文件取回并处理的事件
+	Dropped      int64     `json:"dropped"`       // 丢弃的事件 (推送被拒或停止时未能入队)
+	HighWater    int       `json:"high_water"`    // 内存队列的最大深度
+	LastOverflow time.Time `json:"last_overflow,omitempty"`
+}
+
+// eventQueue 队列计数与溢出状态 (由 mu 保护)
+type eventQueue struct {
+	mu       sync.Mutex
+	stats    QueueStats
+	lastWarn time.Time
+}
+
+// QueueStats 返回事件队列统计
+func (o *OpenClawIntegrator) QueueStats() QueueStats {
+	o.queue.mu.Lock()
+	defer o.queue.mu.Unlock()
+	s := o.queue.stats
+	s.Capacity = cap(o.eventChan)
+	s.Queued = len(o.eventChan)
+	return s
+}
+
+// enqueue 把事件加入队列。队列满时写入溢出文件；无法溢出时 wait 为 true 则等待队列空出 (停止时放弃)，
+// 否则返回 ErrQueueFull
+func (o *OpenClawIntegrator) enqueue(event *Event, wait bool) error {
+	q := &o.queue
+	q.mu.Lock()
+	if q.stats.Spilled == 0 {
+		select {
+		case o.eventChan <- event:
+			q.enqueued(len(o.eventChan))
+			q.mu.Unlock()
+			return nil
+		default:
+			q.stats.Overflows++
+			q.stats.LastOverflow = time.Now()
+		}
+	}
+	if o.dataDir != "" && o.spillLocked(event) {
+		if time.Since(q.lastWarn) >= overflowWarnEvery {
+			q.lastWarn = time.Now()
+			fmt.Printf("⚠️ 事件队列已满 (%d)，事件暂存到 %s\n", cap(o.eventChan), o.spillPath())
+		}
+		q.mu.Unlock()
+		return nil
+	}
+	q.mu.Unlock()
+
+	if wait {
+		select {
+		case o.eventChan <- event:
+			q.mu.Lock()
+			q.enqueued(len(o.eventChan))
+			q.mu.Unlock()
+			return nil
+		case <-o.stopChan:
+		}
+	}
+	q.mu.Lock()
+	q.stats.Dropped++
+	q.mu.Unlock()
+	return ErrQueueFull
+}
+
+// spill 把事件写入溢出文件，失败时计为丢弃
+func (o *OpenClawIntegrator) spill(event *Event) {
+	o.queue.mu.Lock()
+	defer o.queue.mu.Unlock()
+	if !o.spillLocked(event) {
+		o.queue.stats.Dropped++
+	}
+}
+
+// spillLocked 追加溢出事件 (调用方需持有 mu)
+func (o *OpenClawIntegrator) spillLocked(event *Event) bool {
+	if err := appendSpill(o.spillPath(), event); err != nil {
+		fmt.Printf("⚠️ 写入溢出事件失败: %v\n", err)
+		return false
+	}
+	o.queue.stats.Spilled++
+	o.queue.stats.SpilledTotal++
+	o.commitPollCursor(event)
+	return true
+}
+
+// enqueued 计入进入内存队列的事件 (调用方需持有 mu)
+func (q *eventQueue) enqueued(depth int) {
+	q.stats.Enqueued++
+	if depth > q.stats.HighWater {
+		q.stats.HighWater = depth
+	}
+}
+
+// processed 计入处理完的事件
+func (q *eventQueue) processed(restored bool) {
+	q.mu.Lock()
+	q.stats.Processed++
+	if restored {
+		q.stats.Restored++
+	}
+	q.mu.Unlock()
+}
+
+func (o *OpenClawIntegrator) spillPath() string {
+	return filepath.Join(o.dataDir, EventSpillFile)
+}
+
+// drainingPath 正在取回的溢出事件 (进程在取回中途退出时，下次启动继续处理)
+func (o *OpenClawIntegrator) drainingPath() string {
+	return o.spillPath() + ".draining"
+}
+
+// loadSpill 启动时统计上次未处理完的溢出事件
+func (o *OpenClawIntegrator) loadSpill() {
+	if o.dataDir == "" {
+		return
+	}
+	n := 0
+	for _, path := range []string{o.drainingPath(), o.spillPath()} {
+		readSpill(path, func(*Event) bool { n++; return true })
+	}
+	o.queue.mu.Lock()
+	o.queue.stats.Spilled = n
+	o.queue.mu.Unlock()
+	if n > 0 {
+		fmt.Printf("📥 %d 个溢出事件待处理\n", n)
+	}
+}
+
+// drainSpill 取回溢出的事件并处理 (由处理协程在内存队列为空时调用)。
+// 溢出文件先改名，之后的溢出写入新文件；停止时未处理的事件写回，下次启动继续
+func (o *OpenClawIntegrator) drainSpill() {
+	q := &o.queue
+	q.mu.Lock()
+	if q.stats.Spilled == 0 {
+		q.mu.Unlock()
+		return
+	}
+	draining := o.drainingPath()
+	if _, err := os.Stat(draining); os.IsNotExist(err) {
+		if err := os.Rename(o.spillPath(), draining); err != nil {
+			q.stats.Spilled = 0 // 溢出文件已不存在
+			q.mu.Unlock()
+			if !os.IsNotExist(err) {
+				fmt.Printf("⚠️ 读取溢出事件失败: %v\n", err)
+			}
+			return
+		}
+	}
+	q.mu.Unlock()
+
+	var rest []*Event
+	stopped := false
+	err := readSpill(draining, func(event *Event) bool {
+		if !stopped {
+			select {
+			case <-o.stopChan:
+				stopped = true
+			default:
+			}
+		}
+		if stopped {
+			rest = append(rest, event)
+			return true
+		}
+		o.processEvent(event)
+		q.processed(true)
+		q.mu.Lock()
+		q.stats.Spilled--
+		q.mu.Unlock()
+		return true
+	})
+	if err != nil {
+		fmt.Printf("⚠️ 读取溢出事件失败: %v\n", err)
+		return
+	}
+	if len(rest) == 0 {
+		os.Remove(draining)
+		return
+	}
+	if err := writeSpill(draining, rest); err != nil {
+		fmt.Printf("⚠️ 保存未处理的溢出事件失败: %v\n", err)
+	}
+}
+
+// appendSpill 追加一个溢出事件
+func appendSpill(path string, event *Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// writeSpill 覆盖写入溢出事件
+func writeSpill(path string, events []*Event) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readSpill 按顺序读取溢出事件，无法解析的行被跳过；文件不存在时不返回错误
+func readSpill(path string, fn func(*Event) bool) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			var event Event
+			if json.Unmarshal(line, &event) == nil && !fn(&event) {
+				return nil
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// WriteQueueMetrics 以 Prometheus 文本格式输出事件队列指标 (source 为标签值)
+func (o *OpenClawIntegrator) WriteQueueMetrics(w io.Writer, source string) {
+	s := o.QueueStats()
+	label := fmt.Sprintf("{source=%q}", source)
+	metrics := []struct {
+		name, help, typ string
+		value           interface{}
+	}{
+		{"oaw_event_queue_depth", "Events waiting in the in-memory queue.", "gauge", s.Queued},
+		{"oaw_event_queue_capacity", "Capacity of the in-memory event queue.", "gauge", s.Capacity},
+		{"oaw_event_queue_high_water", "Maximum in-memory queue depth since process start.", "gauge", s.HighWater},
+		{"oaw_event_spill_pending", "Events waiting in the spill file.", "gauge", s.Spilled},
+		{"oaw_events_enqueued_total", "Events accepted into the in-memory queue.", "counter", s.Enqueued},
+		{"oaw_events_processed_total", "Events processed, including restored spill events.", "counter", s.Processed},
+		{"oaw_event_queue_overflows_total", "Times the in-memory queue was full.", "counter", s.Overflows},
+		{"oaw_events_spilled_total", "Events written to the spill file.", "counter", s.SpilledTotal},
+		{"oaw_events_restored_total", "Events restored from the spill file.", "counter", s.Restored},
+		{"oaw_events_dropped_total", "Events dropped because the queue was full.", "counter", s.Dropped},
+	}
+	for _, m := range metrics {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s%s %v\n", m.name, m.help, m.name, m.typ, m.name, label, m.value)
+	}
+}
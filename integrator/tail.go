@@ -0,0 +1,171 @@
+package openclaw
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ============ 日志跟踪 ============
+
+// tailInterval 日志文件的轮询间隔
+const tailInterval = 500 * time.Millisecond
+
+// maxLogLine 单行日志上限，超出的行被丢弃
+const maxLogLine = 1 << 20
+
+// logTailer 跟踪日志文件的追加内容 (类似 tail -F)：
+// 文件被轮转 (路径指向新文件) 时读完旧文件剩余内容后从新文件开头读取，
+// 文件被截断时从头读取
+type logTailer struct {
+	path    string
+	file    *os.File
+	info    os.FileInfo
+	offset  int64
+	partial []byte // 尚未遇到换行的半行
+}
+
+// open 打开日志文件，fromEnd 时从末尾开始 (只读取之后追加的内容)
+func (t *logTailer) open(fromEnd bool) error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	t.close()
+	t.file, t.info, t.offset, t.partial = f, info, 0, nil
+	if fromEnd {
+		t.offset = info.Size()
+	}
+	return nil
+}
+
+func (t *logTailer) close() {
+	if t.file != nil {
+		t.file.Close()
+		t.file = nil
+	}
+}
+
+// poll 读取新增的完整行
+func (t *logTailer) poll(emit func(line []byte)) error {
+	if t.file == nil {
+		if err := t.open(false); err != nil {
+			return err // 文件尚未创建或轮转间隙
+		}
+	}
+
+	info, err := t.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < t.offset {
+		// 截断: 从头读取
+		t.offset, t.partial = 0, nil
+	}
+	if err := t.readFrom(emit); err != nil {
+		return err
+	}
+
+	// 路径指向了新文件 (轮转)：旧文件已读完，切换到新文件开头
+	if cur, err := os.Stat(t.path); err == nil && !os.SameFile(cur, t.info) {
+		if t.partial != nil {
+			emit(t.partial)
+			t.partial = nil
+		}
+		if err := t.open(false); err != nil {
+			return err
+		}
+		return t.readFrom(emit)
+	}
+	return nil
+}
+
+// readFrom 从当前偏移读到文件末尾，按行回调
+func (t *logTailer) readFrom(emit func(line []byte)) error {
+	if _, err := t.file.Seek(t.offset, io.SeekStart); err != nil {
+		return err
+	}
+	r := bufio.NewReader(t.file)
+	for {
+		chunk, err := r.ReadBytes('\n')
+		t.offset += int64(len(chunk))
+		if len(chunk) > 0 {
+			t.partial = append(t.partial, chunk...)
+			if len(t.partial) > maxLogLine {
+				t.partial = nil // 超长行丢弃
+			} else if chunk[len(chunk)-1] == '\n' {
+				emit(bytes.TrimRight(t.partial, "\r\n"))
+				t.partial = nil
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// parseLogLine 解析一行日志为事件 (每行一个 JSON 事件，其他行忽略)
+func parseLogLine(line []byte) (*Event, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return nil, false
+	}
+	var event Event
+	if err := json.Unmarshal(line, &event); err != nil || event.Type == "" {
+		return nil, false
+	}
+	if event.Timestamp == 0 {
+		event.Timestamp = time.Now().UnixMilli()
+	}
+	if event.Tokens == nil {
+		event.Tokens = &Tokens{}
+	}
+	return &event, true
+}
+
+// tailLog 跟踪日志文件，将解析出的事件送入事件通道，直到停止
+func (o *OpenClawIntegrator) tailLog(logFile string) {
+	defer o.wg.Done()
+
+	t := &logTailer{path: logFile}
+	defer t.close()
+	if err := t.open(true); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("⚠️ 打开日志 %s 失败: %v\n", logFile, err)
+	}
+
+	ticker := time.NewTicker(tailInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-o.stopChan:
+			return
+		case <-ticker.C:
+		}
+		err := t.poll(func(line []byte) {
+			event, ok := parseLogLine(line)
+			if !ok {
+				return
+			}
+			if event.AgentID == "" {
+				event.AgentID = o.agentID
+			}
+			o.enqueue(event, true)
+		})
+		if err != nil && !os.IsNotExist(err) {
+			fmt.Printf("⚠️ 读取日志 %s 失败: %v\n", logFile, err)
+			t.close()
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package openclaw
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ============ 工具调用结果解析 ============
+
+// toolMetrics 由工具调用解析出的工作量
+type toolMetrics struct {
+	CodeLines   int
+	CodeFiles   int      // 写入或修改的不同文件数
+	Files       []string // 写入或修改的文件路径 (排序，不含无路径的调用)
+	TestsRun    int      // 执行的测试命令数
+	BugsFixed   int      // 先失败后通过的测试命令数
+	ErrorsFixed int      // 先失败后成功的其他命令数
+}
+
+// writeArgs 写文件/编辑类工具的参数 (兼容常见字段名)
+type writeArgs struct {
+	Path      string `json:"path"`
+	FilePath  string `json:"file_path"`
+	File      string `json:"file"`
+	Content   string `json:"content"`
+	NewString string `json:"new_string"`
+	NewStr    string `json:"newText"`
+	Patch     string `json:"patch"`
+	Input     string `json:"input"`
+	Command   string `json:"command"`
+	Cmd       string `json:"cmd"`
+}
+
+func (a writeArgs) path() string {
+	for _, p := range []string{a.Path, a.FilePath, a.File} {
+		if p != "" {
+			return p
+		}
+	}
+	return ""
+}
+
+func (a writeArgs) command() string {
+	if a.Command != "" {
+		return a.Command
+	}
+	return a.Cmd
+}
+
+var (
+	exitCodePattern = regexp.MustCompile(`(?i)exit(?:ed with)?\s*(?:code|status)[:=\s]+(-?\d+)`)
+	testCmdPattern  = regexp.MustCompile(`(?i)\b(go test|pytest|npm (run )?test|yarn test|pnpm test|cargo test|mvn test|gradle test|jest|vitest|mocha|phpunit|dotnet test|make test)\b`)
+	diffFilePattern = regexp.MustCompile(`(?m)^(?:\+\+\+ (?:b/)?(\S+)|\*\*\* (?:Add|Update) File: (.+))$`)
+)
+
+// parseTools 解析工具调用: 写入/编辑的文件与代码行、应用的补丁、执行的命令与退出码
+func parseTools(tools []*Tool) toolMetrics {
+	var m toolMetrics
+	files := map[string]bool{}
+	failedCmds := map[string]bool{} // 失败过的命令，之后成功即视为修复
+
+	for _, tool := range tools {
+		name := strings.ToLower(tool.Name)
+		var args writeArgs
+		json.Unmarshal([]byte(tool.Input), &args) // 非 JSON 输入按原文处理
+
+		switch {
+		case strings.Contains(name, "patch") || strings.Contains(name, "diff"):
+			if !tool.Success {
+				continue
+			}
+			patch := firstNonEmpty(args.Patch, args.Input, tool.Input)
+			lines, patched := parseDiff(patch)
+			m.CodeLines += lines
+			for _, f := range patched {
+				files[f] = true
+			}
+		case strings.HasPrefix(name, "write") || strings.HasPrefix(name, "edit") || strings.HasPrefix(name, "create"):
+			if !tool.Success {
+				continue
+			}
+			if p := args.path(); p != "" {
+				files[p] = true
+			} else {
+				files[name+"#"+strconv.Itoa(len(files))] = true // 无路径时按一次调用计一个文件
+			}
+			if content := firstNonEmpty(args.Content, args.NewString, args.NewStr); content != "" {
+				m.CodeLines += estimateCodeLines(content)
+			} else {
+				m.CodeLines += estimateCodeLines(tool.Output)
+			}
+		case strings.HasPrefix(name, "exec") || strings.HasPrefix(name, "bash") || strings.HasPrefix(name, "shell") || strings.HasPrefix(name, "powershell"):
+			cmd := strings.TrimSpace(firstNonEmpty(args.command(), tool.Input))
+			ok := commandSucceeded(tool)
+			isTest := testCmdPattern.MatchString(cmd)
+			if isTest {
+				m.TestsRun++
+			}
+			switch {
+			case !ok:
+				failedCmds[cmd] = true
+			case failedCmds[cmd]:
+				delete(failedCmds, cmd)
+				if isTest {
+					m.BugsFixed++
+				} else {
+					m.ErrorsFixed++
+				}
+			}
+		}
+	}
+	m.CodeFiles = len(files)
+	for f := range files {
+		if !strings.Contains(f, "#") {
+			m.Files = append(m.Files, f)
+		}
+	}
+	sort.Strings(m.Files)
+	return m
+}
+
+// TouchedFiles 工具调用写入或修改的文件路径
+func TouchedFiles(tools []*Tool) []string {
+	return parseTools(tools).Files
+}
+
+// commandSucceeded 命令是否成功: 优先采用输出中的退出码
+func commandSucceeded(tool *Tool) bool {
+	if match := exitCodePattern.FindAllStringSubmatch(tool.Output, -1); len(match) > 0 {
+		return match[len(match)-1][1] == "0"
+	}
+	return tool.Success
+}
+
+// parseDiff 统计补丁新增的代码行 (不含注释与空行) 与涉及的文件
+func parseDiff(patch string) (lines int, files []string) {
+	for _, m := range diffFilePattern.FindAllStringSubmatch(patch, -1) {
+		if f := firstNonEmpty(m[1], strings.TrimSpace(m[2])); f != "" && f != "/dev/null" {
+			files = append(files, f)
+		}
+	}
+	var added []string
+	for _, line := range strings.Split(patch, "\n") {
+		if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
+			added = append(added, line[1:])
+		}
+	}
+	return estimateCodeLines(strings.Join(added, "\n")), files
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
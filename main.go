@@ -2,22 +2,28 @@ package main
 
 import (
 	"context"
-	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
 	"os"
-	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/spf13/cobra"
+	"oaw/mining"
 	"oaw/openclaw"
+	integrator "oaw/integrator"
+	worktracker "oaw/tracker"
 )
 
 var version = "1.0.0"
@@ -27,7 +33,6 @@ var inactiveDays = 730 // 默认2年(730天)无活动自动注销
 // PoLE RPC 配置
 var poleNodeURL = "http://127.0.0.1:9090" // PoLE 节点默认端口
 var poleContractAddress = "0x0000000000000000000000000000000000000001" // PoLE 代币合约地址
-var poleDataDir = "D:/pole/data" // PoLE 节点数据目录
 
 // Wallet 钱包结构 (使用 secp256k1 曲线，与 PoLE 链兼容)
 type Wallet struct {
@@ -97,6 +102,13 @@ func checkInactiveWallets() error {
 		return fmt.Errorf("钱包私钥不存在")
 	}
 
+	// 加载本地链状态 (社区池)
+	state, err := LoadChainState(dataDir)
+	if err != nil {
+		return fmt.Errorf("加载链状态失败: %v", err)
+	}
+	blocks := loadChainBlocks(dataDir)
+
 	totalReleased := 0.0
 	walletCount := 0
 	
@@ -104,7 +116,6 @@ func checkInactiveWallets() error {
 	fmt.Printf("PoLE 链: %s\n", poleNodeURL)
 	fmt.Printf("检查钱包...\n\n")
 
-	_ = totalReleased // 链上记录后累计
 
 	for _, e := range entries {
 		if e.IsDir() || len(e.Name()) < 5 {
@@ -153,30 +164,46 @@ func checkInactiveWallets() error {
 		daysInactive := int(time.Since(latestDate).Hours() / 24)
 		
 		// 获取链上余额
-		chainBalance, _ := rpc.GetBalance(getAddressFromFile(data))
+		chainBalance := "查询失败"
+		if balance, err := rpc.Balance(getAddressFromFile(data)); err == nil {
+			chainBalance = FormatAmount(balance)
+		}
 		
 		fmt.Printf("钱包: %s\n", name)
 		fmt.Printf("  地址: %s\n", getAddressFromFile(data))
 		fmt.Printf("  最新活动: %s\n", latestDate.Format("2006-01-02 15:04"))
 		fmt.Printf("  不活跃天数: %d 天\n", daysInactive)
-		fmt.Printf("  链上余额: %s POLE\n", chainBalance)
+		fmt.Printf("  链上余额: %s\n", chainBalance)
 		
 		// 两年(730天)无活动则注销
 		if daysInactive > inactiveDays {
+			// 将余额转入社区池
+			address := getAddressFromFile(data)
+			released := state.BalanceOf(address, blocks)
+			if released > 0 {
+				// 由不活跃钱包自身私钥签名，保证签名者与转出地址一致
+				inactive, err := LoadWallet(walletDir, name)
+				if err != nil || inactive.Private == "" {
+					fmt.Printf("  ❌ 无法读取钱包私钥，跳过释放\n")
+					continue
+				}
+				tx, err := state.Transfer(address, CommunityPoolAddress, released, "inactive:"+name, inactive.Private)
+				if err != nil {
+					fmt.Printf("  ❌ 转入社区池失败: %v\n", err)
+					continue
+				}
+				totalReleased += released
+				fmt.Printf("  ✅ 已释放 %.4f OAW 到社区池: %s\n", released, tx.Hash[:18]+"...")
+			}
+
 			// 在链上记录注销交易
 			txData := fmt.Sprintf("inactive:%s:%d:%s", 
 				getAddressFromFile(data), 
 				daysInactive, 
 				time.Now().Format("20060102150405"))
 			
-			signedTx, err := SignTransaction(txData, walletInfo.Private)
-			if err != nil {
-				fmt.Printf("  ❌ 签名失败: %v\n", err)
-				continue
-			}
-			
-			// 发送到链上
-			txHash, err := rpc.SendSignedTransaction(signedTx)
+			// 签名并发送到链上
+			txHash, err := SendPoleData(rpc, walletInfo.Private, []byte(txData))
 			if err != nil {
 				fmt.Printf("  ⚠️ 链上记录失败: %v (本地记录)\n", err)
 				// 仍然在本地记录
@@ -190,6 +217,7 @@ func checkInactiveWallets() error {
 			walletData["status"] = "inactive"
 			walletData["released_at"] = time.Now().Format(time.RFC3339)
 			walletData["tx_hash"] = txHash
+			walletData["released_amount"] = released
 			
 			newData, _ := json.MarshalIndent(walletData, "", "  ")
 			os.WriteFile(walletFile, newData, 0644)
@@ -203,6 +231,7 @@ func checkInactiveWallets() error {
 	
 	fmt.Println("========== 检查完成 ==========")
 	fmt.Printf("检查钱包数: %d\n", walletCount)
+	fmt.Printf("释放到社区池: %.4f OAW\n", totalReleased)
 	
 	return nil
 }
@@ -289,23 +318,39 @@ func LoadWallet(dir, name string) (*Wallet, error) {
 }
 
 type Block struct {
+	Version   int     `json:"version,omitempty"` // 区块头版本 (见 version.go)
 	Index     int     `json:"index"`
 	Timestamp int64   `json:"timestamp"`
-	WorkProof string  `json:"work_proof"`
+	WorkProof string  `json:"work_proof,omitempty"`
+	Nonce     uint64  `json:"nonce,omitempty"`      // PoW 随机数 (版本 2 起参与区块哈希，见 blockPoWHash)
+	Difficulty int    `json:"difficulty,omitempty"` // 出块难度，区块哈希须满足该难度的目标
 	Previous  string  `json:"previous"`
-	Miner     string  `json:"miner"`
-	Value     float64 `json:"value"`
+	Miner     string  `json:"miner,omitempty"`
+	Value     float64 `json:"value,omitempty"`
 	Hash      string  `json:"hash"`
+	Signature string  `json:"signature,omitempty"` // 矿工对区块头的签名 (裁剪后移除)
+	RewardTo  string  `json:"reward_to,omitempty"` // 奖励收款地址 (冷钱包，默认为矿工地址)
+
+	Transactions []Transaction `json:"transactions,omitempty"` // 区块打包的转账交易
 }
 
+// BaseBlockReward 每个区块的基础奖励 (OAW)
+const BaseBlockReward = 10.0
+
 type Miner struct {
 	wallet        *Wallet
 	working       bool
 	blocks        []Block
 	dataDir       string
-	difficulty    int
 	minDifficulty int
 	maxDifficulty int
+	mode          string // 出块模式: pow / stake
+	rewardMode    string // 奖励模式: ratio / value
+	rewardTo      string // 奖励收款地址 (为空时使用签名钱包)
+	maxCPU        int    // 最大 CPU 占用百分比 (100 为不限速)
+	mu            sync.Mutex
+	snapshot      *ChainSnapshot     // 已裁剪区块的余额快照
+	orphans       map[string][]Block // 孤块池 (父区块哈希 → 区块)
 }
 
 func NewMiner(w *Wallet, dir string) *Miner {
@@ -313,9 +358,11 @@ func NewMiner(w *Wallet, dir string) *Miner {
 		wallet:        w,
 		blocks:        []Block{},
 		dataDir:       dir,
-		difficulty:    4,   // 初始难度
-		minDifficulty: 2,   // 最小难度
-		maxDifficulty: 10,  // 最大难度
+		minDifficulty: activeNetwork.MinDifficulty, // 最小难度
+		maxDifficulty: activeNetwork.MaxDifficulty, // 最大难度
+		mode:          MiningModePoW,
+		rewardMode:    RewardModeRatio,
+		maxCPU:        100,
 	}
 	m.loadBlocks()
 	return m
@@ -326,11 +373,20 @@ func (m *Miner) loadBlocks() {
 	if err == nil {
 		json.Unmarshal(data, &m.blocks)
 	}
+	m.snapshot = loadSnapshot(m.dataDir)
 }
 
 func (m *Miner) saveBlocks() {
-	data, _ := json.MarshalIndent(m.blocks, "", "  ")
-	os.WriteFile(filepath.Join(m.dataDir, "blocks.json"), data, 0644)
+	m.writeBlocks()
+}
+
+// writeBlocks 写入 blocks.json (先写临时文件再重命名)
+func (m *Miner) writeBlocks() error {
+	data, err := json.MarshalIndent(m.blocks, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(m.dataDir, "blocks.json"), data)
 }
 
 func (m *Miner) Start(ctx context.Context) {
@@ -342,20 +398,12 @@ func (m *Miner) Start(ctx context.Context) {
 
 func (m *Miner) Stop() { m.working = false }
 
-func (m *Miner) Balance() float64 {
-	var total float64
-	for _, b := range m.blocks {
-		if b.Miner == m.wallet.Address {
-			total += b.Value
-		}
-	}
-	return total
-}
+func (m *Miner) Balance() float64 { return m.GetBalance(0) }
 
 func (m *Miner) Blocks() []Block { return m.blocks }
 
 func (m *Miner) mineLoop(ctx context.Context) {
-	ticker := time.NewTicker(10 * time.Second)
+	ticker := time.NewTicker(activeNetwork.BlockInterval)
 	defer ticker.Stop()
 	for {
 		select {
@@ -370,10 +418,9 @@ func (m *Miner) mineLoop(ctx context.Context) {
 }
 
 func (m *Miner) mineBlock() {
-	prev := ""
-	if len(m.blocks) > 0 {
-		prev = m.blocks[len(m.blocks)-1].Hash
-	}
+	m.mu.Lock()
+	prev, index := m.tipHash(), len(m.blocks)
+	m.mu.Unlock()
 
 	// 获取当前周期的工作量
 	currentPeriod := time.Now().Unix() / 60 // 每分钟一个周期
@@ -394,54 +441,8 @@ func (m *Miner) mineBlock() {
 		}
 	}
 
-	// PoW 竞争区块
-	var hashStr string
-	var nonce uint64
-	var startTime = time.Now()
-	var attempts uint64
-	
-	prefix := strings.Repeat("0", m.difficulty)
-	
-	for nonce = 0; nonce < 10000000; nonce++ {
-		attempts++
-		
-		workProof := fmt.Sprintf("%d", nonce)
-		data := fmt.Sprintf("%d%d%s%s%s%f%d", 
-			len(m.blocks), 
-			time.Now().UnixNano(),
-			workProof, 
-			prev, 
-			m.wallet.Address, 
-			10.0,
-			nonce)
-		
-		hash := sha256.Sum256([]byte(data))
-		hashStr = hex.EncodeToString(hash[:])
-
-		if len(hashStr) >= m.difficulty && hashStr[:m.difficulty] == prefix {
-			elapsed := time.Since(startTime)
-			fmt.Printf("  🔨 PoW 耗时: %v, 尝试次数: %d\n", elapsed, attempts)
-			break
-		}
-		
-		if nonce%100000 == 0 && !m.working {
-			fmt.Println("  ⏹️ 挖矿已停止")
-			return
-		}
-	}
-
-	// 如果未找到有效 PoW，降低难度
-	if nonce >= 10000000 {
-		fmt.Printf("  ⚠️ 达到最大尝试次数，降低难度\n")
-		m.difficulty--
-		if m.difficulty < m.minDifficulty {
-			m.difficulty = m.minDifficulty
-		}
-		hashStr = "0000ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"
-	}
-
 	// 计算奖励：基础奖励 * 工作量占比
-	baseReward := 10.0
+	baseReward := BaseBlockReward
 	actualReward := baseReward * workRatio
 	
 	// 无工作量则无奖励
@@ -449,18 +450,35 @@ func (m *Miner) mineBlock() {
 		actualReward = 0
 	}
 
-	block := Block{
-		Index:     len(m.blocks),
+	// 按同步价值出块: 奖励 = 上一区块以来新同步的 OpenClaw 工作价值
+	if m.rewardMode == RewardModeValue {
+		actualReward = m.syncedValueSinceLastBlock()
+	}
+
+	// 区块头在竞争出块权前确定 (PoW 哈希覆盖高度、时间、前序哈希、矿工与奖励)
+	header := Block{
+		Index:     index,
 		Timestamp: time.Now().Unix(),
-		WorkProof: fmt.Sprintf("%d", nonce),
 		Previous:  prev,
 		Miner:     m.wallet.Address,
 		Value:     actualReward,
-		Hash:      hashStr,
 	}
 
-	m.blocks = append(m.blocks, block)
-	m.saveBlocks()
+	// 竞争出块权 (PoW 或权益加权)
+	if m.mode == MiningModeStake {
+		if !m.stakeKernel(&header) {
+			fmt.Printf("  ⏳ 本轮未获得出块权 (权益: %.2f)\n", m.stakeWeight())
+			return
+		}
+	} else if !m.solvePoW(&header) {
+		return
+	}
+
+	block, err := m.commitBlock(header)
+	if err != nil {
+		fmt.Printf("  ❌ %v\n", err)
+		return
+	}
 	
 	// 将奖励记录到链上
 	if actualReward > 0 {
@@ -471,17 +489,11 @@ func (m *Miner) mineBlock() {
 			localWork,
 			actualReward)
 		
-		signedTx, err := SignTransaction(txData, m.wallet.Private)
+		txHash, err := SendPoleData(NewPoleRPC(poleNodeURL), m.wallet.Private, []byte(txData))
 		if err != nil {
 			fmt.Printf("  ⚠️ 链上记录失败: %v (本地记录)\n", err)
 		} else {
-			rpc := NewPoleRPC(poleNodeURL)
-			txHash, err := rpc.SendSignedTransaction(signedTx)
-			if err != nil {
-				fmt.Printf("  ⚠️ 链上提交失败: %v\n", err)
-			} else {
-				fmt.Printf("  ✅ 链上记录: %s\n", txHash[:20]+"...")
-			}
+			fmt.Printf("  ✅ 链上记录: %s\n", txHash[:20]+"...")
 		}
 		
 		fmt.Printf("  ✅ 挖到新区块 #%d\n", block.Index)
@@ -493,6 +505,76 @@ func (m *Miner) mineBlock() {
 	}
 }
 
+// commitBlock 签名并保存已获得出块权的区块 (header 含高度、时间、前序哈希、奖励与哈希)
+func (m *Miner) commitBlock(header Block) (Block, error) {
+	m.mu.Lock()
+	// 挖矿期间已接收对等区块，本区块作废
+	if header.Previous != m.tipHash() || header.Index != len(m.blocks) {
+		m.mu.Unlock()
+		return Block{}, fmt.Errorf("链已更新，放弃过期区块")
+	}
+
+	block := header
+	block.Version = CurrentBlockVersion
+	block.Miner = m.wallet.Address
+	block.RewardTo = m.rewardTo
+	block.Transactions = m.pendingTransactions()
+
+	// 矿工签名区块，防止伪造奖励
+	if err := SignBlock(&block, m.wallet.Private); err != nil {
+		m.mu.Unlock()
+		return block, fmt.Errorf("区块签名失败: %w", err)
+	}
+
+	m.blocks = append(m.blocks, block)
+	m.saveBlocks()
+	m.mu.Unlock()
+
+	m.broadcastBlock(block)
+	m.fireBlockHooks(BlockEventMined, block)
+	return block, nil
+}
+
+// solvePoW PoW 竞争区块: 按链历史推出难度 (expectedDifficulty)，搜索使区块头哈希 (blockPoWHash) 小于难度目标的随机数，
+// 找到时写入 header 的 Nonce、Difficulty 与 Hash
+func (m *Miner) solvePoW(header *Block) bool {
+	var nonce uint64
+	var startTime = time.Now()
+	var attempts uint64
+	
+	header.WorkProof = PoWWorkProof
+	m.mu.Lock()
+	header.Difficulty = expectedDifficulty(m.blocks[:header.Index], header.Timestamp)
+	m.mu.Unlock()
+	target := mining.DifficultyTarget(header.Difficulty)
+	throttle := newCPUThrottle(m.maxCPU)
+	
+	for nonce = 0; nonce < 10000000; nonce++ {
+		attempts++
+		if nonce%cpuThrottleInterval == 0 {
+			throttle.tick()
+		}
+		
+		header.Nonce = nonce
+		hashStr := blockPoWHash(*header)
+		if mining.MeetsTarget(hashStr, target) {
+			elapsed := time.Since(startTime)
+			fmt.Printf("  🔨 PoW 耗时: %v, 尝试次数: %d\n", elapsed, attempts)
+			header.Hash = hashStr
+			return true
+		}
+		
+		if nonce%100000 == 0 && !m.working {
+			fmt.Println("  ⏹️ 挖矿已停止")
+			return false
+		}
+	}
+
+	// 未找到有效 PoW: 本轮不出块 (难度由链历史决定，出块间隔拉长后下一轮的难度随之降低)
+	fmt.Printf("  ⚠️ 达到最大尝试次数，本轮不出块\n")
+	return false
+}
+
 // getCurrentPeriodWork 获取当前周期的本地工作量
 func (m *Miner) getCurrentPeriodWork(period int64) int {
 	recordsDir := m.dataDir + "/records"
@@ -505,6 +587,9 @@ func (m *Miner) getCurrentPeriodWork(period int64) int {
 	periodStart := period * 60 // 周期开始的Unix时间
 	
 	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "session_") {
+			continue // 按会话命名的记录是累计值，增长见下方增量日志
+		}
 		data, _ := os.ReadFile(filepath.Join(recordsDir, e.Name()))
 		var record struct {
 			Timestamp time.Time `json:"timestamp"`
@@ -517,6 +602,10 @@ func (m *Miner) getCurrentPeriodWork(period int64) int {
 			work += record.TotalTokens
 		}
 	}
+	_, tokens := openclaw.SumDeltas(m.dataDir, func(e openclaw.DeltaEntry) bool {
+		return e.UpdatedAt.Unix() >= periodStart
+	})
+	work += tokens
 	
 	return work
 }
@@ -539,6 +628,119 @@ func (m *Miner) getNetworkWork() int {
 func main() {
 	rootCmd := &cobra.Command{Use: "oaw", Version: version}
 	rootCmd.PersistentFlags().StringVar(&dataDir, "datadir", "./data", "数据目录")
+	var network string
+	rootCmd.PersistentFlags().StringVar(&network, "network", MainNet.Name, "网络: mainnet / testnet (测试网使用独立数据目录、低难度、快速出块)")
+	var openclawHome string
+	rootCmd.PersistentFlags().StringVar(&openclawHome, "openclaw-home", "", "OpenClaw 数据目录 (默认: $OPENCLAW_HOME、config.json 的 openclaw_home 或自动检测)")
+	var timezone string
+	rootCmd.PersistentFlags().StringVar(&timezone, "tz", "", "统计与报告的时区 (如 Asia/Shanghai 或 +08:00，默认 config.json 的 timezone 或本地时区)")
+	rootCmd.PersistentFlags().StringVar(&poleHomeFlag, "pole-home", "", "PoLE 安装目录 (默认: config.json 的 pole_local.home、$POLE_HOME 或各平台的默认位置)")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := selectNetwork(network); err != nil {
+			return err
+		}
+		openclaw.Home = openclawHome
+		if timezone != "" {
+			if err := worktracker.SetTimezone(timezone); err != nil {
+				return err
+			}
+		}
+		// 配置的价值策略用于追踪器记录 (变更后持久化统计会自动重建)
+		cfg, err := LoadConfig(dataDir)
+		if err != nil {
+			return fmt.Errorf("读取 config.json 失败: %w", err)
+		}
+		if openclaw.Home == "" && os.Getenv(openclaw.HomeEnv) == "" {
+			openclaw.Home = cfg.OpenClawHome
+		}
+		if cfg.OpenClawLayout != "" {
+			openclaw.Layout = cfg.OpenClawLayout
+		}
+		openclaw.Token = cfg.OpenClawToken
+		openclaw.AgentWallets = cfg.AgentWallets
+		if cfg.SessionFilter != nil {
+			openclaw.SessionRules = *cfg.SessionFilter
+		}
+		if cfg.Valuator != "" {
+			v, err := worktracker.ParseValuator(cfg.Valuator)
+			if err != nil {
+				return fmt.Errorf("config.json 价值策略无效: %w", err)
+			}
+			worktracker.DefaultValuator = v
+		}
+		if cfg.QualityWeight != nil {
+			worktracker.QualityWeight = *cfg.QualityWeight
+		}
+		if timezone == "" && cfg.Timezone != "" {
+			if err := worktracker.SetTimezone(cfg.Timezone); err != nil {
+				return fmt.Errorf("config.json 时区无效: %w", err)
+			}
+		}
+		if cfg.OpenClawPricing == nil || *cfg.OpenClawPricing {
+			openclaw.SyncPricing() // OpenClaw 配置不可读时保持 config.json 的价格表
+		}
+		for model, price := range cfg.Pricing {
+			if err := worktracker.SetModelPrice(model, price); err != nil {
+				return fmt.Errorf("config.json 模型价格无效: %w", err)
+			}
+		}
+		for model, mv := range cfg.ModelValues {
+			if err := openclaw.SetModelValue(model, mv); err != nil {
+				return fmt.Errorf("config.json 模型价值配置无效: %w", err)
+			}
+		}
+		for kind, bonus := range cfg.KindBonuses {
+			if err := worktracker.SetKindBonus(kind, bonus); err != nil {
+				return fmt.Errorf("config.json 会话类型加成无效: %w", err)
+			}
+		}
+		if cfg.PoleNode != "" {
+			poleNodeURL = cfg.PoleNode
+		}
+		if cfg.PoleContract != "" {
+			poleContractAddress = cfg.PoleContract
+		}
+		PoleNodes = cfg.PoleNodes
+		if cfg.PoleLocal != nil {
+			PoleLocal = *cfg.PoleLocal
+		}
+		if err := LoadWorkRecordABI(cfg.PoleABI); err != nil {
+			return fmt.Errorf("config.json 合约 ABI 无效: %w", err)
+		}
+		if cfg.PoleGas != nil {
+			if err := SetPoleGas(*cfg.PoleGas); err != nil {
+				return fmt.Errorf("config.json gas 策略无效: %w", err)
+			}
+		}
+		if err := SetPoleCurrency(cfg.PoleSymbol, cfg.PoleDecimals); err != nil {
+			return fmt.Errorf("config.json 链上货币无效: %w", err)
+		}
+		for agent, limit := range cfg.RateLimits {
+			if err := worktracker.SetRateLimit(agent, limit); err != nil {
+				return fmt.Errorf("config.json 限流配置无效: %w", err)
+			}
+		}
+		if cfg.EncryptRecords != "" {
+			key, err := loadRecordKey(cfg.EncryptRecords)
+			if err != nil {
+				return fmt.Errorf("config.json 记录加密配置无效: %w", err)
+			}
+			if err := worktracker.SetRecordKey(key); err != nil {
+				return err
+			}
+		}
+		for _, def := range cfg.TaskTypes {
+			if err := worktracker.RegisterTaskType(def); err != nil {
+				return fmt.Errorf("config.json 任务类型无效: %w", err)
+			}
+		}
+		if cfg.Classifier != nil {
+			if err := worktracker.ConfigureClassifier(*cfg.Classifier); err != nil {
+				return fmt.Errorf("config.json 分类器配置无效: %w", err)
+			}
+		}
+		return nil
+	}
 
 	// init
 	rootCmd.AddCommand(&cobra.Command{Use: "init", Short: "初始化", RunE: func(cmd *cobra.Command, args []string) error {
@@ -561,7 +763,7 @@ func main() {
 		w, _ := NewWallet(name)
 		os.MkdirAll(dataDir+"/wallets", 0755)
 		w.Save(dataDir + "/wallets")
-		fmt.Printf("钱包创建成功!\n  名称: %s\n  地址: %s\n  私钥: %s (请保管好!)\n", w.Name, w.Address, w.Private)
+		fmt.Printf("钱包创建成功!\n  名称: %s\n  地址: %s\n  私钥: %s (请保管好!)\n", w.Name, displayAddress(w.Address), w.Private)
 		return nil
 	}})
 
@@ -570,7 +772,7 @@ func main() {
 		for _, e := range entries {
 			w, _ := LoadWallet(dataDir+"/wallets", e.Name()[:len(e.Name())-5])
 			if w != nil {
-				fmt.Printf("  %s: %s\n", w.Name, w.Address)
+				fmt.Printf("  %s: %s\n", w.Name, displayAddress(w.Address))
 			}
 		}
 		return nil
@@ -582,7 +784,33 @@ func main() {
 			return fmt.Errorf("请先创建钱包")
 		}
 		m := NewMiner(w, dataDir)
-		fmt.Printf("余额: %.2f OAW\n", m.Balance())
+		fmt.Printf("已确认: %.2f OAW (≥%d 确认)\n", m.GetBalance(DefaultConfirmations), DefaultConfirmations)
+		fmt.Printf("待确认: %.2f OAW\n", m.PendingBalance(DefaultConfirmations))
+		fmt.Printf("总余额: %.2f OAW\n", m.Balance())
+		return nil
+	}})
+
+	walletCmd.AddCommand(&cobra.Command{Use: "agents", Short: "按钱包汇总各 Agent 的工作量 (config.json 的 agent_wallets)", RunE: func(cmd *cobra.Command, args []string) error {
+		tracker, _ := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		shares := walletShares(tracker)
+		if len(shares) == 0 {
+			fmt.Println("暂无工作记录")
+			return nil
+		}
+		for _, s := range shares {
+			address := "(钱包不存在)"
+			if w, err := LoadWallet(dataDir+"/wallets", s.Wallet); err == nil {
+				address = displayAddress(w.Address)
+			}
+			agents := make([]string, 0, len(s.Agents))
+			for a := range s.Agents {
+				agents = append(agents, a)
+			}
+			sort.Strings(agents)
+			fmt.Printf("%s: %s\n", s.Wallet, address)
+			fmt.Printf("  Agent: %s\n", strings.Join(agents, ", "))
+			fmt.Printf("  记录: %d 条, token: %d, 价值: %.2f OAW\n", s.Records, s.Tokens, s.Value)
+		}
 		return nil
 	}})
 
@@ -594,24 +822,19 @@ func main() {
 	mineCmd := &cobra.Command{Use: "mine", Short: "挖矿管理"}
 	rootCmd.AddCommand(mineCmd)
 
-	mineCmd.AddCommand(&cobra.Command{Use: "start", Short: "开始挖矿", RunE: func(cmd *cobra.Command, args []string) error {
+	var miningMode, rewardMode, rewardAddress, listenAddr, maxCPU string
+	var syncInterval time.Duration
+	mineStartCmd := &cobra.Command{Use: "start", Short: "开始挖矿", RunE: func(cmd *cobra.Command, args []string) error {
 		// 检查 PoLE 节点是否已运行
 		fmt.Println("检查 PoLE 节点状态...")
-		rpc := NewPoleRPC(poleNodeURL)
-		chainID, err := rpc.GetChainID()
+		probe := NewPoleRPC(poleNodeURL).Probe() // 只探测一次，不退避重试
+		chainID, err := probe.GetChainID()
 		if err != nil {
 			// 节点未运行，启动 PoLE 节点
 			fmt.Println("PoLE 节点未运行，正在启动...")
 			
-			// 使用正确的参数启动 PoLE 节点
-			cmdExec := exec.Command("D:/pole/pole-node.exe", 
-				"-data-dir", poleDataDir, 
-				"-genesis", "D:/pole/config/mainnet/genesis.json",
-				"-rpc-port", ":9090", 
-				"-p2p-port", ":26657")
-			cmdExec.Stdout = os.Stdout
-			cmdExec.Stderr = os.Stderr
-			err := cmdExec.Start()
+			// 启动本机 PoLE 节点 (程序与启动参数见 pole_node.go)
+			cmdExec, err := StartPoleNode()
 			if err != nil {
 				fmt.Printf("警告: PoLE 节点启动失败: %v\n", err)
 			} else {
@@ -620,19 +843,21 @@ func main() {
 
 			// 等待节点就绪 (最多等待 60 秒)
 			fmt.Println("等待节点就绪 (最多 60 秒)...")
-			for i := 0; i < 60; i++ {
+			start := time.Now()
+			deadline := start.Add(60 * time.Second)
+			for i := 1; ; i++ {
 				time.Sleep(1 * time.Second)
-				rpc := NewPoleRPC(poleNodeURL)
-				chainID, err = rpc.GetChainID()
+				chainID, err = probe.GetChainID()
 				if err == nil && chainID != "" {
 					fmt.Printf("✅ 节点已就绪 (Chain ID: %s)\n", chainID)
 					break
 				}
-				if i%10 == 0 && i > 0 {
-					fmt.Printf("  等待中... (%d秒)\n", i)
-				}
-				if i == 59 {
+				if time.Now().After(deadline) {
 					fmt.Println("警告: 节点启动超时，继续启动挖矿...")
+					break
+				}
+				if i%10 == 0 {
+					fmt.Printf("  等待中... (%d秒)\n", int(time.Since(start).Seconds()))
 				}
 			}
 		} else {
@@ -644,145 +869,1508 @@ func main() {
 			return fmt.Errorf("请先创建钱包: oaw wallet create")
 		}
 		miner = NewMiner(w, dataDir)
-		miningCtx, miningCancel = context.WithCancel(context.Background())
-		miner.Start(miningCtx)
-		fmt.Printf("挖矿已启动! 地址: %s\n", w.Address)
+		if err := miner.SetMode(miningMode); err != nil {
+			return err
+		}
+		if err := miner.SetRewardMode(rewardMode); err != nil {
+			return err
+		}
+		cpu, err := ParseCPUPercent(maxCPU)
+		if err != nil {
+			return err
+		}
+		miner.SetMaxCPU(cpu)
+		if rewardAddress != "" {
+			if err := miner.SetRewardAddress(rewardAddress); err != nil {
+				return err
+			}
+			fmt.Printf("奖励收款地址: %s\n", rewardAddress)
+		}
+		miningCtx, miningCancel = signal.NotifyContext(context.Background(), os.Interrupt)
+		defer miningCancel()
+		fmt.Printf("挖矿已启动! 地址: %s (模式: %s, 网络: %s)\n", displayAddress(w.Address), miningMode, activeNetwork.Name)
+		fmt.Println("使用 oaw mine stop 或 Ctrl+C 停止")
+		if listenAddr != "" {
+			fmt.Printf("区块接收服务: %s/api/chain/blocks\n", listenAddr)
+			go func() {
+				if err := miner.ServeChain(listenAddr); err != nil {
+					fmt.Printf("❌ 区块接收服务失败: %v\n", err)
+					miningCancel()
+				}
+			}()
+		}
+		if syncInterval > 0 {
+			fmt.Printf("OpenClaw 同步: 每 %s\n", syncInterval)
+			go runSyncLoop(miningCtx, syncInterval, func() error { return syncOnce(defaultSyncOptions()) })
+		}
+		if err := miner.RunDaemon(miningCtx, miningCancel); err != nil {
+			return err
+		}
+		fmt.Printf("挖矿已停止. 余额: %.2f OAW\n", miner.Balance())
 		return nil
-	}})
+	}}
+	mineStartCmd.Flags().StringVar(&miningMode, "mode", MiningModePoW, "出块模式: pow (哈希竞争) / stake (按链上已确认的工作价值加权)")
+	mineStartCmd.Flags().StringVar(&rewardMode, "reward", RewardModeRatio, "奖励模式: ratio (基础奖励×工作量占比) / value (新同步的工作价值)")
+	mineStartCmd.Flags().StringVar(&rewardAddress, "reward-address", "", "奖励收款地址 (冷钱包)，默认为挖矿钱包")
+	mineStartCmd.Flags().StringVar(&poleBinaryFlag, "pole-bin", "", "PoLE 节点程序 (节点未运行时启动，默认: config.json 的 pole_local.binary 或 <PoLE 安装目录>/pole-node)")
+	mineStartCmd.Flags().StringVar(&maxCPU, "max-cpu", "100%", "挖矿最大 CPU 占用 (如 50%)，避免影响 Agent 工作")
+	mineStartCmd.Flags().StringVar(&listenAddr, "listen", "", "接收对等节点区块的监听地址 (如 :8091)")
+	mineStartCmd.Flags().DurationVar(&syncInterval, "sync-interval", 0, "挖矿期间定时从 OpenClaw 同步工作量的间隔 (如 5m，0 为不同步)")
+	mineCmd.AddCommand(mineStartCmd)
 
 	mineCmd.AddCommand(&cobra.Command{Use: "stop", Short: "停止挖矿", RunE: func(cmd *cobra.Command, args []string) error {
-		if miner != nil {
-			miner.Stop()
-			if miningCancel != nil {
-				miningCancel()
-			}
-			fmt.Printf("挖矿已停止. 余额: %.2f OAW\n", miner.Balance())
+		c, err := ReadMinerControl(dataDir)
+		if err != nil {
+			fmt.Println("挖矿未运行")
+			return nil
+		}
+		if err := c.Stop(); errors.Is(err, ErrStaleControl) {
+			removeMinerControl(dataDir)
+			fmt.Printf("挖矿未运行: %v (PID: %d)，已移除残留的控制文件\n", err, c.PID)
+			return nil
+		} else if err != nil {
+			return err
 		}
+		removeMinerControl(dataDir)
+		fmt.Printf("挖矿已停止 (PID: %d)\n", c.PID)
 		return nil
 	}})
 
-	mineCmd.AddCommand(&cobra.Command{Use: "status", Short: "挖矿状态", RunE: func(cmd *cobra.Command, args []string) error {
+	var statusJSON bool
+	mineStatusCmd := &cobra.Command{Use: "status", Short: "挖矿状态", RunE: func(cmd *cobra.Command, args []string) error {
 		w, err := LoadWallet(dataDir+"/wallets", "default")
 		if err != nil {
 			return err
 		}
 		m := NewMiner(w, dataDir)
-		fmt.Printf("状态: %s\n", map[bool]string{true: "运行中", false: "已停止"}[m.working])
-		fmt.Printf("难度: %d (范围: %d-%d)\n", m.difficulty, m.minDifficulty, m.maxDifficulty)
-		fmt.Printf("余额: %.2f OAW\n", m.Balance())
-		fmt.Printf("区块: %d\n", len(m.Blocks()))
+		st := m.Status()
+		// 优先查询运行中的守护进程
+		if c, err := ReadMinerControl(dataDir); err == nil {
+			if running, err := c.Status(); err == nil {
+				st = running
+			}
+		}
+		if statusJSON {
+			data, _ := json.MarshalIndent(st, "", "  ")
+			fmt.Println(string(data))
+			return nil
+		}
+		fmt.Printf("状态: %s\n", map[bool]string{true: "运行中", false: "已停止"}[st.Running])
+		fmt.Printf("难度: %d (范围: %d-%d)\n", st.Difficulty, st.MinDifficulty, st.MaxDifficulty)
+		fmt.Printf("目标: %s\n", st.Target)
+		fmt.Printf("哈希率: %.0f H/s, 预计出块: %.1f 秒\n", st.HashRate, st.EstimatedBlockTime)
+		fmt.Printf("余额: %.2f OAW\n", st.Balance)
+		fmt.Printf("区块: %d\n", st.Blocks)
+		if h := st.OpenClaw; h != nil {
+			if h.Healthy {
+				fmt.Printf("OpenClaw: 正常 (上次成功 %s)\n", h.LastSuccess.Format("2006-01-02 15:04:05"))
+			} else {
+				fmt.Printf("OpenClaw: 不可达 %s (连续失败 %d 次: %s)\n", h.Downtime(time.Now()).Round(time.Second), h.Failures, h.LastError)
+			}
+		}
 		return nil
-	}})
-
-	// sync command - 从 OpenClaw 同步工作量
-	syncCmd := &cobra.Command{Use: "sync", Short: "从 OpenClaw 同步工作量", RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("从 OpenClaw 同步工作量...")
+	}}
+	mineStatusCmd.Flags().BoolVar(&statusJSON, "json", false, "以 JSON 输出")
+	mineCmd.AddCommand(mineStatusCmd)
 
-		err := openclaw.SyncFromSessions(dataDir)
+	mineCmd.AddCommand(&cobra.Command{Use: "verify", Short: "验证区块链", RunE: func(cmd *cobra.Command, args []string) error {
+		w, err := LoadWallet(dataDir+"/wallets", "default")
 		if err != nil {
-			return fmt.Errorf("同步失败: %v", err)
+			return err
 		}
-
-		// 显示统计
-		tokens, value, _ := openclaw.GetTotalStats(dataDir)
-		fmt.Printf("累计 Token: %d\n", tokens)
-		fmt.Printf("累计价值: %.2f OAW\n", value)
-
+		m := NewMiner(w, dataDir)
+		if err := m.VerifyChain(); err != nil {
+			fmt.Printf("❌ 验证失败: %v\n", err)
+			return nil
+		}
+		fmt.Printf("✅ 区块链验证通过 (%d 个区块)\n", len(m.Blocks()))
 		return nil
-	}}
-	rootCmd.AddCommand(syncCmd)
-
-	// pole command - PoLE 链集成
-	poleCmd := &cobra.Command{Use: "pole", Short: "PoLE 链集成"}
-	rootCmd.AddCommand(poleCmd)
+	}})
 
-	poleCmd.AddCommand(&cobra.Command{Use: "sync", Short: "同步到 PoLE 链", RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("=== OAW → PoLE 链同步 ===")
+	// peer commands - 对等节点
+	peerCmd := &cobra.Command{Use: "peer", Short: "对等节点管理"}
+	rootCmd.AddCommand(peerCmd)
 
-		records, err := openclaw.LoadRecords(dataDir + "/records")
+	peerCmd.AddCommand(&cobra.Command{Use: "add", Short: "添加对等节点 <url>", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := LoadConfig(dataDir)
 		if err != nil {
-			return fmt.Errorf("加载记录失败: %w", err)
+			return err
 		}
-
-		fmt.Printf("已加载 %d 条工作记录\n", len(records))
-
-		var totalValue float64
-		var totalTokens int
-		for _, r := range records {
-			totalValue += r.Value
-			totalTokens += r.TotalTokens
+		if !cfg.AddPeer(args[0]) {
+			fmt.Printf("节点已存在: %s\n", args[0])
+			return nil
 		}
-
-		fmt.Printf("\n累计:\n")
-		fmt.Printf("  Token: %d\n", totalTokens)
-		fmt.Printf("  价值: %.2f OAW\n", totalValue)
-
-		// 读取 PoLE 钱包
-		walletData, err := os.ReadFile("D:/pole/wallet.json")
-		if err == nil {
-			var wallet struct {
-				Address string `json:"address"`
-			}
-			json.Unmarshal(walletData, &wallet)
-			fmt.Printf("\n=== PoLE 钱包 ===\n")
-			fmt.Printf("地址: %s\n", wallet.Address)
+		if err := cfg.Save(dataDir); err != nil {
+			return err
 		}
-
-		fmt.Println("\n✅ PoLE 链同步完成!")
+		fmt.Printf("✅ 已添加节点: %s\n", args[0])
 		return nil
 	}})
 
-	poleCmd.AddCommand(&cobra.Command{Use: "wallet", Short: "查看 PoLE 钱包", RunE: func(cmd *cobra.Command, args []string) error {
-		walletData, err := os.ReadFile("D:/pole/wallet.json")
+	peerCmd.AddCommand(&cobra.Command{Use: "remove", Short: "移除对等节点 <url>", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := LoadConfig(dataDir)
 		if err != nil {
-			return fmt.Errorf("读取钱包失败: %w", err)
+			return err
 		}
-
-		var wallet struct {
-			Accounts []struct {
-				Address string `json:"address"`
-			} `json:"accounts"`
+		if !cfg.RemovePeer(args[0]) {
+			return fmt.Errorf("节点不存在: %s", args[0])
 		}
-		json.Unmarshal(walletData, &wallet)
-
-		fmt.Println("=== PoLE 钱包 ===")
-		if len(wallet.Accounts) > 0 {
-			fmt.Printf("地址: %s\n", wallet.Accounts[0].Address)
+		if err := cfg.Save(dataDir); err != nil {
+			return err
 		}
-		fmt.Println("(余额查询开发中...)")
-
+		fmt.Printf("✅ 已移除节点: %s\n", args[0])
 		return nil
 	}})
 
-	// pole verify - 链上验证
-	poleCmd.AddCommand(&cobra.Command{Use: "verify", Short: "验证链上数据", RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("=== PoLE 链上验证 ===\n")
-
-		// 1. 加载本地记录
-		recordsDir := dataDir + "/records"
-		entries, _ := os.ReadDir(recordsDir)
-		fmt.Printf("本地记录数: %d\n", len(entries))
-
-		// 2. 模拟验证
-		verified := 0
-		if len(entries) > 0 {
-			verifyCount := len(entries)
-			if verifyCount > 10 {
-				verifyCount = 10
-			}
-			verified = verifyCount
+	peerCmd.AddCommand(&cobra.Command{Use: "list", Short: "对等节点列表", RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := LoadConfig(dataDir)
+		if err != nil {
+			return err
+		}
+		for _, p := range cfg.Peers {
+			fmt.Printf("  %s\n", p)
 		}
-
-		fmt.Printf("已验证: %d\n", verified)
-		fmt.Printf("缺失: %d\n", 0)
-		fmt.Printf("验证率: %.1f%%\n", float64(verified)/float64(len(entries))*100)
-
 		return nil
 	}})
 
-	// pole stats - 链上统计
-	poleCmd.AddCommand(&cobra.Command{Use: "stats", Short: "链上统计", RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("=== PoLE 链上统计 ===\n")
+	var pruneKeep int
+	minePruneCmd := &cobra.Command{Use: "prune", Short: "裁剪旧区块 (保留区块头与余额快照)", RunE: func(cmd *cobra.Command, args []string) error {
+		w, err := LoadWallet(dataDir+"/wallets", "default")
+		if err != nil {
+			return err
+		}
+		m := NewMiner(w, dataDir)
+		pruned, err := m.Prune(pruneKeep)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ 已裁剪 %d 个区块 (快照高度: %d)\n", pruned, m.snapshot.Height)
+		return nil
+	}}
+	minePruneCmd.Flags().IntVar(&pruneKeep, "keep", DefaultPruneKeep, "保留完整数据的最近区块数")
+	mineCmd.AddCommand(minePruneCmd)
+
+	// hook commands - 新区块事件钩子
+	hookCmd := &cobra.Command{Use: "hook", Short: "新区块事件钩子"}
+	rootCmd.AddCommand(hookCmd)
+
+	var hookCommand, hookURL string
+	hookAddCmd := &cobra.Command{Use: "add", Short: "添加钩子 (--cmd 或 --url)", RunE: func(cmd *cobra.Command, args []string) error {
+		if hookCommand == "" && hookURL == "" {
+			return fmt.Errorf("请指定 --cmd 或 --url")
+		}
+		cfg, err := LoadConfig(dataDir)
+		if err != nil {
+			return err
+		}
+		cfg.Hooks = append(cfg.Hooks, Hook{Command: hookCommand, URL: hookURL})
+		if err := cfg.Save(dataDir); err != nil {
+			return err
+		}
+		fmt.Printf("✅ 已添加钩子 #%d\n", len(cfg.Hooks)-1)
+		return nil
+	}}
+	hookAddCmd.Flags().StringVar(&hookCommand, "cmd", "", "shell 命令 (区块事件 JSON 从 stdin 传入)")
+	hookAddCmd.Flags().StringVar(&hookURL, "url", "", "webhook 地址 (POST 区块事件 JSON)")
+	hookCmd.AddCommand(hookAddCmd)
+
+	hookCmd.AddCommand(&cobra.Command{Use: "list", Short: "钩子列表", RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := LoadConfig(dataDir)
+		if err != nil {
+			return err
+		}
+		for i, h := range cfg.Hooks {
+			if h.Command != "" {
+				fmt.Printf("  #%d 命令: %s\n", i, h.Command)
+			}
+			if h.URL != "" {
+				fmt.Printf("  #%d webhook: %s\n", i, h.URL)
+			}
+		}
+		return nil
+	}})
+
+	hookCmd.AddCommand(&cobra.Command{Use: "remove", Short: "移除钩子 <序号>", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
+		i, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("无效序号: %s", args[0])
+		}
+		cfg, err := LoadConfig(dataDir)
+		if err != nil {
+			return err
+		}
+		if i < 0 || i >= len(cfg.Hooks) {
+			return fmt.Errorf("钩子 #%d 不存在", i)
+		}
+		cfg.Hooks = append(cfg.Hooks[:i], cfg.Hooks[i+1:]...)
+		if err := cfg.Save(dataDir); err != nil {
+			return err
+		}
+		fmt.Printf("✅ 已移除钩子 #%d\n", i)
+		return nil
+	}})
+
+	// sync command - 从 OpenClaw 同步工作量
+	syncCmd := &cobra.Command{Use: "sync", Short: "从 OpenClaw 同步工作量", RunE: func(cmd *cobra.Command, args []string) error {
+		spec, _ := cmd.Flags().GetString("valuator")
+		valuator, err := worktracker.ParseValuator(spec)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("从 OpenClaw 同步工作量 (价值策略: %s)...\n", valuator)
+
+		opts := defaultSyncOptions()
+		opts.Valuator = valuator
+		if include, _ := cmd.Flags().GetStringSlice("agent"); len(include) > 0 {
+			opts.Filter.Include = include
+		}
+		if exclude, _ := cmd.Flags().GetStringSlice("exclude-agent"); len(exclude) > 0 {
+			opts.Filter.Exclude = append(opts.Filter.Exclude, exclude...)
+		}
+		opts.Transcripts, _ = cmd.Flags().GetBool("transcripts")
+
+		if watch, _ := cmd.Flags().GetBool("watch"); watch {
+			interval, _ := cmd.Flags().GetDuration("interval")
+			if interval <= 0 {
+				return fmt.Errorf("无效的同步间隔: %s", interval)
+			}
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+			fmt.Printf("持续同步中 (间隔 %s，Ctrl+C 停止)\n", interval)
+			runSyncLoop(ctx, interval, func() error { return syncOnce(opts) })
+			return nil
+		}
+
+		err = syncOnce(opts)
+		if err != nil {
+			return fmt.Errorf("同步失败: %v", err)
+		}
+
+		// 显示统计
+		tokens, value, _ := openclaw.GetTotalStats(dataDir)
+		fmt.Printf("累计 Token: %d\n", tokens)
+		fmt.Printf("累计价值: %.2f OAW\n", value)
+
+		return nil
+	}}
+	syncCmd.Flags().String("valuator", "composite", "价值策略: token/lines/flat[:价值]/duration[:每小时价值]/composite，或加权组合如 0.5*token+lines")
+	syncCmd.Flags().Bool("transcripts", true, "按会话记录文件 (JSONL) 逐条消息生成记录 (文件不存在时按会话记录增量)")
+	syncCmd.Flags().StringSlice("agent", nil, "只同步名称匹配的 Agent (通配符，可重复，默认全部)")
+	syncCmd.Flags().StringSlice("exclude-agent", nil, "排除名称匹配的 Agent (通配符，可重复)")
+	syncCmd.Flags().Bool("watch", false, "持续在后台定时同步 (失败时退避重试)")
+	syncCmd.Flags().Duration("interval", 5*time.Minute, "--watch 的同步间隔")
+	importCmd := &cobra.Command{Use: "import [文件或目录...]", Short: "导入归档/轮转的 OpenClaw 会话 (默认扫描各 Agent 的会话目录)", RunE: func(cmd *cobra.Command, args []string) error {
+		opts := defaultSyncOptions()
+		if include, _ := cmd.Flags().GetStringSlice("agent"); len(include) > 0 {
+			opts.Filter.Include = include
+		}
+		opts.Transcripts, _ = cmd.Flags().GetBool("transcripts")
+
+		var files []openclaw.ArchiveFile
+		if len(args) == 0 {
+			found, err := openclaw.FindArchives(opts.Filter)
+			if err != nil {
+				return fmt.Errorf("查找归档失败: %w", err)
+			}
+			files = found
+		}
+		// 指定的文件或目录归入 --agent 的第一个 Agent (默认为默认 Agent)
+		agent := openclaw.DefaultAgent
+		if len(opts.Filter.Include) > 0 {
+			agent = opts.Filter.Include[0]
+		}
+		for _, path := range args {
+			info, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				found, err := openclaw.ScanArchives(agent, path)
+				if err != nil {
+					return err
+				}
+				files = append(files, found...)
+				continue
+			}
+			files = append(files, openclaw.ArchiveFile{Agent: agent, Path: path, Transcript: strings.Contains(filepath.Base(path), ".jsonl"), ModTime: info.ModTime()})
+		}
+		if len(files) == 0 {
+			fmt.Println("没有找到归档的会话文件")
+			return nil
+		}
+
+		if list, _ := cmd.Flags().GetBool("list"); list {
+			for _, f := range files {
+				kind := "会话列表"
+				if f.Transcript {
+					kind = "会话记录"
+				}
+				fmt.Printf("%-10s %s  %s  %s\n", f.Agent, f.ModTime.Format("2006-01-02 15:04"), kind, f.Path)
+			}
+			return nil
+		}
+		fmt.Printf("导入 %d 个归档文件...\n", len(files))
+		return openclaw.ImportArchives(dataDir, opts.Valuator, files, opts.Transcripts)
+	}}
+	importCmd.Flags().StringSlice("agent", nil, "只导入名称匹配的 Agent (通配符，可重复)；指定文件时为其所属 Agent")
+	importCmd.Flags().Bool("transcripts", true, "按会话记录文件逐条消息导入，并导入不在会话列表中的会话记录")
+	importCmd.Flags().Bool("list", false, "只列出找到的归档文件")
+	syncCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(syncCmd)
+
+	// pricing command - 模型单价
+	rootCmd.AddCommand(&cobra.Command{Use: "pricing", Short: "查看模型单价表 (OpenClaw 配置与 config.json 的 pricing)", RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := LoadConfig(dataDir)
+		if err != nil {
+			return err
+		}
+		fromOpenClaw, err := openclaw.ModelPricing()
+		if err != nil {
+			fmt.Printf("⚠️ 读取 OpenClaw 配置失败: %v\n", err)
+		}
+		models := make([]string, 0, len(worktracker.Pricing))
+		for model := range worktracker.Pricing {
+			models = append(models, model)
+		}
+		if len(models) == 0 {
+			fmt.Println("未配置模型单价 (OpenClaw 配置 models.providers.*.models[].cost 或 config.json 的 pricing)")
+			return nil
+		}
+		sort.Strings(models)
+		fmt.Println("模型单价 (美元/百万 token):")
+		for _, model := range models {
+			p := worktracker.Pricing[model]
+			source := "config.json"
+			if _, ok := cfg.Pricing[model]; !ok {
+				if _, ok := fromOpenClaw[model]; ok {
+					source = "OpenClaw"
+				}
+			}
+			fmt.Printf("  %-40s 输入 %-8.3f 输出 %-8.3f 缓存 %-8.3f %s\n", model, p.Input, p.Output, p.Cache, source)
+		}
+		return nil
+	}})
+
+	// poll command - 实时轮询 OpenClaw API
+	pollCmd := &cobra.Command{Use: "poll", Short: "轮询 OpenClaw API 实时记录工作量 (可同时连接多个实例)", RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := pollOptionsFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+		tracker, err := openPollTracker()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+		fmt.Printf("轮询 %d 个 OpenClaw 实例、%d 个其他来源 (Ctrl+C 停止):\n", len(opts.instances), len(opts.adapters))
+		return runPoll(ctx, tracker, opts)
+	}}
+	addPollFlags(pollCmd)
+	rootCmd.AddCommand(pollCmd)
+
+	// events command - 事件日志
+	eventsCmd := &cobra.Command{Use: "events", Short: "集成器事件日志 (oaw poll 收到的事件)"}
+	eventsReplayCmd := &cobra.Command{Use: "replay", Short: "按当前分类规则与价格重新推导事件日志中的记录 (变化的记录写入修正记录)", RunE: func(cmd *cobra.Command, args []string) error {
+		var opts integrator.ReplayOptions
+		if since, _ := cmd.Flags().GetString("since"); since != "" {
+			t, err := worktracker.ParseFilterTime(since)
+			if err != nil {
+				return err
+			}
+			opts.Since = t
+		}
+		opts.All, _ = cmd.Flags().GetBool("all")
+		opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+
+		paths := journalPaths()
+		if len(paths) == 0 {
+			fmt.Println("没有事件日志 (oaw poll 运行时写入 data/events.jsonl)")
+			return nil
+		}
+		tracker, err := openPollTracker()
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			res, err := integrator.Replay(tracker, path, opts)
+			if err != nil {
+				return fmt.Errorf("重放 %s 失败: %w", path, err)
+			}
+			fmt.Printf("%s: %d 个事件，修正 %d 条记录，未变化 %d 条", path, res.Events, res.Amended, res.Unchanged)
+			if res.Unrecorded > 0 || res.Missing > 0 {
+				fmt.Printf(" (跳过未生成记录的事件 %d 个、已不存在的记录 %d 条)", res.Unrecorded, res.Missing)
+			}
+			fmt.Println()
+		}
+		if opts.DryRun {
+			fmt.Println("(试运行，未写入修正记录)")
+		}
+		return nil
+	}}
+	eventsReplayCmd.Flags().String("since", "", "只重放此后收到的事件 (RFC3339 或 2006-01-02)")
+	eventsReplayCmd.Flags().Bool("all", false, "结果未变化的记录也重新生成 (价值策略变化后重算统计)")
+	eventsReplayCmd.Flags().Bool("dry-run", false, "只统计会被修正的记录")
+	eventsCmd.AddCommand(eventsReplayCmd)
+	rootCmd.AddCommand(eventsCmd)
+
+	// report command - 工作摘要
+	reportCmd := &cobra.Command{Use: "report", Short: "生成工作摘要 (可附在 PR 或发票中)"}
+	reportSessionCmd := &cobra.Command{Use: "session <id>", Short: "会话的工作摘要: 耗时、token、工具调用、修改的文件、价值与工作证明", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
+		tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		if err != nil {
+			return fmt.Errorf("打开工作记录失败: %w", err)
+		}
+		rep, err := buildSessionReport(tracker, args[0])
+		if err != nil {
+			return err
+		}
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(rep)
+		}
+		rep.writeMarkdown(os.Stdout)
+		return nil
+	}}
+	reportSessionCmd.Flags().Bool("json", false, "输出 JSON (默认 Markdown)")
+	reportCmd.AddCommand(reportSessionCmd)
+	for _, period := range []worktracker.Period{worktracker.PeriodDay, worktracker.PeriodWeek} {
+		short := "某天的工作摘要 (按统计时区的自然日，默认今天): 记录、token、价值与按 Agent 汇总"
+		if period == worktracker.PeriodWeek {
+			short = "某周的工作摘要 (按统计时区的自然周，周一开始，默认本周): 记录、token、价值与按 Agent 汇总"
+		}
+		reportPeriodCmd := &cobra.Command{Use: string(period) + " [date]", Short: short, Args: cobra.MaximumNArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
+			at := time.Now()
+			if len(args) > 0 {
+				t, err := worktracker.ParseFilterTime(args[0])
+				if err != nil {
+					return err
+				}
+				at = t
+			}
+			tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+			if err != nil {
+				return fmt.Errorf("打开工作记录失败: %w", err)
+			}
+			rep := buildPeriodReport(tracker, period, at)
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(rep)
+			}
+			rep.writeMarkdown(os.Stdout)
+			return nil
+		}}
+		reportPeriodCmd.Flags().Bool("json", false, "输出 JSON (默认 Markdown)")
+		reportCmd.AddCommand(reportPeriodCmd)
+	}
+	rootCmd.AddCommand(reportCmd)
+
+	// watch command - 实时工作面板
+	watchCmd := &cobra.Command{Use: "watch", Short: "实时工作面板: 滚动 token/价值速率与各 Agent 统计", RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+		defer fmt.Print("\033[0m\n")
+
+		// 连接运行中的 API 服务
+		if api, _ := cmd.Flags().GetString("api"); api != "" {
+			events, err := streamEvents(ctx, api)
+			if err != nil {
+				return fmt.Errorf("连接 %s 失败: %w", api, err)
+			}
+			runDashboard(ctx, os.Stdout, api, events)
+			return nil
+		}
+
+		// 本进程轮询并订阅追踪器
+		opts, err := pollOptionsFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+		tracker, err := openPollTracker()
+		if err != nil {
+			return err
+		}
+		events, unsubscribe := tracker.Subscribe()
+		defer unsubscribe()
+		go func() {
+			if err := runPoll(ctx, tracker, opts); err != nil {
+				fmt.Printf("⚠️ %v\n", err)
+				cancel()
+			}
+		}()
+		runDashboard(ctx, os.Stdout, opts.describe(), events)
+		return nil
+	}}
+	addPollFlags(watchCmd)
+	watchCmd.Flags().String("api", "", "连接运行中 API 服务的事件流 (如 http://localhost:8080)，不在本进程轮询")
+	rootCmd.AddCommand(watchCmd)
+
+	// records command - 工作记录查询
+	recordsCmd := &cobra.Command{Use: "records", Short: "工作记录"}
+	rootCmd.AddCommand(recordsCmd)
+
+	recordsListCmd := &cobra.Command{Use: "list", Short: "查询工作记录", RunE: func(cmd *cobra.Command, args []string) error {
+		filter := worktracker.RecordFilter{}
+		filter.AgentID, _ = cmd.Flags().GetString("agent")
+		taskType, _ := cmd.Flags().GetString("type")
+		filter.TaskType = worktracker.TaskType(taskType)
+		filter.Status, _ = cmd.Flags().GetString("status")
+		filter.ParentID, _ = cmd.Flags().GetString("parent")
+		filter.Tags, _ = cmd.Flags().GetStringSlice("tag")
+		filter.Latest, _ = cmd.Flags().GetBool("latest")
+		filter.Commit, _ = cmd.Flags().GetString("commit")
+		filter.Limit, _ = cmd.Flags().GetInt("limit")
+		filter.Offset, _ = cmd.Flags().GetInt("offset")
+		filter.Cursor, _ = cmd.Flags().GetString("cursor")
+
+		var err error
+		since, _ := cmd.Flags().GetString("since")
+		if filter.Since, err = worktracker.ParseFilterTime(since); err != nil {
+			return err
+		}
+		until, _ := cmd.Flags().GetString("until")
+		if filter.Until, err = worktracker.ParseFilterTime(until); err != nil {
+			return err
+		}
+
+		tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		if err != nil {
+			return fmt.Errorf("打开工作记录失败: %w", err)
+		}
+		page, err := tracker.QueryPage(filter)
+		if err != nil {
+			return err
+		}
+		if len(page.Records) == 0 {
+			fmt.Println("没有匹配的工作记录")
+			return nil
+		}
+
+		fmt.Printf("📋 工作记录 (%d/%d 条)\n", len(page.Records), page.Total)
+		for _, r := range page.Records {
+			at := r.CompletedAt
+			if at == 0 {
+				at = r.StartedAt
+			}
+			signed := "未签名"
+			if r.IsSigned() {
+				signed = "已签名"
+			}
+			tags := ""
+			if len(r.Tags) > 0 {
+				tags = "  [" + strings.Join(r.Tags, ", ") + "]"
+			}
+			if r.Amends != "" {
+				tags += "  (修正 " + r.Amends + ")"
+			}
+			if r.Flagged() {
+				tags += "  ⚠️ 可疑"
+			}
+			fmt.Printf("  %s  %s  %-8s %-9s %8.4f  %s  %s%s\n",
+				time.UnixMilli(at).Format("2006-01-02 15:04"), r.ID, r.TaskType, r.Status, r.CalculateValue(), r.AgentID, signed, tags)
+		}
+		if page.NextCursor != "" {
+			fmt.Printf("\n下一页: --cursor %s\n", page.NextCursor)
+		}
+		return nil
+	}}
+	recordsListCmd.Flags().String("agent", "", "Agent ID")
+	recordsListCmd.Flags().String("type", "", "任务类型 (coding/debug/writing/...)")
+	recordsListCmd.Flags().String("status", "", "状态 (pending/completed/failed)")
+	recordsListCmd.Flags().StringSlice("tag", nil, "标签 (可重复或逗号分隔，须全部匹配)")
+	recordsListCmd.Flags().Bool("latest", false, "不显示已被修正的旧版本")
+	recordsListCmd.Flags().String("commit", "", "关联该 git 提交的记录 (SHA 或至少 4 位前缀)")
+	recordsListCmd.Flags().String("parent", "", "父任务 ID (列出其子任务)")
+	recordsListCmd.Flags().String("since", "", "起始时间 (2006-01-02 或 RFC3339)")
+	recordsListCmd.Flags().String("until", "", "截止时间 (不含)")
+	recordsListCmd.Flags().Int("limit", 0, "最多显示条数 (0 表示全部)")
+	recordsListCmd.Flags().Int("offset", 0, "跳过条数")
+	recordsListCmd.Flags().String("cursor", "", "分页游标 (上一页输出的下一页游标)")
+	recordsCmd.AddCommand(recordsListCmd)
+
+	recordsCmd.AddCommand(&cobra.Command{Use: "for-commit", Short: "查找产生某个 git 提交的工作记录 <sha> (至少 4 位前缀)", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args[0]) < 4 {
+			return fmt.Errorf("提交 SHA 至少需要 4 位")
+		}
+		tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		if err != nil {
+			return fmt.Errorf("打开工作记录失败: %w", err)
+		}
+		records := tracker.Query(worktracker.RecordFilter{Commit: args[0], Latest: true})
+		if len(records) == 0 {
+			fmt.Printf("没有关联提交 %s 的工作记录 (需在 oaw poll 时设置 --workspace)\n", args[0])
+			return nil
+		}
+		for _, r := range records {
+			fmt.Printf("记录 %s\n", r.ID)
+			fmt.Printf("  Agent: %s  任务: %s (%s)\n", r.AgentID, r.TaskDesc, r.TaskType)
+			fmt.Printf("  时间: %s ~ %s\n", time.UnixMilli(r.StartedAt).Format("2006-01-02 15:04:05"), time.UnixMilli(r.CompletedAt).Format("2006-01-02 15:04:05"))
+			if r.Repo != "" {
+				fmt.Printf("  仓库: %s\n", r.Repo)
+			}
+			fmt.Printf("  提交: %s\n", strings.Join(r.Commits, ", "))
+			fmt.Printf("  代码行: +%d -%d  Token: %d  模型: %s  价值: %.4f OAW\n", r.CodeLines, r.LinesRemoved, r.TokensInput+r.TokensOutput, r.Model, r.CalculateValue())
+			fmt.Printf("  工作证明: %s\n", r.ProofHash)
+			if r.IsSigned() {
+				fmt.Printf("  签名钱包: %s\n", r.Signer)
+			}
+			if r.Amends != "" {
+				fmt.Printf("  修正自: %s\n", r.Amends)
+			}
+		}
+		return nil
+	}})
+
+	recordsCmd.AddCommand(&cobra.Command{Use: "show", Short: "查看记录详情及子任务汇总 <id>", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
+		tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		if err != nil {
+			return fmt.Errorf("打开工作记录失败: %w", err)
+		}
+		detail, err := tracker.GetRecordDetail(args[0])
+		if err != nil {
+			return err
+		}
+
+		r := detail.Record
+		fmt.Printf("📄 记录 %s\n", r.ID)
+		fmt.Printf("  Agent:     %s\n", r.AgentID)
+		fmt.Printf("  类型/状态: %s / %s\n", r.TaskType, r.Status)
+		fmt.Printf("  描述:      %s\n", r.TaskDesc)
+		if r.Encrypted() {
+			fmt.Println("  ⚠️ 记录字段已加密但未配置密钥 (config.json 的 encrypt_records)，工作证明无法按明文校验")
+		}
+		if r.ParentID != "" {
+			fmt.Printf("  父任务:    %s\n", r.ParentID)
+		}
+		if len(r.Tags) > 0 {
+			fmt.Printf("  标签:      %s\n", strings.Join(r.Tags, ", "))
+		}
+		fmt.Printf("  开始:      %s\n", time.UnixMilli(r.StartedAt).Format("2006-01-02 15:04:05"))
+		if r.CompletedAt > 0 {
+			fmt.Printf("  结束:      %s (耗时 %s)\n", time.UnixMilli(r.CompletedAt).Format("2006-01-02 15:04:05"), r.Duration().Round(time.Second))
+		}
+		fmt.Printf("  Token:     输入 %d / 输出 %d\n", r.TokensInput, r.TokensOutput)
+		fmt.Printf("  产出:      代码 %d 行 (%d 文件), 文字 %d, 修复 bug %d, 错误 %d, API 调用 %d\n",
+			r.CodeLines, r.CodeFiles, r.WordsWritten, r.BugsFixed, r.ErrorsFixed, r.APICalls)
+		fmt.Printf("  价值:      %.4f\n", detail.Value)
+		if r.Model != "" || r.CostUSD > 0 {
+			fmt.Printf("  模型/成本: %s / $%.4f\n", r.Model, r.CostUSD)
+		}
+		if r.QualityScore != nil {
+			fmt.Printf("  质量评分:  %.2f (%s, %s)\n", *r.QualityScore, r.ReviewedBy, time.UnixMilli(r.ReviewedAt).Format("2006-01-02 15:04"))
+		}
+		proof := "✅ 与字段一致"
+		if !detail.ProofValid {
+			proof = "❌ 与字段不符"
+		}
+		fmt.Printf("  工作证明:  %s %s\n", r.ProofHash, proof)
+		switch {
+		case detail.SignatureValid:
+			fmt.Printf("  签名:      ✅ %s\n", r.Signer)
+		case r.IsSigned():
+			fmt.Printf("  签名:      ❌ 校验失败 (声明 %s)\n", r.Signer)
+		default:
+			fmt.Println("  签名:      未签名")
+		}
+		if detail.AmendedBy != "" {
+			fmt.Printf("  ⚠️ 已被 %s 修正\n", detail.AmendedBy)
+		}
+		for _, reason := range detail.Anomalies {
+			fmt.Printf("  ⚠️ 可疑: %s\n", reason)
+		}
+
+		rollup, err := tracker.Rollup(args[0])
+		if err != nil {
+			return err
+		}
+		if len(rollup.Subtasks) > 0 {
+			fmt.Println("\n子任务:")
+			var show func(n *worktracker.TaskRollup, indent string)
+			show = func(n *worktracker.TaskRollup, indent string) {
+				r := n.Record
+				fmt.Printf("%s%s  %-8s %-9s 耗时 %-8s 自身 %.4f  汇总 %.4f  %s\n",
+					indent, r.ID, r.TaskType, r.Status, r.Duration().Round(time.Second), n.OwnValue, n.Value, r.TaskDesc)
+				for _, sub := range n.Subtasks {
+					show(sub, indent+"  └ ")
+				}
+			}
+			show(rollup, "  ")
+
+			fmt.Printf("\n汇总: %d 个任务, Token %d, 代码 %d 行, 文字 %d, 修复 bug %d, 价值 %.4f\n",
+				rollup.Tasks, rollup.TotalTokens, rollup.CodeLines, rollup.WordsWritten, rollup.BugsFixed, rollup.Value)
+		}
+
+		if chain := tracker.Amendments(args[0]); len(chain) > 1 {
+			fmt.Println("\n修正历史:")
+			for _, r := range chain {
+				if r.Amends == "" {
+					fmt.Printf("  %s  原始记录  证明 %.16s  价值 %.4f\n", r.ID, r.ProofHash, r.CalculateValue())
+					continue
+				}
+				fmt.Printf("  %s  %s 修正 (原证明 %.16s)  证明 %.16s  价值 %.4f  原因: %s\n",
+					r.ID, time.UnixMilli(r.AmendedAt).Format("2006-01-02 15:04"), r.AmendedProof, r.ProofHash, r.CalculateValue(), r.AmendReason)
+			}
+		}
+		return nil
+	}})
+
+	recordsAmendCmd := &cobra.Command{Use: "amend", Short: "修正已完成的记录 <id> (原记录与工作证明保持不变)", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
+		reason, _ := cmd.Flags().GetString("reason")
+		if reason == "" {
+			return fmt.Errorf("请用 --reason 说明修正原因")
+		}
+
+		tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		if err != nil {
+			return fmt.Errorf("打开工作记录失败: %w", err)
+		}
+		if err := setTrackerSigners(tracker); err != nil {
+			return err
+		}
+
+		chain := tracker.Amendments(args[0])
+		if len(chain) == 0 {
+			return fmt.Errorf("记录不存在: %s", args[0])
+		}
+		orig := chain[len(chain)-1]
+		if orig.ID != args[0] {
+			return fmt.Errorf("记录已被修正，请修正最新版本 %s", orig.ID)
+		}
+
+		// 未指定的指标沿用原值
+		result := worktracker.TaskResult{
+			TokensInput:  orig.TokensInput,
+			TokensOutput: orig.TokensOutput,
+			TokensCache:  orig.TokensCache,
+			CodeLines:    orig.CodeLines,
+			CodeFiles:    orig.CodeFiles,
+			WordsWritten: orig.WordsWritten,
+			BugsFixed:    orig.BugsFixed,
+			APICalls:     orig.APICalls,
+			ErrorsFixed:  orig.ErrorsFixed,
+		}
+		flags := cmd.Flags()
+		if flags.Changed("tokens-input") {
+			result.TokensInput, _ = flags.GetInt64("tokens-input")
+		}
+		if flags.Changed("tokens-output") {
+			result.TokensOutput, _ = flags.GetInt64("tokens-output")
+		}
+		if flags.Changed("tokens-cache") {
+			result.TokensCache, _ = flags.GetInt64("tokens-cache")
+		}
+		if flags.Changed("code-lines") {
+			result.CodeLines, _ = flags.GetInt("code-lines")
+		}
+		if flags.Changed("code-files") {
+			result.CodeFiles, _ = flags.GetInt("code-files")
+		}
+		if flags.Changed("words") {
+			result.WordsWritten, _ = flags.GetInt("words")
+		}
+		if flags.Changed("bugs-fixed") {
+			result.BugsFixed, _ = flags.GetInt("bugs-fixed")
+		}
+		if flags.Changed("api-calls") {
+			result.APICalls, _ = flags.GetInt("api-calls")
+		}
+		if flags.Changed("errors-fixed") {
+			result.ErrorsFixed, _ = flags.GetInt("errors-fixed")
+		}
+
+		amended, err := tracker.Amend(orig.ID, result, reason)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ 已修正记录 %s → %s\n", orig.ID, amended.ID)
+		fmt.Printf("   原工作证明: %s (保持不变)\n", amended.AmendedProof)
+		fmt.Printf("   新工作证明: %s\n", amended.ProofHash)
+		fmt.Printf("   价值: %.4f → %.4f\n", orig.CalculateValue(), amended.CalculateValue())
+		return nil
+	}}
+	recordsAmendCmd.Flags().String("reason", "", "修正原因 (必填)")
+	recordsAmendCmd.Flags().Int64("tokens-input", 0, "输入 token")
+	recordsAmendCmd.Flags().Int64("tokens-output", 0, "输出 token")
+	recordsAmendCmd.Flags().Int64("tokens-cache", 0, "缓存读写 token")
+	recordsAmendCmd.Flags().Int("code-lines", 0, "代码行数")
+	recordsAmendCmd.Flags().Int("code-files", 0, "文件数")
+	recordsAmendCmd.Flags().Int("words", 0, "文字产出")
+	recordsAmendCmd.Flags().Int("bugs-fixed", 0, "修复 bug 数")
+	recordsAmendCmd.Flags().Int("api-calls", 0, "API 调用次数")
+	recordsAmendCmd.Flags().Int("errors-fixed", 0, "错误修复数")
+	recordsCmd.AddCommand(recordsAmendCmd)
+
+	recordsCmd.AddCommand(&cobra.Command{Use: "dedup", Short: "清理重复的工作记录", RunE: func(cmd *cobra.Command, args []string) error {
+		removed, err := openclaw.DedupRecords(filepath.Join(dataDir, "records"))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("清理会话记录失败: %w", err)
+		}
+		fmt.Printf("会话记录: 删除 %d 条重复\n", removed)
+
+		tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		if err != nil {
+			return fmt.Errorf("打开工作记录失败: %w", err)
+		}
+		removed, err = tracker.Dedup()
+		if err != nil {
+			return fmt.Errorf("清理追踪器记录失败: %w", err)
+		}
+		fmt.Printf("追踪器记录: 删除 %d 条重复\n", removed)
+
+		fmt.Println("✅ 去重完成")
+		return nil
+	}})
+
+	recordsCmd.AddCommand(&cobra.Command{Use: "history", Short: "查看会话记录的同步增量历史 [会话 ID]", Args: cobra.MaximumNArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
+		sessionID := ""
+		if len(args) > 0 {
+			sessionID = args[0]
+		}
+		entries, err := openclaw.LoadDeltaLog(dataDir, sessionID)
+		if err != nil {
+			return fmt.Errorf("读取增量日志失败: %w", err)
+		}
+		if len(entries) == 0 {
+			fmt.Println("暂无同步历史")
+			return nil
+		}
+		for _, e := range entries {
+			id := e.SessionID
+			if e.MessageID != "" {
+				id += "/" + e.MessageID
+			}
+			fmt.Printf("%s  %-40s  +%d token (输入 %d / 输出 %d / 缓存 %d)  +%.2f OAW\n",
+				e.SyncedAt.Format("2006-01-02 15:04:05"), id, e.TotalTokens, e.InputTokens, e.OutputTokens, e.CacheTokens, e.Value)
+		}
+		return nil
+	}})
+
+	recordsCmd.AddCommand(&cobra.Command{Use: "import", Short: "从 JSONL 导入工作记录 <file>", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("打开导入文件失败: %w", err)
+		}
+		defer f.Close()
+
+		tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		if err != nil {
+			return fmt.Errorf("打开工作记录失败: %w", err)
+		}
+		result, err := tracker.Import(f)
+		if result != nil {
+			for _, e := range result.Invalid {
+				fmt.Printf("⚠️ 第 %d 行: %s\n", e.Line, e.Err)
+			}
+			fmt.Printf("导入 %d 条，重复 %d 条，无效 %d 条\n", result.Imported, result.Duplicates, len(result.Invalid))
+			if result.ProofsFixed > 0 {
+				fmt.Printf("   %d 条记录的工作证明与字段不符，已重算\n", result.ProofsFixed)
+			}
+			if result.Unsigned > 0 {
+				fmt.Printf("   %d 条记录签名校验失败，已按未签名导入\n", result.Unsigned)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println("✅ 导入完成")
+		return nil
+	}})
+
+	recordsCmd.AddCommand(&cobra.Command{Use: "flagged", Short: "列出被隔离的可疑记录", RunE: func(cmd *cobra.Command, args []string) error {
+		tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		if err != nil {
+			return fmt.Errorf("打开工作记录失败: %w", err)
+		}
+		flagged := tracker.FlaggedRecords()
+		clockIssues := tracker.ClockIssues()
+		if len(clockIssues) > 0 {
+			fmt.Printf("⏰ 时间异常的记录 (%d 条，时钟可能被回拨或伪造，顺序以写入序号为准):\n", len(clockIssues))
+			for _, issue := range clockIssues {
+				fmt.Printf("  %s  %s  %s\n", issue.Record.ID, issue.Record.AgentID, issue.Reason)
+			}
+		}
+		if len(flagged) == 0 {
+			if len(clockIssues) == 0 {
+				fmt.Println("✅ 没有可疑记录")
+			}
+			return nil
+		}
+		fmt.Printf("⚠️ 可疑记录 (%d 条，不计入价值与统计，可用 oaw records amend 更正):\n", len(flagged))
+		for _, r := range flagged {
+			fmt.Printf("  %s  %s  %-8s %s  %s\n",
+				time.UnixMilli(r.CompletedAt).Format("2006-01-02 15:04"), r.ID, r.TaskType, r.AgentID, r.TaskDesc)
+			for _, reason := range r.Anomalies() {
+				fmt.Printf("      - %s\n", reason)
+			}
+		}
+		return nil
+	}})
+
+	recordsCmd.AddCommand(&cobra.Command{Use: "compact", Short: "将已结束记录合并为分段文件 (减少记录目录中的文件数)", RunE: func(cmd *cobra.Command, args []string) error {
+		tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		if err != nil {
+			return fmt.Errorf("打开工作记录失败: %w", err)
+		}
+		result, err := tracker.Compact()
+		if err != nil {
+			return fmt.Errorf("合并失败: %w", err)
+		}
+		if result.Records == 0 {
+			fmt.Printf("✅ 没有需要合并的记录 (分段文件 %d 个)\n", result.Segments)
+			return nil
+		}
+		fmt.Printf("✅ 已合并 %d 条记录，分段文件 %d 个 (tracker/segments/)\n", result.Records, result.Segments)
+		if result.Loose > 0 {
+			fmt.Printf("  %d 个未结束或重复的记录仍为单独文件\n", result.Loose)
+		}
+		return nil
+	}})
+
+	recordsCmd.AddCommand(&cobra.Command{Use: "keygen", Short: "生成记录加密的数据密钥文件 <file>", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
+		if err := worktracker.GenerateRecordKeyFile(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("✅ 数据密钥已生成: %s\n", args[0])
+		fmt.Printf("   在 config.json 中设置 \"encrypt_records\": %q 启用加密，请妥善备份 (丢失后无法解密)\n", args[0])
+		return nil
+	}})
+
+	recordsCmd.AddCommand(&cobra.Command{Use: "encrypt", Short: "按当前密钥加密已有记录的敏感字段", RunE: func(cmd *cobra.Command, args []string) error {
+		if worktracker.RecordKey == nil {
+			return fmt.Errorf("未启用记录加密 (config.json 的 encrypt_records)")
+		}
+		tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		if err != nil {
+			return fmt.Errorf("打开工作记录失败: %w", err)
+		}
+		n, err := tracker.EncryptAll()
+		if err != nil {
+			return fmt.Errorf("加密失败: %w", err)
+		}
+		fmt.Printf("✅ 已重写 %d 条记录 (任务描述与修正原因已加密)\n", n)
+		return nil
+	}})
+
+	recordsCmd.AddCommand(&cobra.Command{Use: "types", Short: "列出任务类型及权重", RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Println("📋 任务类型 (自定义类型在 config.json 的 task_types 中声明):")
+		for _, def := range worktracker.TaskTypes() {
+			source := "配置"
+			if def.Builtin {
+				source = "内置"
+			}
+			fmt.Printf("  %-12s 权重 %-5.2f %s  %s", def.Name, def.Weight, source, def.Desc)
+			if len(def.Keywords) > 0 {
+				fmt.Printf("  关键词: %s", strings.Join(def.Keywords, ", "))
+			}
+			fmt.Println()
+		}
+		return nil
+	}})
+
+	recordsClassifyCmd := &cobra.Command{Use: "classify", Short: "按当前分类器为内容分类 <内容> (用于调试分类规则)", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
+		tools, _ := cmd.Flags().GetStringSlice("tool")
+		fmt.Println(worktracker.Classify(worktracker.ClassifyInput{Content: args[0], Tools: tools}))
+		return nil
+	}}
+	recordsClassifyCmd.Flags().StringSlice("tool", nil, "调用的工具名称 (可重复)")
+	recordsCmd.AddCommand(recordsClassifyCmd)
+
+	webhookCmd := &cobra.Command{Use: "webhook", Short: "任务完成/失败事件 Webhook"}
+	recordsCmd.AddCommand(webhookCmd)
+
+	webhookAddCmd := &cobra.Command{Use: "add", Short: "添加 Webhook <url>", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
+		secret, _ := cmd.Flags().GetString("secret")
+		events, _ := cmd.Flags().GetStringSlice("events")
+		hook := worktracker.Webhook{URL: args[0], Secret: secret}
+		for _, e := range events {
+			hook.Events = append(hook.Events, worktracker.RecordEventType(strings.TrimSpace(e)))
+		}
+
+		tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		if err != nil {
+			return fmt.Errorf("打开工作记录失败: %w", err)
+		}
+		if err := tracker.AddWebhook(hook); err != nil {
+			return err
+		}
+		fmt.Printf("✅ 已添加 Webhook #%d: %s\n", len(tracker.Webhooks())-1, hook.URL)
+		return nil
+	}}
+	webhookAddCmd.Flags().String("secret", "", "HMAC-SHA256 签名密钥 (请求头 X-OAW-Signature)")
+	webhookAddCmd.Flags().StringSlice("events", nil, "订阅的事件: started/completed/failed/amended (默认 completed,failed)")
+	webhookCmd.AddCommand(webhookAddCmd)
+
+	webhookCmd.AddCommand(&cobra.Command{Use: "list", Short: "Webhook 列表", RunE: func(cmd *cobra.Command, args []string) error {
+		tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		if err != nil {
+			return fmt.Errorf("打开工作记录失败: %w", err)
+		}
+		hooks := tracker.Webhooks()
+		if len(hooks) == 0 {
+			fmt.Println("暂无 Webhook")
+			return nil
+		}
+		for i, h := range hooks {
+			events := "completed,failed"
+			if len(h.Events) > 0 {
+				parts := make([]string, len(h.Events))
+				for j, e := range h.Events {
+					parts[j] = string(e)
+				}
+				events = strings.Join(parts, ",")
+			}
+			signed := "未签名"
+			if h.Secret != "" {
+				signed = "HMAC 签名"
+			}
+			fmt.Printf("  #%d %s  [%s]  %s\n", i, h.URL, events, signed)
+		}
+		return nil
+	}})
+
+	webhookCmd.AddCommand(&cobra.Command{Use: "remove", Short: "移除 Webhook <序号>", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
+		i, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("无效的序号: %s", args[0])
+		}
+		tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		if err != nil {
+			return fmt.Errorf("打开工作记录失败: %w", err)
+		}
+		if err := tracker.RemoveWebhook(i); err != nil {
+			return err
+		}
+		fmt.Printf("✅ 已移除 Webhook #%d\n", i)
+		return nil
+	}})
+
+	recordsReviewCmd := &cobra.Command{Use: "review", Short: "设置记录质量评分 <id>", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
+		if !cmd.Flags().Changed("score") {
+			return fmt.Errorf("请用 --score 指定 0~1 的质量评分")
+		}
+		score, _ := cmd.Flags().GetFloat64("score")
+		reviewer, _ := cmd.Flags().GetString("reviewer")
+
+		tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		if err != nil {
+			return fmt.Errorf("打开工作记录失败: %w", err)
+		}
+		before, err := tracker.GetRecord(args[0])
+		if err != nil {
+			return err
+		}
+		oldValue := before.CalculateValue()
+		record, err := tracker.SetQuality(args[0], score, reviewer)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ 已评审记录 %s: 质量评分 %.2f\n", record.ID, *record.QualityScore)
+		fmt.Printf("   价值: %.4f → %.4f\n", oldValue, record.CalculateValue())
+		return nil
+	}}
+	recordsReviewCmd.Flags().Float64("score", 0, "质量评分 (0~1)")
+	recordsReviewCmd.Flags().String("reviewer", "", "评审人")
+	recordsCmd.AddCommand(recordsReviewCmd)
+
+	recordsArchiveCmd := &cobra.Command{Use: "archive", Short: "归档过期记录为月度摘要", RunE: func(cmd *cobra.Command, args []string) error {
+		keep := worktracker.DefaultRetentionMonths
+		if cfg, err := LoadConfig(dataDir); err == nil && cfg.RetentionMonths > 0 {
+			keep = cfg.RetentionMonths
+		}
+		if cmd.Flags().Changed("keep-months") {
+			keep, _ = cmd.Flags().GetInt("keep-months")
+		}
+
+		tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		if err != nil {
+			return fmt.Errorf("打开工作记录失败: %w", err)
+		}
+		result, err := tracker.Archive(keep)
+		if err != nil {
+			return err
+		}
+		if result.Records == 0 {
+			fmt.Printf("没有 %s 之前的记录需要归档 (保留 %d 个月)\n", result.Cutoff.Format("2006-01-02"), keep)
+			return nil
+		}
+		for _, a := range result.Archives {
+			fmt.Printf("%s  任务 %d  Token %d  价值 %.4f  Merkle 根 %s\n",
+				a.Month, a.Summary.TotalTasks, a.Summary.TotalTokens, a.Summary.TotalValue, a.Root)
+		}
+		fmt.Printf("✅ 已归档 %d 条记录 (%s 之前)\n", result.Records, result.Cutoff.Format("2006-01-02"))
+		return nil
+	}}
+	recordsArchiveCmd.Flags().Int("keep-months", 0, "保留的原始记录月数 (默认读取 config.json 的 retention_months，否则 12)")
+	recordsCmd.AddCommand(recordsArchiveCmd)
+
+	// leaderboard command - Agent 排行榜
+	leaderboardCmd := &cobra.Command{Use: "leaderboard", Short: "按已验证价值排名 Agent", RunE: func(cmd *cobra.Command, args []string) error {
+		period, _ := cmd.Flags().GetString("period")
+		lookback, err := worktracker.ParseLookback(period)
+		if err != nil {
+			return err
+		}
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		if err != nil {
+			return fmt.Errorf("打开工作记录失败: %w", err)
+		}
+		var since time.Time
+		if lookback > 0 {
+			since = time.Now().Add(-lookback)
+		}
+		board := tracker.Leaderboard(since, limit)
+		if len(board) == 0 {
+			fmt.Println("暂无已验证的工作记录 (排行榜只计入签名校验通过的记录)")
+			return nil
+		}
+
+		fmt.Printf("🏆 Agent 排行榜 (%s)\n", period)
+		fmt.Printf("  %4s  %-20s  %6s  %10s  %12s  %s\n", "排名", "Agent", "任务", "Token", "已验证价值", "签名钱包")
+		for _, e := range board {
+			fmt.Printf("  %4d  %-20s  %6d  %10d  %12.4f  %s\n", e.Rank, e.AgentID, e.Tasks, e.TotalTokens, e.Value, e.Signer)
+		}
+		return nil
+	}}
+	leaderboardCmd.Flags().String("period", "30d", "统计时长: 30d / 2w / 12h，all 为全部")
+	leaderboardCmd.Flags().Int("limit", 10, "显示条数 (0 为全部)")
+	rootCmd.AddCommand(leaderboardCmd)
+
+	// stats command - 工作量时间序列统计
+	statsCmd := &cobra.Command{Use: "stats", Short: "按日/周/月统计工作量", RunE: func(cmd *cobra.Command, args []string) error {
+		if byAgent, _ := cmd.Flags().GetBool("by-agent"); byAgent {
+			tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+			if err != nil {
+				return fmt.Errorf("打开工作记录失败: %w", err)
+			}
+			agents := tracker.AgentBreakdown()
+			if len(agents) == 0 {
+				fmt.Println("暂无工作记录")
+				return nil
+			}
+			fmt.Println("📊 工作量统计 (按 Agent)")
+			fmt.Printf("  %-20s  %6s  %6s  %6s  %8s  %10s  %10s  %12s  %10s  %6s\n", "Agent", "任务", "完成", "失败", "成功率", "平均耗时", "Token", "价值", "成本($)", "可疑")
+			for _, a := range agents {
+				fmt.Printf("  %-20s  %6d  %6d  %6d  %7.1f%%  %10s  %10d  %12.4f  %10.4f  %6d\n",
+					a.AgentID, a.Tasks, a.CompletedTasks, a.FailedTasks, a.SuccessRate()*100,
+					a.AvgDuration().Round(time.Second), a.TotalTokens, a.TotalValue, a.TotalCostUSD, a.Flagged)
+			}
+			total := tracker.GetStats()
+			fmt.Printf("  合计: 价值 %.4f OAW, 估算成本 $%.4f\n", total.TotalValue, total.TotalCostUSD)
+			return nil
+		}
+
+		p, _ := cmd.Flags().GetString("period")
+		period, err := worktracker.ParsePeriod(p)
+		if err != nil {
+			return err
+		}
+		fromStr, _ := cmd.Flags().GetString("from")
+		from, err := worktracker.ParseFilterTime(fromStr)
+		if err != nil {
+			return err
+		}
+		if today, _ := cmd.Flags().GetBool("today"); today {
+			period, from = worktracker.PeriodDay, worktracker.Today()
+		}
+		toStr, _ := cmd.Flags().GetString("to")
+		to, err := worktracker.ParseFilterTime(toStr)
+		if err != nil {
+			return err
+		}
+
+		tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		if err != nil {
+			return fmt.Errorf("打开工作记录失败: %w", err)
+		}
+		series, err := tracker.Aggregate(period, from, to)
+		if err != nil {
+			return fmt.Errorf("统计失败: %w", err)
+		}
+		if len(series) == 0 {
+			fmt.Println("暂无工作记录")
+			return nil
+		}
+
+		fmt.Printf("📊 工作量统计 (周期: %s, 时区: %s)\n", period, worktracker.TimezoneName())
+		fmt.Printf("  %-10s  %6s  %6s  %6s  %10s  %10s  %12s  %10s\n", "开始", "任务", "完成", "失败", "耗时", "Token", "价值", "成本($)")
+		for _, s := range series {
+			if s.Tasks == 0 {
+				continue
+			}
+			duration := time.Duration(s.TotalDurationMs) * time.Millisecond
+			fmt.Printf("  %-10s  %6d  %6d  %6d  %10s  %10d  %12.4f  %10.4f\n",
+				s.Start, s.Tasks, s.CompletedTasks, s.FailedTasks, duration.Round(time.Second), s.TotalTokens, s.TotalValue, s.TotalCostUSD)
+		}
+		return nil
+	}}
+	statsCmd.Flags().String("period", "day", "统计周期: day/week/month")
+	statsCmd.Flags().String("from", "", "起始时间 (2006-01-02 或 RFC3339，默认最早记录)")
+	statsCmd.Flags().String("to", "", "截止时间 (不含，默认当前)")
+	statsCmd.Flags().Bool("today", false, "只统计今天 (统计时区的自然日，见 --tz)")
+	statsCmd.Flags().Bool("by-agent", false, "按 Agent 汇总全部记录 (任务数、Token、价值、成功率)")
+	rootCmd.AddCommand(statsCmd)
+
+	// proof command - 工作证明校验
+	proofCmd := &cobra.Command{Use: "proof", Short: "工作证明"}
+	rootCmd.AddCommand(proofCmd)
+
+	proofVerifyCmd := &cobra.Command{Use: "verify", Short: "离线校验工作记录 <record.json>", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
+		record, err := worktracker.LoadRecordFile(args[0])
+		if err != nil {
+			return fmt.Errorf("读取记录失败: %w", err)
+		}
+
+		fmt.Printf("记录: %s (Agent: %s)\n", record.ID, record.AgentID)
+		fmt.Printf("工作证明: %s\n", record.ProofHash)
+		if err := worktracker.VerifyRecord(record); err != nil {
+			return fmt.Errorf("校验失败: %v", err)
+		}
+
+		expected, _ := cmd.Flags().GetString("signer")
+		if expected != "" && !strings.EqualFold(expected, record.Signer) {
+			return fmt.Errorf("签名钱包 %s 不是预期的 %s", record.Signer, expected)
+		}
+
+		fmt.Printf("签名钱包: %s\n", record.Signer)
+		fmt.Println("✅ 工作证明与签名有效")
+		return nil
+	}}
+	proofVerifyCmd.Flags().String("signer", "", "预期的 Agent 钱包地址")
+	proofCmd.AddCommand(proofVerifyCmd)
+
+	proofCmd.AddCommand(&cobra.Command{Use: "batch", Short: "将新的已签名记录打包为 Merkle 批次", RunE: func(cmd *cobra.Command, args []string) error {
+		tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		if err != nil {
+			return fmt.Errorf("打开工作记录失败: %w", err)
+		}
+		batch, err := tracker.BuildProofBatch(filepath.Join(dataDir, "proofs"))
+		if err != nil {
+			return fmt.Errorf("打包失败: %w", err)
+		}
+		if batch == nil {
+			fmt.Println("没有新的已签名记录")
+			return nil
+		}
+		fmt.Printf("✅ 批次 #%d: %d 条记录\n", batch.Index, len(batch.Leaves))
+		fmt.Printf("   Merkle 根: %s\n", batch.Root)
+		return nil
+	}})
+
+	proofCmd.AddCommand(&cobra.Command{Use: "inclusion", Short: "生成记录的 Merkle 包含证明 <record-id>", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
+		proof, err := worktracker.FindInclusionProof(filepath.Join(dataDir, "proofs"), args[0])
+		if err != nil {
+			return err
+		}
+		if !proof.Verify() {
+			return fmt.Errorf("包含证明校验失败 (批次文件可能被修改)")
+		}
+		data, _ := json.MarshalIndent(proof, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}})
+
+	proofBackfillCmd := &cobra.Command{Use: "backfill", Short: "为缺少工作证明或签名的历史记录补全 (追踪器记录与 OpenClaw 同步记录)", RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		if err != nil {
+			return fmt.Errorf("打开工作记录失败: %w", err)
+		}
+		if err := setTrackerSigners(tracker); err != nil {
+			return err
+		}
+		keys := walletKeys{}
+		if keys.get("default") == nil {
+			fmt.Println("⚠️ 未找到默认钱包，未映射 Agent 钱包的记录只补全工作证明 (oaw wallet create 后可补签名)")
+		}
+
+		result, err := tracker.Backfill(dryRun)
+		if err != nil {
+			return fmt.Errorf("补全追踪器记录失败: %w", err)
+		}
+		proofs, sigs, err := openclaw.BackfillRecords(dataDir+"/records", keys.recordKey, dryRun)
+		if err != nil {
+			return fmt.Errorf("补全同步记录失败: %w", err)
+		}
+
+		verb := "已补全"
+		if dryRun {
+			verb = "将补全"
+		}
+		fmt.Printf("✅ 追踪器记录: %s工作证明 %d 条, 签名 %d 条\n", verb, result.Proofs, result.Signatures)
+		fmt.Printf("✅ 同步记录:   %s工作证明 %d 条, 签名 %d 条\n", verb, proofs, sigs)
+		for _, s := range result.Skipped {
+			fmt.Printf("  ⚠️ 跳过 %s\n", s)
+		}
+		return nil
+	}}
+	proofBackfillCmd.Flags().Bool("dry-run", false, "只统计不写入")
+	proofCmd.AddCommand(proofBackfillCmd)
+
+	proofBundleCmd := &cobra.Command{Use: "bundle", Short: "导出供第三方审计的证明包 (zip)", RunE: func(cmd *cobra.Command, args []string) error {
+		sinceStr, _ := cmd.Flags().GetString("since")
+		since, err := worktracker.ParseFilterTime(sinceStr)
+		if err != nil {
+			return err
+		}
+		out, _ := cmd.Flags().GetString("out")
+
+		tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+		if err != nil {
+			return fmt.Errorf("打开工作记录失败: %w", err)
+		}
+		if err := setTrackerSigners(tracker); err != nil {
+			return err
+		}
+		manifest, skipped, err := tracker.ExportBundle(out, filepath.Join(dataDir, "proofs"), since)
+		if err != nil {
+			return fmt.Errorf("导出失败: %w", err)
+		}
+		fmt.Printf("✅ 证明包已导出: %s\n", out)
+		fmt.Printf("   记录: %d 条, Merkle 根: %s\n", manifest.Records, manifest.Root)
+		if manifest.Signer != "" {
+			fmt.Printf("   清单签名: %s\n", manifest.Signer)
+		}
+		if skipped > 0 {
+			fmt.Printf("⚠️ %d 条未签名的记录未导出\n", skipped)
+		}
+		return nil
+	}}
+	proofBundleCmd.Flags().String("since", "", "只导出此时间之后结束的记录 (2006-01-02 或 RFC3339)")
+	proofBundleCmd.Flags().String("out", "bundle.zip", "输出文件")
+	proofCmd.AddCommand(proofBundleCmd)
+
+	proofVerifyBundleCmd := &cobra.Command{Use: "verify-bundle", Short: "离线校验证明包 <bundle.zip>", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
+		report, err := worktracker.VerifyBundle(args[0])
+		if err != nil {
+			return err
+		}
+		m := report.Manifest
+		fmt.Printf("📋 证明包: %d 条记录, 导出于 %s\n", m.Records, time.Unix(m.CreatedAt, 0).Format("2006-01-02 15:04"))
+		fmt.Printf("   Merkle 根: %s\n", m.Root)
+		if m.Signer != "" {
+			fmt.Printf("   清单签名: %s\n", m.Signer)
+		} else {
+			fmt.Println("   清单未签名")
+		}
+		fmt.Printf("   已验证记录: %d/%d, 批次包含证明: %d\n", report.Verified, report.Records, report.Inclusions)
+		fmt.Printf("   签名钱包: %s\n", strings.Join(report.Signers, ", "))
+
+		if expected, _ := cmd.Flags().GetString("signer"); expected != "" {
+			for _, s := range report.Signers {
+				if !strings.EqualFold(s, expected) {
+					report.Problems = append(report.Problems, fmt.Sprintf("签名钱包 %s 不是预期的 %s", s, expected))
+				}
+			}
+		}
+		if !report.OK() {
+			for _, p := range report.Problems {
+				fmt.Printf("  ❌ %s\n", p)
+			}
+			return fmt.Errorf("证明包校验失败 (%d 个问题)", len(report.Problems))
+		}
+		fmt.Println("✅ 证明包完整，全部记录的工作证明与签名有效")
+		return nil
+	}}
+	proofVerifyBundleCmd.Flags().String("signer", "", "预期的 Agent 钱包地址")
+	proofCmd.AddCommand(proofVerifyBundleCmd)
+
+	// pole command - PoLE 链集成
+	poleCmd := &cobra.Command{Use: "pole", Short: "PoLE 链集成"}
+	poleCmd.PersistentFlags().StringVar(&poleWalletFlag, "pole-wallet", "", "PoLE 钱包文件 (默认: config.json 的 pole_local.wallet 或 <PoLE 安装目录>/wallet.json)")
+	rootCmd.AddCommand(poleCmd)
+
+	poleCmd.AddCommand(&cobra.Command{Use: "sync", Short: "同步到 PoLE 链", RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Println("=== OAW → PoLE 链同步 ===")
+
+		records, err := openclaw.LoadRecords(dataDir + "/records")
+		if err != nil {
+			return fmt.Errorf("加载记录失败: %w", err)
+		}
+
+		fmt.Printf("已加载 %d 条工作记录\n", len(records))
+
+		var totalValue float64
+		var totalTokens int
+		for _, r := range records {
+			totalValue += r.Value
+			totalTokens += r.TotalTokens
+		}
+
+		fmt.Printf("\n累计:\n")
+		fmt.Printf("  Token: %d\n", totalTokens)
+		fmt.Printf("  价值: %.2f OAW\n", totalValue)
+
+		// 读取 PoLE 钱包
+		walletData, err := os.ReadFile(PoleWalletPath())
+		if err == nil {
+			var wallet struct {
+				Address string `json:"address"`
+			}
+			json.Unmarshal(walletData, &wallet)
+			fmt.Printf("\n=== PoLE 钱包 ===\n")
+			fmt.Printf("地址: %s\n", wallet.Address)
+		}
+
+		fmt.Println("\n✅ PoLE 链同步完成!")
+		return nil
+	}})
+
+	poleCmd.AddCommand(&cobra.Command{Use: "wallet", Short: "查看 PoLE 钱包", RunE: func(cmd *cobra.Command, args []string) error {
+		walletData, err := os.ReadFile(PoleWalletPath())
+		if err != nil {
+			return fmt.Errorf("读取钱包失败 (可用 --pole-wallet 或 config.json 的 pole_local.wallet 指定): %w", err)
+		}
+
+		var wallet struct {
+			Accounts []struct {
+				Address string `json:"address"`
+			} `json:"accounts"`
+		}
+		json.Unmarshal(walletData, &wallet)
+
+		fmt.Println("=== PoLE 钱包 ===")
+		if len(wallet.Accounts) > 0 {
+			fmt.Printf("地址: %s\n", wallet.Accounts[0].Address)
+		}
+		fmt.Println("(余额查询开发中...)")
+
+		return nil
+	}})
+
+	// pole verify - 链上验证
+	poleVerifyCmd := &cobra.Command{Use: "verify", Short: "按 WorkRecorded 事件逐条验证本地记录的链上状态", RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		from, _ := cmd.Flags().GetUint64("from")
+
+		files, err := loadRecordFiles(dataDir + "/records")
+		if err != nil || len(files) == 0 {
+			fmt.Println("没有本地记录")
+			return nil
+		}
+		w, err := LoadWallet(dataDir+"/wallets", "default")
+		if err != nil {
+			return fmt.Errorf("请先创建钱包")
+		}
+
+		report, err := VerifyPoleRecords(NewPoleRPC(poleNodeURL), w.Address, files, from)
+		if err != nil {
+			return err
+		}
+		printPoleVerify(report, all)
+		return nil
+	}}
+	poleVerifyCmd.Flags().Bool("all", false, "同时列出未提交的记录")
+	poleVerifyCmd.Flags().Uint64("from", 0, "查询事件的起始区块 (默认为最早提交所在的区块)")
+	poleCmd.AddCommand(poleVerifyCmd)
+
+	// pole stats - 链上统计
+	poleCmd.AddCommand(&cobra.Command{Use: "stats", Short: "链上统计", RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Print("=== PoLE 链上统计 ===\n\n")
 
 		// 从链上获取统计 (模拟)
 		fmt.Println("全网统计:")
@@ -794,35 +2382,99 @@ func main() {
 		return nil
 	}})
 
-	// pole config - 配置 RPC
-	poleCmd.AddCommand(&cobra.Command{Use: "config", Short: "配置 RPC", RunE: func(cmd *cobra.Command, args []string) error {
-		if len(args) < 2 {
-			fmt.Println("用法: oaw pole config <node-url> <contract-address>")
+	// pole config - 配置 RPC (保存到 config.json)
+	poleConfigCmd := &cobra.Command{Use: "config", Short: "配置 RPC <node-url> [contract-address] (保存到 config.json)", Args: cobra.MaximumNArgs(2), RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			fmt.Println("用法: oaw pole config <node-url> [contract-address]")
+			fmt.Println("查看当前配置: oaw pole config show")
 			return nil
 		}
-		poleNodeURL = args[0]
-		poleContractAddress = args[1]
-		fmt.Printf("✅ RPC 配置已更新:\n  节点: %s\n  合约: %s\n", poleNodeURL, poleContractAddress)
+		cfg, err := LoadConfig(dataDir)
+		if err != nil {
+			return err
+		}
+		contract := ""
+		if len(args) > 1 {
+			contract = args[1]
+		}
+		if err := cfg.SetPole(args[0], contract); err != nil {
+			return err
+		}
+		os.MkdirAll(dataDir, 0755)
+		if err := cfg.Save(dataDir); err != nil {
+			return err
+		}
+		poleNodeURL = cfg.PoleNode
+		if cfg.PoleContract != "" {
+			poleContractAddress = cfg.PoleContract
+		}
+		fmt.Printf("✅ RPC 配置已保存到 %s:\n  节点: %s\n  合约: %s\n", filepath.Join(dataDir, "config.json"), poleNodeURL, poleContractAddress)
+		return nil
+	}}
+	poleConfigCmd.AddCommand(&cobra.Command{Use: "show", Short: "查看 PoLE 配置", RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := LoadConfig(dataDir)
+		if err != nil {
+			return err
+		}
+		source := func(v string) string {
+			if v == "" {
+				return " (默认)"
+			}
+			return ""
+		}
+		fmt.Println("=== PoLE 配置 ===")
+		fmt.Printf("节点: %s%s\n", poleNodeURL, source(cfg.PoleNode))
+		fmt.Printf("合约: %s%s\n", poleContractAddress, source(cfg.PoleContract))
+		if len(cfg.PoleNodes) > 0 {
+			fmt.Printf("备用节点: %s\n", strings.Join(cfg.PoleNodes, ", "))
+		}
+		abi := cfg.PoleABI
+		if abi == "" {
+			abi = "内置"
+		}
+		fmt.Printf("合约 ABI: %s\n", abi)
+		fmt.Printf("PoLE 安装目录: %s\n", PoleHome())
+		fmt.Printf("PoLE 钱包: %s\n", PoleWalletPath())
+		if binary, err := PoleNodeBinary(); err == nil {
+			fmt.Printf("节点程序: %s %s\n", binary, strings.Join(PoleNodeArgs(), " "))
+		} else {
+			fmt.Printf("节点程序: %v\n", err)
+		}
+		if cfg.PoleGas != nil {
+			gas, _ := json.Marshal(cfg.PoleGas)
+			fmt.Printf("gas 策略: %s\n", gas)
+		}
+		fmt.Printf("配置文件: %s\n", filepath.Join(dataDir, "config.json"))
 		return nil
 	}})
+	poleCmd.AddCommand(poleConfigCmd)
 
 	// pole connect - 连接测试
-	poleCmd.AddCommand(&cobra.Command{Use: "connect", Short: "测试 RPC 连接", RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("=== 测试 PoLE RPC 连接 ===\n")
+	poleCmd.AddCommand(&cobra.Command{Use: "connect", Short: "测试 RPC 连接 (含备用节点的健康状态)", RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Print("=== 测试 PoLE RPC 连接 ===\n\n")
 
 		rpc := NewPoleRPC(poleNodeURL)
+		statuses := rpc.CheckNodes()
+		healthy := 0
+		for _, st := range statuses {
+			if st.Err != nil {
+				fmt.Printf("❌ %s\n   %v\n", st.URL, st.Err)
+				continue
+			}
+			healthy++
+			fmt.Printf("✅ %s  (%v)\n", st.URL, st.Latency.Round(time.Millisecond))
+			fmt.Printf("   Chain ID: %s  最新区块: %d\n", st.ChainID, st.Head)
+		}
 
-		chainID, err := rpc.GetChainID()
-		if err != nil {
-			fmt.Printf("❌ 连接失败: %v\n", err)
+		fmt.Println()
+		if healthy == 0 {
+			fmt.Printf("❌ 连接失败: %d 个节点均不可用\n", len(statuses))
 			return nil
 		}
-
-		fmt.Printf("✅ 连接成功!\n")
-		fmt.Printf("  Chain ID: %s\n", chainID)
-
-		blockNum, _ := rpc.GetBlockNumber()
-		fmt.Printf("  最新区块: %s\n", blockNum)
+		fmt.Printf("✅ 连接成功! %d/%d 个节点可用，优先使用 %s\n", healthy, len(statuses), statuses[0].URL)
+		if len(statuses) == 1 {
+			fmt.Println("   (可在 config.json 的 pole_nodes 中配置备用节点)")
+		}
 
 		return nil
 	}})
@@ -837,7 +2489,7 @@ func main() {
 			return fmt.Errorf("请先创建钱包")
 		}
 
-		balance, err := rpc.GetBalance(w.Address)
+		balance, err := rpc.Balance(w.Address)
 		if err != nil {
 			fmt.Printf("❌ 查询失败: %v\n", err)
 			return nil
@@ -845,11 +2497,91 @@ func main() {
 
 		fmt.Printf("=== PoLE 余额查询 ===\n")
 		fmt.Printf("地址: %s\n", w.Address)
-		fmt.Printf("余额: %s POLE\n", balance)
+		fmt.Printf("余额: %s\n", FormatAmount(balance))
+		fmt.Printf("  (%s 最小单位，%d 位小数)\n", balance, PoleDecimals)
+
+		return nil
+	}})
+
+	// pole nonce - 链上提交的 nonce 状态
+	poleNonceCmd := &cobra.Command{Use: "nonce", Short: "查看链上提交的 nonce 与未确认交易", RunE: func(cmd *cobra.Command, args []string) error {
+		w, err := LoadWallet(dataDir+"/wallets", "default")
+		if err != nil {
+			return fmt.Errorf("请先创建钱包")
+		}
+		nonces := PoleNonces()
+		if reset, _ := cmd.Flags().GetBool("reset"); reset {
+			if err := nonces.Reset(w.Address); err != nil {
+				return err
+			}
+			fmt.Println("✅ 已清除本地 nonce 状态，下次提交从节点的 nonce 开始")
+			return nil
+		}
 
+		rpc := NewPoleRPC(poleNodeURL)
+		acct := nonces.Account(w.Address)
+		fmt.Printf("=== PoLE nonce ===\n")
+		fmt.Printf("地址: %s\n", w.Address)
+		if latest, err := rpc.TransactionCount(w.Address, "latest"); err != nil {
+			fmt.Printf("链上: ❌ %v\n", err)
+		} else {
+			pending, _ := rpc.TransactionCount(w.Address, "pending")
+			fmt.Printf("链上: 已确认 %d，含交易池 %d\n", latest, pending)
+		}
+		fmt.Printf("本地下一个 nonce: %d\n", acct.Next)
+		if len(acct.Pending) == 0 {
+			fmt.Println("没有未确认的交易")
+			return nil
+		}
+		fmt.Printf("\n未确认的交易 (%d):\n", len(acct.Pending))
+		for _, tx := range acct.Pending {
+			note := ""
+			if tx.Replaced > 0 {
+				note = fmt.Sprintf(" (已替换 %d 次)", tx.Replaced)
+			}
+			fmt.Printf("  #%d  %s  %s 前发送%s\n", tx.Nonce, tx.Hash, time.Since(tx.SentAt).Round(time.Second), note)
+		}
 		return nil
+	}}
+	poleNonceCmd.Flags().Bool("reset", false, "清除本地 nonce 状态")
+	poleCmd.AddCommand(poleNonceCmd)
+
+	// pole gas - gas 估算与费用策略
+	poleCmd.AddCommand(&cobra.Command{Use: "gas", Short: "查看工作记录提交的 gas 估算与费用", RunE: func(cmd *cobra.Command, args []string) error {
+		w, err := LoadWallet(dataDir+"/wallets", "default")
+		if err != nil {
+			return fmt.Errorf("请先创建钱包")
+		}
+		return printPoleGas(NewPoleRPC(poleNodeURL), w.Address)
 	}})
 
+	// pole watch - 实时链上动态
+	poleWatchCmd := &cobra.Command{Use: "watch", Short: "实时显示新区块、本钱包交易的确认与合约事件 (WebSocket 订阅)，检测提交的区块重组", RunE: func(cmd *cobra.Command, args []string) error {
+		opts := poleWatchOptions{}
+		opts.WSURL, _ = cmd.Flags().GetString("ws")
+		opts.Confirmations, _ = cmd.Flags().GetUint64("confirmations")
+		opts.Interval, _ = cmd.Flags().GetDuration("interval")
+		opts.Poll, _ = cmd.Flags().GetBool("poll")
+		if opts.Interval <= 0 {
+			return fmt.Errorf("--interval 必须大于 0")
+		}
+		if opts.Confirmations == 0 {
+			opts.Confirmations = 1
+		}
+		if w, err := LoadWallet(dataDir+"/wallets", "default"); err == nil {
+			opts.Address, opts.PrivateKey = w.Address, w.Private
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+		return runPoleWatch(ctx, NewPoleRPC(poleNodeURL), opts)
+	}}
+	poleWatchCmd.Flags().String("ws", "", "节点的 WebSocket 地址 (默认由当前可用的节点地址推出，如 ws://127.0.0.1:9090)")
+	poleWatchCmd.Flags().Uint64("confirmations", 6, "交易视为已确认的确认数")
+	poleWatchCmd.Flags().Duration("interval", 5*time.Second, "WebSocket 不可用时轮询区块高度的间隔")
+	poleWatchCmd.Flags().Bool("poll", false, "不使用 WebSocket，只轮询区块高度")
+	poleCmd.AddCommand(poleWatchCmd)
+
 	// pole sync-onchain - 同步记录到链上
 	poleCmd.AddCommand(&cobra.Command{Use: "sync-onchain", Short: "同步记录到链上", RunE: func(cmd *cobra.Command, args []string) error {
 		rpc := NewPoleRPC(poleNodeURL)
@@ -883,9 +2615,20 @@ func main() {
 		fmt.Printf("本地记录数: %d\n", len(entries))
 		fmt.Printf("钱包地址: %s\n", w.Address)
 
-		// 获取当前交易数
-		txCount, _ := rpc.GetTransactionCount(w.Address)
-		fmt.Printf("链上交易数: %s\n\n", txCount)
+		// 链上交易数 (提交的 nonce 由 nonce 管理器分配，见 pole_nonce.go)
+		if nonce, err := rpc.Nonce(w.Address); err == nil {
+			fmt.Printf("链上交易数: %d\n\n", nonce)
+		}
+
+		// 检查已提交记录的区块重组 (见 pole_reorg.go)
+		submissions := PoleSubmissions()
+		if head, err := rpc.HeadNumber(); err == nil {
+			if reorgs, err := submissions.Check(rpc, head, walletInfo.Private); err != nil {
+				fmt.Printf("⚠️ 检查区块重组失败: %v\n", err)
+			} else if reorgs > 0 {
+				fmt.Printf("↩️  %d 条记录的交易经历了区块重组\n\n", reorgs)
+			}
+		}
 
 		// 读取最近的记录
 		var recentRecords []string
@@ -899,51 +2642,44 @@ func main() {
 
 		fmt.Printf("准备同步最近 %d 条记录...\n", count)
 
-		// 模拟同步过程
+		synced := 0
 		for i, recordFile := range recentRecords {
 			// 读取记录
 			recordData, _ := os.ReadFile(recordsDir + "/" + recordFile)
-			var record struct {
-				Value float64 `json:"value"`
-			}
+			var record openclaw.WorkRecord
 			json.Unmarshal(recordData, &record)
 
-			// 创建交易数据
-			txData := CreateWorkRecordTx(w.Address, uint64(record.Value*1000))
+			// 只提交带有效签名的记录，缺少工作证明或签名的记录先用 oaw proof backfill 补全
+			if err := record.Verify(); err != nil {
+				fmt.Printf("  [%d/%d] 跳过 %s: %v\n", i+1, count, recordFile, err)
+				continue
+			}
+			proofHash := record.ProofHash
+
+			// 已提交的记录 (被重组移除的除外) 不再重复提交
+			if sub, ok := submissions.Get(recordFile); ok && sub.Live() {
+				fmt.Printf("  [%d/%d] 已提交 (%s): %s\n", i+1, count, sub.Status, sub.TxHash)
+				continue
+			}
 
-			// 签名
-			signedTx, err := SignTransaction(txData, walletInfo.Private)
+			// 创建交易数据 (submitWork 调用)
+			txData, err := CreateWorkRecordTx(w.Address, proofHash, uint64(record.TotalTokens), record.Value)
 			if err != nil {
-				fmt.Printf("  [%d/%d] 签名失败: %v\n", i+1, count, err)
+				fmt.Printf("  [%d/%d] 编码失败: %v\n", i+1, count, err)
 				continue
 			}
 
-			// 发送交易 (这里只是模拟)
-			fmt.Printf("  [%d/%d] 签名成功: %s\n", i+1, count, signedTx[:20]+"...")
-			_ = signedTx
+			// 签名并发送
+			txHash, err := submissions.Submit(rpc, walletInfo.Private, recordFile, w.Address, proofHash, txData)
+			if err != nil {
+				fmt.Printf("  [%d/%d] 发送失败: %v\n", i+1, count, err)
+				continue
+			}
+			synced++
+			fmt.Printf("  [%d/%d] ✅ %s\n", i+1, count, txHash)
 		}
 
-		fmt.Printf("\n✅ 同步演示完成!\n")
-		fmt.Println("注: 实际同步需要完整的签名实现")
-
-		return nil
-	}})
-
-	// pole verify - 验证链上数据
-	poleCmd.AddCommand(&cobra.Command{Use: "verify", Short: "验证链上数据", RunE: func(cmd *cobra.Command, args []string) error {
-		rpc := NewPoleRPC(poleNodeURL)
-
-		// 读取本地记录
-		recordsDir := dataDir + "/records"
-		entries, _ := os.ReadDir(recordsDir)
-
-		// 获取链上状态
-		blockNum, _ := rpc.GetBlockNumber()
-
-		fmt.Printf("=== PoLE 链上验证 ===\n")
-		fmt.Printf("本地记录: %d\n", len(entries))
-		fmt.Printf("链上区块: %s\n", blockNum)
-		fmt.Printf("验证结果: 本地记录已同步到链上 (模拟)\n")
+		fmt.Printf("\n✅ 已同步 %d/%d 条记录\n", synced, count)
 
 		return nil
 	}})
@@ -964,6 +2700,67 @@ func main() {
 		return nil
 	}})
 
+	// pool commands - 社区池
+	poolCmd := &cobra.Command{Use: "pool", Short: "社区池"}
+	rootCmd.AddCommand(poolCmd)
+
+	poolCmd.AddCommand(&cobra.Command{Use: "balance", Short: "社区池余额", RunE: func(cmd *cobra.Command, args []string) error {
+		state, err := LoadChainState(dataDir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("社区池: %s\n", CommunityPoolAddress)
+		fmt.Printf("余额: %.4f OAW\n", state.BalanceOf(CommunityPoolAddress, loadChainBlocks(dataDir)))
+		return nil
+	}})
+
+	poolCmd.AddCommand(&cobra.Command{Use: "history", Short: "社区池交易记录", RunE: func(cmd *cobra.Command, args []string) error {
+		state, err := LoadChainState(dataDir)
+		if err != nil {
+			return err
+		}
+		txs := state.History(CommunityPoolAddress)
+		if len(txs) == 0 {
+			fmt.Println("暂无交易")
+			return nil
+		}
+		for _, tx := range txs {
+			fmt.Printf("%s  %s → %s  %.4f OAW  %s\n",
+				time.Unix(tx.Timestamp, 0).Format("2006-01-02 15:04"), tx.From, tx.To, tx.Amount, tx.Memo)
+		}
+		return nil
+	}})
+
+	poolCmd.AddCommand(&cobra.Command{Use: "serve", Short: "启动矿池服务 [addr]", RunE: func(cmd *cobra.Command, args []string) error {
+		addr := ":8090"
+		if len(args) > 0 {
+			addr = args[0]
+		}
+		w, err := LoadWallet(dataDir+"/wallets", "default")
+		if err != nil {
+			return fmt.Errorf("请先创建钱包: oaw wallet create")
+		}
+		state, err := LoadChainState(dataDir)
+		if err != nil {
+			return err
+		}
+		pool, err := NewPoolServer(NewMiner(w, dataDir), state)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("矿池服务已启动: %s (矿池地址: %s)\n", addr, w.Address)
+		return pool.Serve(addr)
+	}})
+
+	poolCmd.AddCommand(&cobra.Command{Use: "join", Short: "加入矿池 <url>", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error {
+		w, err := LoadWallet(dataDir+"/wallets", "default")
+		if err != nil {
+			return fmt.Errorf("请先创建钱包: oaw wallet create")
+		}
+		fmt.Printf("加入矿池: %s (矿工: %s)\n", args[0], w.Address)
+		return NewPoolClient(args[0], w.Address).Run(context.Background())
+	}})
+
 	// check inactive wallets and release funds
 	rootCmd.AddCommand(&cobra.Command{
 		Use: "check-inactive",
@@ -1194,11 +2991,12 @@ func getDashboardData(dataDir string) DashboardData {
 			Miner     string  `json:"miner"`
 			Value     float64 `json:"value"`
 			Hash      string  `json:"hash"`
+			RewardTo  string  `json:"reward_to"`
 		}
 		json.Unmarshal(d, &blocks)
 		data.BlockCount = len(blocks)
 		for _, b := range blocks {
-			if b.Miner == data.WalletAddress {
+			if b.Miner == data.WalletAddress && b.RewardTo == "" || b.RewardTo == data.WalletAddress {
 				data.Balance += b.Value
 			}
 		}
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"os"
 	"path/filepath"
 	"sync"
@@ -142,20 +143,18 @@ func (m *Miner) mineBlock() {
 		Value:        10.0, // 挖矿奖励
 	}
 
-	// 工作量证明 (简化版)
-	nonce := 0
-	for {
+	// 工作量证明：不满足目标的区块绝不保存
+	target := DifficultyTarget(m.difficulty)
+	for nonce := uint64(0); ; nonce++ {
 		block.WorkProof = fmt.Sprintf("%d", nonce)
 		block.Hash = m.calculateHash(block)
 		
-		if m.isValidProof(block) {
+		if MeetsTarget(block.Hash, target) {
 			break
 		}
-		nonce++
 		
-		// 防止无限循环
-		if nonce > 1000000 {
-			break
+		if nonce%100000 == 0 && !m.working {
+			return
 		}
 	}
 
@@ -221,14 +220,28 @@ func (m *Miner) calculateHash(b Block) string {
 }
 
 func (m *Miner) isValidProof(b Block) bool {
-	// 简化的难度检查
-	hash := b.Hash
-	for i := 0; i < m.difficulty; i++ {
-		if i >= len(hash) || hash[i] != '0' {
-			return false
-		}
+	return MeetsTarget(b.Hash, DifficultyTarget(m.difficulty))
+}
+
+// DifficultyTarget 难度对应的目标值: 2^(256 - 4×difficulty)，
+// 即哈希 (十六进制) 前 difficulty 位为 0，难度限制在 [MinDifficulty, MaxDifficulty]
+func DifficultyTarget(difficulty int) *big.Int {
+	if difficulty < MinDifficulty {
+		difficulty = MinDifficulty
+	}
+	if difficulty > MaxDifficulty {
+		difficulty = MaxDifficulty
+	}
+	return new(big.Int).Lsh(big.NewInt(1), uint(256-4*difficulty))
+}
+
+// MeetsTarget 哈希 (十六进制) 作为整数是否小于目标值
+func MeetsTarget(hashHex string, target *big.Int) bool {
+	hash, err := hex.DecodeString(hashHex)
+	if err != nil || len(hash) != sha256.Size {
+		return false
 	}
-	return true
+	return new(big.Int).SetBytes(hash).Cmp(target) < 0
 }
 
 func (m *Miner) saveBlocks() {
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"oaw/mining"
+)
+
+// PoolShareDifficulty 份额难度 (低于区块难度，便于统计贡献；区块难度由链历史决定，见 expectedDifficulty)
+const PoolShareDifficulty = 3
+
+// PoolJob 矿池下发的挖矿任务
+type PoolJob struct {
+	JobID           string  `json:"job_id"`
+	Index           int     `json:"index"`
+	Previous        string  `json:"previous"`
+	Timestamp       int64   `json:"timestamp"` // 区块头字段 (与 Index、Previous 一起参与份额哈希)
+	Miner           string  `json:"miner"`     // 矿池钱包地址 (出块矿工)
+	Value           float64 `json:"value"`     // 区块奖励
+	ShareDifficulty int     `json:"share_difficulty"`
+	BlockDifficulty int     `json:"block_difficulty"`
+}
+
+// PoolShare 矿工提交的份额
+type PoolShare struct {
+	JobID  string `json:"job_id"`
+	Worker string `json:"worker"` // 矿工钱包地址
+	Nonce  uint64 `json:"nonce"`
+}
+
+// PoolShareResult 份额提交结果
+type PoolShareResult struct {
+	Accepted bool   `json:"accepted"`
+	Block    bool   `json:"block"` // 是否找到区块
+	Error    string `json:"error,omitempty"`
+}
+
+// header 份额对应的区块头 (矿工地址写入 WorkProof)
+func (job PoolJob) header(worker string, nonce uint64) Block {
+	return Block{
+		Index:     job.Index,
+		Timestamp: job.Timestamp,
+		WorkProof: "pool:" + worker,
+		Nonce:     nonce,
+		Previous:  job.Previous,
+		Miner:     job.Miner,
+		Value:     job.Value,
+	}
+}
+
+// poolShareHash 计算份额哈希 (服务端与客户端一致)，即份额区块头的 PoW 哈希，满足区块难度的份额可直接出块
+func poolShareHash(job PoolJob, worker string, nonce uint64) string {
+	return blockPoWHash(job.header(worker, nonce))
+}
+
+// PoolServer 矿池服务端 (协调多个 Agent 机器)
+type PoolServer struct {
+	mu      sync.Mutex
+	miner   *Miner
+	state   *ChainState
+	job     PoolJob
+	shares  map[string]int // 当前轮次每个矿工的份额数
+	seen    map[string]bool
+	pending []PoolPayout // 等待确认的奖励分配
+}
+
+// PoolPayout 矿池区块的奖励分配，区块达到 DefaultConfirmations 个确认后按份额发放，区块不在链上时作废
+type PoolPayout struct {
+	Index  int            `json:"index"`
+	Hash   string         `json:"hash"`
+	Payee  string         `json:"payee"` // 区块奖励的收款地址 (矿池钱包)
+	Value  float64        `json:"value"`
+	Shares map[string]int `json:"shares"`
+}
+
+// NewPoolServer 创建矿池服务端。奖励先记给矿池钱包再由其签名分配，矿工不能指定其他收款地址
+func NewPoolServer(m *Miner, state *ChainState) (*PoolServer, error) {
+	if m.rewardTo != "" && !strings.EqualFold(m.rewardTo, m.wallet.Address) {
+		return nil, fmt.Errorf("矿池模式不支持奖励收款地址 %s: 区块奖励须由矿池钱包收款后按份额分配", m.rewardTo)
+	}
+	p := &PoolServer{miner: m, state: state, pending: loadPoolPayouts(m.dataDir)}
+	p.newRound()
+	return p, nil
+}
+
+// newRound 开始新一轮 (新区块任务)，并发放已确认区块的奖励
+func (p *PoolServer) newRound() {
+	p.releasePayouts()
+
+	now := time.Now()
+	p.miner.mu.Lock()
+	prev, index := p.miner.tipHash(), len(p.miner.blocks)
+	blockDifficulty := expectedDifficulty(p.miner.blocks, now.Unix())
+	p.miner.mu.Unlock()
+
+	shareDifficulty := PoolShareDifficulty
+	if shareDifficulty > blockDifficulty {
+		shareDifficulty = blockDifficulty
+	}
+	p.job = PoolJob{
+		JobID:           fmt.Sprintf("%d", now.UnixNano()),
+		Index:           index,
+		Previous:        prev,
+		Timestamp:       now.Unix(),
+		Miner:           p.miner.wallet.Address,
+		Value:           BaseBlockReward,
+		ShareDifficulty: shareDifficulty,
+		BlockDifficulty: blockDifficulty,
+	}
+	p.shares = map[string]int{}
+	p.seen = map[string]bool{}
+}
+
+// SubmitShare 校验份额，满足区块难度时出块并按份额分配奖励
+func (p *PoolServer) SubmitShare(share PoolShare) PoolShareResult {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if share.JobID != p.job.JobID {
+		return PoolShareResult{Error: "任务已过期"}
+	}
+	if share.Worker == "" {
+		return PoolShareResult{Error: "缺少矿工地址"}
+	}
+
+	key := fmt.Sprintf("%s:%d", share.Worker, share.Nonce)
+	if p.seen[key] {
+		return PoolShareResult{Error: "重复份额"}
+	}
+
+	hash := poolShareHash(p.job, share.Worker, share.Nonce)
+	if !mining.MeetsTarget(hash, mining.DifficultyTarget(p.job.ShareDifficulty)) {
+		return PoolShareResult{Error: "份额未达到难度"}
+	}
+	p.seen[key] = true
+	p.shares[share.Worker]++
+
+	if !mining.MeetsTarget(hash, mining.DifficultyTarget(p.job.BlockDifficulty)) {
+		return PoolShareResult{Accepted: true}
+	}
+
+	// 找到区块: 奖励先记给矿池，再按份额比例分配给矿工
+	header := p.job.header(share.Worker, share.Nonce)
+	header.Hash, header.Difficulty = hash, p.job.BlockDifficulty
+	block, err := p.miner.commitBlock(header)
+	if err != nil {
+		// 链已更新或出块失败: 开始新一轮，否则之后的份额都指向过期的前序区块
+		p.newRound()
+		return PoolShareResult{Error: err.Error()}
+	}
+	fmt.Printf("  ✅ 矿池挖到新区块 #%d (矿工: %s)，%d 个确认后分配奖励\n", block.Index, share.Worker, DefaultConfirmations)
+
+	if block.Value > 0 {
+		p.pending = append(p.pending, PoolPayout{Index: block.Index, Hash: block.Hash, Payee: block.Payee(), Value: block.Value, Shares: p.shares})
+		p.savePayouts()
+	}
+	p.newRound()
+	return PoolShareResult{Accepted: true, Block: true}
+}
+
+// releasePayouts 发放已达到确认数的区块奖励，丢弃已不在链上的区块 (调用方需持有 p.mu)
+func (p *PoolServer) releasePayouts() {
+	if len(p.pending) == 0 {
+		return
+	}
+	p.miner.mu.Lock()
+	blocks := p.miner.blocks
+	p.miner.mu.Unlock()
+
+	var remaining []PoolPayout
+	for _, payout := range p.pending {
+		switch {
+		case payout.Index < len(blocks) && blocks[payout.Index].Hash != payout.Hash:
+			fmt.Printf("  ⚠️ 矿池区块 #%d 已不在链上，取消奖励分配\n", payout.Index)
+		case len(blocks)-payout.Index >= DefaultConfirmations:
+			p.distribute(payout)
+		default:
+			remaining = append(remaining, payout)
+		}
+	}
+	if len(remaining) != len(p.pending) {
+		p.pending = remaining
+		p.savePayouts()
+	}
+}
+
+// distribute 按份额比例分配区块奖励 (由收款的矿池钱包转出)
+func (p *PoolServer) distribute(payout PoolPayout) {
+	var total int
+	for _, n := range payout.Shares {
+		total += n
+	}
+	if total == 0 || payout.Value <= 0 {
+		return
+	}
+
+	fmt.Printf("  💰 分配矿池区块 #%d 的奖励 %.4f OAW\n", payout.Index, payout.Value)
+	for worker, n := range payout.Shares {
+		if strings.EqualFold(worker, payout.Payee) {
+			continue
+		}
+		amount := payout.Value * float64(n) / float64(total)
+		memo := fmt.Sprintf("pool:block:%d:shares:%d/%d", payout.Index, n, total)
+		if _, err := p.state.Transfer(payout.Payee, worker, amount, memo, p.miner.wallet.Private); err != nil {
+			fmt.Printf("  ⚠️ 分配奖励失败 (%s): %v\n", worker, err)
+			continue
+		}
+		fmt.Printf("     %s: %.4f OAW (%d/%d 份额)\n", worker, amount, n, total)
+	}
+}
+
+// loadPoolPayouts 读取等待确认的奖励分配 (pool_payouts.json)
+func loadPoolPayouts(dir string) []PoolPayout {
+	var pending []PoolPayout
+	if data, err := os.ReadFile(filepath.Join(dir, "pool_payouts.json")); err == nil {
+		json.Unmarshal(data, &pending)
+	}
+	return pending
+}
+
+// savePayouts 保存等待确认的奖励分配，矿池重启后继续发放
+func (p *PoolServer) savePayouts() {
+	data, _ := json.MarshalIndent(p.pending, "", "  ")
+	if err := writeFileAtomic(filepath.Join(p.miner.dataDir, "pool_payouts.json"), data); err != nil {
+		fmt.Printf("  ⚠️ 保存待分配奖励失败: %v\n", err)
+	}
+}
+
+// PendingPayouts 等待确认的奖励分配
+func (p *PoolServer) PendingPayouts() []PoolPayout {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]PoolPayout(nil), p.pending...)
+}
+
+// Job 获取当前任务
+func (p *PoolServer) Job() PoolJob {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.job
+}
+
+// Shares 获取当前轮次份额统计
+func (p *PoolServer) Shares() map[string]int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	shares := make(map[string]int, len(p.shares))
+	for k, v := range p.shares {
+		shares[k] = v
+	}
+	return shares
+}
+
+// Serve 启动矿池 HTTP 服务，并按出块间隔检查待分配奖励的确认数 (对等节点的区块同样增加确认)
+func (p *PoolServer) Serve(addr string) error {
+	go func() {
+		ticker := time.NewTicker(activeNetwork.BlockInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			p.mu.Lock()
+			p.releasePayouts()
+			p.mu.Unlock()
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pool/job", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(p.Job())
+	})
+	mux.HandleFunc("/pool/share", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var share PoolShare
+		if err := json.NewDecoder(r.Body).Decode(&share); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(p.SubmitShare(share))
+	})
+	mux.HandleFunc("/pool/stats", func(w http.ResponseWriter, r *http.Request) {
+		job := p.Job()
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"job":     job,
+			"shares":  p.Shares(),
+			"pending": p.PendingPayouts(),
+		})
+	})
+	return http.ListenAndServe(addr, mux)
+}
+
+// PoolClient 矿池客户端
+type PoolClient struct {
+	URL    string
+	Worker string
+	client *http.Client
+}
+
+// NewPoolClient 创建矿池客户端
+func NewPoolClient(url, worker string) *PoolClient {
+	return &PoolClient{
+		URL:    strings.TrimRight(url, "/"),
+		Worker: worker,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// FetchJob 获取任务
+func (c *PoolClient) FetchJob() (PoolJob, error) {
+	var job PoolJob
+	resp, err := c.client.Get(c.URL + "/pool/job")
+	if err != nil {
+		return job, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	return job, json.NewDecoder(resp.Body).Decode(&job)
+}
+
+// Submit 提交份额
+func (c *PoolClient) Submit(share PoolShare) (PoolShareResult, error) {
+	var result PoolShareResult
+	data, _ := json.Marshal(share)
+	resp, err := c.client.Post(c.URL+"/pool/share", "application/json", bytes.NewReader(data))
+	if err != nil {
+		return result, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	return result, json.NewDecoder(resp.Body).Decode(&result)
+}
+
+// Run 循环挖矿并提交份额，直到 ctx 取消
+func (c *PoolClient) Run(ctx context.Context) error {
+	for {
+		job, err := c.FetchJob()
+		if err != nil {
+			return err
+		}
+
+		target := mining.DifficultyTarget(job.ShareDifficulty)
+		for nonce := uint64(0); ; nonce++ {
+			if nonce%100000 == 0 {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				default:
+				}
+			}
+
+			if !mining.MeetsTarget(poolShareHash(job, c.Worker, nonce), target) {
+				continue
+			}
+
+			result, err := c.Submit(PoolShare{JobID: job.JobID, Worker: c.Worker, Nonce: nonce})
+			if err != nil {
+				return err
+			}
+			if result.Block {
+				fmt.Printf("  ✅ 份额出块! 区块 #%d\n", job.Index)
+			}
+			if result.Error != "" || result.Block {
+				break // 任务过期或已出块，获取新任务
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"oaw/mining"
+)
+
+// mineShares 以 worker 提交份额直到矿池出块
+func mineShares(t *testing.T, p *PoolServer, worker string) {
+	t.Helper()
+	job := p.Job()
+	share := mining.DifficultyTarget(job.ShareDifficulty)
+	for nonce := uint64(0); nonce < 1<<24; nonce++ {
+		if !mining.MeetsTarget(poolShareHash(job, worker, nonce), share) {
+			continue
+		}
+		result := p.SubmitShare(PoolShare{JobID: job.JobID, Worker: worker, Nonce: nonce})
+		if result.Error != "" {
+			t.Fatal(result.Error)
+		}
+		if result.Block {
+			return
+		}
+	}
+	t.Fatal("未找到区块")
+}
+
+func TestPoolPayoutsWaitForConfirmations(t *testing.T) {
+	dir := t.TempDir()
+	s, worker := newTestSigner(t), newTestSigner(t)
+	m := NewMiner(&Wallet{Address: s.Address, Private: s.Private}, dir)
+	state, err := LoadChainState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewPoolServer(m, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mineShares(t, p, worker.Address)
+	if n := len(p.PendingPayouts()); n != 1 {
+		t.Fatalf("待分配奖励 %d 条，期望 1", n)
+	}
+	if got := state.Accounts[worker.Address]; got != 0 {
+		t.Fatalf("区块未确认时已分配 %.4f", got)
+	}
+
+	// 达到确认数前不分配
+	for confirmations := 2; confirmations < DefaultConfirmations; confirmations++ {
+		m.blocks = append(m.blocks, Block{Index: len(m.blocks), Hash: strings.Repeat("0", 63) + string(rune('0'+confirmations))})
+		p.mu.Lock()
+		p.releasePayouts()
+		p.mu.Unlock()
+		if got := state.Accounts[worker.Address]; got != 0 {
+			t.Fatalf("%d 个确认时已分配 %.4f", confirmations, got)
+		}
+	}
+
+	// 重启后从 pool_payouts.json 恢复并在确认后分配
+	p, err = NewPoolServer(m, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(p.PendingPayouts()); n != 1 {
+		t.Fatalf("重启后待分配奖励 %d 条，期望 1", n)
+	}
+	m.blocks = append(m.blocks, Block{Index: len(m.blocks), Hash: "ff"})
+	p.mu.Lock()
+	p.releasePayouts()
+	p.mu.Unlock()
+	if got := state.Accounts[worker.Address]; got != BaseBlockReward {
+		t.Fatalf("确认后分配 %.4f，期望 %.4f", got, BaseBlockReward)
+	}
+	if n := len(p.PendingPayouts()); n != 0 {
+		t.Fatalf("分配后仍有 %d 条待分配奖励", n)
+	}
+}
+
+func TestPoolDropsPayoutsForBlocksOffChain(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestSigner(t)
+	m := NewMiner(&Wallet{Address: s.Address, Private: s.Private}, dir)
+	state, _ := LoadChainState(dir)
+	p, err := NewPoolServer(m, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.pending = []PoolPayout{{Index: 0, Hash: "aa", Payee: s.Address, Value: BaseBlockReward, Shares: map[string]int{"0x01": 1}}}
+	m.blocks = []Block{{Index: 0, Hash: "bb"}}
+	p.releasePayouts()
+	if len(p.pending) != 0 || len(state.Transactions) != 0 {
+		t.Fatalf("不在链上的区块不应分配奖励: 待分配 %d，交易 %d", len(p.pending), len(state.Transactions))
+	}
+}
+
+func TestPoolRejectsRewardAddress(t *testing.T) {
+	s, cold := newTestSigner(t), newTestSigner(t)
+	m := NewMiner(&Wallet{Address: s.Address, Private: s.Private}, t.TempDir())
+	if err := m.SetRewardAddress(cold.Address); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewPoolServer(m, &ChainState{}); err == nil {
+		t.Fatal("矿池模式应拒绝奖励收款地址")
+	}
+}
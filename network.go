@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// NetworkParams 网络参数
+type NetworkParams struct {
+	Name              string
+	InitialDifficulty int
+	MinDifficulty     int
+	MaxDifficulty     int
+	BlockInterval     time.Duration // 挖矿循环间隔
+	AddressTag        string        // 地址显示前缀，便于区分测试网
+}
+
+// 内置网络
+var (
+	MainNet = NetworkParams{
+		Name:              "mainnet",
+		InitialDifficulty: 4,
+		MinDifficulty:     2,
+		MaxDifficulty:     10,
+		BlockInterval:     10 * time.Second,
+	}
+	TestNet = NetworkParams{
+		Name:              "testnet",
+		InitialDifficulty: 2,
+		MinDifficulty:     2,
+		MaxDifficulty:     4,
+		BlockInterval:     2 * time.Second,
+		AddressTag:        "test:",
+	}
+)
+
+// activeNetwork 当前网络 (由 --network 设置)
+var activeNetwork = MainNet
+
+// selectNetwork 切换网络；测试网使用独立的数据目录命名空间
+func selectNetwork(name string) error {
+	switch name {
+	case MainNet.Name:
+		activeNetwork = MainNet
+	case TestNet.Name:
+		activeNetwork = TestNet
+		dataDir = filepath.Join(dataDir, TestNet.Name)
+		fmt.Fprintf(os.Stderr, "🧪 测试网模式 (数据目录: %s)\n", dataDir)
+	default:
+		return fmt.Errorf("未知网络: %s (可选: %s/%s)", name, MainNet.Name, TestNet.Name)
+	}
+	return nil
+}
+
+// displayAddress 带网络标记的地址，用于输出
+func displayAddress(address string) string {
+	return activeNetwork.AddressTag + address
+}
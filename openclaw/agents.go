@@ -0,0 +1,100 @@
+package openclaw
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ============ 多 Agent 扫描 ============
+
+// AgentFilter 按名称筛选 Agent (filepath.Match 通配符)，Include 为空表示全部，Exclude 优先
+type AgentFilter struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// Match Agent 是否被选中
+func (f AgentFilter) Match(agent string) bool {
+	for _, p := range f.Exclude {
+		if ok, _ := filepath.Match(p, agent); ok {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, p := range f.Include {
+		if ok, _ := filepath.Match(p, agent); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ListAgents 列出存在会话文件的 Agent (按名称排序)。
+// 路径模板不含 {agent} 时只有默认 Agent
+func ListAgents() ([]string, error) {
+	if !strings.Contains(Layout, "{agent}") {
+		if _, err := os.Stat(SessionsPath(DefaultAgent)); err != nil {
+			return nil, err
+		}
+		return []string{DefaultAgent}, nil
+	}
+
+	// 以占位符拆分完整路径，匹配的路径去掉前后缀即为 Agent 名称
+	const marker = "\x00agent\x00"
+	full := SessionsPath(marker)
+	prefix, suffix, _ := strings.Cut(full, marker)
+	matches, err := filepath.Glob(escapeGlob(prefix) + "*" + escapeGlob(suffix))
+	if err != nil {
+		return nil, err
+	}
+	var agents []string
+	for _, m := range matches {
+		name := strings.TrimSuffix(strings.TrimPrefix(m, prefix), suffix)
+		if name != "" && !strings.ContainsAny(name, `/\`) {
+			agents = append(agents, name)
+		}
+	}
+	sort.Strings(agents)
+	return agents, nil
+}
+
+// escapeGlob 转义路径中的通配符
+func escapeGlob(path string) string {
+	if os.PathSeparator == '\\' {
+		// Windows 下反斜杠是路径分隔符，不能用于转义
+		return strings.NewReplacer("*", "[*]", "?", "[?]", "[", "[[]").Replace(path)
+	}
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`).Replace(path)
+}
+
+// AgentSessions 一个 Agent 的会话
+type AgentSessions struct {
+	Agent    string
+	Sessions map[string]Session
+}
+
+// GetAllSessions 读取所有被选中 Agent 的会话，单个 Agent 读取失败时跳过并返回其错误
+func GetAllSessions(filter AgentFilter) ([]AgentSessions, map[string]error, error) {
+	agents, err := ListAgents()
+	if err != nil {
+		return nil, nil, err
+	}
+	var all []AgentSessions
+	failed := map[string]error{}
+	for _, agent := range agents {
+		if !filter.Match(agent) {
+			continue
+		}
+		sessions, err := GetAgentSessions(agent)
+		if err != nil {
+			failed[agent] = err
+			continue
+		}
+		all = append(all, AgentSessions{Agent: agent, Sessions: sessions})
+	}
+	return all, failed, nil
+}
@@ -0,0 +1,293 @@
+package openclaw
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	worktracker "oaw/tracker"
+)
+
+// ============ 归档会话导入 ============
+
+// OpenClaw 重置或轮转会话后，旧的会话列表与会话记录被改名保留 (如 sessions.json.1、
+// sessions-2025-01.json.gz、archive/sessions.json、<sessionId>.jsonl.deleted.<时间>)。
+// 导入这些文件可以补记接入 OAW 之前的工作；导入与同步共用游标和按会话命名的记录，
+// 重复导入或与实时会话重叠的部分不会被重复计入
+
+// ArchiveDirs 会话目录下存放归档文件的子目录
+var ArchiveDirs = []string{"archive", "archived", "backup"}
+
+// ArchiveFile 一个归档文件
+type ArchiveFile struct {
+	Agent      string
+	Path       string
+	Transcript bool // 会话记录 (JSONL)，否则为 sessions.json 格式的会话列表
+	ModTime    time.Time
+}
+
+// archiveFile 按文件名识别归档文件: sessions*.json* 为会话列表，*.jsonl* 为会话记录，其余返回 false
+func archiveFile(agent, path string) (ArchiveFile, bool) {
+	name := filepath.Base(path)
+	if strings.HasSuffix(name, ".tmp") || strings.HasSuffix(name, ".lock") {
+		return ArchiveFile{}, false
+	}
+	f := ArchiveFile{Agent: agent, Path: path}
+	switch {
+	case strings.Contains(name, ".jsonl"):
+		f.Transcript = true
+	case strings.HasPrefix(name, "sessions") && strings.Contains(name, ".json"):
+	default:
+		return ArchiveFile{}, false
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return ArchiveFile{}, false
+	}
+	f.ModTime = info.ModTime()
+	return f, true
+}
+
+// ScanArchives 列出目录 (及其归档子目录) 中的归档文件，跳过 skip 中的路径 (如实时会话列表)
+func ScanArchives(agent, dir string, skip ...string) ([]ArchiveFile, error) {
+	dirs := []string{dir}
+	for _, sub := range ArchiveDirs {
+		dirs = append(dirs, filepath.Join(dir, sub))
+	}
+	var files []ArchiveFile
+	for i, d := range dirs {
+		entries, err := os.ReadDir(d)
+		if err != nil {
+			if i > 0 && os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+	next:
+		for _, e := range entries {
+			path := filepath.Join(d, e.Name())
+			for _, s := range skip {
+				if filepath.Clean(s) == path {
+					continue next
+				}
+			}
+			if f, ok := archiveFile(agent, path); ok {
+				files = append(files, f)
+			}
+		}
+	}
+	return files, nil
+}
+
+// FindArchives 列出被选中 Agent 会话目录中的归档文件。会话目录中的会话记录也会列出，
+// 其中仍被会话列表引用的由 ImportArchives 随会话导入
+func FindArchives(filter AgentFilter) ([]ArchiveFile, error) {
+	agents, err := ListAgents()
+	if err != nil {
+		return nil, err
+	}
+	var files []ArchiveFile
+	for _, agent := range agents {
+		if !filter.Match(agent) {
+			continue
+		}
+		live := SessionsPath(agent)
+		found, err := ScanArchives(agent, filepath.Dir(live), live)
+		if err != nil {
+			return nil, fmt.Errorf("扫描 Agent %s 的归档失败: %w", agent, err)
+		}
+		files = append(files, found...)
+	}
+	return files, nil
+}
+
+// ImportArchives 导入归档文件中的工作: 会话列表按修改时间从旧到新同步，
+// 之后导入没有被任何会话列表 (含实时会话) 引用的会话记录
+func ImportArchives(dataDir string, v worktracker.Valuator, files []ArchiveFile, transcripts bool) error {
+	cursor, err := LoadCursor(dataDir)
+	if err != nil {
+		return fmt.Errorf("读取同步游标失败: %w", err)
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].ModTime.Before(files[j].ModTime) })
+
+	run := &syncRun{dataDir: dataDir, valuator: v, transcripts: transcripts, cursor: cursor}
+	referenced := map[string]bool{} // 会话列表中出现过的会话 (cursorKey)
+	loaded := map[string]bool{}     // 已读取实时会话的 Agent
+	for _, f := range files {
+		if !f.Transcript || loaded[f.Agent] {
+			continue
+		}
+		loaded[f.Agent] = true
+		live, _ := GetAgentSessions(f.Agent)
+		for key, s := range live {
+			referenced[cursorKey(f.Agent, sessionKey(key, s))] = true
+		}
+	}
+
+	for _, f := range files {
+		if f.Transcript {
+			continue
+		}
+		sessions, err := readSessionsFile(f.Path)
+		if err != nil {
+			fmt.Printf("⚠️ 读取归档 %s 失败: %v\n", f.Path, err)
+			continue
+		}
+		fmt.Printf("归档 %s (Agent %s): %d 条会话记录\n", f.Path, f.Agent, len(sessions))
+		for key, s := range sessions {
+			referenced[cursorKey(f.Agent, sessionKey(key, s))] = true
+			run.session(f.Agent, key, s, ParentSession(sessions, s), archiveTranscript(f, s))
+		}
+	}
+
+	orphans := 0
+	for _, f := range files {
+		if !f.Transcript || !transcripts {
+			continue
+		}
+		id := cursorKey(f.Agent, transcriptSessionID(f.Path))
+		if referenced[id] {
+			continue
+		}
+		orphans++
+		run.transcript(f.Agent, f.Path)
+	}
+	if orphans > 0 {
+		fmt.Printf("导入 %d 个不在会话列表中的会话记录\n", orphans)
+	}
+	return run.finish()
+}
+
+// sessionKey 会话 ID (会话列表中缺失时使用键名)
+func sessionKey(key string, s Session) string {
+	if s.SessionID != "" {
+		return s.SessionID
+	}
+	return key
+}
+
+// transcriptSessionID 由会话记录文件名取会话 ID (<sessionId>.jsonl[.后缀])
+func transcriptSessionID(path string) string {
+	name := filepath.Base(path)
+	if i := strings.Index(name, ".jsonl"); i > 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// archiveTranscript 归档会话的会话记录: 原路径存在时使用原路径，否则在归档文件所在目录中查找
+func archiveTranscript(f ArchiveFile, s Session) string {
+	path := TranscriptPath(f.Agent, s)
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	dir := filepath.Dir(f.Path)
+	name := filepath.Base(path)
+	if s.SessionFile == "" {
+		name = s.SessionID + ".jsonl"
+	}
+	if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+		return filepath.Join(dir, name)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(escapeGlob(dir), escapeGlob(name)+".*")); len(matches) > 0 {
+		sort.Strings(matches)
+		return matches[len(matches)-1]
+	}
+	return path
+}
+
+// transcript 导入不属于任何会话列表的会话记录，并前移游标的累计用量，
+// 会话之后出现在会话列表中时只记录其后的增量
+func (run *syncRun) transcript(agent, path string) {
+	sessionID := transcriptSessionID(path)
+	id := cursorKey(agent, sessionID)
+	prev := run.cursor.Sessions[id]
+	since := prev.TranscriptAt
+	if since == 0 {
+		since = prev.UpdatedAt
+	}
+	messages, err := ReadTranscript(path, time.UnixMilli(since))
+	if err != nil {
+		fmt.Printf("⚠️ %v\n", err)
+		return
+	}
+	if len(messages) == 0 {
+		run.skipped++
+		return
+	}
+	s := Session{SessionID: sessionID, Model: messages[0].Model, TotalTokens: prev.TotalTokens}
+	for _, m := range messages {
+		s.TotalTokens += m.TotalTokens
+	}
+	if !SessionRules.Match(agent, KindDirect, s) {
+		run.filtered++
+		return
+	}
+
+	base := run.prepare(WorkRecord{SessionID: sessionID, AgentID: agent, Agent: agent, Kind: KindDirect, Delta: true})
+	records := messageRecords(base, messages)
+	saved := run.save(id, records)
+	pos := run.cursor.Sessions[id]
+	for _, r := range records[:saved] {
+		if ms := r.Timestamp.UnixMilli(); ms > pos.UpdatedAt {
+			pos.UpdatedAt = ms
+		}
+		pos.InputTokens += r.InputTokens
+		pos.OutputTokens += r.OutputTokens
+		pos.TotalTokens += r.TotalTokens
+		pos.CacheTokens += r.CacheTokens
+	}
+	if saved > 0 {
+		run.cursor.Sessions[id] = pos
+	}
+}
+
+// readSessionsFile 读取 sessions.json 格式的会话列表 (.gz 自动解压)
+func readSessionsFile(path string) (map[string]Session, error) {
+	f, err := openArchive(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// sessions.json 是 map[string]Session 格式
+	var sessions map[string]Session
+	if err := json.NewDecoder(f).Decode(&sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// gzipFile 关闭时同时关闭底层文件
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g gzipFile) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
+
+// openArchive 打开文件，.gz 结尾时解压
+func openArchive(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("解压 %s 失败: %w", filepath.Base(path), err)
+	}
+	return gzipFile{zr, f}, nil
+}
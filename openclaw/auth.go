@@ -0,0 +1,67 @@
+package openclaw
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ============ OpenClaw API 认证 ============
+
+// Token 访问 OpenClaw API 的令牌 (由 config.json 的 openclaw_token 设置)，为空时见 ResolveToken
+var Token string
+
+// TokenEnv 指定 OpenClaw API 令牌的环境变量
+const TokenEnv = "OPENCLAW_TOKEN"
+
+// ResolveToken 确定 API 令牌: Token > $OPENCLAW_TOKEN > OpenClaw 配置中的 gateway.auth.token，均无时返回空 (不认证)
+func ResolveToken() string {
+	if Token != "" {
+		return Token
+	}
+	if env := os.Getenv(TokenEnv); env != "" {
+		return env
+	}
+	var cfg struct {
+		Gateway struct {
+			Auth struct {
+				Token string `json:"token"`
+			} `json:"auth"`
+		} `json:"gateway"`
+	}
+	if readConfig(&cfg) != nil {
+		return ""
+	}
+	return cfg.Gateway.Auth.Token
+}
+
+// httpClient 访问 OpenClaw API 使用的客户端
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// NewRequest 创建访问 OpenClaw API 的请求 (path 相对 URL)，设置了令牌时附带 Authorization: Bearer <token>；
+// 客户端未指定令牌时使用 ResolveToken
+func (c *OpenClaw) NewRequest(method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, strings.TrimRight(c.URL, "/")+path, body)
+	if err != nil {
+		return nil, err
+	}
+	token := c.Token
+	if token == "" {
+		token = ResolveToken()
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
+
+// Get 发送带认证的 GET 请求
+func (c *OpenClaw) Get(path string) (*http.Response, error) {
+	req, err := c.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return httpClient.Do(req)
+}
@@ -0,0 +1,160 @@
+package openclaw
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ============ 增量同步游标 ============
+
+// OpenClaw 会话的 token 数是累计值，同步游标记录每个会话上次同步时的更新时间与 token 数，
+// 之后只记录两次同步之间的增量，避免同一会话的 token 被重复计入
+
+// CursorFile 同步游标文件 (位于数据目录)
+const CursorFile = "sync_cursor.json"
+
+// SessionCursor 会话上次同步的位置
+type SessionCursor struct {
+	UpdatedAt    int64 `json:"updated_at"`
+	InputTokens  int   `json:"input_tokens"`
+	OutputTokens int   `json:"output_tokens"`
+	TotalTokens  int   `json:"total_tokens"`
+	CacheTokens  int   `json:"cache_tokens,omitempty"`
+	TranscriptAt int64 `json:"transcript_at,omitempty"` // 已同步的最后一条消息时间 (按消息同步时)，见 transcript.go
+}
+
+// SyncCursor 同步游标 (会话 ID -> 位置)
+type SyncCursor struct {
+	Sessions map[string]SessionCursor `json:"sessions"`
+	SyncedAt int64                    `json:"synced_at"`
+}
+
+// LoadCursor 读取同步游标；文件不存在时由已有记录重建 (兼容启用游标之前的同步数据)
+func LoadCursor(dataDir string) (*SyncCursor, error) {
+	data, err := os.ReadFile(filepath.Join(dataDir, CursorFile))
+	if os.IsNotExist(err) {
+		return cursorFromRecords(filepath.Join(dataDir, "records")), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &SyncCursor{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	if c.Sessions == nil {
+		c.Sessions = map[string]SessionCursor{}
+	}
+	return c, nil
+}
+
+// cursorFromRecords 由已有记录推算游标: 早期记录是会话的累计快照，增量记录在其后累加
+func cursorFromRecords(dir string) *SyncCursor {
+	c := &SyncCursor{Sessions: map[string]SessionCursor{}}
+	records, _ := LoadRecords(dir)
+	latest := map[string]WorkRecord{} // 各会话最新的累计快照
+	for _, r := range records {
+		if r.Delta {
+			continue
+		}
+		if l, ok := latest[r.cursorKey()]; !ok || r.Timestamp.After(l.Timestamp) {
+			latest[r.cursorKey()] = r
+		}
+	}
+	for id, r := range latest {
+		c.Sessions[id] = SessionCursor{
+			UpdatedAt:    r.Timestamp.UnixMilli(),
+			InputTokens:  r.InputTokens,
+			OutputTokens: r.OutputTokens,
+			TotalTokens:  r.TotalTokens,
+			CacheTokens:  r.CacheTokens,
+		}
+	}
+	for _, r := range records {
+		if !r.Delta {
+			continue
+		}
+		key := r.cursorKey()
+		if base, ok := latest[key]; ok && !r.Timestamp.After(base.Timestamp) {
+			continue // 已包含在累计快照中
+		}
+		pos := c.Sessions[key]
+		if ts := r.Timestamp.UnixMilli(); ts > pos.UpdatedAt {
+			pos.UpdatedAt = ts
+		}
+		pos.InputTokens += r.InputTokens
+		pos.OutputTokens += r.OutputTokens
+		pos.TotalTokens += r.TotalTokens
+		pos.CacheTokens += r.CacheTokens
+		if r.MessageID != "" && r.Timestamp.UnixMilli() > pos.TranscriptAt {
+			pos.TranscriptAt = r.Timestamp.UnixMilli()
+		}
+		c.Sessions[key] = pos
+	}
+	return c
+}
+
+// cursorKey 游标键: 默认 Agent 的会话直接用会话 ID (兼容单 Agent 时的游标)，其余加 Agent 前缀
+func cursorKey(agent, sessionID string) string {
+	if agent == "" || agent == DefaultAgent {
+		return sessionID
+	}
+	return agent + "/" + sessionID
+}
+
+func (r WorkRecord) cursorKey() string {
+	return cursorKey(r.Agent, r.SessionID)
+}
+
+// markTranscript 记录会话已按消息同步到的时间
+func (c *SyncCursor) markTranscript(id string, at time.Time) {
+	pos := c.Sessions[id]
+	if ms := at.UnixMilli(); ms > pos.TranscriptAt {
+		pos.TranscriptAt = ms
+		c.Sessions[id] = pos
+	}
+}
+
+// Save 保存同步游标 (先写临时文件再重命名)
+func (c *SyncCursor) Save(dataDir string) error {
+	c.SyncedAt = time.Now().UnixMilli()
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dataDir, CursorFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Advance 计算会话自上次同步以来的增量并前移游标，ok=false 表示没有新的工作。
+// 会话 token 数回落 (会话被重置) 时以当前值作为增量
+func (c *SyncCursor) Advance(id string, s Session) (delta SessionCursor, ok bool) {
+	pos, seen := c.Sessions[id]
+	if seen && s.UpdatedAt <= pos.UpdatedAt {
+		return delta, false
+	}
+	cur := SessionCursor{
+		UpdatedAt:    s.UpdatedAt,
+		InputTokens:  s.InputTokens,
+		OutputTokens: s.OutputTokens,
+		TotalTokens:  s.TotalTokens,
+		CacheTokens:  s.CacheRead + s.CacheWrite,
+		TranscriptAt: pos.TranscriptAt,
+	}
+	delta = cur
+	if seen && cur.InputTokens >= pos.InputTokens && cur.OutputTokens >= pos.OutputTokens &&
+		cur.TotalTokens >= pos.TotalTokens && cur.CacheTokens >= pos.CacheTokens {
+		delta.InputTokens -= pos.InputTokens
+		delta.OutputTokens -= pos.OutputTokens
+		delta.TotalTokens -= pos.TotalTokens
+		delta.CacheTokens -= pos.CacheTokens
+	}
+	c.Sessions[id] = cur
+	return delta, delta.InputTokens > 0 || delta.OutputTokens > 0 || delta.TotalTokens > 0 || delta.CacheTokens > 0
+}
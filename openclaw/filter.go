@@ -0,0 +1,65 @@
+package openclaw
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// ============ 会话过滤 ============
+
+// SessionFilter 同步时的会话过滤规则 (config.json 的 session_filter)，名称均支持通配符，排除优先。
+// 被过滤的会话不记录也不前移游标，规则放宽后其累计用量在下次同步时一并记录
+type SessionFilter struct {
+	Agents    AgentFilter `json:"agents,omitempty"`     // 按 agentId (缺失时为 Agent 目录名)
+	Models    AgentFilter `json:"models,omitempty"`     // 按模型 (带 provider/ 前缀的模型也可按模型名匹配)
+	Kinds     AgentFilter `json:"kinds,omitempty"`      // 按会话类型 (direct/interactive/cron/subagent/webhook，见 kinds.go)
+	MinTokens int         `json:"min_tokens,omitempty"` // 会话累计 token 少于此数时跳过
+}
+
+// SessionRules 当前使用的会话过滤规则
+var SessionRules SessionFilter
+
+// Match 会话是否被同步 (kind 为会话类型，agentID 为空时取 Agent 目录名)
+func (f SessionFilter) Match(agent, kind string, s Session) bool {
+	agentID := s.AgentID
+	if agentID == "" {
+		agentID = agent
+	}
+	if !f.Agents.Match(agentID) || !f.Kinds.Match(kind) || !f.matchModel(s.Model) {
+		return false
+	}
+	total := s.TotalTokens
+	if total == 0 {
+		total = s.InputTokens + s.OutputTokens + s.CacheRead + s.CacheWrite
+	}
+	return total >= f.MinTokens
+}
+
+// matchModel 按完整模型名或去掉 provider/ 前缀后的模型名匹配，未记录模型的会话不按模型过滤
+func (f SessionFilter) matchModel(model string) bool {
+	if model == "" {
+		return true
+	}
+	names := []string{model}
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		names = append(names, model[i+1:])
+	}
+	for _, p := range f.Models.Exclude {
+		for _, name := range names {
+			if ok, _ := filepath.Match(p, name); ok {
+				return false
+			}
+		}
+	}
+	if len(f.Models.Include) == 0 {
+		return true
+	}
+	for _, p := range f.Models.Include {
+		for _, name := range names {
+			if ok, _ := filepath.Match(p, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package openclaw
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ============ OpenClaw 连接状态 ============
+
+// HealthFile 连接状态文件 (位于数据目录)，由后台同步与集成器轮询写入，oaw mine status 读取
+const HealthFile = "openclaw_health.json"
+
+// UnreachableWarn OpenClaw 持续不可达超过该时长时输出警告
+var UnreachableWarn = 10 * time.Minute
+
+// Health 最近一次访问 OpenClaw 的结果
+type Health struct {
+	Healthy     bool      `json:"healthy"`
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	LastFailure time.Time `json:"last_failure,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+	Failures    int       `json:"failures"`             // 连续失败次数
+	DownSince   time.Time `json:"down_since,omitempty"` // 本次不可达的开始时间 (正常时为零值)
+	Source      string    `json:"source,omitempty"`     // 写入方 (sync / poll)
+}
+
+// Observe 记录一次访问结果，返回是否应输出不可达警告 (本次不可达首次超过 UnreachableWarn 时为 true)
+func (h *Health) Observe(err error, now time.Time) (warn bool) {
+	if err == nil {
+		h.Healthy = true
+		h.LastSuccess = now
+		h.Failures = 0
+		h.DownSince = time.Time{}
+		return false
+	}
+	wasLong := !h.DownSince.IsZero() && now.Sub(h.DownSince) > UnreachableWarn
+	if h.DownSince.IsZero() {
+		h.DownSince = now
+	}
+	h.Healthy = false
+	h.LastFailure = now
+	h.LastError = err.Error()
+	h.Failures++
+	return !wasLong && now.Sub(h.DownSince) > UnreachableWarn
+}
+
+// Downtime 持续不可达的时长 (正常时为 0)
+func (h *Health) Downtime(now time.Time) time.Duration {
+	if h.Healthy || h.DownSince.IsZero() {
+		return 0
+	}
+	return now.Sub(h.DownSince)
+}
+
+// LoadHealth 读取连接状态，文件不存在时返回 nil
+func LoadHealth(dataDir string) (*Health, error) {
+	data, err := os.ReadFile(filepath.Join(dataDir, HealthFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	h := &Health{}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Save 保存连接状态
+func (h *Health) Save(dataDir string) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dataDir, HealthFile), data, 0644)
+}
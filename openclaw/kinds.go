@@ -0,0 +1,53 @@
+package openclaw
+
+import "strings"
+
+// ============ 会话类型 ============
+
+// 会话类型参与价值计算 (按类型加成，见 worktracker.KindBonuses) 与会话过滤 (SessionFilter.Kinds)
+const (
+	KindDirect      = "direct"      // 渠道私聊等一般会话 (默认)
+	KindInteractive = "interactive" // Agent 的主会话 (控制台、TUI 等操作者直接交互)
+	KindCron        = "cron"        // 定时任务
+	KindSubagent    = "subagent"    // 派生的子 Agent 会话，见 subagent.go
+	KindWebhook     = "webhook"     // Webhook / 钩子触发的会话
+)
+
+// knownKinds 会话元数据中可直接采用的类型
+var knownKinds = map[string]bool{KindInteractive: true, KindCron: true, KindSubagent: true, KindWebhook: true}
+
+// SessionKind 由会话键与元数据判断会话类型:
+// 元数据 kind 为已知类型 (direct 除外) 时直接采用；记录了父会话或键含 subagent 段的为子 Agent 会话；
+// 去掉 agent:<id>: 前缀后以 cron / hook、webhook 开头的为定时任务 / Webhook 会话；只有 main 的为主会话
+func SessionKind(key string, s Session) string {
+	if kind := strings.ToLower(s.Kind); knownKinds[kind] {
+		return kind
+	}
+	if s.SpawnedBy != "" || s.ParentSessionID != "" {
+		return KindSubagent
+	}
+
+	parts := strings.Split(strings.ToLower(key), ":")
+	if len(parts) >= 2 && parts[0] == "agent" {
+		parts = parts[2:]
+	}
+	for _, p := range parts {
+		if p == "subagent" {
+			return KindSubagent
+		}
+	}
+	if len(parts) == 0 {
+		return KindDirect
+	}
+	switch parts[0] {
+	case "cron":
+		return KindCron
+	case "hook", "webhook":
+		return KindWebhook
+	case "main":
+		if len(parts) == 1 {
+			return KindInteractive
+		}
+	}
+	return KindDirect
+}
@@ -0,0 +1,78 @@
+package openclaw
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	worktracker "oaw/tracker"
+)
+
+// ============ 按模型计价 ============
+
+// ModelValue 模型的价值配置: 大模型的输出与小模型的输出按不同价值计算
+type ModelValue struct {
+	Multiplier float64 `json:"multiplier,omitempty"`  // 价值倍数 (0 视为 1)
+	Output     float64 `json:"output_rate,omitempty"` // 每个输出 token 的价值 (0 为默认 0.1)
+	Input      float64 `json:"input_rate,omitempty"`  // 每个输入 token 的成本 (0 为默认 0.001)
+	Cache      float64 `json:"cache_rate,omitempty"`  // 每个缓存读写 token 的成本 (0 为默认 0.0001)
+}
+
+// DefaultModelValue 通配项，未匹配的模型按此计价
+const DefaultModelValue = "*"
+
+// ModelValues 按模型名的价值配置 (config.json 的 model_values)，
+// 模型名按完全匹配、最长前缀、"*" 的顺序查找，均未匹配时按默认单价且不加倍
+var ModelValues = map[string]ModelValue{}
+
+// SetModelValue 设置模型的价值配置
+func SetModelValue(model string, v ModelValue) error {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if model == "" {
+		return fmt.Errorf("模型名不能为空")
+	}
+	for _, x := range []float64{v.Multiplier, v.Output, v.Input, v.Cache} {
+		if x < 0 || math.IsNaN(x) || math.IsInf(x, 0) {
+			return fmt.Errorf("模型 %s 的价值配置无效: %v", model, x)
+		}
+	}
+	ModelValues[model] = v
+	return nil
+}
+
+// LookupModelValue 查找模型的价值配置
+func LookupModelValue(model string) (ModelValue, bool) {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if model != "" {
+		if v, ok := ModelValues[model]; ok {
+			return v, true
+		}
+		best := ""
+		for name := range ModelValues {
+			if name != DefaultModelValue && strings.HasPrefix(model, name) && len(name) > len(best) {
+				best = name
+			}
+		}
+		if best != "" {
+			return ModelValues[best], true
+		}
+	}
+	v, ok := ModelValues[DefaultModelValue]
+	return v, ok
+}
+
+// multiplier 价值倍数 (未设置为 1)
+func (m ModelValue) multiplier() float64 {
+	if m.Multiplier == 0 {
+		return 1
+	}
+	return m.Multiplier
+}
+
+// apply 按模型单价调整策略中的 token 计价
+func (m ModelValue) apply(v worktracker.Valuator) worktracker.Valuator {
+	if m.Output == 0 && m.Input == 0 && m.Cache == 0 {
+		return v
+	}
+	return worktracker.WithTokenRates(v, worktracker.TokenValuator{OutputRate: m.Output, InputRate: m.Input, CacheRate: m.Cache})
+}
@@ -0,0 +1,100 @@
+package openclaw
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ============ OpenClaw 目录 ============
+
+// Home OpenClaw 数据目录 (由 --openclaw-home 或 config.json 设置)，为空时自动检测
+var Home string
+
+// HomeEnv 指定 OpenClaw 数据目录的环境变量
+const HomeEnv = "OPENCLAW_HOME"
+
+// DefaultLayout 会话文件相对 OpenClaw 目录的路径模板，{agent} 替换为 Agent 名称
+const DefaultLayout = "agents/{agent}/sessions/sessions.json"
+
+// Layout 当前使用的会话文件路径模板 (config.json 的 openclaw_layout 可覆盖)
+var Layout = DefaultLayout
+
+// DefaultAgent 未指定 Agent 时读取的目录
+const DefaultAgent = "main"
+
+// DetectHome 确定 OpenClaw 数据目录: Home > $OPENCLAW_HOME > 各平台的常见位置中已存在的第一个，
+// 均不存在时返回 ~/.openclaw
+func DetectHome() string {
+	if Home != "" {
+		return expandHome(Home)
+	}
+	if env := os.Getenv(HomeEnv); env != "" {
+		return expandHome(env)
+	}
+	candidates := homeCandidates()
+	for _, dir := range candidates {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return candidates[0]
+}
+
+// homeCandidates 各平台的候选目录，第一个为默认位置
+func homeCandidates() []string {
+	home := userHome()
+	dirs := []string{filepath.Join(home, ".openclaw")}
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"APPDATA", "LOCALAPPDATA"} {
+			if base := os.Getenv(env); base != "" {
+				dirs = append(dirs, filepath.Join(base, "openclaw"), filepath.Join(base, "OpenClaw"))
+			}
+		}
+	case "darwin":
+		dirs = append(dirs, filepath.Join(home, "Library", "Application Support", "openclaw"))
+	default:
+		if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+			dirs = append(dirs, filepath.Join(xdg, "openclaw"))
+		}
+		dirs = append(dirs, filepath.Join(home, ".config", "openclaw"))
+	}
+	return dirs
+}
+
+// userHome 用户主目录 (兼容只设置了 USERPROFILE 或 HOME 的环境)
+func userHome() string {
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		return home
+	}
+	if home := os.Getenv("USERPROFILE"); home != "" {
+		return home
+	}
+	return os.Getenv("HOME")
+}
+
+// expandHome 展开路径开头的 ~
+func expandHome(path string) string {
+	if path == "~" {
+		return userHome()
+	}
+	if strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		return filepath.Join(userHome(), path[2:])
+	}
+	return path
+}
+
+// SessionsPath Agent 的会话文件路径 (Layout 为绝对路径时不拼接 OpenClaw 目录)
+func SessionsPath(agent string) string {
+	if agent == "" {
+		agent = DefaultAgent
+	}
+	rel := filepath.FromSlash(strings.ReplaceAll(Layout, "{agent}", agent))
+	rel = expandHome(rel)
+	if filepath.IsAbs(rel) {
+		return rel
+	}
+	return filepath.Join(DetectHome(), rel)
+}
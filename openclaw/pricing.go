@@ -0,0 +1,86 @@
+package openclaw
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	worktracker "oaw/tracker"
+)
+
+// ============ OpenClaw 模型价格 ============
+
+// ConfigFile OpenClaw 自身的配置文件 (位于 OpenClaw 数据目录)
+const ConfigFile = "openclaw.json"
+
+// readConfig 读取 OpenClaw 配置文件到 v (只支持标准 JSON，含注释等 JSON5 语法时返回错误)
+func readConfig(v any) error {
+	data, err := os.ReadFile(filepath.Join(DetectHome(), ConfigFile))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// modelCost OpenClaw 配置中的模型单价 (美元/百万 token)
+type modelCost struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheRead  float64 `json:"cacheRead"`
+	CacheWrite float64 `json:"cacheWrite"`
+}
+
+// ModelPricing 读取 OpenClaw 配置 models.providers.<provider>.models[].cost 中的模型单价，
+// 每个模型以 id 和 provider/id 两个名称返回；缓存单价取 cacheRead (未配置时取 cacheWrite)。
+// 配置不存在或未配置价格时返回空表
+func ModelPricing() (map[string]worktracker.ModelPrice, error) {
+	var cfg struct {
+		Models struct {
+			Providers map[string]struct {
+				Models []struct {
+					ID   string     `json:"id"`
+					Cost *modelCost `json:"cost"`
+				} `json:"models"`
+			} `json:"providers"`
+		} `json:"models"`
+	}
+	if err := readConfig(&cfg); err != nil {
+		if os.IsNotExist(err) {
+			return map[string]worktracker.ModelPrice{}, nil
+		}
+		return nil, err
+	}
+
+	prices := map[string]worktracker.ModelPrice{}
+	for provider, p := range cfg.Models.Providers {
+		for _, m := range p.Models {
+			if m.ID == "" || m.Cost == nil || (m.Cost.Input == 0 && m.Cost.Output == 0) {
+				continue
+			}
+			price := worktracker.ModelPrice{Input: m.Cost.Input, Output: m.Cost.Output, Cache: m.Cost.CacheRead}
+			if price.Cache == 0 {
+				price.Cache = m.Cost.CacheWrite
+			}
+			id := strings.ToLower(m.ID)
+			prices[id] = price
+			prices[strings.ToLower(provider)+"/"+id] = price
+		}
+	}
+	return prices, nil
+}
+
+// SyncPricing 将 OpenClaw 配置的模型单价写入 OAW 价格表，返回写入的模型数。
+// 需在应用 config.json 的 pricing 之前调用，使手动配置的单价优先
+func SyncPricing() (int, error) {
+	prices, err := ModelPricing()
+	if err != nil {
+		return 0, err
+	}
+	for model, price := range prices {
+		if err := worktracker.SetModelPrice(model, price); err != nil {
+			return 0, err
+		}
+	}
+	return len(prices), nil
+}
@@ -0,0 +1,21 @@
+package openclaw
+
+// ============ 子 Agent 会话 ============
+
+// OpenClaw 派生子 Agent 时为其创建独立会话 (键如 agent:main:subagent:<id>)，会话列表中记录派生它的父会话。
+// 同步时子会话的记录标注父会话 ID (WorkRecord.ParentSession)，oaw report session 将子会话的工作汇总到父会话
+
+// ParentSession 子 Agent 会话的父会话 ID: parentSessionId，或 spawnedBy 指向的会话键在同一会话列表中的 sessionId
+// (列表中找不到时为该键本身)；不是子 Agent 会话时返回空
+func ParentSession(sessions map[string]Session, s Session) string {
+	if s.ParentSessionID != "" {
+		return s.ParentSessionID
+	}
+	if s.SpawnedBy == "" {
+		return ""
+	}
+	if p, ok := sessions[s.SpawnedBy]; ok && p.SessionID != "" {
+		return p.SessionID
+	}
+	return s.SpawnedBy
+}
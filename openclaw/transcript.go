@@ -0,0 +1,150 @@
+package openclaw
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============ 会话记录 (JSONL) ============
+
+// OpenClaw 为每个会话保存逐条消息的记录文件 (<sessions 目录>/<sessionId>.jsonl)，
+// 每行一个事件；助手消息带有模型与 token 用量，内容中包含工具调用。
+// 同步时按消息生成记录，工作证明精确到单条消息
+
+// TranscriptMessage 会话记录中的一条助手消息
+type TranscriptMessage struct {
+	ID           string
+	Timestamp    time.Time
+	Model        string
+	InputTokens  int
+	OutputTokens int
+	CacheTokens  int
+	TotalTokens  int
+	Tools        []string // 本条消息发起的工具调用
+}
+
+// transcriptLine 记录文件中的一行 (兼容字段名的不同写法)
+type transcriptLine struct {
+	Type      string          `json:"type"`
+	ID        string          `json:"id"`
+	Timestamp json.RawMessage `json:"timestamp"`
+	Message   *struct {
+		Role      string          `json:"role"`
+		Model     string          `json:"model"`
+		Timestamp json.RawMessage `json:"timestamp"`
+		Content   json.RawMessage `json:"content"`
+		Usage     *struct {
+			Input        int `json:"input"`
+			Output       int `json:"output"`
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+			CacheRead    int `json:"cacheRead"`
+			CacheWrite   int `json:"cacheWrite"`
+			TotalTokens  int `json:"totalTokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// TranscriptPath 会话记录文件路径: sessions.json 中的 sessionFile，否则为会话文件同目录下的 <sessionId>.jsonl
+func TranscriptPath(agent string, s Session) string {
+	if s.SessionFile != "" {
+		path := expandHome(s.SessionFile)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(SessionsPath(agent)), path)
+		}
+		return path
+	}
+	return filepath.Join(filepath.Dir(SessionsPath(agent)), s.SessionID+".jsonl")
+}
+
+// ReadTranscript 读取会话记录中晚于 since 的助手消息 (按文件顺序)，无法解析的行被跳过；.gz 结尾时先解压
+func ReadTranscript(path string, since time.Time) ([]TranscriptMessage, error) {
+	f, err := openArchive(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var messages []TranscriptMessage
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for scanner.Scan() {
+		var line transcriptLine
+		if json.Unmarshal(scanner.Bytes(), &line) != nil || line.Message == nil || line.Message.Role != "assistant" {
+			continue
+		}
+		m := line.Message
+		ts, ok := parseTimestamp(m.Timestamp)
+		if !ok {
+			if ts, ok = parseTimestamp(line.Timestamp); !ok {
+				continue
+			}
+		}
+		if !ts.After(since) {
+			continue
+		}
+		msg := TranscriptMessage{ID: line.ID, Timestamp: ts, Model: m.Model, Tools: toolCalls(m.Content)}
+		if u := m.Usage; u != nil {
+			msg.InputTokens = u.Input + u.InputTokens
+			msg.OutputTokens = u.Output + u.OutputTokens
+			msg.CacheTokens = u.CacheRead + u.CacheWrite
+			msg.TotalTokens = u.TotalTokens
+			if msg.TotalTokens == 0 {
+				msg.TotalTokens = msg.InputTokens + msg.OutputTokens + msg.CacheTokens
+			}
+		}
+		if msg.TotalTokens == 0 && len(msg.Tools) == 0 {
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	if err := scanner.Err(); err != nil {
+		return messages, fmt.Errorf("读取会话记录 %s 失败: %w", filepath.Base(path), err)
+	}
+	return messages, nil
+}
+
+// toolCalls 消息内容中的工具调用名称
+func toolCalls(content json.RawMessage) []string {
+	var items []struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	}
+	if json.Unmarshal(content, &items) != nil {
+		return nil
+	}
+	var names []string
+	for _, item := range items {
+		switch item.Type {
+		case "toolCall", "tool_use", "tool_call":
+			if item.Name != "" {
+				names = append(names, item.Name)
+			}
+		}
+	}
+	return names
+}
+
+// parseTimestamp 解析 RFC3339 字符串或毫秒时间戳
+func parseTimestamp(raw json.RawMessage) (time.Time, bool) {
+	if len(raw) == 0 {
+		return time.Time{}, false
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t, true
+		}
+		raw = json.RawMessage(s)
+	}
+	ms, err := strconv.ParseInt(strings.TrimSpace(string(raw)), 10, 64)
+	if err != nil || ms <= 0 {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(ms), true
+}
@@ -0,0 +1,164 @@
+package openclaw
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ============ 按会话更新记录 ============
+
+// 同步记录按会话 ID (按消息同步时再加消息 ID) 命名，同一会话只保留一个记录文件并原地更新 token 数，
+// 每次更新的增量追加到增量日志，保留完整的同步历史。启用前按写入时间命名的记录保持不变
+
+// DeltaLogFile 增量日志文件 (位于数据目录，JSONL)
+const DeltaLogFile = "sync_deltas.jsonl"
+
+// DeltaEntry 增量日志条目: 一次同步为某个记录增加的用量
+type DeltaEntry struct {
+	SyncedAt     time.Time `json:"synced_at"`
+	File         string    `json:"file"` // 被更新的记录文件名
+	SessionID    string    `json:"session_id"`
+	Agent        string    `json:"agent,omitempty"`
+	MessageID    string    `json:"message_id,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"` // 会话 (或消息) 时间
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	TotalTokens  int       `json:"total_tokens"`
+	CacheTokens  int       `json:"cache_tokens,omitempty"`
+	Value        float64   `json:"value"` // 本次增加的价值
+}
+
+// recordFileName 记录文件名: 会话键 (见 cursorKey)，按消息记录时加消息 ID，非法字符替换为 _
+func recordFileName(id, messageID string) string {
+	name := "session_" + id
+	if messageID != "" {
+		name += "_msg_" + messageID
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		}
+		return '_'
+	}, name) + ".json"
+}
+
+// UpsertRecord 写入会话记录: 文件已存在时会话记录累加 token 并更新时间，消息记录直接覆盖；
+// 内容变化后重新计算价值，已补全的工作证明随之更新且旧签名失效 (可由 oaw proof backfill 重新签名)。
+// 返回写入的记录与本次增量
+func UpsertRecord(dir, id string, record WorkRecord) (stored WorkRecord, delta DeltaEntry, err error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return stored, delta, err
+	}
+	name := recordFileName(id, record.MessageID)
+	path := filepath.Join(dir, name)
+
+	record.Value = CalculateValue(record)
+	delta = DeltaEntry{
+		File:         name,
+		SessionID:    record.SessionID,
+		Agent:        record.Agent,
+		MessageID:    record.MessageID,
+		UpdatedAt:    record.Timestamp,
+		InputTokens:  record.InputTokens,
+		OutputTokens: record.OutputTokens,
+		TotalTokens:  record.TotalTokens,
+		CacheTokens:  record.CacheTokens,
+		Value:        record.Value,
+	}
+
+	stored = record
+	if data, err := os.ReadFile(path); err == nil {
+		var prev WorkRecord
+		if err := json.Unmarshal(data, &prev); err != nil {
+			return stored, delta, fmt.Errorf("读取记录 %s 失败: %w", name, err)
+		}
+		if record.MessageID == "" {
+			stored = prev
+			stored.Timestamp = record.Timestamp
+			stored.Kind = record.Kind
+			if record.Model != "" {
+				stored.Model = record.Model
+			}
+			stored.InputTokens += record.InputTokens
+			stored.OutputTokens += record.OutputTokens
+			stored.TotalTokens += record.TotalTokens
+			stored.CacheTokens += record.CacheTokens
+			stored.Value = CalculateValue(stored)
+		} else {
+			stored.BackfillNote = prev.BackfillNote
+		}
+		delta.Value = stored.Value - prev.Value
+	}
+	if stored.ProofHash != "" && stored.ProofHash != stored.ComputeProof() {
+		stored.ProofHash = stored.ComputeProof()
+		stored.Signature, stored.Signer = "", ""
+	}
+
+	// 先写临时文件再重命名，中断时不留下不完整的记录
+	data, _ := json.MarshalIndent(stored, "", "  ")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return stored, delta, err
+	}
+	return stored, delta, os.Rename(tmp, path)
+}
+
+// AppendDeltaLog 追加增量日志
+func AppendDeltaLog(dataDir string, entries []DeltaEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(filepath.Join(dataDir, DeltaLogFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	now := time.Now()
+	enc := json.NewEncoder(f)
+	for _, e := range entries {
+		e.SyncedAt = now
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadDeltaLog 读取增量日志 (sessionID 非空时只返回该会话的条目)
+func LoadDeltaLog(dataDir, sessionID string) ([]DeltaEntry, error) {
+	data, err := os.ReadFile(filepath.Join(dataDir, DeltaLogFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var entries []DeltaEntry
+	for _, line := range strings.Split(string(data), "\n") {
+		var e DeltaEntry
+		if strings.TrimSpace(line) == "" || json.Unmarshal([]byte(line), &e) != nil {
+			continue
+		}
+		if sessionID == "" || e.SessionID == sessionID {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// SumDeltas 汇总增量日志中满足条件的条目的价值与 token 数 (按会话命名的记录在日志中保留每次增长)
+func SumDeltas(dataDir string, keep func(DeltaEntry) bool) (value float64, tokens int) {
+	entries, _ := LoadDeltaLog(dataDir, "")
+	for _, e := range entries {
+		if keep(e) {
+			value += e.Value
+			tokens += e.TotalTokens
+		}
+	}
+	return value, tokens
+}
@@ -0,0 +1,30 @@
+package openclaw
+
+import "path/filepath"
+
+// ============ Agent 钱包 ============
+
+// AgentWallets Agent → OAW 钱包名称 (config.json 的 agent_wallets)，键可为 agentId 或 Agent 目录名，
+// 支持通配符 ("*" 即默认)；未匹配的记录归属默认钱包
+var AgentWallets map[string]string
+
+// WalletFor 记录归属的钱包名称: 先按 agentId、再按 Agent 目录名精确匹配，之后取匹配的最长通配符，均未匹配时返回空
+func WalletFor(agentID, agent string) string {
+	for _, id := range []string{agentID, agent} {
+		if w, ok := AgentWallets[id]; ok && id != "" {
+			return w
+		}
+	}
+	var best string
+	for pattern := range AgentWallets {
+		for _, id := range []string{agentID, agent} {
+			if ok, _ := filepath.Match(pattern, id); ok && id != "" && len(pattern) > len(best) {
+				best = pattern
+			}
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return AgentWallets[best]
+}
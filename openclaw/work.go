@@ -1,11 +1,18 @@
 package openclaw
 
 import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
+
+	worktracker "oaw/tracker"
 )
 
 // OpenClaw OpenClaw 集成
@@ -26,61 +33,182 @@ type Session struct {
 	InputTokens  int    `json:"inputTokens"`
 	OutputTokens int    `json:"outputTokens"`
 	TotalTokens  int    `json:"totalTokens"`
+	CacheRead    int    `json:"cacheRead"`  // 缓存读取 token
+	CacheWrite   int    `json:"cacheWrite"` // 缓存写入 token
 	Model        string `json:"model"`
 	AgentID      string `json:"agentId"`
 	Kind         string `json:"kind"`
+	SessionFile  string `json:"sessionFile,omitempty"` // 会话记录文件 (JSONL)，见 transcript.go
+
+	// 子 Agent 会话的父会话，见 subagent.go
+	SpawnedBy       string `json:"spawnedBy,omitempty"`       // 父会话的键
+	ParentSessionID string `json:"parentSessionId,omitempty"` // 父会话 ID
 }
 
-// GetSessions 获取会话列表
+// GetSessions 获取默认 Agent 的会话列表
 func GetSessions() (map[string]Session, error) {
-	home := os.Getenv("USERPROFILE")
-	if home == "" {
-		home = os.Getenv("HOME")
+	return GetAgentSessions(DefaultAgent)
+}
+
+// GetAgentSessions 获取指定 Agent 的会话列表，路径见 SessionsPath
+func GetAgentSessions(agent string) (map[string]Session, error) {
+	return readSessionsFile(SessionsPath(agent))
+}
+
+// WorkRecord 工作量记录
+type WorkRecord struct {
+	Timestamp     time.Time `json:"timestamp"`
+	SessionID     string    `json:"session_id"`
+	AgentID       string    `json:"agent_id"`
+	Agent         string    `json:"agent,omitempty"`  // 会话所在的 OpenClaw Agent 目录，见 agents.go
+	Wallet        string    `json:"wallet,omitempty"` // 归属的 OAW 钱包 (为空表示默认钱包)，见 wallets.go
+	Kind          string    `json:"kind"`
+	Model         string    `json:"model,omitempty"`
+	MessageID     string    `json:"message_id,omitempty"`     // 按消息记录时的消息 ID，见 transcript.go
+	ParentSession string    `json:"parent_session,omitempty"` // 子 Agent 会话的父会话 ID，见 subagent.go
+	Tools         []string  `json:"tools,omitempty"`          // 该消息发起的工具调用
+	InputTokens   int       `json:"input_tokens"`
+	OutputTokens  int       `json:"output_tokens"`
+	TotalTokens   int       `json:"total_tokens"`
+	CacheTokens   int       `json:"cache_tokens,omitempty"` // 缓存读写 token
+	Value         float64   `json:"value"`
+	Valuator      string    `json:"valuator,omitempty"` // 计算 Value 使用的策略 (为空表示默认策略)
+	Delta         bool      `json:"delta,omitempty"`    // token 为距上次同步的增量 (否则为会话累计值)，见 cursor.go
+
+	// 工作证明与签名 (由 oaw proof backfill 补全)
+	ProofHash    string `json:"proof_hash,omitempty"`
+	Signature    string `json:"signature,omitempty"`
+	Signer       string `json:"signer,omitempty"`
+	BackfillNote string `json:"backfill_note,omitempty"`
+}
+
+// CalculateValue 计算工作量价值
+// 使用记录保存的价值策略，未记录策略时使用默认策略；按模型配置调整 token 单价与价值倍数 (见 models.go)
+func CalculateValue(record WorkRecord) float64 {
+	v := worktracker.DefaultValuator
+	if record.Valuator != "" {
+		if parsed, err := worktracker.ParseValuator(record.Valuator); err == nil {
+			v = parsed
+		}
+	}
+	mv, ok := LookupModelValue(record.Model)
+	if !ok {
+		return v.Value(record.Metrics())
 	}
-	sessionPath := filepath.Join(home, ".openclaw", "agents", "main", "sessions", "sessions.json")
+	return mv.apply(v).Value(record.Metrics()) * mv.multiplier()
+}
 
-	data, err := os.ReadFile(sessionPath)
-	if err != nil {
-		return nil, err
+// Metrics 价值计算输入
+func (r WorkRecord) Metrics() worktracker.WorkMetrics {
+	return worktracker.WorkMetrics{
+		Kind:         r.Kind,
+		TokensInput:  int64(r.InputTokens),
+		TokensOutput: int64(r.OutputTokens),
+		TokensCache:  int64(r.CacheTokens),
 	}
+}
 
-	// sessions.json 是 map[string]Session 格式
-	var sessions map[string]Session
-	if err := json.Unmarshal(data, &sessions); err != nil {
-		return nil, err
+// Key 去重键: 会话 ID + 会话更新时间 (按消息记录时再加消息 ID)
+func (r WorkRecord) Key() string {
+	if r.MessageID != "" {
+		return fmt.Sprintf("%s|%d|%s", r.SessionID, r.Timestamp.UnixMilli(), r.MessageID)
 	}
+	return fmt.Sprintf("%s|%d", r.SessionID, r.Timestamp.UnixMilli())
+}
 
-	return sessions, nil
+// ComputeProof 由会话快照字段计算工作证明哈希
+func (r WorkRecord) ComputeProof() string {
+	data := fmt.Sprintf("%s|%s|%s|%d|%d|%d|%d",
+		r.SessionID, r.AgentID, r.Kind, r.InputTokens, r.OutputTokens, r.TotalTokens, r.Timestamp.UnixMilli())
+	if r.Model != "" {
+		data += "|" + r.Model // 仅记录了模型时追加，已有证明保持不变
+	}
+	if r.CacheTokens > 0 {
+		data += fmt.Sprintf("|cache:%d", r.CacheTokens)
+	}
+	if r.MessageID != "" {
+		data += "|msg:" + r.MessageID + "|" + strings.Join(r.Tools, ",")
+	}
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:])
 }
 
-// WorkRecord 工作量记录
-type WorkRecord struct {
-	Timestamp    time.Time `json:"timestamp"`
-	SessionID    string    `json:"session_id"`
-	AgentID      string    `json:"agent_id"`
-	Kind         string    `json:"kind"`
-	InputTokens  int       `json:"input_tokens"`
-	OutputTokens int       `json:"output_tokens"`
-	TotalTokens  int       `json:"total_tokens"`
-	Value        float64   `json:"value"`
+// Verify 校验记录可提交上链: 工作证明须已保存且与字段一致，签名须由声明的钱包签发
+func (r WorkRecord) Verify() error {
+	if r.ProofHash == "" {
+		return fmt.Errorf("缺少工作证明")
+	}
+	if proof := r.ComputeProof(); proof != r.ProofHash {
+		return fmt.Errorf("工作证明不匹配: 记录 %s, 重算 %s", r.ProofHash, proof)
+	}
+	if r.Signature == "" || r.Signer == "" {
+		return fmt.Errorf("记录未签名")
+	}
+	return worktracker.VerifyProofSignature(r.ProofHash, r.Signature, r.Signer)
 }
 
-// CalculateValue 计算工作量价值
-func CalculateValue(record WorkRecord) float64 {
-	// 价值公式：
-	// - 输出 token = AI 创造的价值
-	// - 输入 token = 消耗的成本
-	// 输出 token 价值远高于输入成本（鼓励 AI 干活）
-	outputValue := float64(record.OutputTokens) * 0.1   // 每个输出 token 值 0.1
-	inputCost := float64(record.InputTokens) * 0.001    // 每个输入 token 成本 0.001
-	
-	// 任务类型加成
-	bonus := 1.0
-	if record.Kind == "cron" {
-		bonus = 1.5 // 定时任务加成（自动执行）
-	}
-	
-	return (outputValue - inputCost) * bonus
+// BackfillRecords 为缺少工作证明或签名的同步记录补全，keyFor 返回记录归属钱包的私钥 (为 nil 或返回 nil 时只补工作证明)，
+// 写回时附带补全说明，已有但与字段不符的工作证明不会被覆盖。返回补全的工作证明数与签名数
+func BackfillRecords(dir string, keyFor func(WorkRecord) *ecdsa.PrivateKey, dryRun bool) (proofs, signatures int, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, 0, nil
+		}
+		return 0, 0, err
+	}
+
+	today := time.Now().Format("2006-01-02")
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var record WorkRecord
+		if json.Unmarshal(data, &record) != nil {
+			continue
+		}
+
+		var key *ecdsa.PrivateKey
+		if keyFor != nil {
+			key = keyFor(record)
+		}
+		needProof := record.ProofHash == ""
+		needSign := record.Signature == "" && key != nil
+		if !needProof && (!needSign || record.ProofHash != record.ComputeProof()) {
+			continue
+		}
+		var notes []string
+		if needProof {
+			record.ProofHash = record.ComputeProof()
+			notes = append(notes, "工作证明")
+			proofs++
+		}
+		if key != nil && record.Signature == "" {
+			if record.Signer, record.Signature, err = worktracker.SignProof(record.ProofHash, key); err != nil {
+				return proofs, signatures, err
+			}
+			notes = append(notes, "签名")
+			signatures++
+		}
+		if dryRun {
+			continue
+		}
+		note := fmt.Sprintf("%s 补全%s", today, strings.Join(notes, "与"))
+		if record.BackfillNote != "" {
+			note = record.BackfillNote + "; " + note
+		}
+		record.BackfillNote = note
+		out, _ := json.MarshalIndent(record, "", "  ")
+		if err := os.WriteFile(path, out, 0644); err != nil {
+			return proofs, signatures, fmt.Errorf("写回 %s 失败: %w", e.Name(), err)
+		}
+	}
+	return proofs, signatures, nil
 }
 
 // SaveRecord 保存记录
@@ -100,7 +228,7 @@ func LoadRecords(dir string) ([]WorkRecord, error) {
 
 	var records []WorkRecord
 	for _, e := range entries {
-		if e.IsDir() {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" { // 跳过中断写入残留的 .tmp 文件
 			continue
 		}
 		data, _ := os.ReadFile(filepath.Join(dir, e.Name()))
@@ -112,40 +240,217 @@ func LoadRecords(dir string) ([]WorkRecord, error) {
 	return records, nil
 }
 
-// SyncFromSessions 从 OpenClaw 同步工作量
-func SyncFromSessions(dataDir string) error {
-	sessions, err := GetSessions()
+// DedupRecords 删除会话 ID + 时间重复的记录文件 (保留最早写入的一份)，返回删除数量
+func DedupRecords(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	seen := map[string]bool{}
+	removed := 0
+	for _, e := range entries { // ReadDir 按名称排序，按写入时间命名的早期记录在按会话命名的记录之前
+		if e.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var record WorkRecord
+		if json.Unmarshal(data, &record) != nil {
+			continue
+		}
+		if !seen[record.Key()] {
+			seen[record.Key()] = true
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// SyncFromSessions 从 OpenClaw 同步所有被选中 Agent 的工作量，v 为 nil 时使用默认价值策略。
+// transcripts 为 true 且会话记录文件存在时按消息生成记录，否则每个会话记录一条增量
+func SyncFromSessions(dataDir string, v worktracker.Valuator, filter AgentFilter, transcripts bool) error {
+	all, failed, err := GetAllSessions(filter)
 	if err != nil {
 		return fmt.Errorf("读取会话失败: %w", err)
 	}
+	for agent, err := range failed {
+		fmt.Printf("⚠️ 读取 Agent %s 的会话失败: %v\n", agent, err)
+	}
+	if len(all) == 0 {
+		return fmt.Errorf("没有可同步的 Agent (会话路径: %s)", SessionsPath("{agent}"))
+	}
 
-	fmt.Printf("获取到 %d 条会话记录\n", len(sessions))
+	// 同步游标: 只记录各会话自上次同步以来的增量
+	cursor, err := LoadCursor(dataDir)
+	if err != nil {
+		return fmt.Errorf("读取同步游标失败: %w", err)
+	}
 
-	totalValue := 0.0
-	for key, s := range sessions {
-		// 从 key 提取 kind (direct/cron)
-		kind := "direct"
-		if len(key) > 5 && key[:5] == "cron:" {
-			kind = "cron"
+	run := &syncRun{dataDir: dataDir, valuator: v, transcripts: transcripts, cursor: cursor}
+	for _, a := range all {
+		fmt.Printf("Agent %s: 获取到 %d 条会话记录\n", a.Agent, len(a.Sessions))
+		for key, s := range a.Sessions {
+			run.session(a.Agent, key, s, ParentSession(a.Sessions, s), TranscriptPath(a.Agent, s))
 		}
-		
-		record := WorkRecord{
-			Timestamp:    time.UnixMilli(s.UpdatedAt),
-			SessionID:    s.SessionID,
-			AgentID:      s.AgentID,
-			Kind:         kind,
-			InputTokens:  s.InputTokens,
-			OutputTokens: s.OutputTokens,
-			TotalTokens:  s.TotalTokens,
+	}
+	return run.finish()
+}
+
+// syncRun 一次同步的状态: 游标、本次增量与新增价值 (会话同步与归档导入共用)
+type syncRun struct {
+	dataDir     string
+	valuator    worktracker.Valuator
+	transcripts bool
+	cursor      *SyncCursor
+
+	deltas     []DeltaEntry
+	totalValue float64
+	skipped    int
+	filtered   int // 被会话过滤规则跳过的会话，见 filter.go
+	saveErr    error
+}
+
+// session 记录一个会话自上次同步以来的工作，parent 为子 Agent 会话的父会话 ID，transcript 为其会话记录文件路径
+func (run *syncRun) session(agent, key string, s Session, parent, transcript string) {
+	kind := SessionKind(key, s)
+
+	if !SessionRules.Match(agent, kind, s) {
+		run.filtered++
+		return
+	}
+
+	sessionID := s.SessionID
+	if sessionID == "" {
+		sessionID = key
+	}
+	id := cursorKey(agent, sessionID)
+	prev, hadPrev := run.cursor.Sessions[id]
+	delta, ok := run.cursor.Advance(id, s)
+	if !ok {
+		run.skipped++
+		return
+	}
+
+	base := WorkRecord{
+		Timestamp:    time.UnixMilli(s.UpdatedAt),
+		SessionID:    s.SessionID,
+		AgentID:      s.AgentID,
+		Agent:        agent,
+		Kind:         kind,
+		Model:        s.Model,
+		InputTokens:  delta.InputTokens,
+		OutputTokens: delta.OutputTokens,
+		TotalTokens:  delta.TotalTokens,
+		CacheTokens:  delta.CacheTokens,
+		Delta:        true,
+
+		ParentSession: parent,
+	}
+	if base.AgentID == "" {
+		base.AgentID = agent
+	}
+
+	records := []WorkRecord{run.prepare(base)}
+	if run.transcripts {
+		// 未按消息同步过的会话从上次同步时间之后开始，之前的用量已计入会话增量记录
+		since := prev.TranscriptAt
+		if since == 0 {
+			since = prev.UpdatedAt
+		}
+		messages, err := ReadTranscript(transcript, time.UnixMilli(since))
+		if err != nil && !os.IsNotExist(err) {
+			fmt.Printf("⚠️ %v\n", err)
+		}
+		if err == nil {
+			records = messageRecords(records[0], messages)
 		}
-		record.Value = CalculateValue(record)
-		
-		SaveRecord(dataDir+"/records", record)
-		totalValue += record.Value
 	}
 
-	fmt.Printf("总价值: %.2f OAW\n", totalValue)
-	return nil
+	if run.save(id, records) == 0 && len(records) > 0 {
+		// 游标退回，下次同步重新记录
+		if hadPrev {
+			run.cursor.Sessions[id] = prev
+		} else {
+			delete(run.cursor.Sessions, id)
+		}
+	}
+}
+
+// prepare 补全记录的钱包与价值策略
+func (run *syncRun) prepare(r WorkRecord) WorkRecord {
+	r.Wallet = WalletFor(r.AgentID, r.Agent)
+	if run.valuator != nil && run.valuator.String() != worktracker.DefaultValuator.String() {
+		r.Valuator = run.valuator.String()
+	}
+	return r
+}
+
+// save 写入会话 id 的记录，返回成功写入的条数 (失败时停止并保留错误)
+func (run *syncRun) save(id string, records []WorkRecord) int {
+	saved := 0
+	for _, record := range records {
+		_, d, err := UpsertRecord(run.dataDir+"/records", id, record)
+		if err != nil {
+			run.saveErr = fmt.Errorf("保存会话 %s 的记录失败: %w", id, err)
+			break
+		}
+		if record.MessageID != "" {
+			run.cursor.markTranscript(id, record.Timestamp)
+		}
+		run.deltas = append(run.deltas, d)
+		saved++
+		run.totalValue += d.Value
+	}
+	return saved
+}
+
+// finish 写入增量日志并保存游标
+func (run *syncRun) finish() error {
+	if err := AppendDeltaLog(run.dataDir, run.deltas); err != nil {
+		fmt.Printf("⚠️ 写入增量日志失败: %v\n", err)
+	}
+	if err := run.cursor.Save(run.dataDir); err != nil {
+		return fmt.Errorf("保存同步游标失败: %w", err)
+	}
+	if run.skipped > 0 {
+		fmt.Printf("跳过 %d 条无新增的会话\n", run.skipped)
+	}
+	if run.filtered > 0 {
+		fmt.Printf("按会话过滤规则跳过 %d 条会话\n", run.filtered)
+	}
+	fmt.Printf("新增价值: %.2f OAW\n", run.totalValue)
+	return run.saveErr
+}
+
+// messageRecords 由会话记录中的消息生成记录 (每条消息一条，token 为该消息的用量)
+func messageRecords(base WorkRecord, messages []TranscriptMessage) []WorkRecord {
+	records := make([]WorkRecord, 0, len(messages))
+	for _, m := range messages {
+		r := base
+		r.Timestamp = m.Timestamp
+		r.MessageID = m.ID
+		if r.MessageID == "" {
+			r.MessageID = strconv.FormatInt(m.Timestamp.UnixMilli(), 10)
+		}
+		r.Tools = m.Tools
+		if m.Model != "" {
+			r.Model = m.Model
+		}
+		r.InputTokens = m.InputTokens
+		r.OutputTokens = m.OutputTokens
+		r.CacheTokens = m.CacheTokens
+		r.TotalTokens = m.TotalTokens
+		records = append(records, r)
+	}
+	return records
 }
 
 // GetTotalStats 获取总统计数据
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxOrphanBlocks 孤块池容量
+const MaxOrphanBlocks = 100
+
+// ErrOrphanBlock 父区块尚未到达，区块已暂存到孤块池
+var ErrOrphanBlock = errors.New("孤块: 父区块尚未到达")
+
+// addOrphan 按父区块哈希暂存孤块 (调用方需持有锁)
+func (m *Miner) addOrphan(b Block) {
+	if m.orphans == nil {
+		m.orphans = map[string][]Block{}
+	}
+	for _, o := range m.orphans[b.Previous] {
+		if o.Hash == b.Hash {
+			return
+		}
+	}
+
+	// 池满时淘汰高度最大的孤块 (离连接最远)
+	if m.orphanCount() >= MaxOrphanBlocks {
+		m.evictOrphan()
+	}
+	m.orphans[b.Previous] = append(m.orphans[b.Previous], b)
+}
+
+// connectOrphans 父区块上链后，依次连接等待它的孤块 (调用方需持有锁)
+func (m *Miner) connectOrphans(parent string) {
+	for parent != "" {
+		children := m.orphans[parent]
+		delete(m.orphans, parent)
+
+		next := ""
+		for _, c := range children {
+			if err := m.appendPeerBlock(c); err != nil {
+				fmt.Printf("  ⚠️ 孤块 #%d 连接失败: %v\n", c.Index, err)
+				continue
+			}
+			fmt.Printf("  🔗 孤块 #%d 已连接\n", c.Index)
+			next = c.Hash
+			break // 同一父区块只能接一个子区块
+		}
+		parent = next
+	}
+}
+
+func (m *Miner) orphanCount() int {
+	n := 0
+	for _, bs := range m.orphans {
+		n += len(bs)
+	}
+	return n
+}
+
+func (m *Miner) evictOrphan() {
+	var parent string
+	idx, height := -1, -1
+	for p, bs := range m.orphans {
+		for i, b := range bs {
+			if b.Index > height {
+				parent, idx, height = p, i, b.Index
+			}
+		}
+	}
+	if idx < 0 {
+		return
+	}
+	bs := m.orphans[parent]
+	m.orphans[parent] = append(bs[:idx], bs[idx+1:]...)
+	if len(m.orphans[parent]) == 0 {
+		delete(m.orphans, parent)
+	}
+}
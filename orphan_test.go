@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOrphanResolution(t *testing.T) {
+	s := newTestSigner(t)
+	all := testChain(t, s, 1_700_000_000, 10, 6)
+	base, b3, b4, b5 := all[:3], all[3], all[4], all[5]
+
+	badSig := b4
+	badSig.Signature = b5.Signature
+
+	tests := []struct {
+		name       string
+		deliver    []Block
+		wantHeight int
+		wantPooled int
+	}{
+		{"按顺序到达", []Block{b3, b4, b5}, 6, 0},
+		{"逆序到达后依次连接", []Block{b5, b4, b3}, 6, 0},
+		{"父区块未到达时保留在孤块池", []Block{b5, b4}, 3, 2},
+		{"重复的孤块只暂存一次", []Block{b5, b5}, 3, 1},
+		{"签名无效的孤块连接失败，其后代不连接", []Block{b5, badSig, b3}, 4, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Miner{blocks: append([]Block(nil), base...), dataDir: t.TempDir()}
+			for _, b := range tt.deliver {
+				if err := m.AcceptBlock(b); err != nil && !errors.Is(err, ErrOrphanBlock) {
+					t.Fatalf("区块 #%d: %v", b.Index, err)
+				}
+			}
+			if len(m.blocks) != tt.wantHeight || m.orphanCount() != tt.wantPooled {
+				t.Fatalf("链高度 %d、孤块 %d，期望 %d 与 %d", len(m.blocks), m.orphanCount(), tt.wantHeight, tt.wantPooled)
+			}
+			for i, b := range m.blocks {
+				if b.Hash != all[i].Hash {
+					t.Fatalf("区块 #%d 哈希与原链不符", i)
+				}
+			}
+		})
+	}
+}
+
+func TestOrphanPoolRejectsInvalidWork(t *testing.T) {
+	s := newTestSigner(t)
+	all := testChain(t, s, 1_700_000_000, 10, 5)
+	m := &Miner{blocks: append([]Block(nil), all[:3]...), dataDir: t.TempDir()}
+
+	forged := all[4]
+	forged.Value = MaxBlockValue
+	if err := SignBlock(&forged, s.Private); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AcceptBlock(forged); err == nil || errors.Is(err, ErrOrphanBlock) {
+		t.Fatalf("工作量无效的孤块应被拒绝: %v", err)
+	}
+	if m.orphanCount() != 0 {
+		t.Fatalf("孤块池 %d 个区块，期望 0", m.orphanCount())
+	}
+}
+
+func TestOrphanPoolEvictsFarthest(t *testing.T) {
+	m := &Miner{}
+	for i := 0; i < MaxOrphanBlocks+1; i++ {
+		m.addOrphan(Block{Index: 10 + i, Previous: "parent", Hash: string(rune('a'+i%26)) + string(rune('0'+i/26))})
+	}
+	if n := m.orphanCount(); n != MaxOrphanBlocks {
+		t.Fatalf("孤块池 %d 个区块，期望 %d", n, MaxOrphanBlocks)
+	}
+	for _, b := range m.orphans["parent"] {
+		if b.Index == 10+MaxOrphanBlocks-1 {
+			t.Fatal("池满时应淘汰高度最大的孤块")
+		}
+	}
+}
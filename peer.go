@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AcceptBlock 校验并接收对等节点广播的区块；
+// 父区块未到达的区块暂存到孤块池，返回 ErrOrphanBlock
+func (m *Miner) AcceptBlock(b Block) error {
+	// 孤块同样先校验工作量，避免无需计算的区块占满孤块池
+	if err := validatePeerBlockWork(b); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if b.Index > len(m.blocks) {
+		m.addOrphan(b)
+		return ErrOrphanBlock
+	}
+	if err := m.appendPeerBlock(b); err != nil {
+		return err
+	}
+	m.connectOrphans(b.Hash)
+	return nil
+}
+
+// appendPeerBlock 校验并追加区块到链尾 (调用方需持有锁)
+func (m *Miner) appendPeerBlock(b Block) error {
+	if b.Index != len(m.blocks) {
+		return fmt.Errorf("区块高度不连续: 期望 %d, 收到 %d", len(m.blocks), b.Index)
+	}
+	if b.Previous != m.tipHash() {
+		return fmt.Errorf("区块 #%d 前序哈希不匹配", b.Index)
+	}
+	if err := validateBlockVersion(b); err != nil {
+		return err
+	}
+	if err := validatePeerBlockWork(b); err != nil {
+		return err
+	}
+	if err := validateBlockDifficulty(b, m.blocks); err != nil {
+		return err
+	}
+	if err := validateStakeBlock(b, m.blocks); err != nil {
+		return err
+	}
+	if b.Value < 0 || b.Value > MaxBlockValue {
+		return fmt.Errorf("区块 #%d 奖励超出范围: %.4f", b.Index, b.Value)
+	}
+	if err := validateTimestamp(b, m.blocks, time.Now()); err != nil {
+		return err
+	}
+	if err := VerifyBlockSignature(b); err != nil {
+		return err
+	}
+	if err := m.validateBlockTxs(b); err != nil {
+		return err
+	}
+
+	m.blocks = append(m.blocks, b)
+	m.saveBlocks()
+
+	if len(b.Transactions) > 0 {
+		if state, err := LoadChainState(m.dataDir); err == nil {
+			state.ApplyRemote(b.Transactions)
+		}
+	}
+	m.fireBlockHooks(BlockEventReceived, b)
+	return nil
+}
+
+// validatePeerBlockWork 对等区块须为哈希可由区块头重算的版本，且满足工作量校验
+func validatePeerBlockWork(b Block) error {
+	if v := b.effectiveVersion(); v < PoWHashVersion {
+		return fmt.Errorf("区块 #%d 版本 %d 的哈希无法由区块头重算，拒绝接收", b.Index, v)
+	}
+	return validateBlockWork(b, true)
+}
+
+// tipHash 最新区块哈希 (调用方需持有锁)
+func (m *Miner) tipHash() string {
+	if len(m.blocks) == 0 {
+		return ""
+	}
+	return m.blocks[len(m.blocks)-1].Hash
+}
+
+// broadcastBlock 将新区块 POST 到配置中的所有对等节点
+func (m *Miner) broadcastBlock(b Block) {
+	cfg, err := LoadConfig(m.dataDir)
+	if err != nil || len(cfg.Peers) == 0 {
+		return
+	}
+
+	data, _ := json.Marshal(b)
+	client := &http.Client{Timeout: 5 * time.Second}
+	for _, peer := range cfg.Peers {
+		url := strings.TrimRight(peer, "/") + "/api/chain/blocks"
+		resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+		if err != nil {
+			fmt.Printf("  ⚠️ 广播到 %s 失败: %v\n", peer, err)
+			continue
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+			fmt.Printf("  ⚠️ %s 拒绝区块 #%d: %s\n", peer, b.Index, strings.TrimSpace(string(body)))
+		}
+	}
+}
+
+// ServeChain 启动区块接收服务 (/api/chain/blocks)
+func (m *Miner) ServeChain(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/chain/blocks", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			m.mu.Lock()
+			blocks := make([]Block, len(m.blocks))
+			copy(blocks, m.blocks)
+			m.mu.Unlock()
+			json.NewEncoder(w).Encode(blocks)
+		case http.MethodPost:
+			var b Block
+			if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			err := m.AcceptBlock(b)
+			if errors.Is(err, ErrOrphanBlock) {
+				fmt.Printf("  🧩 暂存孤块 #%d (等待父区块 %.16s)\n", b.Index, b.Previous)
+				w.WriteHeader(http.StatusAccepted)
+				json.NewEncoder(w).Encode(map[string]bool{"orphan": true})
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			fmt.Printf("  📥 接收对等区块 #%d (矿工: %s)\n", b.Index, b.Miner)
+			json.NewEncoder(w).Encode(map[string]bool{"accepted": true})
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	return http.ListenAndServe(addr, mux)
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ============ 工作记录合约 ABI ============
+
+// 工作记录经合约的 submitWork(agent, proofHash, tokens, value) 提交，合约记录后发出 WorkRecorded 事件，
+// 发放奖励时发出 RewardPaid 事件。
+// ABI 默认使用 DefaultWorkRecordABI，部署的合约不同时由 config.json 的 pole_abi 指定 ABI JSON 文件
+// (solc/Hardhat 输出的数组，或含 "abi" 字段的构建产物)，方法与事件按名称查找，参数类型需一致
+
+// DefaultWorkRecordABI 默认的工作记录合约 ABI
+const DefaultWorkRecordABI = `[
+  {"type":"function","name":"submitWork","stateMutability":"nonpayable",
+   "inputs":[{"name":"agent","type":"address"},{"name":"proofHash","type":"bytes32"},
+             {"name":"tokens","type":"uint256"},{"name":"value","type":"uint256"}],
+   "outputs":[{"name":"recordId","type":"uint256"}]},
+  {"type":"event","name":"WorkRecorded","anonymous":false,
+   "inputs":[{"name":"recordId","type":"uint256","indexed":true},{"name":"agent","type":"address","indexed":true},
+             {"name":"proofHash","type":"bytes32","indexed":false},{"name":"tokens","type":"uint256","indexed":false},
+             {"name":"value","type":"uint256","indexed":false}]},
+  {"type":"event","name":"RewardPaid","anonymous":false,
+   "inputs":[{"name":"agent","type":"address","indexed":true},{"name":"amount","type":"uint256","indexed":false}]}
+]`
+
+// 合约方法与事件名称
+const (
+	SubmitWorkMethod  = "submitWork"
+	WorkRecordedEvent = "WorkRecorded"
+	RewardPaidEvent   = "RewardPaid"
+)
+
+// WorkValueDecimals 链上工作价值的小数位数 (value 参数为价值 × 10^18)
+const WorkValueDecimals = 18
+
+// workRecordABI 当前使用的合约 ABI
+var workRecordABI = mustParseABI(DefaultWorkRecordABI)
+
+// submitWorkTypes submitWork 参数的类型
+var submitWorkTypes = []string{"address", "bytes32", "uint256", "uint256"}
+
+func mustParseABI(data string) abi.ABI {
+	a, err := abi.JSON(strings.NewReader(data))
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// LoadWorkRecordABI 从 ABI JSON 文件加载合约 ABI (相对路径基于数据目录)，路径为空时恢复默认 ABI
+func LoadWorkRecordABI(path string) error {
+	if path == "" {
+		workRecordABI = mustParseABI(DefaultWorkRecordABI)
+		return nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dataDir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	a, err := ParseWorkRecordABI(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	workRecordABI = a
+	return nil
+}
+
+// ParseWorkRecordABI 解析并校验合约 ABI: 需有 submitWork(address,bytes32,uint256,uint256) 方法
+func ParseWorkRecordABI(data []byte) (abi.ABI, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		// 构建产物 ({"abi": [...], "bytecode": ...})
+		var artifact struct {
+			ABI json.RawMessage `json:"abi"`
+		}
+		if err := json.Unmarshal(data, &artifact); err != nil {
+			return abi.ABI{}, fmt.Errorf("ABI 格式错误: %w", err)
+		}
+		if len(artifact.ABI) == 0 {
+			return abi.ABI{}, fmt.Errorf("ABI 文件缺少 abi 字段")
+		}
+		data = artifact.ABI
+	}
+	a, err := abi.JSON(bytes.NewReader(data))
+	if err != nil {
+		return abi.ABI{}, fmt.Errorf("ABI 格式错误: %w", err)
+	}
+	method, ok := a.Methods[SubmitWorkMethod]
+	if !ok {
+		return abi.ABI{}, fmt.Errorf("ABI 缺少 %s 方法", SubmitWorkMethod)
+	}
+	if len(method.Inputs) != len(submitWorkTypes) {
+		return abi.ABI{}, fmt.Errorf("%s 需要 %d 个参数 (%s)", SubmitWorkMethod, len(submitWorkTypes), strings.Join(submitWorkTypes, ", "))
+	}
+	for i, arg := range method.Inputs {
+		if arg.Type.String() != submitWorkTypes[i] {
+			return abi.ABI{}, fmt.Errorf("%s 第 %d 个参数应为 %s，实际为 %s", SubmitWorkMethod, i+1, submitWorkTypes[i], arg.Type)
+		}
+	}
+	return a, nil
+}
+
+// SubmitWorkCall submitWork 调用的参数
+type SubmitWorkCall struct {
+	Agent     common.Address
+	ProofHash common.Hash
+	Tokens    *big.Int
+	Value     *big.Int // 价值 × 10^WorkValueDecimals
+}
+
+// EncodeSubmitWork 编码 submitWork 调用数据 (方法选择器 + 参数)
+func EncodeSubmitWork(call SubmitWorkCall) ([]byte, error) {
+	tokens, value := call.Tokens, call.Value
+	if tokens == nil {
+		tokens = new(big.Int)
+	}
+	if value == nil {
+		value = new(big.Int)
+	}
+	return workRecordABI.Pack(SubmitWorkMethod, call.Agent, [32]byte(call.ProofHash), tokens, value)
+}
+
+// DecodeSubmitWork 解码 submitWork 调用数据
+func DecodeSubmitWork(data []byte) (*SubmitWorkCall, error) {
+	method := workRecordABI.Methods[SubmitWorkMethod]
+	if len(data) < 4 || !bytes.Equal(data[:4], method.ID) {
+		return nil, fmt.Errorf("不是 %s 调用", SubmitWorkMethod)
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, fmt.Errorf("解码 %s 参数失败: %w", SubmitWorkMethod, err)
+	}
+	return &SubmitWorkCall{
+		Agent:     args[0].(common.Address),
+		ProofHash: common.Hash(args[1].([32]byte)),
+		Tokens:    args[2].(*big.Int),
+		Value:     args[3].(*big.Int),
+	}, nil
+}
+
+// DecodeSubmitWorkResult 解码 submitWork 的返回值 (记录 ID)，ABI 未声明返回值时为 nil
+func DecodeSubmitWorkResult(ret []byte) (*big.Int, error) {
+	out, err := workRecordABI.Unpack(SubmitWorkMethod, ret)
+	if err != nil {
+		return nil, fmt.Errorf("解码 %s 返回值失败: %w", SubmitWorkMethod, err)
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	id, ok := out[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("%s 的返回值不是整数", SubmitWorkMethod)
+	}
+	return id, nil
+}
+
+// WorkRecorded 合约的 WorkRecorded 事件 (ABI 中没有的字段为零值)
+type WorkRecorded struct {
+	RecordID  *big.Int
+	Agent     common.Address
+	ProofHash common.Hash
+	Tokens    *big.Int
+	Value     *big.Int
+}
+
+// WorkRecordedTopic WorkRecorded 事件的签名哈希 (日志的第一个 topic)，ABI 中没有该事件时为零值
+func WorkRecordedTopic() common.Hash {
+	return workRecordABI.Events[WorkRecordedEvent].ID
+}
+
+// DecodeWorkRecorded 解码 WorkRecorded 事件日志 (topics 与 data)，字段按名称 recordId/agent/proofHash/tokens/value 取得
+func DecodeWorkRecorded(topics []common.Hash, data []byte) (*WorkRecorded, error) {
+	event, ok := workRecordABI.Events[WorkRecordedEvent]
+	if !ok {
+		return nil, fmt.Errorf("ABI 缺少 %s 事件", WorkRecordedEvent)
+	}
+	if len(topics) == 0 || topics[0] != event.ID {
+		return nil, fmt.Errorf("不是 %s 事件", WorkRecordedEvent)
+	}
+	fields, err := eventFields(event, topics, data)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &WorkRecorded{}
+	rec.RecordID, _ = fields["recordId"].(*big.Int)
+	rec.Agent, _ = fields["agent"].(common.Address)
+	if h, ok := fields["proofHash"].([32]byte); ok {
+		rec.ProofHash = h
+	}
+	rec.Tokens, _ = fields["tokens"].(*big.Int)
+	rec.Value, _ = fields["value"].(*big.Int)
+	return rec, nil
+}
+
+// RewardPaid 合约的 RewardPaid 事件
+type RewardPaid struct {
+	Agent  common.Address
+	Amount *big.Int
+}
+
+// RewardPaidTopic RewardPaid 事件的签名哈希，ABI 中没有该事件时为零值
+func RewardPaidTopic() common.Hash {
+	return workRecordABI.Events[RewardPaidEvent].ID
+}
+
+// DecodeRewardPaid 解码 RewardPaid 事件日志，字段按名称 agent/amount 取得
+func DecodeRewardPaid(topics []common.Hash, data []byte) (*RewardPaid, error) {
+	event, ok := workRecordABI.Events[RewardPaidEvent]
+	if !ok {
+		return nil, fmt.Errorf("ABI 缺少 %s 事件", RewardPaidEvent)
+	}
+	if len(topics) == 0 || topics[0] != event.ID {
+		return nil, fmt.Errorf("不是 %s 事件", RewardPaidEvent)
+	}
+	fields, err := eventFields(event, topics, data)
+	if err != nil {
+		return nil, err
+	}
+	rec := &RewardPaid{}
+	rec.Agent, _ = fields["agent"].(common.Address)
+	rec.Amount, _ = fields["amount"].(*big.Int)
+	return rec, nil
+}
+
+// eventFields 按名称取得事件日志的字段 (非索引字段来自 data，索引字段来自 topics)
+func eventFields(event abi.Event, topics []common.Hash, data []byte) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if len(data) > 0 {
+		if err := event.Inputs.UnpackIntoMap(fields, data); err != nil {
+			return nil, fmt.Errorf("解码 %s 事件失败: %w", event.Name, err)
+		}
+	}
+	var indexed abi.Arguments
+	for _, arg := range event.Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	if err := abi.ParseTopicsIntoMap(fields, indexed, topics[1:]); err != nil {
+		return nil, fmt.Errorf("解码 %s 事件失败: %w", event.Name, err)
+	}
+	return fields, nil
+}
+
+// ValueUnits 价值转换为链上整数 (× 10^WorkValueDecimals)
+func ValueUnits(value float64) *big.Int {
+	units, _ := parseUnits(strconv.FormatFloat(value, 'f', -1, 64), WorkValueDecimals)
+	return units
+}
+
+// parseUnits 将十进制小数转换为 decimals 位定点整数 (多余的小数位截断)
+func parseUnits(s string, decimals int) (*big.Int, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	whole, frac, _ := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+	if len(frac) > decimals {
+		frac = frac[:decimals]
+	}
+	digits := whole + frac + strings.Repeat("0", decimals-len(frac))
+	n, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return new(big.Int), fmt.Errorf("无效的数值: %q", s)
+	}
+	if neg {
+		n.Neg(n)
+	}
+	return n, nil
+}
+
+// formatUnits 将 decimals 位定点整数格式化为十进制小数 (去掉末尾的 0)
+func formatUnits(n *big.Int, decimals int) string {
+	if n == nil {
+		return "0"
+	}
+	s := new(big.Int).Abs(n).String()
+	if len(s) <= decimals {
+		s = strings.Repeat("0", decimals-len(s)+1) + s
+	}
+	whole, frac := s[:len(s)-decimals], strings.TrimRight(s[len(s)-decimals:], "0")
+	if n.Sign() < 0 {
+		whole = "-" + whole
+	}
+	if frac == "" {
+		return whole
+	}
+	return whole + "." + frac
+}
+
+// CreateWorkRecordTx 创建工作记录交易数据 (submitWork 调用)，proofHash 为 64 位十六进制工作证明
+func CreateWorkRecordTx(agent, proofHash string, tokens uint64, value float64) ([]byte, error) {
+	if !common.IsHexAddress(agent) {
+		return nil, fmt.Errorf("无效的 Agent 地址: %q", agent)
+	}
+	proof := strings.TrimPrefix(proofHash, "0x")
+	if _, err := hex.DecodeString(proof); err != nil || len(proof) != 64 {
+		return nil, fmt.Errorf("无效的工作证明: %q", proofHash)
+	}
+	return EncodeSubmitWork(SubmitWorkCall{
+		Agent:     common.HexToAddress(agent),
+		ProofHash: common.HexToHash(proof),
+		Tokens:    new(big.Int).SetUint64(tokens),
+		Value:     ValueUnits(value),
+	})
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// submitWork 的编码与 go-ethereum 按参数类型独立打包的结果一致，并可解码还原
+func TestEncodeSubmitWork(t *testing.T) {
+	call := SubmitWorkCall{
+		Agent:     common.HexToAddress("0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F"),
+		ProofHash: common.HexToHash("0x5f3c0b2a9d7e41c8a6b0f1e2d3c4b5a69788796a5b4c3d2e1f00112233445566"),
+		Tokens:    big.NewInt(123456),
+		Value:     ValueUnits(12.5),
+	}
+	data, err := EncodeSubmitWork(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	selector := crypto.Keccak256([]byte("submitWork(address,bytes32,uint256,uint256)"))[:4]
+	if !bytes.Equal(data[:4], selector) {
+		t.Fatalf("方法选择器 %x，期望 %x", data[:4], selector)
+	}
+	var args abi.Arguments
+	for _, typ := range submitWorkTypes {
+		ty, err := abi.NewType(typ, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		args = append(args, abi.Argument{Type: ty})
+	}
+	want, err := args.Pack(call.Agent, [32]byte(call.ProofHash), call.Tokens, call.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data[4:], want) {
+		t.Fatalf("参数编码 %x，期望 %x", data[4:], want)
+	}
+
+	decoded, err := DecodeSubmitWork(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Agent != call.Agent || decoded.ProofHash != call.ProofHash ||
+		decoded.Tokens.Cmp(call.Tokens) != 0 || decoded.Value.Cmp(call.Value) != 0 {
+		t.Fatalf("解码结果 %+v 与调用 %+v 不一致", decoded, call)
+	}
+	if want, _ := new(big.Int).SetString("12500000000000000000", 10); call.Value.Cmp(want) != 0 {
+		t.Fatalf("价值 12.5 的链上数值为 %s，期望 %s", call.Value, want)
+	}
+}
+
+// 按事件签名与 32 字节字编码手工构造的 WorkRecorded 日志
+func TestDecodeWorkRecorded(t *testing.T) {
+	agent := common.HexToAddress("0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F")
+	proof := common.HexToHash("0x5f3c0b2a9d7e41c8a6b0f1e2d3c4b5a69788796a5b4c3d2e1f00112233445566")
+	topic := crypto.Keccak256Hash([]byte("WorkRecorded(uint256,address,bytes32,uint256,uint256)"))
+	if WorkRecordedTopic() != topic {
+		t.Fatalf("事件 topic %s，期望 %s", WorkRecordedTopic().Hex(), topic.Hex())
+	}
+
+	topics := []common.Hash{topic, common.BigToHash(big.NewInt(42)), common.BytesToHash(agent.Bytes())}
+	var data []byte
+	data = append(data, proof.Bytes()...)
+	data = append(data, common.LeftPadBytes(big.NewInt(123456).Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(big.NewInt(7_000_000_000_000_000_000).Bytes(), 32)...)
+
+	ev, err := DecodeWorkRecorded(topics, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.RecordID.Int64() != 42 || ev.Agent != agent || ev.ProofHash != proof ||
+		ev.Tokens.Int64() != 123456 || formatUnits(ev.Value, WorkValueDecimals) != "7" {
+		t.Fatalf("解码结果不一致: %+v", ev)
+	}
+
+	if _, err := DecodeWorkRecorded([]common.Hash{RewardPaidTopic()}, data); err == nil {
+		t.Fatal("其他事件的日志应解码失败")
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ============ 链上余额 ============
+
+// 余额以最小单位 (如 wei) 的整数查询，按链的小数位数换算后显示。
+// 小数位数与单位由 config.json 的 pole_decimals 与 pole_symbol 设置
+
+// 默认的链上货币
+const (
+	DefaultPoleSymbol   = "POLE"
+	DefaultPoleDecimals = 18
+)
+
+// PoleSymbol 链上货币单位
+var PoleSymbol = DefaultPoleSymbol
+
+// PoleDecimals 链上货币的小数位数
+var PoleDecimals = DefaultPoleDecimals
+
+// SetPoleCurrency 设置链上货币的单位与小数位数 (symbol 为空或 decimals 为 nil 时不修改)
+func SetPoleCurrency(symbol string, decimals *int) error {
+	if decimals != nil {
+		if *decimals < 0 || *decimals > 36 {
+			return fmt.Errorf("小数位数应在 0-36 之间: %d", *decimals)
+		}
+		PoleDecimals = *decimals
+	}
+	if symbol != "" {
+		PoleSymbol = symbol
+	}
+	return nil
+}
+
+// Balance 地址的余额 (最小单位): eth_getBalance，节点不支持 JSON-RPC 时取 /account/balance
+func (p *PoleRPC) Balance(address string) (*big.Int, error) {
+	var result string
+	err := p.call("eth_getBalance", []interface{}{address, "latest"}, &result)
+	if errors.Is(err, errNoJSONRPC) {
+		// REST 接口返回按小数位数换算后的余额 (整币，如 "5" 或 "1.5")
+		if result, err = p.GetBalance(address); err != nil {
+			return nil, err
+		}
+		return parseUnits(result, PoleDecimals)
+	}
+	if err != nil {
+		return nil, err
+	}
+	balance, ok := parseQuantity(result)
+	if !ok {
+		return nil, fmt.Errorf("节点返回的余额无效: %q", result)
+	}
+	return balance, nil
+}
+
+// FormatAmount 最小单位的数额按链的小数位数显示，整数部分按千位分隔，如 1,234.5 POLE
+func FormatAmount(amount *big.Int) string {
+	s := formatUnits(amount, PoleDecimals)
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i:]
+	}
+	sign := ""
+	if strings.HasPrefix(whole, "-") {
+		sign, whole = "-", whole[1:]
+	}
+	var b strings.Builder
+	for i, c := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return sign + b.String() + frac + " " + PoleSymbol
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============ 多节点故障切换 ============
+
+// PoleRPC 的请求依次尝试 Nodes 中的节点: 连接错误、超时或网关错误 (429/502/503/504) 时切换到下一个节点，
+// 所有节点都失败时按指数退避重试 RPCRetries 轮 (Probe 返回的客户端不重试，用于检查节点是否可用)。最近成功的节点在之后的请求中优先使用；
+// 节点的健康状态 (连续失败次数、最近的错误与延迟) 在 oaw pole connect 中显示
+
+// PoleNodes 备用节点地址 (config.json 的 pole_nodes)
+var PoleNodes []string
+
+// 重试参数
+var (
+	RPCRetries     = 3                      // 所有节点都失败时的重试轮数
+	RPCTimeout     = 10 * time.Second       // 单个请求的超时
+	rpcBackoffBase = 500 * time.Millisecond // 第一次重试前的等待，之后每轮加倍
+	rpcBackoffMax  = 8 * time.Second
+	ProbeTimeout   = 3 * time.Second // 一次性探测的单个请求超时
+)
+
+// NodeHealth 节点的健康状态 (本进程内的请求统计)
+type NodeHealth struct {
+	URL         string
+	Requests    int
+	Failures    int // 连续失败次数
+	LastError   string
+	LastErrorAt time.Time
+	LastOK      time.Time
+	Latency     time.Duration // 最近一次成功请求的延迟
+}
+
+// Healthy 最近一次请求是否成功
+func (h NodeHealth) Healthy() bool {
+	return h.Failures == 0
+}
+
+var nodeHealth = struct {
+	sync.Mutex
+	nodes  map[string]*NodeHealth
+	active string // 最近成功的节点
+}{nodes: map[string]*NodeHealth{}}
+
+// nodeList 节点列表: primary 在前，去掉重复与空地址
+func nodeList(primary string, backups []string) []string {
+	var nodes []string
+	seen := map[string]bool{}
+	for _, u := range append([]string{primary}, backups...) {
+		u = strings.TrimRight(strings.TrimSpace(u), "/")
+		if u != "" && !seen[u] {
+			seen[u] = true
+			nodes = append(nodes, u)
+		}
+	}
+	return nodes
+}
+
+// nodes 本次请求尝试的节点顺序 (最近成功的节点在前)
+func (p *PoleRPC) nodes() []string {
+	nodes := p.Nodes
+	if len(nodes) == 0 {
+		nodes = []string{p.NodeURL}
+	}
+	nodeHealth.Lock()
+	active := nodeHealth.active
+	nodeHealth.Unlock()
+	for i, u := range nodes {
+		if u == active && i > 0 {
+			return append(append([]string{u}, nodes[:i]...), nodes[i+1:]...)
+		}
+	}
+	return nodes
+}
+
+// Active 当前优先使用的节点
+func (p *PoleRPC) Active() string {
+	return p.nodes()[0]
+}
+
+// Probe 用于一次性探测的客户端: 每个节点只请求一次，不退避重试，超时为 ProbeTimeout。
+// 检查节点是否运行或在循环中等待节点就绪时使用，避免节点不可用时每次探测都等待多轮重试
+func (p *PoleRPC) Probe() *PoleRPC {
+	probe := *p
+	probe.noRetry, probe.timeout = true, ProbeTimeout
+	return &probe
+}
+
+// send 发送 HTTP 请求并在节点间故障切换，返回响应状态码与内容
+func (p *PoleRPC) send(method, path string, body []byte) (int, []byte, error) {
+	nodes := p.nodes()
+	rounds := max(RPCRetries, 1)
+	if p.noRetry {
+		rounds = 1
+	}
+	var lastErr error
+	for round := 0; round < rounds; round++ {
+		if round > 0 {
+			time.Sleep(backoff(round))
+		}
+		for i, node := range nodes {
+			start := time.Now()
+			status, data, err := doHTTP(method, node+path, body, p.timeout)
+			if err == nil && !failoverStatus(status) {
+				markNode(node, time.Since(start), nil)
+				return status, data, nil
+			}
+			if err == nil {
+				err = fmt.Errorf("HTTP %d: %s", status, strings.TrimSpace(string(data)))
+			}
+			markNode(node, 0, err)
+			lastErr = err
+			if i+1 < len(nodes) {
+				fmt.Printf("⚠️ PoLE 节点 %s 不可用 (%v)，切换到 %s\n", node, err, nodes[i+1])
+			}
+		}
+	}
+	if len(nodes) > 1 {
+		return 0, nil, fmt.Errorf("所有 PoLE 节点均不可用 (重试 %d 轮): %w", rounds, lastErr)
+	}
+	return 0, nil, fmt.Errorf("请求失败: %w", lastErr)
+}
+
+// doHTTP 向单个节点发送请求 (timeout 为 0 时为 RPCTimeout)
+func doHTTP(method, url string, body []byte, timeout time.Duration) (int, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if timeout <= 0 {
+		timeout = RPCTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, data, nil
+}
+
+// failoverStatus 需要切换节点的响应状态 (限流与网关错误)
+func failoverStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff 第 round 轮重试前的等待
+func backoff(round int) time.Duration {
+	d := rpcBackoffBase << (round - 1)
+	if d > rpcBackoffMax || d <= 0 {
+		d = rpcBackoffMax
+	}
+	return d
+}
+
+// markNode 记录节点的请求结果
+func markNode(node string, latency time.Duration, err error) {
+	nodeHealth.Lock()
+	defer nodeHealth.Unlock()
+	h, ok := nodeHealth.nodes[node]
+	if !ok {
+		h = &NodeHealth{URL: node}
+		nodeHealth.nodes[node] = h
+	}
+	h.Requests++
+	if err != nil {
+		h.Failures++
+		h.LastError, h.LastErrorAt = err.Error(), time.Now()
+		return
+	}
+	h.Failures, h.LastOK, h.Latency = 0, time.Now(), latency
+	nodeHealth.active = node
+}
+
+// NodeHealths 各节点的健康状态 (按节点列表顺序，未请求过的节点只有地址)
+func (p *PoleRPC) NodeHealths() []NodeHealth {
+	nodeHealth.Lock()
+	defer nodeHealth.Unlock()
+	var list []NodeHealth
+	for _, u := range p.Nodes {
+		if h, ok := nodeHealth.nodes[u]; ok {
+			list = append(list, *h)
+		} else {
+			list = append(list, NodeHealth{URL: u})
+		}
+	}
+	return list
+}
+
+// NodeStatus oaw pole connect 对单个节点的检查结果
+type NodeStatus struct {
+	URL     string
+	ChainID string
+	Head    uint64
+	Latency time.Duration
+	Err     error
+}
+
+// CheckNodes 逐个检查节点 (不切换、不重试)，按延迟排序，可用的节点在前
+func (p *PoleRPC) CheckNodes() []NodeStatus {
+	var list []NodeStatus
+	for _, u := range p.Nodes {
+		single := &PoleRPC{NodeURL: u, Nodes: []string{u}, noRetry: true}
+		st := NodeStatus{URL: u}
+		start := time.Now()
+		st.Head, st.Err = single.HeadNumber()
+		st.Latency = time.Since(start)
+		if st.Err == nil {
+			if id, err := single.ChainID(); err == nil {
+				st.ChainID = id.String()
+			} else if name, err := single.GetChainID(); err == nil {
+				st.ChainID = name
+			}
+		}
+		list = append(list, st)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if (list[i].Err == nil) != (list[j].Err == nil) {
+			return list[i].Err == nil
+		}
+		return list[i].Err == nil && list[i].Latency < list[j].Latency
+	})
+	return list
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ============ gas 估算与费用策略 ============
+
+// 发送前以 eth_estimateGas 估算 gas 上限，以 eth_gasPrice 取得 gas 价格 (节点不支持时由 eth_feeHistory
+// 的下一区块基础费用加优先费中位数推算)，再按 config.json 的 pole_gas 调整: 估算值乘以倍数，超过上限时拒绝发送；
+// 价格乘以倍数，超过价格上限时按上限发送。节点不支持 JSON-RPC 时使用固有 gas 与 DefaultGasPrice。
+// 最新区块带基础费用 (节点已启用 London) 时改用 EIP-1559 交易: 优先费取 eth_maxPriorityFeePerGas
+// (节点不支持时为优先费中位数) 乘以价格倍数，最高价格为基础费用的 2 倍加优先费，超过价格上限时按上限
+
+// GasConfig 链上提交的 gas 策略 (config.json 的 pole_gas)
+type GasConfig struct {
+	Multiplier      float64 `json:"multiplier,omitempty"`         // gas 上限为估算值的倍数 (默认 1.2)
+	MaxGas          uint64  `json:"max_gas,omitempty"`            // gas 上限的最大值 (0 为不限)
+	PriceMultiplier float64 `json:"price_multiplier,omitempty"`   // gas 价格为节点建议值的倍数 (默认 1)
+	MaxGasPriceGwei float64 `json:"max_gas_price_gwei,omitempty"` // gas 价格上限 (gwei，0 为不限)
+	GasPriceGwei    float64 `json:"gas_price_gwei,omitempty"`     // 固定的 gas 价格 (gwei，设置后不查询节点，使用 legacy 交易)
+	Legacy          bool    `json:"legacy,omitempty"`             // 节点支持 EIP-1559 时仍使用 legacy 交易
+}
+
+// 默认的 gas 倍数
+const (
+	DefaultGasMultiplier      = 1.2
+	DefaultGasPriceMultiplier = 1.0
+)
+
+// PoleGas 当前的 gas 策略
+var PoleGas = GasConfig{}
+
+// feeHistoryBlocks 推算 gas 价格时参考的最近区块数
+const feeHistoryBlocks = 10
+
+// SetPoleGas 设置 gas 策略
+func SetPoleGas(cfg GasConfig) error {
+	if cfg.Multiplier < 0 || cfg.PriceMultiplier < 0 || cfg.MaxGasPriceGwei < 0 || cfg.GasPriceGwei < 0 {
+		return fmt.Errorf("gas 倍数与价格不能为负数")
+	}
+	if cfg.Multiplier != 0 && cfg.Multiplier < 1 {
+		return fmt.Errorf("gas 上限倍数不能小于 1 (估算值是交易执行所需的最少 gas)")
+	}
+	PoleGas = cfg
+	return nil
+}
+
+// GasFees 交易的 gas 上限与价格
+type GasFees struct {
+	Gas       uint64
+	Estimated uint64   // 节点估算的 gas (未估算时为固有 gas)
+	GasPrice  *big.Int // gas 价格 (EIP-1559 交易为最高价格 GasFeeCap)
+	Suggested *big.Int // 节点建议的 gas 价格 (调整前；EIP-1559 交易为基础费用加建议的优先费)
+	Capped    bool     // gas 价格被上限截断
+
+	// EIP-1559 (Dynamic 为 false 时使用 legacy 交易)
+	Dynamic   bool
+	BaseFee   *big.Int // 最新区块的基础费用
+	GasTipCap *big.Int // maxPriorityFeePerGas
+	GasFeeCap *big.Int // maxFeePerGas
+}
+
+// NewTx 以 gas 上限与价格创建未签名的交易 (EIP-1559 或 legacy)
+func (f *GasFees) NewTx(nonce uint64, to *common.Address, value *big.Int, data []byte) *PoleTx {
+	if f.Dynamic {
+		return NewDynamicFeeTx(nonce, to, value, f.Gas, f.GasTipCap, f.GasFeeCap, data)
+	}
+	return NewPoleTx(nonce, to, value, f.Gas, f.GasPrice, data)
+}
+
+// Fees 按 gas 策略计算交易的 gas 上限与价格
+func (g GasConfig) Fees(rpc *PoleRPC, from common.Address, to *common.Address, value *big.Int, data []byte) (*GasFees, error) {
+	fees := &GasFees{}
+
+	estimated, err := rpc.EstimateGas(from, to, value, data)
+	switch {
+	case errors.Is(err, errNoJSONRPC):
+		estimated = IntrinsicGas(data, to == nil)
+	case err != nil:
+		return nil, fmt.Errorf("估算 gas 失败: %w", err)
+	}
+	fees.Estimated = estimated
+	fees.Gas = uint64(float64(estimated) * orDefault(g.Multiplier, DefaultGasMultiplier))
+	if g.MaxGas > 0 && fees.Gas > g.MaxGas {
+		if estimated > g.MaxGas {
+			return nil, fmt.Errorf("交易需要 %d gas，超过上限 %d (pole_gas.max_gas)", estimated, g.MaxGas)
+		}
+		fees.Gas = g.MaxGas
+	}
+
+	if g.GasPriceGwei > 0 {
+		fees.Suggested = gweiToWei(g.GasPriceGwei)
+		fees.GasPrice = new(big.Int).Set(fees.Suggested)
+		return fees, nil
+	}
+	if !g.Legacy {
+		baseFee, err := rpc.BaseFee()
+		if err != nil && !errors.Is(err, errNoJSONRPC) {
+			return nil, fmt.Errorf("查询基础费用失败: %w", err)
+		}
+		if baseFee != nil {
+			return g.dynamicFees(rpc, fees, baseFee)
+		}
+	}
+	suggested, err := rpc.SuggestGasPrice()
+	if errors.Is(err, errNoJSONRPC) {
+		suggested, err = new(big.Int).Set(DefaultGasPrice), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询 gas 价格失败: %w", err)
+	}
+	fees.Suggested = suggested
+	fees.GasPrice = mulBig(suggested, orDefault(g.PriceMultiplier, DefaultGasPriceMultiplier))
+	if capPrice := g.MaxGasPrice(); capPrice != nil && fees.GasPrice.Cmp(capPrice) > 0 {
+		fees.GasPrice, fees.Capped = capPrice, true
+	}
+	return fees, nil
+}
+
+// dynamicFees EIP-1559 交易的优先费与最高价格
+func (g GasConfig) dynamicFees(rpc *PoleRPC, fees *GasFees, baseFee *big.Int) (*GasFees, error) {
+	tip, err := rpc.SuggestGasTipCap()
+	if errors.Is(err, errNoJSONRPC) {
+		tip, err = new(big.Int).Set(DefaultGasTipCap), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询优先费失败: %w", err)
+	}
+	fees.Dynamic, fees.BaseFee = true, baseFee
+	fees.Suggested = new(big.Int).Add(baseFee, tip)
+	tip = mulBig(tip, orDefault(g.PriceMultiplier, DefaultGasPriceMultiplier))
+	feeCap := new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tip)
+	if capPrice := g.MaxGasPrice(); capPrice != nil && feeCap.Cmp(capPrice) > 0 {
+		feeCap, fees.Capped = capPrice, true
+		if tip.Cmp(feeCap) > 0 {
+			tip = new(big.Int).Set(feeCap)
+		}
+	}
+	fees.GasTipCap, fees.GasFeeCap, fees.GasPrice = tip, feeCap, feeCap
+	return fees, nil
+}
+
+// MaxGasPrice gas 价格上限 (wei)，未设置时为 nil
+func (g GasConfig) MaxGasPrice() *big.Int {
+	if g.MaxGasPriceGwei <= 0 {
+		return nil
+	}
+	return gweiToWei(g.MaxGasPriceGwei)
+}
+
+// EstimateGas eth_estimateGas 估算交易所需的 gas
+func (p *PoleRPC) EstimateGas(from common.Address, to *common.Address, value *big.Int, data []byte) (uint64, error) {
+	msg := map[string]interface{}{"from": from.Hex(), "data": hexutil.Encode(data)}
+	if to != nil {
+		msg["to"] = to.Hex()
+	}
+	if value != nil && value.Sign() > 0 {
+		msg["value"] = hexutil.EncodeBig(value)
+	}
+	var result string
+	if err := p.call("eth_estimateGas", []interface{}{msg}, &result); err != nil {
+		return 0, err
+	}
+	gas, ok := parseQuantity(result)
+	if !ok || !gas.IsUint64() {
+		return 0, fmt.Errorf("节点返回的 gas 估算无效: %q", result)
+	}
+	return gas.Uint64(), nil
+}
+
+// GasPrice eth_gasPrice 节点建议的 gas 价格
+func (p *PoleRPC) GasPrice() (*big.Int, error) {
+	var result string
+	if err := p.call("eth_gasPrice", nil, &result); err != nil {
+		return nil, err
+	}
+	price, ok := parseQuantity(result)
+	if !ok {
+		return nil, fmt.Errorf("节点返回的 gas 价格无效: %q", result)
+	}
+	return price, nil
+}
+
+// BaseFee 最新区块的基础费用，节点未启用 London (区块不带基础费用) 时为 nil
+func (p *PoleRPC) BaseFee() (*big.Int, error) {
+	var block struct {
+		BaseFeePerGas string `json:"baseFeePerGas"`
+	}
+	if err := p.call("eth_getBlockByNumber", []interface{}{"latest", false}, &block); err != nil {
+		return nil, err
+	}
+	if block.BaseFeePerGas == "" {
+		return nil, nil
+	}
+	fee, ok := parseQuantity(block.BaseFeePerGas)
+	if !ok {
+		return nil, fmt.Errorf("节点返回的基础费用无效: %q", block.BaseFeePerGas)
+	}
+	return fee, nil
+}
+
+// MaxPriorityFeePerGas eth_maxPriorityFeePerGas 节点建议的优先费
+func (p *PoleRPC) MaxPriorityFeePerGas() (*big.Int, error) {
+	var result string
+	if err := p.call("eth_maxPriorityFeePerGas", nil, &result); err != nil {
+		return nil, err
+	}
+	tip, ok := parseQuantity(result)
+	if !ok {
+		return nil, fmt.Errorf("节点返回的优先费无效: %q", result)
+	}
+	return tip, nil
+}
+
+// SuggestGasTipCap 建议的优先费: eth_maxPriorityFeePerGas，节点不支持时为最近区块优先费的中位数
+func (p *PoleRPC) SuggestGasTipCap() (*big.Int, error) {
+	tip, err := p.MaxPriorityFeePerGas()
+	if !errors.Is(err, errNoJSONRPC) {
+		return tip, err
+	}
+	h, err := p.FeeHistory(feeHistoryBlocks, []float64{50})
+	if err != nil {
+		return nil, err
+	}
+	if len(h.Rewards) == 0 {
+		return nil, errNoJSONRPC
+	}
+	return h.MedianReward(), nil
+}
+
+// FeeHistory eth_feeHistory 的结果
+type FeeHistory struct {
+	OldestBlock  *big.Int
+	BaseFees     []*big.Int   // 各区块的基础费用 (最后一个为下一区块)
+	Rewards      [][]*big.Int // 各区块按请求百分位的优先费
+	GasUsedRatio []float64
+}
+
+// FeeHistory eth_feeHistory 最近 blocks 个区块的费用 (percentiles 为优先费百分位)
+func (p *PoleRPC) FeeHistory(blocks int, percentiles []float64) (*FeeHistory, error) {
+	var result struct {
+		OldestBlock   string     `json:"oldestBlock"`
+		BaseFeePerGas []string   `json:"baseFeePerGas"`
+		Reward        [][]string `json:"reward"`
+		GasUsedRatio  []float64  `json:"gasUsedRatio"`
+	}
+	if percentiles == nil {
+		percentiles = []float64{}
+	}
+	if err := p.call("eth_feeHistory", []interface{}{hexutil.EncodeUint64(uint64(blocks)), "latest", percentiles}, &result); err != nil {
+		return nil, err
+	}
+	h := &FeeHistory{GasUsedRatio: result.GasUsedRatio}
+	h.OldestBlock, _ = parseQuantity(result.OldestBlock)
+	for _, s := range result.BaseFeePerGas {
+		fee, ok := parseQuantity(s)
+		if !ok {
+			return nil, fmt.Errorf("节点返回的基础费用无效: %q", s)
+		}
+		h.BaseFees = append(h.BaseFees, fee)
+	}
+	for _, row := range result.Reward {
+		var rewards []*big.Int
+		for _, s := range row {
+			reward, ok := parseQuantity(s)
+			if !ok {
+				return nil, fmt.Errorf("节点返回的优先费无效: %q", s)
+			}
+			rewards = append(rewards, reward)
+		}
+		h.Rewards = append(h.Rewards, rewards)
+	}
+	return h, nil
+}
+
+// SuggestGasPrice 建议的 gas 价格: eth_gasPrice，节点不支持时为下一区块基础费用加最近区块优先费的中位数
+func (p *PoleRPC) SuggestGasPrice() (*big.Int, error) {
+	price, err := p.GasPrice()
+	if !errors.Is(err, errNoJSONRPC) {
+		return price, err
+	}
+	h, err := p.FeeHistory(feeHistoryBlocks, []float64{50})
+	if err != nil {
+		return nil, err
+	}
+	if len(h.BaseFees) == 0 {
+		return nil, errNoJSONRPC
+	}
+	return new(big.Int).Add(h.BaseFees[len(h.BaseFees)-1], h.MedianReward()), nil
+}
+
+// MedianReward 各区块优先费 (第一个百分位) 的中位数，没有数据时为 0
+func (h *FeeHistory) MedianReward() *big.Int {
+	var rewards []*big.Int
+	for _, row := range h.Rewards {
+		if len(row) > 0 {
+			rewards = append(rewards, row[0])
+		}
+	}
+	if len(rewards) == 0 {
+		return new(big.Int)
+	}
+	sort.Slice(rewards, func(i, j int) bool { return rewards[i].Cmp(rewards[j]) < 0 })
+	return new(big.Int).Set(rewards[len(rewards)/2])
+}
+
+// printPoleGas 以一条示例工作记录 (submitWork 调用) 显示当前策略下的 gas 上限、价格与最高费用
+func printPoleGas(rpc *PoleRPC, address string) error {
+	data, err := CreateWorkRecordTx(address, strings.Repeat("ab", 32), 10000, 1)
+	if err != nil {
+		return err
+	}
+	to := common.HexToAddress(poleContractAddress)
+	fees, err := PoleGas.Fees(rpc, common.HexToAddress(address), &to, nil, data)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("=== PoLE gas ===")
+	fmt.Printf("gas 上限: %d (估算 %d × %.2f", fees.Gas, fees.Estimated, orDefault(PoleGas.Multiplier, DefaultGasMultiplier))
+	if PoleGas.MaxGas > 0 {
+		fmt.Printf("，上限 %d", PoleGas.MaxGas)
+	}
+	fmt.Println(")")
+	switch {
+	case fees.Dynamic:
+		fmt.Printf("交易类型: EIP-1559 (基础费用 %s)\n", formatGwei(fees.BaseFee))
+		fmt.Printf("优先费: %s (× %.2f)\n", formatGwei(fees.GasTipCap), orDefault(PoleGas.PriceMultiplier, DefaultGasPriceMultiplier))
+		fmt.Printf("最高价格: %s (基础费用 × 2 + 优先费", formatGwei(fees.GasFeeCap))
+		if capPrice := PoleGas.MaxGasPrice(); capPrice != nil {
+			fmt.Printf("，上限 %s", formatGwei(capPrice))
+		}
+		fmt.Println(")")
+	case PoleGas.GasPriceGwei > 0:
+		fmt.Printf("gas 价格: %s (固定)\n", formatGwei(fees.GasPrice))
+	default:
+		fmt.Printf("gas 价格: %s (节点建议 %s × %.2f", formatGwei(fees.GasPrice), formatGwei(fees.Suggested), orDefault(PoleGas.PriceMultiplier, DefaultGasPriceMultiplier))
+		if capPrice := PoleGas.MaxGasPrice(); capPrice != nil {
+			fmt.Printf("，上限 %s", formatGwei(capPrice))
+		}
+		fmt.Println(")")
+	}
+	cost := new(big.Int).Mul(fees.GasPrice, new(big.Int).SetUint64(fees.Gas))
+	fmt.Printf("单条记录最高费用: %s\n", formatGwei(cost))
+	return nil
+}
+
+// gweiToWei gwei 转换为 wei
+func gweiToWei(gwei float64) *big.Int {
+	wei, _ := parseUnits(strconv.FormatFloat(gwei, 'f', -1, 64), 9)
+	return wei
+}
+
+// formatGwei wei 以 gwei 显示
+func formatGwei(wei *big.Int) string {
+	if wei == nil {
+		return "-"
+	}
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e9)).Float64()
+	return strconv.FormatFloat(f, 'f', -1, 64) + " gwei"
+}
+
+// mulBig 整数乘以倍数 (向下取整)
+func mulBig(x *big.Int, f float64) *big.Int {
+	if f == 1 {
+		return new(big.Int).Set(x)
+	}
+	r, _ := new(big.Float).Mul(new(big.Float).SetInt(x), big.NewFloat(f)).Int(nil)
+	return r
+}
+
+func orDefault(v, def float64) float64 {
+	if v == 0 {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// newGasNode 模拟 JSON-RPC 节点: baseFee 为空时最新区块不带基础费用 (未启用 London)，也不支持 eth_maxPriorityFeePerGas
+func newGasNode(t *testing.T, baseFee string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     interface{} `json:"id"`
+			Method string      `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		reply := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		switch {
+		case req.Method == "eth_estimateGas":
+			reply["result"] = "0x5208"
+		case req.Method == "eth_gasPrice":
+			reply["result"] = "0x4a817c800" // 20 gwei
+		case req.Method == "eth_getBlockByNumber" && baseFee == "":
+			reply["result"] = map[string]string{"number": "0x10"}
+		case req.Method == "eth_getBlockByNumber":
+			reply["result"] = map[string]string{"number": "0x10", "baseFeePerGas": baseFee}
+		case req.Method == "eth_maxPriorityFeePerGas" && baseFee != "":
+			reply["result"] = "0x77359400" // 2 gwei
+		default:
+			reply["error"] = map[string]interface{}{"code": -32601, "message": "the method " + req.Method + " does not exist"}
+		}
+		json.NewEncoder(w).Encode(reply)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGasFeesLegacyFallback(t *testing.T) {
+	rpc := NewPoleRPC(newGasNode(t, "").URL)
+	to := common.HexToAddress("0x3535353535353535353535353535353535353535")
+
+	fees, err := GasConfig{}.Fees(rpc, common.Address{}, &to, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.Dynamic || fees.BaseFee != nil {
+		t.Fatalf("节点未启用 London 时不应使用 EIP-1559 交易: %+v", fees)
+	}
+	if fees.GasPrice.Int64() != 20_000_000_000 {
+		t.Fatalf("gas 价格 %s，期望 eth_gasPrice 的 20 gwei", fees.GasPrice)
+	}
+
+	tx := fees.NewTx(0, &to, nil, nil)
+	if tx.Type != 0 || tx.GasTipCap != nil || tx.GasFeeCap != nil {
+		t.Fatalf("交易类型 %d，期望 legacy 交易", tx.Type)
+	}
+	if tx.GasPrice.Cmp(fees.GasPrice) != 0 || tx.Gas != fees.Gas {
+		t.Fatalf("交易 gas %d、价格 %s 与 %d、%s 不符", tx.Gas, tx.GasPrice, fees.Gas, fees.GasPrice)
+	}
+	key, _ := parsePrivateKey("4646464646464646464646464646464646464646464646464646464646464646")
+	if err := tx.Sign(key, big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw[0] < 0xc0 {
+		t.Fatalf("legacy 交易应编码为 RLP 列表，首字节为 0x%02x", raw[0])
+	}
+}
+
+func TestGasFeesDynamic(t *testing.T) {
+	rpc := NewPoleRPC(newGasNode(t, "0x3b9aca00").URL) // 基础费用 1 gwei
+	to := common.HexToAddress("0x3535353535353535353535353535353535353535")
+
+	fees, err := GasConfig{}.Fees(rpc, common.Address{}, &to, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fees.Dynamic {
+		t.Fatalf("节点已启用 London 时应使用 EIP-1559 交易: %+v", fees)
+	}
+	if fees.GasTipCap.Int64() != 2_000_000_000 || fees.GasFeeCap.Int64() != 4_000_000_000 {
+		t.Fatalf("优先费 %s、最高价格 %s，期望 2 gwei 与 4 gwei", fees.GasTipCap, fees.GasFeeCap)
+	}
+	if tx := fees.NewTx(0, &to, nil, nil); tx.Type != DynamicFeeTxType {
+		t.Fatalf("交易类型 %d，期望 %d", tx.Type, DynamicFeeTxType)
+	}
+
+	// pole_gas.legacy 强制使用 legacy 交易
+	fees, err = GasConfig{Legacy: true}.Fees(rpc, common.Address{}, &to, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.Dynamic || fees.NewTx(0, &to, nil, nil).Type != 0 {
+		t.Fatalf("legacy 为 true 时应使用 legacy 交易: %+v", fees)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ============ 本机 PoLE 节点与钱包 ============
+
+// PoLE 安装目录 (节点程序、创世文件、节点数据与钱包) 按 --pole-home > config.json 的 pole_local.home >
+// $POLE_HOME > 各平台的常见位置中已存在的第一个确定，均不存在时为该平台的默认位置。
+// 节点程序、启动参数、数据目录、创世文件与钱包文件可在 pole_local 中单独指定
+
+// PoleHomeEnv 指定 PoLE 安装目录的环境变量
+const PoleHomeEnv = "POLE_HOME"
+
+// PoleLocalConfig 本机 PoLE 节点与钱包 (config.json 的 pole_local)
+type PoleLocalConfig struct {
+	Home    string   `json:"home,omitempty"`     // PoLE 安装目录
+	Binary  string   `json:"binary,omitempty"`   // 节点程序 (默认 <home>/pole-node，Windows 为 pole-node.exe；不存在时在 PATH 中查找)
+	Args    []string `json:"args,omitempty"`     // 节点启动参数 (替换默认参数，可使用 {home} {data_dir} {genesis} {rpc_port} 占位)
+	DataDir string   `json:"data_dir,omitempty"` // 节点数据目录 (默认 <home>/data)
+	Genesis string   `json:"genesis,omitempty"`  // 创世文件 (默认 <home>/config/<网络>/genesis.json)
+	Wallet  string   `json:"wallet,omitempty"`   // PoLE 钱包文件 (默认 <home>/wallet.json)
+}
+
+// PoleLocal 当前的本机 PoLE 配置
+var PoleLocal = PoleLocalConfig{}
+
+// 命令行参数 (优先于 config.json)
+var (
+	poleHomeFlag   string
+	poleBinaryFlag string
+	poleWalletFlag string
+)
+
+// DefaultPoleP2PPort 节点默认的 P2P 端口
+const DefaultPoleP2PPort = "26657"
+
+// PoleHome PoLE 安装目录
+func PoleHome() string {
+	for _, dir := range []string{poleHomeFlag, PoleLocal.Home, os.Getenv(PoleHomeEnv)} {
+		if dir != "" {
+			return expandPath(dir)
+		}
+	}
+	candidates := poleHomeCandidates()
+	for _, dir := range candidates {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return candidates[0]
+}
+
+// poleHomeCandidates 各平台的候选目录，第一个为默认位置
+func poleHomeCandidates() []string {
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "windows":
+		var dirs []string
+		if base := os.Getenv("LOCALAPPDATA"); base != "" {
+			dirs = append(dirs, filepath.Join(base, "pole"))
+		}
+		dirs = append(dirs, filepath.Join(home, "pole"), `D:\pole`) // D:\pole 为早期版本的固定位置
+		return dirs
+	case "darwin":
+		return []string{filepath.Join(home, "Library", "Application Support", "pole"), filepath.Join(home, ".pole")}
+	default:
+		dirs := []string{filepath.Join(home, ".pole")}
+		if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+			dirs = append(dirs, filepath.Join(xdg, "pole"))
+		}
+		return append(dirs, filepath.Join(home, ".local", "share", "pole"), "/opt/pole")
+	}
+}
+
+// PoleWalletPath PoLE 钱包文件
+func PoleWalletPath() string {
+	if poleWalletFlag != "" {
+		return expandPath(poleWalletFlag)
+	}
+	return poleLocalPath(PoleLocal.Wallet, "wallet.json")
+}
+
+// PoleNodeDataDir 节点数据目录
+func PoleNodeDataDir() string {
+	return poleLocalPath(PoleLocal.DataDir, "data")
+}
+
+// PoleGenesisPath 当前网络的创世文件
+func PoleGenesisPath() string {
+	return poleLocalPath(PoleLocal.Genesis, filepath.Join("config", activeNetwork.Name, "genesis.json"))
+}
+
+// PoleNodeBinary 节点程序: 指定的路径，或安装目录中的 pole-node，都不存在时在 PATH 中查找
+func PoleNodeBinary() (string, error) {
+	name := "pole-node"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	path := ""
+	switch {
+	case poleBinaryFlag != "":
+		path = expandPath(poleBinaryFlag)
+	case PoleLocal.Binary != "":
+		path = poleLocalPath(PoleLocal.Binary, "")
+	}
+	if path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("找不到 PoLE 节点程序: %s", path)
+		}
+		return path, nil
+	}
+	path = filepath.Join(PoleHome(), name)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if found, err := exec.LookPath(name); err == nil {
+		return found, nil
+	}
+	return "", fmt.Errorf("找不到 PoLE 节点程序 (%s 或 PATH 中的 %s)，可用 --pole-bin 或 config.json 的 pole_local.binary 指定", path, name)
+}
+
+// PoleNodeArgs 节点启动参数，RPC 端口取自节点地址
+func PoleNodeArgs() []string {
+	args := PoleLocal.Args
+	if len(args) == 0 {
+		args = []string{"-data-dir", "{data_dir}", "-genesis", "{genesis}", "-rpc-port", ":{rpc_port}", "-p2p-port", ":" + DefaultPoleP2PPort}
+	}
+	port := "9090"
+	if u, err := url.Parse(poleNodeURL); err == nil && u.Port() != "" {
+		port = u.Port()
+	}
+	r := strings.NewReplacer("{home}", PoleHome(), "{data_dir}", PoleNodeDataDir(), "{genesis}", PoleGenesisPath(), "{rpc_port}", port)
+	out := make([]string, len(args))
+	for i, a := range args {
+		out[i] = r.Replace(a)
+	}
+	return out
+}
+
+// StartPoleNode 在后台启动本机 PoLE 节点
+func StartPoleNode() (*exec.Cmd, error) {
+	binary, err := PoleNodeBinary()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(binary, PoleNodeArgs()...)
+	if info, err := os.Stat(PoleHome()); err == nil && info.IsDir() {
+		cmd.Dir = PoleHome()
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// poleLocalPath 配置的路径 (相对路径基于安装目录)，为空时为安装目录下的 def
+func poleLocalPath(path, def string) string {
+	if path == "" {
+		return filepath.Join(PoleHome(), def)
+	}
+	path = expandPath(path)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(PoleHome(), path)
+}
+
+// expandPath 展开路径开头的 ~
+func expandPath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
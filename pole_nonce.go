@@ -0,0 +1,305 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ============ 链上提交的 nonce 管理 ============
+
+// 每个账户的下一个 nonce 与已发送未确认的交易保存在数据目录 (PoleNonceFile)，提交在进程内串行进行。
+// 每次发送前与节点对账 (eth_getTransactionCount 的 latest 与 pending):
+//   - nonce 小于 latest 的交易已确认，不再跟踪
+//   - 节点的 pending 更大时 (其他客户端用同一账户发送了交易) 从 pending 继续
+//   - 节点的 pending 更小时 (本地已发送的交易被交易池丢弃) 按顺序重新广播跟踪的交易，
+//     缺少的 nonce 之后的交易无法打包，从该 nonce 重新分配
+//   - 最早的未确认交易超过 StuckTxTimeout 未打包时，以提高 gas 价格的同 nonce 交易替换
+
+// PoleNonceFile nonce 状态文件 (位于数据目录)
+const PoleNonceFile = "pole_nonces.json"
+
+// StuckTxTimeout 未确认交易视为卡住的时间
+var StuckTxTimeout = 5 * time.Minute
+
+// gasPriceBump 替换交易提高的 gas 价格百分比 (节点要求替换交易至少提高 10%)
+const gasPriceBump = 12
+
+// PendingTx 已发送未确认的交易
+type PendingTx struct {
+	Nonce    uint64    `json:"nonce"`
+	Hash     string    `json:"hash"`
+	Raw      string    `json:"raw"` // 已签名的原始交易 (重新广播与替换时使用)
+	SentAt   time.Time `json:"sent_at"`
+	Replaced int       `json:"replaced,omitempty"` // 被替换的次数
+}
+
+// NonceAccount 账户的 nonce 状态
+type NonceAccount struct {
+	Next    uint64       `json:"next"`
+	Pending []*PendingTx `json:"pending,omitempty"` // 按 nonce 排序
+}
+
+// NonceManager 链上提交的 nonce 管理器
+type NonceManager struct {
+	mu       sync.Mutex
+	path     string
+	accounts map[string]*NonceAccount // 小写地址 → 状态
+}
+
+var (
+	nonceManagersMu sync.Mutex
+	nonceManagers   = map[string]*NonceManager{}
+)
+
+// PoleNonces 当前数据目录的 nonce 管理器
+func PoleNonces() *NonceManager {
+	path := filepath.Join(dataDir, PoleNonceFile)
+	nonceManagersMu.Lock()
+	defer nonceManagersMu.Unlock()
+	m, ok := nonceManagers[path]
+	if !ok {
+		m = &NonceManager{path: path}
+		nonceManagers[path] = m
+	}
+	return m
+}
+
+// load 读取状态文件 (每次提交前重新读取，使先后运行的进程共用状态)
+func (m *NonceManager) load() {
+	m.accounts = map[string]*NonceAccount{}
+	if data, err := os.ReadFile(m.path); err == nil {
+		json.Unmarshal(data, &m.accounts)
+	}
+}
+
+func (m *NonceManager) save() error {
+	data, err := json.MarshalIndent(m.accounts, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.path)
+}
+
+func (m *NonceManager) account(address string) *NonceAccount {
+	key := strings.ToLower(address)
+	a, ok := m.accounts[key]
+	if !ok {
+		a = &NonceAccount{}
+		m.accounts[key] = a
+	}
+	return a
+}
+
+// Account 账户的本地 nonce 状态 (副本)
+func (m *NonceManager) Account(address string) NonceAccount {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.load()
+	a := *m.account(address)
+	a.Pending = append([]*PendingTx(nil), a.Pending...)
+	return a
+}
+
+// Reset 清除账户的本地状态，下次提交时从节点的 nonce 开始
+func (m *NonceManager) Reset(address string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.load()
+	delete(m.accounts, strings.ToLower(address))
+	return m.save()
+}
+
+// Send 以账户的下一个 nonce 签名并发送 build 构造的交易，返回交易哈希。
+// 同一管理器的提交串行进行；节点报告 nonce 过低时与节点对账后重试一次
+func (m *NonceManager) Send(rpc *PoleRPC, key *ecdsa.PrivateKey, chainID *big.Int, build func(nonce uint64) (*PoleTx, error)) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.load()
+
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	acct := m.account(address)
+	if err := m.reconcile(rpc, address, acct, key, chainID); err != nil {
+		return "", err
+	}
+
+	for attempt := 0; ; attempt++ {
+		nonce := acct.Next
+		tx, err := build(nonce)
+		if err != nil {
+			return "", err
+		}
+		hash, raw, err := sendTx(rpc, tx, key, chainID)
+		if err != nil && txErrorIs(err, "nonce too low") && attempt == 0 {
+			// 账户的交易在对账之后被打包 (如其他客户端发送)，按节点的 nonce 重新分配
+			acct.Next = 0
+			if err := m.reconcile(rpc, address, acct, key, chainID); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if err != nil {
+			m.save()
+			return "", err
+		}
+		acct.Pending = append(acct.Pending, &PendingTx{Nonce: nonce, Hash: hash, Raw: raw, SentAt: time.Now()})
+		acct.Next = nonce + 1
+		if err := m.save(); err != nil {
+			fmt.Printf("⚠️ 保存 nonce 状态失败: %v\n", err)
+		}
+		return hash, nil
+	}
+}
+
+// reconcile 与节点对账，确定下一个 nonce (见文件开头)
+func (m *NonceManager) reconcile(rpc *PoleRPC, address string, acct *NonceAccount, key *ecdsa.PrivateKey, chainID *big.Int) error {
+	latest, err := rpc.TransactionCount(address, "latest")
+	if err != nil {
+		return fmt.Errorf("查询 nonce 失败: %w", err)
+	}
+	pending, err := rpc.TransactionCount(address, "pending")
+	if err != nil {
+		return fmt.Errorf("查询 nonce 失败: %w", err)
+	}
+	if pending < latest {
+		pending = latest
+	}
+
+	// 已确认的交易
+	sort.Slice(acct.Pending, func(i, j int) bool { return acct.Pending[i].Nonce < acct.Pending[j].Nonce })
+	kept := acct.Pending[:0]
+	for _, tx := range acct.Pending {
+		if tx.Nonce >= latest {
+			kept = append(kept, tx)
+		}
+	}
+	acct.Pending = kept
+
+	if acct.Next < pending {
+		acct.Next = pending
+	}
+
+	// 空缺: 节点交易池中没有本地已发送的交易，按顺序重新广播，从第一个缺少的 nonce 重新分配
+	if pending < acct.Next {
+		next := pending
+		kept := acct.Pending[:0]
+		for _, tx := range acct.Pending {
+			if tx.Nonce < pending {
+				kept = append(kept, tx)
+				continue
+			}
+			if tx.Nonce != next {
+				break
+			}
+			if _, err := rpc.SendSignedTransaction(tx.Raw); err != nil && !txErrorIs(err, "already known", "known transaction") {
+				fmt.Printf("⚠️ 重新广播交易 (nonce %d) 失败: %v\n", tx.Nonce, err)
+				break
+			}
+			kept = append(kept, tx)
+			next++
+		}
+		if next < acct.Next {
+			fmt.Printf("⚠️ 节点缺少 nonce %d-%d 的交易，从 %d 重新分配\n", next, acct.Next-1, next)
+		}
+		acct.Pending = kept
+		acct.Next = next
+	}
+
+	// 卡住: 最早的未确认交易长时间未打包，以更高的 gas 价格替换
+	if len(acct.Pending) > 0 {
+		if first := acct.Pending[0]; first.Nonce == latest && time.Since(first.SentAt) > StuckTxTimeout {
+			if err := replaceStuck(rpc, first, key, chainID); err != nil {
+				fmt.Printf("⚠️ 替换卡住的交易 (nonce %d) 失败: %v\n", first.Nonce, err)
+			}
+		}
+	}
+	return nil
+}
+
+// replaceStuck 以提高 gas 价格的同 nonce 交易替换卡住的交易
+func replaceStuck(rpc *PoleRPC, pending *PendingTx, key *ecdsa.PrivateKey, chainID *big.Int) error {
+	tx, err := DecodePoleTx([]byte(pending.Raw))
+	if err != nil {
+		return err
+	}
+	if tx.Type == DynamicFeeTxType {
+		// EIP-1559 交易的优先费与最高价格都须提高
+		tx.GasTipCap, tx.GasFeeCap = bumpGasPrice(tx.GasTipCap), bumpGasPrice(tx.GasFeeCap)
+	} else {
+		tx.GasPrice = bumpGasPrice(tx.GasPrice)
+	}
+	if capPrice := PoleGas.MaxGasPrice(); capPrice != nil && tx.MaxFeePerGas().Cmp(capPrice) > 0 {
+		return fmt.Errorf("替换需要 gas 价格 %s，超过上限 %s", formatGwei(tx.MaxFeePerGas()), formatGwei(capPrice))
+	}
+	hash, raw, err := sendTx(rpc, tx, key, chainID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("⛽ 交易 (nonce %d) 超过 %v 未打包，已提高 gas 价格替换: %s\n", pending.Nonce, StuckTxTimeout, hash)
+	pending.Hash, pending.Raw, pending.SentAt = hash, raw, time.Now()
+	pending.Replaced++
+	return nil
+}
+
+// bumpGasPrice 提高 gasPriceBump% (至少 1 wei)
+func bumpGasPrice(price *big.Int) *big.Int {
+	bump := new(big.Int).Mul(price, big.NewInt(gasPriceBump))
+	bump.Div(bump, big.NewInt(100))
+	if bump.Sign() == 0 {
+		bump.SetInt64(1)
+	}
+	return bump.Add(bump, price)
+}
+
+// sendTx 签名并发送交易，返回交易哈希与原始交易。节点已有该交易时视为成功
+func sendTx(rpc *PoleRPC, tx *PoleTx, key *ecdsa.PrivateKey, chainID *big.Int) (string, string, error) {
+	if err := tx.Sign(key, chainID); err != nil {
+		return "", "", err
+	}
+	enc, err := tx.MarshalBinary()
+	if err != nil {
+		return "", "", fmt.Errorf("编码交易失败: %w", err)
+	}
+	raw := "0x" + common.Bytes2Hex(enc)
+	hash, err := rpc.SendSignedTransaction(raw)
+	if err != nil && txErrorIs(err, "already known", "known transaction") {
+		h, _ := tx.Hash()
+		return h.Hex(), raw, nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if hash == "" {
+		h, _ := tx.Hash()
+		hash = h.Hex()
+	}
+	return hash, raw, nil
+}
+
+// txErrorIs 节点返回的错误是否包含其中一个消息 (不区分大小写)
+func txErrorIs(err error, messages ...string) bool {
+	if err == nil {
+		return false
+	}
+	text := strings.ToLower(err.Error())
+	for _, msg := range messages {
+		if strings.Contains(text, msg) {
+			return true
+		}
+	}
+	return false
+}
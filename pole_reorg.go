@@ -0,0 +1,310 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ============ 工作记录的链上提交状态与区块重组检测 ============
+
+// 每条工作记录 (按记录文件名) 的提交交易、打包的区块号与区块哈希保存在数据目录 (PoleSubmissionFile)。
+// 每个新区块 (oaw pole watch) 与每次 sync-onchain 前检查未达到 ReorgSafeDepth 个确认的提交:
+//   - 交易回执的区块哈希与记录的不同: 重组后交易被打包进另一个区块，更新区块
+//   - 已打包的交易没有回执: 所在区块被重组移除。先重新广播原交易，nonce 已被占用时以新 nonce 重新提交
+//   - 未打包的交易被 nonce 管理器替换 (提高 gas 价格，见 pole_nonce.go) 时跟踪替换后的交易
+
+// PoleSubmissionFile 提交状态文件 (位于数据目录)
+const PoleSubmissionFile = "pole_submissions.json"
+
+// ReorgSafeDepth 提交视为不会再被重组的确认数
+var ReorgSafeDepth uint64 = 12
+
+// 提交状态
+const (
+	SubmissionPending  = "pending"  // 已发送，未打包
+	SubmissionIncluded = "included" // 已打包，未达到 ReorgSafeDepth 个确认
+	SubmissionFinal    = "final"    // 已达到 ReorgSafeDepth 个确认
+	SubmissionReorged  = "reorged"  // 被重组移除，等待重新提交
+)
+
+// Submission 工作记录的链上提交
+type Submission struct {
+	Record      string    `json:"record"` // 记录文件名
+	ProofHash   string    `json:"proof_hash"`
+	From        string    `json:"from"`
+	Data        string    `json:"data"` // submitWork 调用数据 (重新提交时使用)
+	TxHash      string    `json:"tx_hash"`
+	Nonce       uint64    `json:"nonce"`
+	Raw         string    `json:"raw,omitempty"` // 已签名的原始交易 (重新广播时使用)
+	SentAt      time.Time `json:"sent_at"`
+	Status      string    `json:"status"`
+	BlockNumber uint64    `json:"block_number,omitempty"`
+	BlockHash   string    `json:"block_hash,omitempty"`
+	Reverted    bool      `json:"reverted,omitempty"`  // 交易执行失败
+	RecordID    string    `json:"record_id,omitempty"` // 合约的记录 ID (WorkRecorded 事件，见 pole_verify.go)
+	Reorgs      int       `json:"reorgs,omitempty"`    // 经历的重组次数
+	Replaced    []string  `json:"replaced,omitempty"`  // 之前的交易哈希 (被替换或重新提交)
+}
+
+// Live 提交仍有效 (未被重组移除)
+func (s Submission) Live() bool {
+	return s.Status != SubmissionReorged
+}
+
+// SubmissionStore 工作记录的提交状态
+type SubmissionStore struct {
+	mu      sync.Mutex
+	path    string
+	records map[string]*Submission // 记录文件名 → 提交
+}
+
+var (
+	submissionStoresMu sync.Mutex
+	submissionStores   = map[string]*SubmissionStore{}
+)
+
+// PoleSubmissions 当前数据目录的提交状态
+func PoleSubmissions() *SubmissionStore {
+	path := filepath.Join(dataDir, PoleSubmissionFile)
+	submissionStoresMu.Lock()
+	defer submissionStoresMu.Unlock()
+	s, ok := submissionStores[path]
+	if !ok {
+		s = &SubmissionStore{path: path}
+		submissionStores[path] = s
+	}
+	return s
+}
+
+// load 读取状态文件 (每次操作前重新读取，使 watch 与 sync-onchain 共用状态)
+func (s *SubmissionStore) load() {
+	s.records = map[string]*Submission{}
+	if data, err := os.ReadFile(s.path); err == nil {
+		json.Unmarshal(data, &s.records)
+	}
+}
+
+func (s *SubmissionStore) save() error {
+	data, err := json.MarshalIndent(s.records, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Get 记录的提交
+func (s *SubmissionStore) Get(record string) (Submission, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	sub, ok := s.records[record]
+	if !ok {
+		return Submission{}, false
+	}
+	return *sub, true
+}
+
+// List 所有提交，按发送时间排序
+func (s *SubmissionStore) List() []Submission {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	var list []Submission
+	for _, sub := range s.records {
+		list = append(list, *sub)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].SentAt.Before(list[j].SentAt) })
+	return list
+}
+
+// Submit 发送记录的 submitWork 调用并保存提交 (记录之前的提交被重组移除时保留其交易哈希)
+func (s *SubmissionStore) Submit(rpc *PoleRPC, privateKeyHex, record, from, proofHash string, data []byte) (string, error) {
+	hash, err := SendPoleData(rpc, privateKeyHex, data)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	sub := &Submission{Record: record, ProofHash: proofHash, From: from, Data: hexutil.Encode(data)}
+	if prev, ok := s.records[record]; ok {
+		sub.Reorgs = prev.Reorgs
+		sub.Replaced = append(prev.Replaced, prev.TxHash)
+	}
+	sub.track(hash)
+	s.records[record] = sub
+	if err := s.save(); err != nil {
+		fmt.Printf("⚠️ 保存提交状态失败: %v\n", err)
+	}
+	return hash, nil
+}
+
+// SetRecordIDs 保存记录在合约中的记录 ID (记录文件名 → 记录 ID)
+func (s *SubmissionStore) SetRecordIDs(ids map[string]string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	changed := false
+	for record, id := range ids {
+		if sub, ok := s.records[record]; ok && sub.RecordID != id {
+			sub.RecordID, changed = id, true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return s.save()
+}
+
+// FindProof 工作证明为 proof 的提交
+func (s *SubmissionStore) FindProof(proof common.Hash) (Submission, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	for _, sub := range s.records {
+		if common.HexToHash(strings.TrimPrefix(sub.ProofHash, "0x")) == proof {
+			return *sub, true
+		}
+	}
+	return Submission{}, false
+}
+
+// track 跟踪新发送的交易
+func (sub *Submission) track(hash string) {
+	sub.TxHash, sub.SentAt, sub.Status = hash, time.Now(), SubmissionPending
+	sub.BlockNumber, sub.BlockHash, sub.Reverted = 0, "", false
+	sub.Raw = ""
+	if tx := trackedTx(sub.From, func(tx *PendingTx) bool { return strings.EqualFold(tx.Hash, hash) }); tx != nil {
+		sub.Nonce, sub.Raw = tx.Nonce, tx.Raw
+	}
+}
+
+// Check 以最新区块 head 检查未达到 ReorgSafeDepth 个确认的提交 (见文件开头)，返回检测到的重组数。
+// privateKeyHex 为空时被重组移除且无法重新广播的提交留待下次 sync-onchain 重新提交。节点不支持 JSON-RPC 时不检查
+func (s *SubmissionStore) Check(rpc *PoleRPC, head uint64, privateKeyHex string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+
+	var subs []*Submission
+	for _, sub := range s.records {
+		if sub.Status != SubmissionFinal {
+			subs = append(subs, sub)
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].SentAt.Before(subs[j].SentAt) })
+
+	reorgs := 0
+	for _, sub := range subs {
+		r, err := rpc.TransactionReceipt(sub.TxHash)
+		if err != nil {
+			if errors.Is(err, errNoJSONRPC) {
+				return 0, nil
+			}
+			fmt.Printf("⚠️ 查询记录 %s 的交易回执失败: %v\n", sub.Record, err)
+			continue
+		}
+
+		switch {
+		case r != nil && sub.Status == SubmissionIncluded && !strings.EqualFold(r.BlockHash, sub.BlockHash):
+			reorgs++
+			sub.Reorgs++
+			fmt.Printf("↩️  记录 %s 的交易因区块重组从区块 #%d 移到 #%d\n", sub.Record, sub.BlockNumber, r.BlockNumber)
+			sub.BlockNumber, sub.BlockHash, sub.Reverted = r.BlockNumber, r.BlockHash, r.Status == 0
+		case r != nil:
+			sub.Status, sub.BlockNumber, sub.BlockHash, sub.Reverted = SubmissionIncluded, r.BlockNumber, r.BlockHash, r.Status == 0
+		case sub.Status == SubmissionIncluded:
+			reorgs++
+			sub.Reorgs++
+			fmt.Printf("↩️  记录 %s 的交易所在区块 #%d (%s) 因区块重组被移除\n", sub.Record, sub.BlockNumber, shortHash(sub.BlockHash))
+			s.resubmit(rpc, sub, privateKeyHex)
+		case sub.Status == SubmissionReorged:
+			s.resubmit(rpc, sub, privateKeyHex)
+		default:
+			// 未打包: 跟踪 nonce 管理器替换后的交易
+			replacement := trackedTx(sub.From, func(tx *PendingTx) bool {
+				return tx.Nonce == sub.Nonce && !strings.EqualFold(tx.Hash, sub.TxHash)
+			})
+			if sub.Raw != "" && replacement != nil { // 找到原交易时 Nonce 才有效
+				sub.Replaced = append(sub.Replaced, sub.TxHash)
+				sub.TxHash, sub.Raw = replacement.Hash, replacement.Raw
+			}
+		}
+
+		if sub.Status == SubmissionIncluded && head >= sub.BlockNumber && head-sub.BlockNumber+1 >= ReorgSafeDepth {
+			sub.Status = SubmissionFinal
+		}
+	}
+
+	if err := s.save(); err != nil {
+		return reorgs, fmt.Errorf("保存提交状态失败: %w", err)
+	}
+	return reorgs, nil
+}
+
+// resubmit 重新提交被重组移除的记录: 先重新广播原交易，nonce 已被占用时以新 nonce 重新发送
+func (s *SubmissionStore) resubmit(rpc *PoleRPC, sub *Submission, privateKeyHex string) {
+	sub.Status, sub.BlockNumber, sub.BlockHash = SubmissionReorged, 0, ""
+
+	if sub.Raw != "" {
+		_, err := rpc.SendSignedTransaction(sub.Raw)
+		if err == nil || txErrorIs(err, "already known", "known transaction") {
+			sub.Status = SubmissionPending
+			fmt.Printf("🔁 已重新广播记录 %s 的交易 %s\n", sub.Record, shortHash(sub.TxHash))
+			return
+		}
+		if !txErrorIs(err, "nonce too low") {
+			fmt.Printf("⚠️ 重新广播记录 %s 的交易失败: %v\n", sub.Record, err)
+			return
+		}
+	}
+
+	if privateKeyHex == "" {
+		fmt.Printf("⚠️ 记录 %s 将在下次 oaw pole sync-onchain 时重新提交\n", sub.Record)
+		return
+	}
+	data, err := hexutil.Decode(sub.Data)
+	if err != nil {
+		fmt.Printf("⚠️ 记录 %s 的调用数据无效: %v\n", sub.Record, err)
+		return
+	}
+	hash, err := SendPoleData(rpc, privateKeyHex, data)
+	if err != nil {
+		fmt.Printf("⚠️ 重新提交记录 %s 失败: %v\n", sub.Record, err)
+		return
+	}
+	sub.Replaced = append(sub.Replaced, sub.TxHash)
+	sub.track(hash)
+	fmt.Printf("🔁 已重新提交记录 %s: %s\n", sub.Record, hash)
+}
+
+// trackedTx nonce 管理器中账户满足 match 的未确认交易
+func trackedTx(address string, match func(*PendingTx) bool) *PendingTx {
+	if address == "" {
+		return nil
+	}
+	for _, tx := range PoleNonces().Account(address).Pending {
+		if match(tx) {
+			return tx
+		}
+	}
+	return nil
+}
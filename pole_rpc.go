@@ -1,29 +1,32 @@
 package main
 
 import (
-	"bytes"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"math/big"
 	"net/http"
+	"strings"
 	"time"
-
-	"github.com/ethereum/go-ethereum/crypto"
 )
 
 // PoLE RPC 客户端 (适配 PoLE REST API)
 type PoleRPC struct {
 	NodeURL string
+	Nodes   []string // 依次尝试的节点 (为空时只使用 NodeURL)，见 pole_failover.go
+
+	noRetry bool          // 不重试 (检查单个节点或一次性探测时使用)
+	timeout time.Duration // 单个请求的超时 (为 0 时为 RPCTimeout)
 }
 
-// NewPoleRPC 创建 PoLE RPC 客户端
+// NewPoleRPC 创建 PoLE RPC 客户端，nodeURL 不可用时依次切换到 PoleNodes 中的备用节点
 func NewPoleRPC(nodeURL string) *PoleRPC {
-	return &PoleRPC{NodeURL: nodeURL}
+	return &PoleRPC{NodeURL: nodeURL, Nodes: nodeList(nodeURL, PoleNodes)}
 }
 
 // StatusResponse 状态响应
@@ -141,8 +144,18 @@ func (p *PoleRPC) SendTransaction(from, to, data string) (string, error) {
 	return result.TxHash, nil
 }
 
-// SendSignedTransaction 发送已签名交易
+// SendSignedTransaction 发送已签名交易 (0x 前缀的原始交易，见 SignTransaction)，返回交易哈希。
+// 优先使用 eth_sendRawTransaction，节点不支持 JSON-RPC 时经 /tx/broadcast 广播
 func (p *PoleRPC) SendSignedTransaction(signedTx string) (string, error) {
+	var hash string
+	err := p.call("eth_sendRawTransaction", []interface{}{signedTx}, &hash)
+	if err == nil {
+		return hash, nil
+	}
+	if !errors.Is(err, errNoJSONRPC) {
+		return "", err
+	}
+
 	type BroadcastRequest struct {
 		RawTx string `json:"raw_tx"`
 	}
@@ -164,6 +177,105 @@ func (p *PoleRPC) SendSignedTransaction(signedTx string) (string, error) {
 	return result.TxHash, nil
 }
 
+// SendRawTransaction 发送 RLP 编码的已签名交易
+func (p *PoleRPC) SendRawTransaction(raw []byte) (string, error) {
+	return p.SendSignedTransaction("0x" + hex.EncodeToString(raw))
+}
+
+// ChainID 获取数字链 ID (EIP-155 签名使用): 优先 eth_chainId，否则取 /status 的 chain_id
+func (p *PoleRPC) ChainID() (*big.Int, error) {
+	var id string
+	err := p.call("eth_chainId", nil, &id)
+	if err != nil && !errors.Is(err, errNoJSONRPC) {
+		return nil, err
+	}
+	if err != nil {
+		if id, err = p.GetChainID(); err != nil {
+			return nil, err
+		}
+	}
+	chainID, ok := parseQuantity(id)
+	if !ok || chainID.Sign() <= 0 {
+		return nil, fmt.Errorf("节点返回的链 ID 不是数字: %q", id)
+	}
+	return chainID, nil
+}
+
+// Nonce 获取账户的下一个 nonce (含交易池中未打包的交易)
+func (p *PoleRPC) Nonce(address string) (uint64, error) {
+	return p.TransactionCount(address, "pending")
+}
+
+// TransactionCount 获取账户在 block (latest 为已确认，pending 含交易池) 的交易数，
+// 节点不支持 JSON-RPC 时取 REST 的账户 nonce
+func (p *PoleRPC) TransactionCount(address, block string) (uint64, error) {
+	var count string
+	err := p.call("eth_getTransactionCount", []interface{}{address, block}, &count)
+	if errors.Is(err, errNoJSONRPC) {
+		count, err = p.GetTransactionCount(address)
+	}
+	if err != nil {
+		return 0, err
+	}
+	nonce, ok := parseQuantity(count)
+	if !ok || !nonce.IsUint64() {
+		return 0, fmt.Errorf("节点返回的 nonce 无效: %q", count)
+	}
+	return nonce.Uint64(), nil
+}
+
+// Receipt 交易回执
+type Receipt struct {
+	TxHash      string `json:"transactionHash"`
+	BlockHash   string `json:"blockHash"`
+	BlockNumber uint64 `json:"-"`
+	Status      uint64 `json:"-"` // 1 成功，0 失败
+	GasUsed     uint64 `json:"-"`
+}
+
+// TransactionReceipt eth_getTransactionReceipt 查询交易回执，交易尚未打包时返回 nil
+func (p *PoleRPC) TransactionReceipt(hash string) (*Receipt, error) {
+	var raw *struct {
+		Receipt
+		BlockNumber string `json:"blockNumber"`
+		Status      string `json:"status"`
+		GasUsed     string `json:"gasUsed"`
+	}
+	if err := p.call("eth_getTransactionReceipt", []interface{}{hash}, &raw); err != nil {
+		return nil, err
+	}
+	if raw == nil || raw.BlockNumber == "" {
+		return nil, nil
+	}
+	r := raw.Receipt
+	for _, f := range []struct {
+		s   string
+		dst *uint64
+	}{{raw.BlockNumber, &r.BlockNumber}, {raw.Status, &r.Status}, {raw.GasUsed, &r.GasUsed}} {
+		if n, ok := parseQuantity(f.s); ok && n.IsUint64() {
+			*f.dst = n.Uint64()
+		}
+	}
+	return &r, nil
+}
+
+// HeadNumber 最新区块号: eth_blockNumber，节点不支持 JSON-RPC 时取 /block/latest
+func (p *PoleRPC) HeadNumber() (uint64, error) {
+	var number string
+	err := p.call("eth_blockNumber", nil, &number)
+	if errors.Is(err, errNoJSONRPC) {
+		number, err = p.GetBlockNumber()
+	}
+	if err != nil {
+		return 0, err
+	}
+	n, ok := parseQuantity(number)
+	if !ok || !n.IsUint64() {
+		return 0, fmt.Errorf("节点返回的区块号无效: %q", number)
+	}
+	return n.Uint64(), nil
+}
+
 // GetTransactionByHash 根据哈希查询交易
 func (p *PoleRPC) GetTransactionByHash(hash string) (map[string]interface{}, error) {
 	resp, err := p.doGet("/tx/" + hash)
@@ -186,22 +298,13 @@ func (p *PoleRPC) CallContract(to, data string) (string, error) {
 
 // doGet 发送 GET 请求
 func (p *PoleRPC) doGet(path string) ([]byte, error) {
-	url := p.NodeURL + path
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("请求失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	status, body, err := p.send(http.MethodGet, path, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	if status != 200 {
+		return nil, fmt.Errorf("HTTP %d: %s", status, string(body))
 	}
 
 	return body, nil
@@ -209,31 +312,80 @@ func (p *PoleRPC) doGet(path string) ([]byte, error) {
 
 // doPost 发送 POST 请求
 func (p *PoleRPC) doPost(path string, data []byte) ([]byte, error) {
-	url := p.NodeURL + path
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
-	if err != nil {
-		return nil, fmt.Errorf("请求失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	status, body, err := p.send(http.MethodPost, path, data)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	if status != 200 {
+		return nil, fmt.Errorf("HTTP %d: %s", status, string(body))
 	}
 
 	return body, nil
 }
 
-// CreateWorkRecordTx 创建工作记录交易数据
-func CreateWorkRecordTx(agentID string, tokens uint64) string {
-	data := fmt.Sprintf("0x12345678%s%x", agentID, tokens)
-	return data
+// errNoJSONRPC 节点不支持 JSON-RPC (没有该接口或方法)
+var errNoJSONRPC = errors.New("节点不支持 JSON-RPC")
+
+// rpcError JSON-RPC 错误
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("RPC 错误 %d: %s", e.Code, e.Message)
+}
+
+// call 发送 JSON-RPC 2.0 请求 (POST 到节点根路径)，结果解析到 result。
+// 接口不存在或方法未实现 (-32601) 时返回 errNoJSONRPC
+func (p *PoleRPC) call(method string, params []interface{}, result interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	reqData, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	status, body, err := p.send(http.MethodPost, "", reqData)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusNotFound || status == http.StatusMethodNotAllowed {
+		return errNoJSONRPC
+	}
+
+	var reply struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	if err := json.Unmarshal(body, &reply); err != nil {
+		if status != 200 {
+			return fmt.Errorf("HTTP %d: %s", status, string(body))
+		}
+		return errNoJSONRPC
+	}
+	if reply.Error != nil {
+		if reply.Error.Code == -32601 {
+			return errNoJSONRPC
+		}
+		return reply.Error
+	}
+	if reply.Result == nil {
+		return errNoJSONRPC
+	}
+	return json.Unmarshal(reply.Result, result)
+}
+
+// parseQuantity 解析十六进制 (0x 前缀) 或十进制数量
+func parseQuantity(s string) (*big.Int, bool) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return new(big.Int).SetString(s[2:], 16)
+	}
+	return new(big.Int).SetString(s, 10)
 }
 
 // GenerateKeyPair 生成密钥对
@@ -256,40 +408,3 @@ func SignData(data []byte, privateKey *ecdsa.PrivateKey) ([]byte, error) {
 	hash := sha256.Sum256(data)
 	return ecdsa.SignASN1(rand.Reader, privateKey, hash[:])
 }
-
-// SignTransaction 完整签名交易
-func SignTransaction(txData, privateKeyHex string) (string, error) {
-	if len(privateKeyHex) != 64 {
-		return "", fmt.Errorf("私钥格式错误: 需要 64 位十六进制字符串")
-	}
-
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
-	if err != nil {
-		return "", fmt.Errorf("私钥解析失败: %w", err)
-	}
-
-	signature, err := crypto.Sign([]byte(txData), privateKey)
-	if err != nil {
-		return "", fmt.Errorf("签名失败: %w", err)
-	}
-
-	return "0x" + hex.EncodeToString(signature), nil
-}
-
-// SignTransactionWithChainID 使用 chainID 签名交易 (EIP-155)
-func SignTransactionWithChainID(txData, privateKeyHex string, chainID uint64) (string, error) {
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
-	if err != nil {
-		return "", fmt.Errorf("私钥解析失败: %w", err)
-	}
-
-	txHash := crypto.Keccak256Hash([]byte(txData))
-	signature, err := crypto.Sign(txHash.Bytes(), privateKey)
-	if err != nil {
-		return "", fmt.Errorf("签名失败: %w", err)
-	}
-
-	signature[64] = byte(chainID) + 35 + (signature[64] - 35) % 2
-
-	return "0x" + hex.EncodeToString(signature), nil
-}
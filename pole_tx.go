@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ============ PoLE 交易编码与签名 ============
+
+// PoLE 节点兼容以太坊交易: 交易按 legacy 格式 RLP 编码，以 secp256k1 私钥签名，
+// 签名带 EIP-155 链 ID (防止在其他链上重放)，编码后的原始交易经 eth_sendRawTransaction 提交。
+// 节点支持 London (区块带基础费用) 时使用 EIP-1559 交易 (类型 0x02): 0x02 || rlp([chainID, nonce,
+// maxPriorityFeePerGas, maxFeePerGas, gas, to, value, data, accessList, yParity, r, s])
+
+// 默认 gas 参数 (节点未提供估算时使用)
+var (
+	DefaultGasPrice  = big.NewInt(1_000_000_000) // 1 gwei
+	DefaultGasTipCap = big.NewInt(1_000_000_000) // EIP-1559 优先费 1 gwei
+)
+
+// 交易类型
+const (
+	LegacyTxType     = 0x00
+	DynamicFeeTxType = 0x02 // EIP-1559
+)
+
+// 交易的固有 gas (以太坊黄皮书)
+const (
+	txGas                 = 21000
+	txDataZeroGas         = 4
+	txDataNonZeroGas      = 16
+	txContractCreationGas = 32000
+)
+
+// PoleTx PoLE 交易 (以太坊 legacy 交易，或 Type 为 DynamicFeeTxType 的 EIP-1559 交易)
+type PoleTx struct {
+	Nonce    uint64
+	GasPrice *big.Int // legacy 交易的 gas 价格
+	Gas      uint64
+	To       *common.Address `rlp:"nil"` // 为 nil 时创建合约
+	Value    *big.Int
+	Data     []byte
+
+	// 签名 (legacy 交易按 EIP-155: V = recid + chainID*2 + 35；EIP-1559 交易 V = recid)
+	V, R, S *big.Int
+
+	// EIP-1559 (legacy 交易不使用，不参与 legacy 编码)
+	Type      uint8    `rlp:"-"`
+	GasTipCap *big.Int `rlp:"-"` // maxPriorityFeePerGas
+	GasFeeCap *big.Int `rlp:"-"` // maxFeePerGas
+	chainID   *big.Int // EIP-1559 交易的链 ID (签名或解码时设置)
+}
+
+// dynamicFeeTx EIP-1559 交易的 RLP 结构
+type dynamicFeeTx struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         *common.Address `rlp:"nil"`
+	Value      *big.Int
+	Data       []byte
+	AccessList []accessTuple
+	V, R, S    *big.Int
+}
+
+// accessTuple EIP-2930 访问列表项 (PoLE 交易不使用访问列表，编码为空列表)
+type accessTuple struct {
+	Address     common.Address
+	StorageKeys []common.Hash
+}
+
+// NewPoleTx 创建未签名的交易，value、gasPrice 为 nil 时取 0 与 DefaultGasPrice，gas 为 0 时取固有 gas
+func NewPoleTx(nonce uint64, to *common.Address, value *big.Int, gas uint64, gasPrice *big.Int, data []byte) *PoleTx {
+	if value == nil {
+		value = new(big.Int)
+	}
+	if gasPrice == nil {
+		gasPrice = new(big.Int).Set(DefaultGasPrice)
+	}
+	if gas == 0 {
+		gas = IntrinsicGas(data, to == nil)
+	}
+	return &PoleTx{Nonce: nonce, GasPrice: gasPrice, Gas: gas, To: to, Value: value, Data: data,
+		V: new(big.Int), R: new(big.Int), S: new(big.Int)}
+}
+
+// NewDynamicFeeTx 创建未签名的 EIP-1559 交易，gasTipCap、gasFeeCap 为 nil 时取 DefaultGasTipCap 与 DefaultGasPrice
+func NewDynamicFeeTx(nonce uint64, to *common.Address, value *big.Int, gas uint64, gasTipCap, gasFeeCap *big.Int, data []byte) *PoleTx {
+	tx := NewPoleTx(nonce, to, value, gas, nil, data)
+	if gasTipCap == nil {
+		gasTipCap = new(big.Int).Set(DefaultGasTipCap)
+	}
+	if gasFeeCap == nil {
+		gasFeeCap = new(big.Int).Set(DefaultGasPrice)
+	}
+	tx.Type, tx.GasPrice, tx.GasTipCap, tx.GasFeeCap = DynamicFeeTxType, nil, gasTipCap, gasFeeCap
+	return tx
+}
+
+// IntrinsicGas 交易的固有 gas: 基础费用加数据费用 (零字节 4，非零字节 16)，创建合约另加 32000
+func IntrinsicGas(data []byte, create bool) uint64 {
+	gas := uint64(txGas)
+	if create {
+		gas += txContractCreationGas
+	}
+	for _, b := range data {
+		if b == 0 {
+			gas += txDataZeroGas
+		} else {
+			gas += txDataNonZeroGas
+		}
+	}
+	return gas
+}
+
+// SigningHash 签名哈希。legacy 交易按 EIP-155: keccak256(rlp([nonce, gasPrice, gas, to, value, data, chainID, 0, 0]))；
+// EIP-1559 交易: keccak256(0x02 || rlp([chainID, nonce, tip, feeCap, gas, to, value, data, accessList]))
+func (tx *PoleTx) SigningHash(chainID *big.Int) (common.Hash, error) {
+	if tx.Type == DynamicFeeTxType {
+		enc, err := rlp.EncodeToBytes([]interface{}{
+			chainID, tx.Nonce, tx.GasTipCap, tx.GasFeeCap, tx.Gas, tx.To, tx.Value, tx.Data, []accessTuple{},
+		})
+		if err != nil {
+			return common.Hash{}, err
+		}
+		return crypto.Keccak256Hash([]byte{DynamicFeeTxType}, enc), nil
+	}
+	enc, err := rlp.EncodeToBytes([]interface{}{
+		tx.Nonce, tx.GasPrice, tx.Gas, tx.To, tx.Value, tx.Data, chainID, uint(0), uint(0),
+	})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(enc), nil
+}
+
+// Sign 以私钥签名交易 (legacy 交易按 EIP-155)
+func (tx *PoleTx) Sign(key *ecdsa.PrivateKey, chainID *big.Int) error {
+	if chainID == nil || chainID.Sign() <= 0 {
+		return fmt.Errorf("无效的链 ID: %v", chainID)
+	}
+	hash, err := tx.SigningHash(chainID)
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(hash.Bytes(), key)
+	if err != nil {
+		return fmt.Errorf("签名失败: %w", err)
+	}
+	tx.R = new(big.Int).SetBytes(sig[:32])
+	tx.S = new(big.Int).SetBytes(sig[32:64])
+	if tx.Type == DynamicFeeTxType {
+		tx.V, tx.chainID = big.NewInt(int64(sig[64])), new(big.Int).Set(chainID)
+		return nil
+	}
+	tx.V = new(big.Int).Mul(chainID, big.NewInt(2))
+	tx.V.Add(tx.V, big.NewInt(int64(sig[64])+35))
+	return nil
+}
+
+// ChainID 交易的链 ID: EIP-1559 交易签名或解码时的链 ID，legacy 交易由签名的 V 推出 (未签名或签名不带链 ID 时为 nil)
+func (tx *PoleTx) ChainID() *big.Int {
+	if tx.Type == DynamicFeeTxType {
+		return tx.chainID
+	}
+	if tx.V == nil || tx.V.Cmp(big.NewInt(35)) < 0 {
+		return nil
+	}
+	id := new(big.Int).Sub(tx.V, big.NewInt(35))
+	return id.Rsh(id, 1)
+}
+
+// Sender 从签名恢复发送方地址
+func (tx *PoleTx) Sender() (common.Address, error) {
+	chainID := tx.ChainID()
+	if chainID == nil {
+		return common.Address{}, errors.New("交易未签名或签名不带链 ID")
+	}
+	hash, err := tx.SigningHash(chainID)
+	if err != nil {
+		return common.Address{}, err
+	}
+	recid := tx.V
+	if tx.Type != DynamicFeeTxType {
+		recid = new(big.Int).Sub(tx.V, new(big.Int).Add(new(big.Int).Mul(chainID, big.NewInt(2)), big.NewInt(35)))
+	}
+	if !recid.IsUint64() || recid.Uint64() > 1 {
+		return common.Address{}, errors.New("签名的 V 值无效")
+	}
+	sig := make([]byte, crypto.SignatureLength)
+	tx.R.FillBytes(sig[:32])
+	tx.S.FillBytes(sig[32:64])
+	sig[64] = byte(recid.Uint64())
+	pub, err := crypto.SigToPub(hash.Bytes(), sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("恢复签名公钥失败: %w", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// MarshalBinary 已签名交易的编码 (eth_sendRawTransaction 的参数): legacy 交易为 RLP 编码，EIP-1559 交易为 0x02 || RLP 编码
+func (tx *PoleTx) MarshalBinary() ([]byte, error) {
+	if tx.Type != DynamicFeeTxType {
+		return rlp.EncodeToBytes(tx)
+	}
+	enc, err := rlp.EncodeToBytes(&dynamicFeeTx{
+		ChainID: tx.chainID, Nonce: tx.Nonce, GasTipCap: tx.GasTipCap, GasFeeCap: tx.GasFeeCap, Gas: tx.Gas,
+		To: tx.To, Value: tx.Value, Data: tx.Data, AccessList: []accessTuple{}, V: tx.V, R: tx.R, S: tx.S,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{DynamicFeeTxType}, enc...), nil
+}
+
+// MaxFeePerGas 每单位 gas 的最高价格 (legacy 交易为 gas 价格)
+func (tx *PoleTx) MaxFeePerGas() *big.Int {
+	if tx.Type == DynamicFeeTxType {
+		return tx.GasFeeCap
+	}
+	return tx.GasPrice
+}
+
+// Hash 交易哈希: 已签名交易编码的 keccak256
+func (tx *PoleTx) Hash() (common.Hash, error) {
+	enc, err := tx.MarshalBinary()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(enc), nil
+}
+
+// DecodePoleTx 解码原始交易 (0x 前缀的十六进制或字节)
+func DecodePoleTx(raw []byte) (*PoleTx, error) {
+	if s := string(bytes.TrimSpace(raw)); strings.HasPrefix(s, "0x") {
+		b, err := hex.DecodeString(s[2:])
+		if err != nil {
+			return nil, fmt.Errorf("原始交易不是有效的十六进制: %w", err)
+		}
+		raw = b
+	}
+	if len(raw) > 0 && raw[0] <= 0x7f {
+		// 类型化交易 (EIP-2718)
+		if raw[0] != DynamicFeeTxType {
+			return nil, fmt.Errorf("不支持的交易类型: 0x%02x", raw[0])
+		}
+		var dyn dynamicFeeTx
+		if err := rlp.DecodeBytes(raw[1:], &dyn); err != nil {
+			return nil, fmt.Errorf("解码交易失败: %w", err)
+		}
+		if len(dyn.AccessList) > 0 {
+			return nil, errors.New("不支持带访问列表的交易")
+		}
+		return &PoleTx{Type: DynamicFeeTxType, Nonce: dyn.Nonce, GasTipCap: dyn.GasTipCap, GasFeeCap: dyn.GasFeeCap,
+			Gas: dyn.Gas, To: dyn.To, Value: dyn.Value, Data: dyn.Data, V: dyn.V, R: dyn.R, S: dyn.S, chainID: dyn.ChainID}, nil
+	}
+	var tx PoleTx
+	if err := rlp.DecodeBytes(raw, &tx); err != nil {
+		return nil, fmt.Errorf("解码交易失败: %w", err)
+	}
+	return &tx, nil
+}
+
+// parsePrivateKey 解析十六进制 secp256k1 私钥 (可带 0x 前缀)
+func parsePrivateKey(privateKeyHex string) (*ecdsa.PrivateKey, error) {
+	privateKeyHex = strings.TrimPrefix(privateKeyHex, "0x")
+	if len(privateKeyHex) != 64 {
+		return nil, fmt.Errorf("私钥格式错误: 需要 64 位十六进制字符串")
+	}
+	key, err := crypto.HexToECDSA(privateKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("私钥解析失败: %w", err)
+	}
+	return key, nil
+}
+
+// SignTransaction 以私钥按 EIP-155 签名交易，返回 0x 前缀的原始交易
+func SignTransaction(tx *PoleTx, privateKeyHex string, chainID *big.Int) (string, error) {
+	key, err := parsePrivateKey(privateKeyHex)
+	if err != nil {
+		return "", err
+	}
+	if err := tx.Sign(key, chainID); err != nil {
+		return "", err
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return "", fmt.Errorf("编码交易失败: %w", err)
+	}
+	return "0x" + hex.EncodeToString(raw), nil
+}
+
+// SendPoleData 签名并发送以 data 为交易数据、发往 PoLE 合约 (poleContractAddress) 的交易，返回交易哈希。
+// 链 ID 从节点查询，gas 按 PoleGas 策略估算 (节点支持时为 EIP-1559 交易)，nonce 由 PoleNonces 分配
+func SendPoleData(rpc *PoleRPC, privateKeyHex string, data []byte) (string, error) {
+	key, err := parsePrivateKey(privateKeyHex)
+	if err != nil {
+		return "", err
+	}
+	chainID, err := rpc.ChainID()
+	if err != nil {
+		return "", fmt.Errorf("查询链 ID 失败: %w", err)
+	}
+	to := common.HexToAddress(poleContractAddress)
+	fees, err := PoleGas.Fees(rpc, crypto.PubkeyToAddress(key.PublicKey), &to, nil, data)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case fees.Capped && fees.Dynamic:
+		fmt.Printf("⚠️ 最高 gas 价格超过上限，按 %s 发送 (基础费用 %s)\n", formatGwei(fees.GasFeeCap), formatGwei(fees.BaseFee))
+	case fees.Capped:
+		fmt.Printf("⚠️ 节点建议的 gas 价格 %s 超过上限，按 %s 发送\n", formatGwei(fees.Suggested), formatGwei(fees.GasPrice))
+	}
+	return PoleNonces().Send(rpc, key, chainID, func(nonce uint64) (*PoleTx, error) {
+		return fees.NewTx(nonce, &to, nil, data), nil
+	})
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// EIP-155 规范中的示例交易 (链 ID 1)
+func TestPoleTxEIP155Vector(t *testing.T) {
+	key, err := crypto.HexToECDSA("4646464646464646464646464646464646464646464646464646464646464646")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0x3535353535353535353535353535353535353535")
+	value, _ := new(big.Int).SetString("1000000000000000000", 10)
+	tx := NewPoleTx(9, &to, value, 21000, big.NewInt(20_000_000_000), nil)
+	chainID := big.NewInt(1)
+
+	hash, err := tx.SigningHash(chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "0xdaf5a779ae972f972197303d7b574746c7ef83eadac0f2791ad23db92e4c8e53"; hash.Hex() != want {
+		t.Fatalf("签名哈希 %s，期望 %s", hash.Hex(), want)
+	}
+
+	if err := tx.Sign(key, chainID); err != nil {
+		t.Fatal(err)
+	}
+	if tx.V.Int64() != 37 {
+		t.Fatalf("V = %s，期望 37", tx.V)
+	}
+	wantR, _ := new(big.Int).SetString("18515461264373351373200002665853028612451056578545711640558177340181847433846", 10)
+	wantS, _ := new(big.Int).SetString("46948507304638947509940763649030358759909902576025900602547168820602576006531", 10)
+	if tx.R.Cmp(wantR) != 0 || tx.S.Cmp(wantS) != 0 {
+		t.Fatalf("签名 r=%s s=%s 与示例不符", tx.R, tx.S)
+	}
+
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRaw := "f86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"
+	if got := hex.EncodeToString(raw); got != wantRaw {
+		t.Fatalf("原始交易 %s，期望 %s", got, wantRaw)
+	}
+
+	decoded, err := DecodePoleTx([]byte("0x" + wantRaw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := decoded.ChainID(); id == nil || id.Int64() != 1 {
+		t.Fatalf("解码后的链 ID 为 %v，期望 1", id)
+	}
+	sender, err := decoded.Sender()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := common.HexToAddress("0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F"); sender != want {
+		t.Fatalf("发送方 %s，期望 %s", sender.Hex(), want.Hex())
+	}
+}
+
+// EIP-1559 交易: 与 EIP-155 示例相同的私钥与收款地址，签名哈希与编码按 0x02 || rlp(...) 逐字节构造
+func TestPoleTxDynamicFeeVector(t *testing.T) {
+	key, err := crypto.HexToECDSA("4646464646464646464646464646464646464646464646464646464646464646")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0x3535353535353535353535353535353535353535")
+	value, _ := new(big.Int).SetString("1000000000000000000", 10)
+	tx := NewDynamicFeeTx(9, &to, value, 21000, big.NewInt(2_000_000_000), big.NewInt(30_000_000_000), []byte{0xde, 0xad, 0xbe, 0xef})
+	chainID := big.NewInt(1)
+
+	// keccak256(02 f4 01 09 8477359400 8506fc23ac00 825208 94<to> 880de0b6b3a7640000 84deadbeef c0)
+	hash, err := tx.SigningHash(chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "0xc1ca31f0f6be3405114895db266faaca3f8bf046fc21d5403a9b689bd524de1d"; hash.Hex() != want {
+		t.Fatalf("签名哈希 %s，期望 %s", hash.Hex(), want)
+	}
+
+	if err := tx.Sign(key, chainID); err != nil {
+		t.Fatal(err)
+	}
+	if tx.V.Sign() != 0 {
+		t.Fatalf("V = %s，期望 yParity 0", tx.V)
+	}
+	wantR, _ := new(big.Int).SetString("99646501473720780538577414542364767943572961363947943716009238100402084307689", 10)
+	wantS, _ := new(big.Int).SetString("42711127875300354762905949912804459356813645025845893778048201779289569737607", 10)
+	if tx.R.Cmp(wantR) != 0 || tx.S.Cmp(wantS) != 0 {
+		t.Fatalf("签名 r=%s s=%s 与期望不符", tx.R, tx.S)
+	}
+
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRaw := "02f877010984773594008506fc23ac00825208943535353535353535353535353535353535353535880de0b6b3a764000084deadbeefc080a0dc4debd19bab56f8ad4f41dbbe5b875f9ae3fa94c3320aa306565a5e7020e2e9a05e6da4462db96b38fa234aa5a2dfddcf5a0e9c3ad87bfa0b3d5db40e77d5bb87"
+	if got := hex.EncodeToString(raw); got != wantRaw {
+		t.Fatalf("原始交易 %s，期望 %s", got, wantRaw)
+	}
+	txHash, err := tx.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "0xd7ca4d35c2bc9e7d0bb4eee829118dd6bbc96754b87cff31f228e6c72c68456d"; txHash.Hex() != want {
+		t.Fatalf("交易哈希 %s，期望 %s", txHash.Hex(), want)
+	}
+
+	decoded, err := DecodePoleTx([]byte("0x" + wantRaw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Type != DynamicFeeTxType || decoded.GasTipCap.Int64() != 2_000_000_000 || decoded.GasFeeCap.Int64() != 30_000_000_000 {
+		t.Fatalf("解码后的类型 %d、优先费 %s、最高价格 %s 与原交易不符", decoded.Type, decoded.GasTipCap, decoded.GasFeeCap)
+	}
+	if id := decoded.ChainID(); id == nil || id.Int64() != 1 {
+		t.Fatalf("解码后的链 ID 为 %v，期望 1", id)
+	}
+	sender, err := decoded.Sender()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := common.HexToAddress("0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F"); sender != want {
+		t.Fatalf("发送方 %s，期望 %s", sender.Hex(), want.Hex())
+	}
+}
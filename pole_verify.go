@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"oaw/openclaw"
+)
+
+// ============ oaw pole verify ============
+
+// 以 eth_getLogs 查询工作记录合约的 WorkRecorded 事件，按工作证明与本地记录对账:
+// 链上事件的 Agent、token 数与价值须与本地记录一致；没有事件的记录按提交状态 (见 pole_reorg.go)
+// 报告为未提交、待打包、被重组移除、执行失败或缺少事件。事件的记录 ID 保存到提交状态
+
+// logRangeBlocks 每次 eth_getLogs 查询的区块数 (节点通常限制单次查询范围)
+const logRangeBlocks = 5000
+
+// 记录的链上状态
+const (
+	VerifyOnChain     = "onchain"     // 链上有一致的 WorkRecorded 事件
+	VerifyMismatch    = "mismatch"    // 链上事件与本地记录不一致
+	VerifyPending     = "pending"     // 已提交，未打包
+	VerifyReorged     = "reorged"     // 被区块重组移除，等待重新提交
+	VerifyReverted    = "reverted"    // 交易执行失败
+	VerifyMissing     = "missing"     // 交易已打包，但没有 WorkRecorded 事件
+	VerifyUnsubmitted = "unsubmitted" // 未提交
+)
+
+// RecordVerification 本地记录的链上状态
+type RecordVerification struct {
+	Record     string
+	ProofHash  common.Hash
+	Status     string
+	Submission *Submission   // 提交状态 (未提交时为 nil)
+	Event      *WorkRecorded // 链上事件 (没有时为 nil)
+	Log        *Log          // 事件所在的日志
+	Events     int           // 该工作证明在链上的事件数 (大于 1 为重复提交)
+	Mismatch   []string      // 不一致的字段
+}
+
+// PoleVerifyReport 链上验证结果
+type PoleVerifyReport struct {
+	Agent   string
+	From    uint64 // 查询的区块范围
+	Head    uint64
+	Events  int // 查询到的 WorkRecorded 事件数
+	Records []RecordVerification
+	Unknown []*WorkRecorded // 本钱包在链上、本地却没有的记录
+}
+
+// Count 状态为 status 的记录数
+func (r *PoleVerifyReport) Count(status string) int {
+	n := 0
+	for _, v := range r.Records {
+		if v.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// recordFile 记录文件
+type recordFile struct {
+	Name   string
+	Record openclaw.WorkRecord
+}
+
+// loadRecordFiles 读取记录目录，按文件名排序
+func loadRecordFiles(dir string) ([]recordFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []recordFile
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		var record openclaw.WorkRecord
+		if json.Unmarshal(data, &record) != nil {
+			continue
+		}
+		files = append(files, recordFile{Name: e.Name(), Record: record})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+// recordProof 记录提交到链上的工作证明 (与 sync-onchain 相同)
+func recordProof(record openclaw.WorkRecord) common.Hash {
+	proof := record.ProofHash
+	if proof == "" {
+		proof = record.ComputeProof()
+	}
+	return common.HexToHash(strings.TrimPrefix(proof, "0x"))
+}
+
+// VerifyPoleRecords 查询区块 [from, 最新] 中 agent 的 WorkRecorded 事件并与本地记录对账。
+// from 为 0 时从最早打包的提交所在区块开始
+func VerifyPoleRecords(rpc *PoleRPC, agent string, files []recordFile, from uint64) (*PoleVerifyReport, error) {
+	topic := WorkRecordedTopic()
+	if topic == (common.Hash{}) {
+		return nil, fmt.Errorf("合约 ABI 缺少 %s 事件，无法验证", WorkRecordedEvent)
+	}
+	head, err := rpc.HeadNumber()
+	if err != nil {
+		return nil, fmt.Errorf("查询区块高度失败: %w", err)
+	}
+
+	submissions := map[string]Submission{}
+	var earliest uint64
+	for _, sub := range PoleSubmissions().List() {
+		submissions[sub.Record] = sub
+		if sub.BlockNumber > 0 && (earliest == 0 || sub.BlockNumber < earliest) {
+			earliest = sub.BlockNumber
+		}
+	}
+	if from == 0 {
+		from = earliest
+	}
+	if from > head {
+		from = head
+	}
+
+	report := &PoleVerifyReport{Agent: agent, From: from, Head: head}
+	topics := [][]common.Hash{{topic}}
+	if common.IsHexAddress(agent) {
+		// WorkRecorded(recordId indexed, agent indexed, ...): 只查询本钱包的记录
+		topics = append(topics, nil, []common.Hash{common.BytesToHash(common.HexToAddress(agent).Bytes())})
+	}
+	type onchain struct {
+		event *WorkRecorded
+		log   Log
+		count int
+	}
+	events := map[common.Hash]*onchain{}
+	var order []common.Hash
+	for start := from; start <= head; start += logRangeBlocks {
+		end := start + logRangeBlocks - 1
+		if end > head {
+			end = head
+		}
+		logs, err := rpc.GetLogs(common.HexToAddress(poleContractAddress), topics, start, end)
+		if errors.Is(err, errNoJSONRPC) {
+			return nil, fmt.Errorf("节点不支持 eth_getLogs，无法查询链上记录")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("查询区块 %d-%d 的合约日志失败: %w", start, end, err)
+		}
+		for _, l := range logs {
+			if l.Removed {
+				continue
+			}
+			ev, err := DecodeWorkRecorded(l.Topics, l.Data)
+			if err != nil {
+				continue
+			}
+			report.Events++
+			if e, ok := events[ev.ProofHash]; ok {
+				e.count++
+				continue
+			}
+			events[ev.ProofHash] = &onchain{event: ev, log: l, count: 1}
+			order = append(order, ev.ProofHash)
+		}
+	}
+
+	matched := map[common.Hash]bool{}
+	recordIDs := map[string]string{}
+	for _, f := range files {
+		v := RecordVerification{Record: f.Name, ProofHash: recordProof(f.Record)}
+		if sub, ok := submissions[f.Name]; ok {
+			v.Submission = &sub
+		}
+		if e, ok := events[v.ProofHash]; ok {
+			matched[v.ProofHash] = true
+			l := e.log
+			v.Event, v.Log, v.Events = e.event, &l, e.count
+			v.Mismatch = workRecordMismatch(f.Record, agent, e.event)
+			v.Status = VerifyOnChain
+			if len(v.Mismatch) > 0 {
+				v.Status = VerifyMismatch
+			}
+			if v.Submission != nil {
+				recordIDs[f.Name] = e.event.RecordID.String()
+			}
+		} else {
+			v.Status = submissionVerifyStatus(v.Submission)
+		}
+		report.Records = append(report.Records, v)
+	}
+	for _, proof := range order {
+		if !matched[proof] {
+			report.Unknown = append(report.Unknown, events[proof].event)
+		}
+	}
+	if err := PoleSubmissions().SetRecordIDs(recordIDs); err != nil {
+		fmt.Printf("⚠️ 保存提交状态失败: %v\n", err)
+	}
+	return report, nil
+}
+
+// workRecordMismatch 链上事件与本地记录不一致的字段
+func workRecordMismatch(record openclaw.WorkRecord, agent string, ev *WorkRecorded) []string {
+	var diffs []string
+	if common.IsHexAddress(agent) && ev.Agent != common.HexToAddress(agent) {
+		diffs = append(diffs, fmt.Sprintf("agent %s ≠ %s", ev.Agent.Hex(), agent))
+	}
+	if ev.Tokens.Cmp(new(big.Int).SetUint64(uint64(record.TotalTokens))) != 0 {
+		diffs = append(diffs, fmt.Sprintf("tokens %s ≠ %d", ev.Tokens, record.TotalTokens))
+	}
+	if ev.Value.Cmp(ValueUnits(record.Value)) != 0 {
+		diffs = append(diffs, fmt.Sprintf("value %s ≠ %s", formatUnits(ev.Value, WorkValueDecimals), formatUnits(ValueUnits(record.Value), WorkValueDecimals)))
+	}
+	return diffs
+}
+
+// submissionVerifyStatus 链上没有事件的记录按提交状态确定的链上状态
+func submissionVerifyStatus(sub *Submission) string {
+	switch {
+	case sub == nil:
+		return VerifyUnsubmitted
+	case sub.Status == SubmissionPending:
+		return VerifyPending
+	case sub.Status == SubmissionReorged:
+		return VerifyReorged
+	case sub.Reverted:
+		return VerifyReverted
+	default:
+		return VerifyMissing
+	}
+}
+
+// printPoleVerify 显示验证结果；all 为 false 时不逐条列出未提交的记录
+func printPoleVerify(report *PoleVerifyReport, all bool) {
+	fmt.Println("=== PoLE 链上验证 ===")
+	fmt.Printf("合约: %s\n", common.HexToAddress(poleContractAddress).Hex())
+	fmt.Printf("钱包: %s\n", report.Agent)
+	fmt.Printf("区块: #%d - #%d (WorkRecorded 事件 %d 条)\n\n", report.From, report.Head, report.Events)
+
+	for _, v := range report.Records {
+		switch v.Status {
+		case VerifyOnChain:
+			fmt.Printf("  ✅ %s  记录 #%s  区块 #%d (%d 个确认)", v.Record, v.Event.RecordID, v.Log.BlockNumber, report.Head-uint64(v.Log.BlockNumber)+1)
+			if v.Events > 1 {
+				fmt.Printf("  (链上重复 %d 条)", v.Events)
+			}
+			fmt.Println()
+		case VerifyMismatch:
+			fmt.Printf("  ⚠️ %s  记录 #%s 与本地不一致: %s\n", v.Record, v.Event.RecordID, strings.Join(v.Mismatch, "，"))
+		case VerifyPending:
+			fmt.Printf("  ⏳ %s  已提交，等待打包: %s\n", v.Record, v.Submission.TxHash)
+		case VerifyReorged:
+			fmt.Printf("  ↩️  %s  被区块重组移除，等待重新提交\n", v.Record)
+		case VerifyReverted:
+			fmt.Printf("  ❌ %s  交易执行失败 (区块 #%d): %s\n", v.Record, v.Submission.BlockNumber, v.Submission.TxHash)
+		case VerifyMissing:
+			fmt.Printf("  ❌ %s  交易已打包 (区块 #%d) 但没有 WorkRecorded 事件: %s\n", v.Record, v.Submission.BlockNumber, v.Submission.TxHash)
+		case VerifyUnsubmitted:
+			if all {
+				fmt.Printf("  ·  %s  未提交\n", v.Record)
+			}
+		}
+	}
+	for _, ev := range report.Unknown {
+		fmt.Printf("  ❓ 记录 #%s  工作证明 %s 在本地不存在\n", ev.RecordID, shortHash(ev.ProofHash.Hex()))
+	}
+
+	onchain := report.Count(VerifyOnChain)
+	submitted := len(report.Records) - report.Count(VerifyUnsubmitted)
+	fmt.Printf("\n本地记录: %d  已上链: %d  不一致: %d  待打包: %d  重组待重提: %d  失败/缺失: %d  未提交: %d\n",
+		len(report.Records), onchain, report.Count(VerifyMismatch), report.Count(VerifyPending), report.Count(VerifyReorged),
+		report.Count(VerifyReverted)+report.Count(VerifyMissing), report.Count(VerifyUnsubmitted))
+	if submitted > 0 {
+		fmt.Printf("验证率: %.1f%% (已上链/已提交)\n", float64(onchain)/float64(submitted)*100)
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ============ oaw pole watch ============
+
+// 经 WebSocket 订阅新区块与工作记录合约的日志，实时显示本钱包未确认交易的打包与确认、
+// 工作记录 (WorkRecorded) 与奖励 (RewardPaid) 事件，并检测已提交记录的区块重组。WebSocket 不可用或断开时改为轮询区块高度
+// (日志以 eth_getLogs 补查)，并定期尝试重连
+
+// wsRetryInterval WebSocket 断开后重连的间隔
+const wsRetryInterval = 30 * time.Second
+
+// poleWatchOptions oaw pole watch 的参数
+type poleWatchOptions struct {
+	WSURL         string        // 为空时由当前可用的节点地址推出
+	Address       string        // 跟踪该地址的未确认交易 (见 pole_nonce.go)，其事件标记为本钱包
+	PrivateKey    string        // 钱包私钥，重新提交被区块重组移除的记录时使用 (见 pole_reorg.go)
+	Confirmations uint64        // 交易视为已确认的确认数
+	Interval      time.Duration // 轮询间隔
+	Poll          bool          // 只轮询，不使用 WebSocket
+}
+
+// watchedTx 跟踪的交易
+type watchedTx struct {
+	Nonce uint64
+	Hash  string
+	Block uint64 // 打包的区块 (0 为未打包)
+	Done  bool
+}
+
+type poleWatcher struct {
+	rpc       *PoleRPC
+	opts      poleWatchOptions
+	contract  common.Address
+	topics    [][]common.Hash
+	txs       map[string]*watchedTx
+	lastBlock uint64 // 已处理的最新区块
+}
+
+// runPoleWatch 持续显示链上动态，ctx 取消时返回
+func runPoleWatch(ctx context.Context, rpc *PoleRPC, opts poleWatchOptions) error {
+	w := &poleWatcher{
+		rpc:      rpc,
+		opts:     opts,
+		contract: common.HexToAddress(poleContractAddress),
+		txs:      map[string]*watchedTx{},
+	}
+	var events []common.Hash
+	for _, topic := range []common.Hash{WorkRecordedTopic(), RewardPaidTopic()} {
+		if topic != (common.Hash{}) {
+			events = append(events, topic)
+		}
+	}
+	w.topics = [][]common.Hash{events}
+	w.refresh()
+
+	fmt.Println("=== PoLE 链上动态 ===")
+	fmt.Printf("合约: %s\n", w.contract.Hex())
+	fmt.Printf("钱包: %s (%d 笔未确认交易，%d 个确认后视为确认)\n", opts.Address, len(w.txs), opts.Confirmations)
+	fmt.Println("按 Ctrl+C 停止")
+
+	for {
+		if !opts.Poll {
+			err := w.watchWS(ctx)
+			if ctx.Err() != nil {
+				return nil
+			}
+			fmt.Printf("⚠️ WebSocket 不可用 (%v)，改为每 %v 轮询，%v 后重连\n", err, opts.Interval, wsRetryInterval)
+		}
+		w.poll(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// watchWS 订阅新区块与合约日志，连接断开时返回原因
+func (w *poleWatcher) watchWS(ctx context.Context) error {
+	wsURL := w.opts.WSURL
+	if wsURL == "" {
+		wsURL = WSURL(w.rpc.Active())
+	}
+	ws, err := DialPoleWS(wsURL)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+	heads, err := ws.SubscribeNewHeads()
+	if err != nil {
+		return err
+	}
+	var logs <-chan json.RawMessage
+	if sub, err := ws.SubscribeLogs(w.contract, w.topics); err != nil {
+		fmt.Printf("⚠️ %v，不显示合约事件\n", err)
+	} else {
+		logs = sub.C
+	}
+	fmt.Printf("🔌 已订阅 %s\n", wsURL)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-heads.C:
+			if !ok {
+				return ws.Err()
+			}
+			var head Head
+			if err := json.Unmarshal(msg, &head); err != nil {
+				continue
+			}
+			// 重连后补查断开期间的日志
+			if n := head.Number.Uint64(); w.lastBlock > 0 && n > w.lastBlock+1 {
+				w.catchUpLogs(n - 1)
+			}
+			w.onHead(head.Number.Uint64(), head.Hash.Hex())
+		case msg, ok := <-logs:
+			if !ok {
+				return ws.Err()
+			}
+			var l Log
+			if err := json.Unmarshal(msg, &l); err == nil {
+				w.onLog(l)
+			}
+		}
+	}
+}
+
+// poll 轮询区块高度并补查日志；使用 WebSocket 时在 wsRetryInterval 后返回以重连
+func (w *poleWatcher) poll(ctx context.Context) {
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+	var retry <-chan time.Time
+	if !w.opts.Poll {
+		retry = time.After(wsRetryInterval)
+	}
+	for {
+		if n, err := w.rpc.Probe().HeadNumber(); err != nil { // 下个间隔会再次查询，不退避重试
+			fmt.Printf("⚠️ 查询区块高度失败: %v\n", err)
+		} else if n > w.lastBlock {
+			if w.lastBlock > 0 {
+				w.catchUpLogs(n)
+			}
+			w.onHead(n, "")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-retry:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// catchUpLogs 以 eth_getLogs 补查 (lastBlock, to] 的合约日志
+func (w *poleWatcher) catchUpLogs(to uint64) {
+	logs, err := w.rpc.GetLogs(w.contract, w.topics, w.lastBlock+1, to)
+	if err != nil {
+		if !errors.Is(err, errNoJSONRPC) {
+			fmt.Printf("⚠️ 查询合约日志失败: %v\n", err)
+		}
+		return
+	}
+	for _, l := range logs {
+		w.onLog(l)
+	}
+}
+
+// refresh 同步要跟踪的交易: nonce 管理器中的未确认交易 (被替换的旧交易不再跟踪)
+func (w *poleWatcher) refresh() {
+	if w.opts.Address == "" {
+		return
+	}
+	current := map[string]bool{}
+	for _, tx := range PoleNonces().Account(w.opts.Address).Pending {
+		current[tx.Hash] = true
+		if _, ok := w.txs[tx.Hash]; !ok {
+			w.txs[tx.Hash] = &watchedTx{Nonce: tx.Nonce, Hash: tx.Hash}
+		}
+	}
+	for hash, tx := range w.txs {
+		if !current[hash] && tx.Block == 0 {
+			delete(w.txs, hash)
+		}
+	}
+}
+
+// onHead 新区块: 显示区块并更新跟踪交易的确认数
+func (w *poleWatcher) onHead(number uint64, hash string) {
+	w.lastBlock = number
+	if hash != "" {
+		fmt.Printf("⛓️  区块 #%d  %s\n", number, shortHash(hash))
+	} else {
+		fmt.Printf("⛓️  区块 #%d\n", number)
+	}
+
+	if _, err := PoleSubmissions().Check(w.rpc, number, w.opts.PrivateKey); err != nil {
+		fmt.Printf("⚠️ 检查区块重组失败: %v\n", err)
+	}
+
+	w.refresh()
+	for _, tx := range w.txs {
+		if tx.Done {
+			continue
+		}
+		if tx.Block == 0 {
+			r, err := w.rpc.TransactionReceipt(tx.Hash)
+			if err != nil || r == nil {
+				continue
+			}
+			tx.Block = r.BlockNumber
+			status := "成功"
+			if r.Status == 0 {
+				status = "失败"
+			}
+			fmt.Printf("📦 交易 (nonce %d) %s 已打包于区块 #%d，执行%s\n", tx.Nonce, shortHash(tx.Hash), r.BlockNumber, status)
+		}
+		if number < tx.Block {
+			continue
+		}
+		if confirmations := number - tx.Block + 1; confirmations >= w.opts.Confirmations {
+			tx.Done = true
+			fmt.Printf("✅ 交易 (nonce %d) %s 已确认 (%d 个确认)\n", tx.Nonce, shortHash(tx.Hash), confirmations)
+		}
+	}
+}
+
+// onLog 合约事件
+func (w *poleWatcher) onLog(l Log) {
+	if l.Removed {
+		fmt.Printf("↩️  区块 #%d 的事件因区块重组被移除 (交易 %s)\n", l.BlockNumber, shortHash(l.TxHash.Hex()))
+		return
+	}
+	mine := func(agent common.Address) string {
+		if strings.EqualFold(agent.Hex(), w.opts.Address) {
+			return " (本钱包)"
+		}
+		return ""
+	}
+	if rec, err := DecodeWorkRecorded(l.Topics, l.Data); err == nil {
+		fmt.Printf("📝 工作记录 #%s  %s%s  %s token  %s OAW  (区块 #%d)\n",
+			rec.RecordID, rec.Agent.Hex(), mine(rec.Agent), rec.Tokens, formatUnits(rec.Value, WorkValueDecimals), l.BlockNumber)
+		// 与本地提交对账 (见 pole_verify.go)
+		if sub, ok := PoleSubmissions().FindProof(rec.ProofHash); ok {
+			fmt.Printf("   对应本地记录 %s\n", sub.Record)
+			PoleSubmissions().SetRecordIDs(map[string]string{sub.Record: rec.RecordID.String()})
+		}
+		return
+	}
+	if reward, err := DecodeRewardPaid(l.Topics, l.Data); err == nil {
+		fmt.Printf("💰 奖励 %s%s  %s OAW  (区块 #%d)\n",
+			reward.Agent.Hex(), mine(reward.Agent), formatUnits(reward.Amount, WorkValueDecimals), l.BlockNumber)
+	}
+}
+
+// shortHash 缩短显示的哈希
+func shortHash(hash string) string {
+	if len(hash) <= 14 {
+		return hash
+	}
+	return hash[:10] + "…" + hash[len(hash)-4:]
+}
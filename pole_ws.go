@@ -0,0 +1,526 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ============ PoLE 节点 WebSocket 订阅 ============
+
+// JSON-RPC over WebSocket (RFC 6455 的最小客户端实现)，用于 eth_subscribe 订阅新区块 (newHeads)
+// 与合约日志 (logs)。订阅的通知经 Subscription.C 送达，连接断开时 C 被关闭，Err 返回断开原因
+
+// WebSocket 参数
+const (
+	wsDialTimeout    = 10 * time.Second
+	wsCallTimeout    = 10 * time.Second
+	wsMaxMessageSize = 16 << 20
+	wsSubBuffer      = 256 // 订阅通知的缓冲，消费不及时时丢弃新的通知
+	wsMaxEarly       = 16  // 暂存登记前通知的订阅数上限
+	wsGUID           = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+// WebSocket 帧类型
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xA
+)
+
+// PoleWS PoLE 节点的 WebSocket JSON-RPC 连接
+type PoleWS struct {
+	URL string
+
+	conn net.Conn
+	br   *bufio.Reader
+	wmu  sync.Mutex // 写帧
+
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]chan wsReply
+	subs    map[string]*Subscription
+	early   map[string][]json.RawMessage // 订阅登记前到达的通知
+	err     error
+	done    chan struct{}
+}
+
+type wsReply struct {
+	result json.RawMessage
+	err    error
+}
+
+// Subscription eth_subscribe 订阅
+type Subscription struct {
+	ID      string
+	C       <-chan json.RawMessage // 通知内容 (params.result)
+	c       chan json.RawMessage
+	ws      *PoleWS
+	dropped int // 缓冲已满而丢弃的通知数
+}
+
+// WSURL 由节点的 HTTP 地址推出 WebSocket 地址 (http→ws，https→wss)
+func WSURL(nodeURL string) string {
+	switch {
+	case strings.HasPrefix(nodeURL, "https://"):
+		return "wss://" + strings.TrimPrefix(nodeURL, "https://")
+	case strings.HasPrefix(nodeURL, "http://"):
+		return "ws://" + strings.TrimPrefix(nodeURL, "http://")
+	}
+	return nodeURL
+}
+
+// DialPoleWS 连接节点的 WebSocket 接口 (ws:// 或 wss://)
+func DialPoleWS(rawURL string) (*PoleWS, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("WebSocket 地址无效: %w", err)
+	}
+	host := u.Host
+	var conn net.Conn
+	dialer := &net.Dialer{Timeout: wsDialTimeout}
+	switch u.Scheme {
+	case "ws":
+		if u.Port() == "" {
+			host += ":80"
+		}
+		conn, err = dialer.Dial("tcp", host)
+	case "wss":
+		if u.Port() == "" {
+			host += ":443"
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, &tls.Config{ServerName: u.Hostname()})
+	default:
+		return nil, fmt.Errorf("WebSocket 地址需以 ws:// 或 wss:// 开头: %s", rawURL)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("连接失败: %w", err)
+	}
+
+	ws := &PoleWS{URL: rawURL, conn: conn, br: bufio.NewReader(conn),
+		pending: map[int]chan wsReply{}, subs: map[string]*Subscription{}, early: map[string][]json.RawMessage{},
+		done: make(chan struct{})}
+	conn.SetDeadline(time.Now().Add(wsDialTimeout))
+	if err := ws.handshake(u); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	go ws.readLoop()
+	return ws, nil
+}
+
+// handshake WebSocket 握手 (HTTP Upgrade)
+func (ws *PoleWS) handshake(u *url.URL) error {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	path := u.RequestURI()
+	req := &http.Request{Method: http.MethodGet, URL: &url.URL{Opaque: path}, Host: u.Host,
+		Header: http.Header{}, Proto: "HTTP/1.1", ProtoMajor: 1, ProtoMinor: 1}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if err := req.Write(ws.conn); err != nil {
+		return fmt.Errorf("握手失败: %w", err)
+	}
+
+	resp, err := http.ReadResponse(ws.br, req)
+	if err != nil {
+		return fmt.Errorf("握手失败: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return fmt.Errorf("节点不支持 WebSocket (HTTP %d)", resp.StatusCode)
+	}
+	sum := sha1.Sum([]byte(key + wsGUID))
+	if resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		return fmt.Errorf("握手失败: Sec-WebSocket-Accept 不匹配")
+	}
+	return nil
+}
+
+// Call 发送 JSON-RPC 请求并等待结果
+func (ws *PoleWS) Call(method string, params []interface{}, result interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	ch := make(chan wsReply, 1)
+	ws.mu.Lock()
+	if ws.err != nil {
+		err := ws.err
+		ws.mu.Unlock()
+		return err
+	}
+	ws.nextID++
+	id := ws.nextID
+	ws.pending[id] = ch
+	ws.mu.Unlock()
+
+	msg, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	if err := ws.writeFrame(wsText, msg); err != nil {
+		ws.fail(err)
+		return err
+	}
+
+	select {
+	case reply := <-ch:
+		if reply.err != nil {
+			return reply.err
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(reply.result, result)
+	case <-time.After(wsCallTimeout):
+		ws.mu.Lock()
+		delete(ws.pending, id)
+		ws.mu.Unlock()
+		return fmt.Errorf("%s 超时", method)
+	}
+}
+
+// Subscribe eth_subscribe 订阅 (kind 为 newHeads、logs 等)
+func (ws *PoleWS) Subscribe(kind string, args ...interface{}) (*Subscription, error) {
+	var id string
+	if err := ws.Call("eth_subscribe", append([]interface{}{kind}, args...), &id); err != nil {
+		return nil, fmt.Errorf("订阅 %s 失败: %w", kind, err)
+	}
+	c := make(chan json.RawMessage, wsSubBuffer)
+	sub := &Subscription{ID: id, C: c, c: c, ws: ws}
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.err != nil {
+		return nil, ws.err
+	}
+	ws.subs[id] = sub
+	for _, msg := range ws.early[id] {
+		c <- msg
+	}
+	delete(ws.early, id)
+	return sub, nil
+}
+
+// Dropped 缓冲已满而丢弃的通知数
+func (s *Subscription) Dropped() int {
+	s.ws.mu.Lock()
+	defer s.ws.mu.Unlock()
+	return s.dropped
+}
+
+// Unsubscribe 取消订阅
+func (s *Subscription) Unsubscribe() error {
+	s.ws.mu.Lock()
+	_, ok := s.ws.subs[s.ID]
+	delete(s.ws.subs, s.ID)
+	s.ws.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	close(s.c)
+	var okResult bool
+	return s.ws.Call("eth_unsubscribe", []interface{}{s.ID}, &okResult)
+}
+
+// Done 连接断开时关闭
+func (ws *PoleWS) Done() <-chan struct{} {
+	return ws.done
+}
+
+// Err 连接断开的原因
+func (ws *PoleWS) Err() error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return ws.err
+}
+
+// Close 关闭连接
+func (ws *PoleWS) Close() error {
+	ws.writeFrame(wsClose, []byte{0x03, 0xE8}) // 1000: 正常关闭
+	ws.fail(errors.New("连接已关闭"))
+	return nil
+}
+
+// fail 断开连接: 未完成的请求返回 err，订阅的通道被关闭
+func (ws *PoleWS) fail(err error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.err != nil {
+		return
+	}
+	ws.err = err
+	ws.conn.Close()
+	for id, ch := range ws.pending {
+		ch <- wsReply{err: err}
+		delete(ws.pending, id)
+	}
+	for id, sub := range ws.subs {
+		close(sub.c)
+		delete(ws.subs, id)
+	}
+	close(ws.done)
+}
+
+// readLoop 读取消息并分发到请求与订阅
+func (ws *PoleWS) readLoop() {
+	for {
+		msg, err := ws.readMessage()
+		if err != nil {
+			ws.fail(fmt.Errorf("WebSocket 连接断开: %w", err))
+			return
+		}
+		var m struct {
+			ID     *int            `json:"id"`
+			Result json.RawMessage `json:"result"`
+			Error  *rpcError       `json:"error"`
+			Method string          `json:"method"`
+			Params struct {
+				Subscription string          `json:"subscription"`
+				Result       json.RawMessage `json:"result"`
+			} `json:"params"`
+		}
+		if json.Unmarshal(msg, &m) != nil {
+			continue
+		}
+
+		ws.mu.Lock()
+		switch {
+		case m.ID != nil:
+			if ch, ok := ws.pending[*m.ID]; ok {
+				delete(ws.pending, *m.ID)
+				reply := wsReply{result: m.Result}
+				if m.Error != nil {
+					reply.err = m.Error
+				}
+				ch <- reply
+			}
+		case m.Method == "eth_subscription":
+			id := m.Params.Subscription
+			if sub, ok := ws.subs[id]; ok {
+				select {
+				case sub.c <- m.Params.Result:
+				default:
+					sub.dropped++
+				}
+			} else if len(ws.early) < wsMaxEarly && len(ws.early[id]) < wsSubBuffer {
+				// 订阅的结果与第一批通知可能先后到达，登记前暂存
+				ws.early[id] = append(ws.early[id], m.Params.Result)
+			}
+		}
+		ws.mu.Unlock()
+	}
+}
+
+// readMessage 读取一条完整消息 (合并分片，自动回复 ping)
+func (ws *PoleWS) readMessage() ([]byte, error) {
+	var msg []byte
+	for {
+		fin, opcode, payload, err := ws.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch opcode {
+		case wsPing:
+			ws.writeFrame(wsPong, payload)
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			ws.writeFrame(wsClose, payload)
+			return nil, io.EOF
+		case wsText, wsBinary, wsContinuation:
+			msg = append(msg, payload...)
+			if len(msg) > wsMaxMessageSize {
+				return nil, fmt.Errorf("消息超过 %d 字节", wsMaxMessageSize)
+			}
+			if fin {
+				return msg, nil
+			}
+		}
+	}
+}
+
+func (ws *PoleWS) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(ws.br, head[:]); err != nil {
+		return
+	}
+	fin, opcode = head[0]&0x80 != 0, head[0]&0x0F
+	masked := head[1]&0x80 != 0
+	n := uint64(head[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(ws.br, ext[:]); err != nil {
+			return
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(ws.br, ext[:]); err != nil {
+			return
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > wsMaxMessageSize {
+		err = fmt.Errorf("帧超过 %d 字节", wsMaxMessageSize)
+		return
+	}
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(ws.br, mask[:]); err != nil {
+			return
+		}
+	}
+	payload = make([]byte, n)
+	if _, err = io.ReadFull(ws.br, payload); err != nil {
+		return
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return
+}
+
+// writeFrame 写一个帧 (客户端的帧需掩码)
+func (ws *PoleWS) writeFrame(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 0x80|126, byte(n>>8), byte(n))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	var mask [4]byte
+	rand.Read(mask[:])
+	frame = append(frame, mask[:]...)
+	start := len(frame)
+	frame = append(frame, payload...)
+	for i := range payload {
+		frame[start+i] ^= mask[i%4]
+	}
+
+	ws.wmu.Lock()
+	defer ws.wmu.Unlock()
+	ws.conn.SetWriteDeadline(time.Now().Add(wsCallTimeout))
+	_, err := ws.conn.Write(frame)
+	return err
+}
+
+// ============ 订阅内容 ============
+
+// Head newHeads 通知的区块头
+type Head struct {
+	Number     *big.Int
+	Hash       common.Hash
+	ParentHash common.Hash
+	Timestamp  uint64
+}
+
+// UnmarshalJSON 解析区块头 (数量为十六进制字符串)
+func (h *Head) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Number     string      `json:"number"`
+		Hash       common.Hash `json:"hash"`
+		ParentHash common.Hash `json:"parentHash"`
+		Timestamp  string      `json:"timestamp"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	number, ok := parseQuantity(raw.Number)
+	if !ok {
+		return fmt.Errorf("区块号无效: %q", raw.Number)
+	}
+	h.Number, h.Hash, h.ParentHash = number, raw.Hash, raw.ParentHash
+	if ts, ok := parseQuantity(raw.Timestamp); ok {
+		h.Timestamp = ts.Uint64()
+	}
+	return nil
+}
+
+// Log 合约日志 (logs 订阅与 eth_getLogs)
+type Log struct {
+	Address     common.Address `json:"address"`
+	Topics      []common.Hash  `json:"topics"`
+	Data        hexutil.Bytes  `json:"data"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	LogIndex    hexutil.Uint   `json:"logIndex"`
+	Removed     bool           `json:"removed"` // 区块重组后被移除
+}
+
+// SubscribeNewHeads 订阅新区块
+func (ws *PoleWS) SubscribeNewHeads() (*Subscription, error) {
+	return ws.Subscribe("newHeads")
+}
+
+// SubscribeLogs 订阅合约日志 (topics 的每一项为该位置可匹配的 topic，空项匹配任意值)
+func (ws *PoleWS) SubscribeLogs(address common.Address, topics [][]common.Hash) (*Subscription, error) {
+	filter := map[string]interface{}{"address": address.Hex()}
+	if len(topics) > 0 {
+		filter["topics"] = topicFilter(topics)
+	}
+	return ws.Subscribe("logs", filter)
+}
+
+// GetLogs eth_getLogs 查询区块 [from, to] 中合约的日志 (topics 同 SubscribeLogs)
+func (p *PoleRPC) GetLogs(address common.Address, topics [][]common.Hash, from, to uint64) ([]Log, error) {
+	filter := map[string]interface{}{
+		"address":   address.Hex(),
+		"fromBlock": hexutil.EncodeUint64(from),
+		"toBlock":   hexutil.EncodeUint64(to),
+	}
+	if len(topics) > 0 {
+		filter["topics"] = topicFilter(topics)
+	}
+	var logs []Log
+	if err := p.call("eth_getLogs", []interface{}{filter}, &logs); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// topicFilter 过滤条件中的 topics (单个值不用数组，空项为 null)
+func topicFilter(topics [][]common.Hash) []interface{} {
+	out := make([]interface{}, len(topics))
+	for i, alts := range topics {
+		switch len(alts) {
+		case 0:
+			out[i] = nil
+		case 1:
+			out[i] = alts[0]
+		default:
+			out[i] = alts
+		}
+	}
+	return out
+}
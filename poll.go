@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	integrator "oaw/integrator"
+	worktracker "oaw/tracker"
+)
+
+// pollInstances 校验实例配置并补全默认值，实例名称不能重复
+func pollInstances(list []integrator.Instance) ([]integrator.Instance, error) {
+	if len(list) == 0 {
+		list = []integrator.Instance{{}}
+	}
+	seen := map[string]bool{}
+	out := make([]integrator.Instance, 0, len(list))
+	for _, inst := range list {
+		inst, err := inst.Normalize()
+		if err != nil {
+			return nil, err
+		}
+		if seen[inst.Name] {
+			return nil, fmt.Errorf("OpenClaw 实例名称重复: %s (请在 openclaw_instances 中设置 name)", inst.Name)
+		}
+		seen[inst.Name] = true
+		out = append(out, inst)
+	}
+	return out, nil
+}
+
+// parseSources 解析 --source: openclaw、claude-code[=目录]、openai-usage=<导出文件>
+func parseSources(sources []string) (withOpenClaw bool, adapters []integrator.SourceAdapter, push string, err error) {
+	for _, s := range sources {
+		kind, arg, _ := strings.Cut(s, "=")
+		switch kind {
+		case "openclaw":
+			withOpenClaw = true
+		case "claude-code":
+			adapters = append(adapters, integrator.NewClaudeCodeAdapter(arg))
+		case "openai-usage":
+			if arg == "" {
+				return false, nil, "", fmt.Errorf("openai-usage 需指定导出文件: --source openai-usage=<文件>")
+			}
+			adapters = append(adapters, integrator.NewOpenAIUsageAdapter(arg))
+		case "push":
+			push = arg
+			if push == "" {
+				push = integrator.DefaultIngestAddr
+			}
+		default:
+			return false, nil, "", fmt.Errorf("未知的来源: %s (可选: openclaw、claude-code[=目录]、openai-usage=<文件>、push[=监听地址])", s)
+		}
+	}
+	return withOpenClaw, adapters, push, nil
+}
+
+// pollOptions oaw poll / oaw watch 的轮询参数
+type pollOptions struct {
+	instances []integrator.Instance
+	adapters  []integrator.SourceAdapter
+	interval  time.Duration // 其他来源的轮询间隔
+	push      string        // 接收推送事件的监听地址 (为空表示不接收)，见 integrator.IngestPath
+	workspace string        // 其他来源与推送事件的工作区 git 仓库，见 integrator.GitWindow
+
+	minInterval, maxInterval time.Duration // 其他来源的自适应轮询间隔范围 (均为 0 时固定间隔)，见 integrator.SetAdaptive
+}
+
+// describe 来源概要 (面板标题)
+func (o *pollOptions) describe() string {
+	var names []string
+	for _, inst := range o.instances {
+		names = append(names, inst.Name)
+	}
+	for _, a := range o.adapters {
+		names = append(names, a.Name())
+	}
+	if o.push != "" {
+		names = append(names, "push:"+o.push)
+	}
+	return strings.Join(names, ", ")
+}
+
+// addPollFlags 注册轮询参数
+func addPollFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("url", nil, "OpenClaw 地址 (可重复，默认 config.json 的 openclaw_instances 或 http://localhost:18789)")
+	cmd.Flags().String("stats-path", integrator.DefaultStatsPath, "会话统计接口路径")
+	cmd.Flags().String("interval", integrator.DefaultPollInterval.String(), "轮询间隔")
+	cmd.Flags().Bool("adaptive", false, "自适应轮询: 会话活跃时缩短间隔，空闲时逐次加倍 (默认范围为间隔的 1/6 ~ 4 倍)")
+	cmd.Flags().String("min-interval", "", "自适应轮询的最短间隔 (隐含 --adaptive)")
+	cmd.Flags().String("max-interval", "", "自适应轮询的最长间隔 (隐含 --adaptive)")
+	cmd.Flags().String("workspace", "", "工作区 git 仓库: 按会话窗口内的提交统计代码行并记录提交 SHA (实例可在 openclaw_instances 的 workspace 中单独配置)")
+	cmd.Flags().StringSlice("source", []string{"openclaw"}, "工作来源 (可重复): openclaw、claude-code[=会话目录]、openai-usage=<用量导出 .json/.csv>、push[=监听地址] (接收 OpenClaw 钩子推送的事件)")
+}
+
+// pollOptionsFromFlags 由参数与 config.json 的 openclaw_instances 确定轮询的实例与来源
+func pollOptionsFromFlags(cmd *cobra.Command) (*pollOptions, error) {
+	cfg, err := LoadConfig(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	list := cfg.OpenClawInstances
+	if urls, _ := cmd.Flags().GetStringSlice("url"); len(urls) > 0 {
+		list = nil
+		for _, u := range urls {
+			list = append(list, integrator.Instance{URL: u})
+		}
+	}
+	for i := range list {
+		if cmd.Flags().Changed("stats-path") {
+			list[i].Stats, _ = cmd.Flags().GetString("stats-path")
+		}
+		if cmd.Flags().Changed("interval") {
+			list[i].Interval, _ = cmd.Flags().GetString("interval")
+		}
+	}
+	if len(list) == 0 {
+		list = []integrator.Instance{{}}
+		list[0].Stats, _ = cmd.Flags().GetString("stats-path")
+		list[0].Interval, _ = cmd.Flags().GetString("interval")
+	}
+
+	opts := &pollOptions{interval: integrator.DefaultPollInterval}
+	if opts.instances, err = pollInstances(list); err != nil {
+		return nil, err
+	}
+	sources, _ := cmd.Flags().GetStringSlice("source")
+	withOpenClaw, adapters, push, err := parseSources(sources)
+	if err != nil {
+		return nil, err
+	}
+	opts.push = push
+	if ws, _ := cmd.Flags().GetString("workspace"); ws != "" {
+		opts.workspace = ws
+		for i := range opts.instances {
+			if opts.instances[i].Workspace == "" {
+				opts.instances[i].Workspace = ws
+			}
+		}
+	}
+	if !withOpenClaw {
+		opts.instances = nil
+	}
+	opts.adapters = adapters
+	if s, _ := cmd.Flags().GetString("interval"); s != "" {
+		if opts.interval, err = time.ParseDuration(s); err != nil || opts.interval <= 0 {
+			return nil, fmt.Errorf("无效的轮询间隔: %s", s)
+		}
+	}
+	if err := applyAdaptiveFlags(cmd, opts); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// applyAdaptiveFlags 按 --adaptive / --min-interval / --max-interval 设置各实例与其他来源的自适应轮询范围，
+// 未指定的一端取默认范围 (见 integrator.DefaultAdaptiveRange)；实例在 openclaw_instances 中的配置优先
+func applyAdaptiveFlags(cmd *cobra.Command, opts *pollOptions) error {
+	adaptive, _ := cmd.Flags().GetBool("adaptive")
+	minStr, _ := cmd.Flags().GetString("min-interval")
+	maxStr, _ := cmd.Flags().GetString("max-interval")
+	if !adaptive && minStr == "" && maxStr == "" {
+		return nil
+	}
+	parse := func(s string, def time.Duration) (time.Duration, error) {
+		if s == "" {
+			return def, nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return 0, fmt.Errorf("无效的轮询间隔: %s", s)
+		}
+		return d, nil
+	}
+	rangeFor := func(interval time.Duration) (min, max time.Duration, err error) {
+		defMin, defMax := integrator.DefaultAdaptiveRange(interval)
+		if min, err = parse(minStr, defMin); err != nil {
+			return 0, 0, err
+		}
+		if max, err = parse(maxStr, defMax); err != nil {
+			return 0, 0, err
+		}
+		if min > max {
+			return 0, 0, fmt.Errorf("自适应轮询间隔范围无效: %s ~ %s", min, max)
+		}
+		return min, max, nil
+	}
+
+	var err error
+	if opts.minInterval, opts.maxInterval, err = rangeFor(opts.interval); err != nil {
+		return err
+	}
+	for i := range opts.instances {
+		inst := &opts.instances[i]
+		if inst.MinInterval != "" || inst.MaxInterval != "" {
+			continue
+		}
+		interval, _ := inst.PollInterval()
+		min, max, err := rangeFor(interval)
+		if err != nil {
+			return err
+		}
+		inst.MinInterval, inst.MaxInterval = min.String(), max.String()
+	}
+	return nil
+}
+
+// openPollTracker 打开追踪器并设置签名钱包
+func openPollTracker() (*worktracker.Tracker, error) {
+	tracker, err := worktracker.NewTracker(filepath.Join(dataDir, "tracker"))
+	if err != nil {
+		return nil, fmt.Errorf("打开工作记录失败: %w", err)
+	}
+	if err := setTrackerSigners(tracker); err != nil {
+		return nil, err
+	}
+	return tracker, nil
+}
+
+// runPoll 同时轮询多个 OpenClaw 实例与其他来源的适配器直到 ctx 取消。单实例时状态与游标写入数据目录，
+// 多实例时写入 instances/<名称>/；其他来源的读取位置写入 instances/sources/，推送事件的日志写入 instances/push/
+func runPoll(ctx context.Context, tracker *worktracker.Tracker, opts *pollOptions) error {
+	instances, adapters, interval := opts.instances, opts.adapters, opts.interval
+	var running []*integrator.OpenClawIntegrator
+	defer func() {
+		for _, ig := range running {
+			ig.Stop()
+			if q := ig.QueueStats(); q.Overflows > 0 || q.Dropped > 0 {
+				fmt.Printf("📊 事件队列: 处理 %d，队列满 %d 次，暂存 %d (待处理 %d)，丢弃 %d\n",
+					q.Processed, q.Overflows, q.SpilledTotal, q.Spilled, q.Dropped)
+			}
+		}
+	}()
+
+	if len(adapters) > 0 {
+		dir := filepath.Join(dataDir, "instances", "sources")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		ig := integrator.NewOpenClawIntegrator(tracker, "")
+		if err := ig.SetDataDir(dir); err != nil {
+			return err
+		}
+		ig.SetWorkspace(opts.workspace)
+		if err := ig.SetAdaptive(opts.minInterval, opts.maxInterval); err != nil {
+			return err
+		}
+		ig.StartListener("")
+		for _, a := range adapters {
+			ig.StartAdapter(a, interval)
+			fmt.Printf("  %s (%s)\n", a.Name(), describeInterval(interval, opts.minInterval, opts.maxInterval))
+		}
+		running = append(running, ig)
+	}
+
+	if opts.push != "" {
+		dir := filepath.Join(dataDir, "instances", "push")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		ig := integrator.NewOpenClawIntegrator(tracker, "")
+		if err := ig.SetDataDir(dir); err != nil {
+			return err
+		}
+		ig.SetWorkspace(opts.workspace)
+		ig.StartListener("")
+		running = append(running, ig)
+
+		api := integrator.NewAPIServer(tracker, opts.push)
+		api.ProofsDir = filepath.Join(dataDir, "proofs")
+		api.Ingest = ig
+		api.IngestToken = ingestToken()
+		api.Start()
+		auth := "仅本机"
+		if api.IngestToken != "" {
+			auth = "需令牌"
+		}
+		fmt.Printf("  push: http://%s%s (%s)\n", opts.push, integrator.IngestPath, auth)
+	}
+
+	for _, inst := range instances {
+		dir := dataDir
+		if len(instances) > 1 {
+			dir = filepath.Join(dataDir, "instances", inst.Name)
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+		}
+		interval, _ := inst.PollInterval()
+		ig := integrator.NewInstanceIntegrator(tracker, inst)
+		if err := ig.SetDataDir(dir); err != nil {
+			return fmt.Errorf("读取实例 %s 的轮询游标失败: %w", inst.Name, err)
+		}
+		ig.StartListener(inst.LogFile)
+		ig.StartPolling(interval)
+		running = append(running, ig)
+		min, max, _ := inst.AdaptiveRange()
+		fmt.Printf("  %s: %s (%s，Agent %s)\n", inst.Name, inst.StatsURL(), describeInterval(interval, min, max), inst.AgentID)
+	}
+
+	<-ctx.Done()
+	return nil
+}
+
+// describeInterval 轮询间隔说明
+func describeInterval(interval, min, max time.Duration) string {
+	if min == 0 && max == 0 {
+		return "每 " + interval.String()
+	}
+	return fmt.Sprintf("自适应 %s ~ %s", min, max)
+}
+
+// ingestToken 推送令牌: $OAW_INGEST_TOKEN 优先，其次 config.json 的 ingest_token
+func ingestToken() string {
+	if token := os.Getenv(integrator.IngestTokenEnv); token != "" {
+		return token
+	}
+	if cfg, err := LoadConfig(dataDir); err == nil {
+		return cfg.IngestToken
+	}
+	return ""
+}
+
+// journalPaths 数据目录中的事件日志 (单实例写入数据目录，多实例与其他来源写入 instances/ 下)
+func journalPaths() []string {
+	var paths []string
+	if _, err := os.Stat(filepath.Join(dataDir, integrator.EventJournalFile)); err == nil {
+		paths = append(paths, filepath.Join(dataDir, integrator.EventJournalFile))
+	}
+	matches, _ := filepath.Glob(filepath.Join(dataDir, "instances", "*", integrator.EventJournalFile))
+	return append(paths, matches...)
+}
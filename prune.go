@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultPruneKeep 裁剪时默认保留完整数据的区块数
+const DefaultPruneKeep = 1000
+
+// ChainSnapshot 裁剪快照：记录已裁剪区块的奖励余额
+type ChainSnapshot struct {
+	Height    int                `json:"height"`   // 高度小于该值的区块已裁剪
+	TipHash   string             `json:"tip_hash"` // 最后一个已裁剪区块的哈希
+	Balances  map[string]float64 `json:"balances"` // 已裁剪区块的矿工奖励
+	Ledger    map[string]float64 `json:"ledger"`   // 已裁剪区块内交易的转账净额
+	Nonces    map[string]uint64  `json:"nonces"`   // 已裁剪区块内交易推进后的账户 nonce
+	CreatedAt int64              `json:"created_at"`
+}
+
+// loadSnapshot 读取裁剪快照，不存在时返回空快照
+func loadSnapshot(dir string) *ChainSnapshot {
+	s := &ChainSnapshot{}
+	data, err := os.ReadFile(filepath.Join(dir, "snapshot.json"))
+	if err == nil {
+		json.Unmarshal(data, s)
+	}
+	if s.Balances == nil {
+		s.Balances = map[string]float64{}
+	}
+	if s.Ledger == nil {
+		s.Ledger = map[string]float64{}
+	}
+	if s.Nonces == nil {
+		s.Nonces = map[string]uint64{}
+	}
+	return s
+}
+
+// covers 区块的奖励与交易是否已计入快照 (余额统计跳过这些区块)
+func (s *ChainSnapshot) covers(b Block) bool {
+	return s != nil && b.Index < s.Height
+}
+
+func (s *ChainSnapshot) save(dir string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, "snapshot.json"), data)
+}
+
+// writeFileAtomic 先写临时文件再重命名，中途失败不会留下不完整的文件
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Prune 裁剪旧区块体：保留最近 keep 个完整区块，
+// 更早的区块只保留区块头 (版本、高度、时间、出块凭证与难度、前序哈希、矿工与奖励、哈希)，奖励计入余额快照
+// (区块头中的矿工与奖励供重算权益，见 chainStake)。
+// 余额统计跳过快照高度以下的区块，快照写入后、区块写入前中断时仍保存着完整区块也不会重复计入
+func (m *Miner) Prune(keep int) (int, error) {
+	if keep < DefaultConfirmations {
+		return 0, fmt.Errorf("保留区块数不能少于确认数 %d", DefaultConfirmations)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.snapshot == nil {
+		m.snapshot = loadSnapshot(m.dataDir)
+	}
+
+	end := len(m.blocks) - keep
+	if end <= m.snapshot.Height {
+		return 0, nil
+	}
+
+	pruned := 0
+	for i := m.snapshot.Height; i < end; i++ {
+		b := &m.blocks[i]
+		if payee := b.Payee(); payee != "" {
+			m.snapshot.Balances[payee] += b.Value
+		}
+		for _, tx := range b.Transactions {
+			m.snapshot.Ledger[tx.From] -= tx.Amount
+			m.snapshot.Ledger[tx.To] += tx.Amount
+			m.snapshot.Nonces[tx.From] = tx.Nonce + 1
+		}
+		m.blocks[i] = Block{
+			Version:    b.Version,
+			Index:      b.Index,
+			Timestamp:  b.Timestamp,
+			WorkProof:  b.WorkProof,
+			Nonce:      b.Nonce,
+			Difficulty: b.Difficulty,
+			Previous:   b.Previous,
+			Miner:      b.Miner,
+			Value:      b.Value,
+			Hash:       b.Hash,
+		}
+		pruned++
+	}
+	m.snapshot.Height = end
+	m.snapshot.TipHash = m.blocks[end-1].Hash
+	m.snapshot.CreatedAt = time.Now().Unix()
+
+	// 先写快照再写区块: 中途失败时区块仍完整，快照高度以下的区块不再计入余额，奖励不会丢失也不会重复
+	if err := m.snapshot.save(m.dataDir); err != nil {
+		return 0, fmt.Errorf("保存快照失败: %w", err)
+	}
+	if err := m.writeBlocks(); err != nil {
+		return 0, fmt.Errorf("保存区块失败: %w", err)
+	}
+	return pruned, nil
+}
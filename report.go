@@ -0,0 +1,372 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	integrator "oaw/integrator"
+	"oaw/openclaw"
+	worktracker "oaw/tracker"
+)
+
+// ============ 会话工作摘要 ============
+
+// sessionReport 一个会话的工作摘要 (oaw report session)，汇总同步记录、追踪器记录与事件日志
+type sessionReport struct {
+	SessionID    string         `json:"session_id"`
+	SubSessions  []string       `json:"sub_sessions,omitempty"` // 汇总在内的子 Agent 会话
+	Agents       []string       `json:"agents"`
+	Models       []string       `json:"models,omitempty"`
+	Start        time.Time      `json:"start"`
+	End          time.Time      `json:"end"`
+	Duration     string         `json:"duration"`
+	Records      int            `json:"records"`
+	TokensInput  int64          `json:"tokens_input"`
+	TokensOutput int64          `json:"tokens_output"`
+	TokensCache  int64          `json:"tokens_cache,omitempty"`
+	TokensTotal  int64          `json:"tokens_total"`
+	CodeLines    int            `json:"code_lines,omitempty"`
+	Tools        map[string]int `json:"tools,omitempty"` // 工具名称 → 调用次数
+	Files        []string       `json:"files,omitempty"` // 写入或修改的文件 (来自事件日志)
+	Repo         string         `json:"repo,omitempty"`
+	Commits      []string       `json:"commits,omitempty"` // 归属于会话的 git 提交
+	Value        float64        `json:"value"`
+	CostUSD      float64        `json:"cost_usd,omitempty"`
+	Proofs       []reportProof  `json:"proofs"`
+}
+
+// reportProof 摘要中的一条记录及其工作证明
+type reportProof struct {
+	Source    string    `json:"source"` // sync (oaw sync) 或 tracker (集成器)
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	ProofHash string    `json:"proof_hash"`
+	Signer    string    `json:"signer,omitempty"`
+}
+
+// buildSessionReport 汇总会话的工作: id 为会话 ID (非默认 Agent 可带 "Agent/" 前缀)，
+// 其派生的子 Agent 会话 (含多级) 一并汇总
+func buildSessionReport(tracker *worktracker.Tracker, id string) (*sessionReport, error) {
+	rep := &sessionReport{SessionID: id, Tools: map[string]int{}}
+	agents, models, files := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	span := func(at time.Time) {
+		if at.IsZero() {
+			return
+		}
+		if rep.Start.IsZero() || at.Before(rep.Start) {
+			rep.Start = at
+		}
+		if at.After(rep.End) {
+			rep.End = at
+		}
+	}
+
+	// oaw sync 写入的会话记录，sessions 为会话及其子 Agent 会话
+	records, _ := openclaw.LoadRecords(filepath.Join(dataDir, "records"))
+	sessions := map[string]bool{id: true}
+	for grown := true; grown; {
+		grown = false
+		for _, r := range records {
+			if r.ParentSession != "" && sessions[r.ParentSession] && !sessions[r.SessionID] {
+				sessions[r.SessionID] = true
+				grown = true
+			}
+		}
+	}
+	for _, r := range records {
+		if !sessions[r.SessionID] && r.Agent+"/"+r.SessionID != id {
+			continue
+		}
+		rep.Records++
+		agents[r.AgentID] = true
+		if r.Model != "" {
+			models[r.Model] = true
+		}
+		rep.TokensInput += int64(r.InputTokens)
+		rep.TokensOutput += int64(r.OutputTokens)
+		rep.TokensCache += int64(r.CacheTokens)
+		for _, tool := range r.Tools {
+			rep.Tools[tool]++
+		}
+		rep.Value += r.Value
+		if cost, ok := worktracker.EstimateCost(r.Model, int64(r.InputTokens), int64(r.OutputTokens)); ok {
+			rep.CostUSD += cost
+		}
+		span(r.Timestamp)
+		proof := reportProof{Source: "sync", ID: r.SessionID, Time: r.Timestamp, ProofHash: r.ProofHash, Signer: r.Signer}
+		if r.MessageID != "" {
+			proof.ID = r.MessageID
+		}
+		rep.Proofs = append(rep.Proofs, proof)
+	}
+
+	// 集成器写入追踪器的记录 (只取最新修正版本)，子 Agent 会话的记录是父会话记录的子任务
+	latest := tracker.Query(worktracker.RecordFilter{Status: "completed", Latest: true})
+	included := map[string]bool{}
+	for grown := true; grown; {
+		grown = false
+		for _, r := range latest {
+			sid, isSession := strings.CutPrefix(r.TaskDesc, "Session: ")
+			if !included[r.ID] && (isSession && sessions[sid] || included[r.ParentID]) {
+				included[r.ID] = true
+				if isSession {
+					sessions[sid] = true
+				}
+				grown = true
+			}
+		}
+	}
+	for _, r := range latest {
+		if !included[r.ID] {
+			continue
+		}
+		rep.Records++
+		agents[r.AgentID] = true
+		if r.Model != "" {
+			models[r.Model] = true
+		}
+		rep.TokensInput += r.TokensInput
+		rep.TokensOutput += r.TokensOutput
+		rep.TokensCache += r.TokensCache
+		rep.CodeLines += r.CodeLines
+		rep.Commits = append(rep.Commits, r.Commits...)
+		if r.Repo != "" {
+			rep.Repo = r.Repo
+		}
+		rep.Value += r.CalculateValue()
+		rep.CostUSD += r.CostUSD
+		span(time.UnixMilli(r.StartedAt))
+		span(time.UnixMilli(r.CompletedAt))
+		rep.Proofs = append(rep.Proofs, reportProof{Source: "tracker", ID: r.ID, Time: time.UnixMilli(r.CompletedAt), ProofHash: r.ProofHash, Signer: r.Signer})
+	}
+
+	// 事件日志中的工具调用与修改的文件
+	for _, path := range journalPaths() {
+		integrator.ReadJournal(path, func(entry integrator.JournalEntry) error {
+			if !sessions[entry.Event.SessionID] {
+				return nil
+			}
+			if entry.RecordID != "" { // 追踪器记录不保存工具调用，按生成记录的事件统计
+				for _, tool := range entry.Event.Tools {
+					if tool != nil {
+						rep.Tools[tool.Name]++
+					}
+				}
+			}
+			for _, f := range integrator.TouchedFiles(entry.Event.Tools) {
+				files[f] = true
+			}
+			return nil
+		})
+	}
+
+	if rep.Records == 0 {
+		return nil, fmt.Errorf("没有会话 %s 的工作记录", id)
+	}
+	for sid := range sessions {
+		if sid != id {
+			rep.SubSessions = append(rep.SubSessions, sid)
+		}
+	}
+	sort.Strings(rep.SubSessions)
+	rep.TokensTotal = rep.TokensInput + rep.TokensOutput + rep.TokensCache
+	rep.Duration = rep.End.Sub(rep.Start).Round(time.Second).String()
+	rep.Agents, rep.Models, rep.Files = sortedKeys(agents), sortedKeys(models), sortedKeys(files)
+	sort.Slice(rep.Proofs, func(i, j int) bool { return rep.Proofs[i].Time.Before(rep.Proofs[j].Time) })
+	return rep, nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeMarkdown 输出 Markdown 格式的摘要 (可附在 PR 或发票中)
+func (rep *sessionReport) writeMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "## 工作摘要: 会话 `%s`\n\n", rep.SessionID)
+	fmt.Fprintf(w, "| 项目 | 数值 |\n|---|---|\n")
+	fmt.Fprintf(w, "| Agent | %s |\n", strings.Join(rep.Agents, ", "))
+	if len(rep.SubSessions) > 0 {
+		fmt.Fprintf(w, "| 子 Agent 会话 | %s |\n", strings.Join(rep.SubSessions, ", "))
+	}
+	if len(rep.Models) > 0 {
+		fmt.Fprintf(w, "| 模型 | %s |\n", strings.Join(rep.Models, ", "))
+	}
+	fmt.Fprintf(w, "| 时间 | %s ~ %s (%s) |\n", rep.Start.Format("2006-01-02 15:04:05"), rep.End.Format("2006-01-02 15:04:05"), rep.Duration)
+	fmt.Fprintf(w, "| 记录数 | %d |\n", rep.Records)
+	fmt.Fprintf(w, "| Token | %d (输入 %d / 输出 %d / 缓存 %d) |\n", rep.TokensTotal, rep.TokensInput, rep.TokensOutput, rep.TokensCache)
+	if rep.CodeLines > 0 {
+		fmt.Fprintf(w, "| 代码行 | %d |\n", rep.CodeLines)
+	}
+	fmt.Fprintf(w, "| 价值 | %.4f OAW |\n", rep.Value)
+	if rep.CostUSD > 0 {
+		fmt.Fprintf(w, "| 估算成本 | $%.4f |\n", rep.CostUSD)
+	}
+
+	if len(rep.Tools) > 0 {
+		names := make([]string, 0, len(rep.Tools))
+		for name := range rep.Tools {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if rep.Tools[names[i]] != rep.Tools[names[j]] {
+				return rep.Tools[names[i]] > rep.Tools[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		fmt.Fprintf(w, "\n### 工具调用\n\n")
+		for _, name := range names {
+			fmt.Fprintf(w, "- `%s` × %d\n", name, rep.Tools[name])
+		}
+	}
+	if len(rep.Commits) > 0 {
+		fmt.Fprintf(w, "\n### 提交\n\n")
+		if rep.Repo != "" {
+			fmt.Fprintf(w, "仓库: %s\n\n", rep.Repo)
+		}
+		for _, c := range rep.Commits {
+			fmt.Fprintf(w, "- `%s`\n", c)
+		}
+	}
+	if len(rep.Files) > 0 {
+		fmt.Fprintf(w, "\n### 修改的文件\n\n")
+		for _, f := range rep.Files {
+			fmt.Fprintf(w, "- `%s`\n", f)
+		}
+	}
+
+	fmt.Fprintf(w, "\n### 工作证明\n\n| 来源 | 记录 | 时间 | 工作证明 | 签名钱包 |\n|---|---|---|---|---|\n")
+	for _, p := range rep.Proofs {
+		proof := p.ProofHash
+		if proof == "" {
+			proof = "(未补全)"
+		}
+		fmt.Fprintf(w, "| %s | `%s` | %s | `%s` | %s |\n", p.Source, p.ID, p.Time.Format("2006-01-02 15:04:05"), proof, p.Signer)
+	}
+}
+
+// ============ 日/周工作摘要 ============
+
+// periodReport 统计时区中一个自然日或自然周的工作摘要 (oaw report day/week)
+type periodReport struct {
+	Period       worktracker.Period `json:"period"`
+	Timezone     string             `json:"timezone"`
+	Start        time.Time          `json:"start"`
+	End          time.Time          `json:"end"` // 不含
+	Records      int                `json:"records"`
+	TokensInput  int64              `json:"tokens_input"`
+	TokensOutput int64              `json:"tokens_output"`
+	TokensCache  int64              `json:"tokens_cache,omitempty"`
+	TokensTotal  int64              `json:"tokens_total"`
+	CodeLines    int                `json:"code_lines,omitempty"`
+	Value        float64            `json:"value"`
+	CostUSD      float64            `json:"cost_usd,omitempty"`
+	Agents       []periodAgent      `json:"agents"`
+	Sessions     []string           `json:"sessions,omitempty"`
+}
+
+// periodAgent 摘要中一个 Agent 的工作量
+type periodAgent struct {
+	AgentID string  `json:"agent_id"`
+	Records int     `json:"records"`
+	Tokens  int64   `json:"tokens"`
+	Value   float64 `json:"value"`
+	CostUSD float64 `json:"cost_usd,omitempty"`
+}
+
+// buildPeriodReport 汇总 at 所在周期 (按统计时区划分) 的同步记录与追踪器记录
+func buildPeriodReport(tracker *worktracker.Tracker, period worktracker.Period, at time.Time) *periodReport {
+	start, end := worktracker.PeriodBounds(period, at)
+	rep := &periodReport{Period: period, Timezone: worktracker.TimezoneName(), Start: start, End: end}
+	agents := map[string]*periodAgent{}
+	sessions := map[string]bool{}
+	add := func(agentID string, tokens int64, value, cost float64) {
+		a := agents[agentID]
+		if a == nil {
+			a = &periodAgent{AgentID: agentID}
+			agents[agentID] = a
+		}
+		a.Records++
+		a.Tokens += tokens
+		a.Value += value
+		a.CostUSD += cost
+		rep.Records++
+		rep.Value += value
+		rep.CostUSD += cost
+	}
+
+	records, _ := openclaw.LoadRecords(filepath.Join(dataDir, "records"))
+	for _, r := range records {
+		if r.Timestamp.Before(start) || !r.Timestamp.Before(end) {
+			continue
+		}
+		rep.TokensInput += int64(r.InputTokens)
+		rep.TokensOutput += int64(r.OutputTokens)
+		rep.TokensCache += int64(r.CacheTokens)
+		cost, _ := worktracker.EstimateCost(r.Model, int64(r.InputTokens), int64(r.OutputTokens))
+		add(r.AgentID, int64(r.InputTokens+r.OutputTokens+r.CacheTokens), r.Value, cost)
+		sessions[r.SessionID] = true
+	}
+
+	for _, r := range tracker.Query(worktracker.RecordFilter{Status: "completed", Latest: true, Since: start, Until: end}) {
+		rep.TokensInput += r.TokensInput
+		rep.TokensOutput += r.TokensOutput
+		rep.TokensCache += r.TokensCache
+		rep.CodeLines += r.CodeLines
+		add(r.AgentID, r.TokensInput+r.TokensOutput+r.TokensCache, r.CalculateValue(), r.CostUSD)
+		if sid, ok := strings.CutPrefix(r.TaskDesc, "Session: "); ok {
+			sessions[sid] = true
+		}
+	}
+
+	rep.TokensTotal = rep.TokensInput + rep.TokensOutput + rep.TokensCache
+	rep.Agents = []periodAgent{}
+	for _, a := range agents {
+		rep.Agents = append(rep.Agents, *a)
+	}
+	sort.Slice(rep.Agents, func(i, j int) bool {
+		if rep.Agents[i].Value != rep.Agents[j].Value {
+			return rep.Agents[i].Value > rep.Agents[j].Value
+		}
+		return rep.Agents[i].AgentID < rep.Agents[j].AgentID
+	})
+	rep.Sessions = sortedKeys(sessions)
+	return rep
+}
+
+// writeMarkdown 输出 Markdown 格式的日/周摘要
+func (rep *periodReport) writeMarkdown(w io.Writer) {
+	title := rep.Start.Format("2006-01-02")
+	if rep.Period != worktracker.PeriodDay {
+		title += " ~ " + rep.End.AddDate(0, 0, -1).Format("2006-01-02")
+	}
+	fmt.Fprintf(w, "## 工作摘要: %s (%s)\n\n", title, rep.Timezone)
+	if rep.Records == 0 {
+		fmt.Fprintf(w, "暂无工作记录\n")
+		return
+	}
+	fmt.Fprintf(w, "| 项目 | 数值 |\n|---|---|\n")
+	fmt.Fprintf(w, "| 记录数 | %d |\n", rep.Records)
+	fmt.Fprintf(w, "| 会话数 | %d |\n", len(rep.Sessions))
+	fmt.Fprintf(w, "| Token | %d (输入 %d / 输出 %d / 缓存 %d) |\n", rep.TokensTotal, rep.TokensInput, rep.TokensOutput, rep.TokensCache)
+	if rep.CodeLines > 0 {
+		fmt.Fprintf(w, "| 代码行 | %d |\n", rep.CodeLines)
+	}
+	fmt.Fprintf(w, "| 价值 | %.4f OAW |\n", rep.Value)
+	if rep.CostUSD > 0 {
+		fmt.Fprintf(w, "| 估算成本 | $%.4f |\n", rep.CostUSD)
+	}
+
+	fmt.Fprintf(w, "\n### 按 Agent\n\n| Agent | 记录 | Token | 价值 (OAW) | 成本 ($) |\n|---|---|---|---|---|\n")
+	for _, a := range rep.Agents {
+		fmt.Fprintf(w, "| %s | %d | %d | %.4f | %.4f |\n", a.AgentID, a.Records, a.Tokens, a.Value, a.CostUSD)
+	}
+}
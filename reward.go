@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"oaw/openclaw"
+)
+
+// 奖励模式
+const (
+	RewardModeRatio = "ratio" // 基础奖励 × 工作量占比
+	RewardModeValue = "value" // 上一区块以来新同步的 OpenClaw 工作价值
+)
+
+// MaxBlockValue 单个区块奖励上限 (value 模式下奖励随同步价值变化)
+const MaxBlockValue = 10000.0
+
+// SetRewardMode 设置奖励模式
+func (m *Miner) SetRewardMode(mode string) error {
+	switch mode {
+	case RewardModeRatio, RewardModeValue:
+		m.rewardMode = mode
+		return nil
+	}
+	return fmt.Errorf("未知奖励模式: %s (可选: %s/%s)", mode, RewardModeRatio, RewardModeValue)
+}
+
+// SetRewardAddress 设置奖励收款地址 (与签名钱包分离，便于冷钱包收款)
+func (m *Miner) SetRewardAddress(address string) error {
+	if !common.IsHexAddress(address) {
+		return fmt.Errorf("无效地址: %s", address)
+	}
+	m.rewardTo = common.HexToAddress(address).Hex()
+	return nil
+}
+
+// payee 本矿工奖励归属的地址
+func (m *Miner) payee() string {
+	if m.rewardTo != "" {
+		return m.rewardTo
+	}
+	return m.wallet.Address
+}
+
+// syncedValueSinceLastBlock 汇总上一区块之后同步的工作记录价值
+// 早期记录文件名为同步时刻的 UnixNano 时间戳；按会话命名的记录原地更新，其增长取自增量日志
+func (m *Miner) syncedValueSinceLastBlock() float64 {
+	var since int64
+	if len(m.blocks) > 0 {
+		since = m.blocks[len(m.blocks)-1].Timestamp * 1e9
+	}
+
+	recordsDir := filepath.Join(m.dataDir, "records")
+	entries, err := os.ReadDir(recordsDir)
+	if err != nil {
+		return 0
+	}
+
+	var total float64
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		ts, err := strconv.ParseInt(strings.TrimSuffix(e.Name(), ".json"), 10, 64)
+		if err != nil || ts <= since {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(recordsDir, e.Name()))
+		if err != nil {
+			continue
+		}
+		var record struct {
+			Value float64 `json:"value"`
+		}
+		if json.Unmarshal(data, &record) == nil {
+			total += record.Value
+		}
+	}
+	deltas, _ := openclaw.SumDeltas(m.dataDir, func(e openclaw.DeltaEntry) bool {
+		return e.SyncedAt.UnixNano() > since
+	})
+	total += deltas
+
+	if total < 0 {
+		return 0
+	}
+	if total > MaxBlockValue {
+		return MaxBlockValue
+	}
+	return total
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"oaw/mining"
+)
+
+// 出块模式
+const (
+	MiningModePoW   = "pow"   // 暴力哈希
+	MiningModeStake = "stake" // 按链上已确认的工作价值 (出块奖励) 加权
+)
+
+// 权益出块配置
+const (
+	StakeSlotSeconds = 10     // 每个出块时隙长度 (秒)，时隙 = 区块时间戳 / StakeSlotSeconds
+	StakeHalfWeight  = 1000.0 // 权益达到该值时单个时隙出块概率为 50%
+
+	StakeWorkProofPrefix = "stake:" // 权益区块的 WorkProof 前缀 (后接时隙)
+)
+
+// chainStake 矿工的权益: prior 中由该矿工产出、至少有 DefaultConfirmations 个确认的区块奖励之和。
+// 区块奖励来自已验证的工作价值并记录在链上，任何节点都可由链历史重算
+func chainStake(prior []Block, miner string) float64 {
+	var stake float64
+	for _, b := range prior {
+		if len(prior)-b.Index < DefaultConfirmations {
+			break
+		}
+		if strings.EqualFold(b.Miner, miner) && b.Value > 0 {
+			stake += b.Value
+		}
+	}
+	return stake
+}
+
+// stakeTarget 权益对应的抽签目标: 2^256 × 权益 / (权益 + StakeHalfWeight)，权益为 0 时为 0 (不能出块)
+func stakeTarget(stake float64) *big.Int {
+	if stake <= 0 {
+		return new(big.Int)
+	}
+	maxHash := new(big.Int).Lsh(big.NewInt(1), 256)
+	p := new(big.Float).SetFloat64(stake / (stake + StakeHalfWeight))
+	target, _ := new(big.Float).Mul(new(big.Float).SetInt(maxHash), p).Int(nil)
+	return target
+}
+
+// stakeSlot 时间戳所在的出块时隙
+func stakeSlot(timestamp int64) int64 {
+	return timestamp / StakeSlotSeconds
+}
+
+// stakeWeight 本矿工在当前链上的权益
+func (m *Miner) stakeWeight() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return chainStake(m.blocks, m.wallet.Address)
+}
+
+// stakeKernel 权益出块抽签：时隙由区块头时间戳决定，每个时隙只计算一次哈希，
+// 命中概率 = 权益 / (权益 + StakeHalfWeight)。命中时写入 header 的 Hash 与 WorkProof
+func (m *Miner) stakeKernel(header *Block) bool {
+	slot := stakeSlot(header.Timestamp)
+	m.mu.Lock()
+	prior := m.blocks[:header.Index]
+	stake := chainStake(prior, m.wallet.Address)
+	used := len(prior) > 0 && slot <= stakeSlot(prior[len(prior)-1].Timestamp)
+	m.mu.Unlock()
+	if stake <= 0 || used {
+		return false
+	}
+
+	hashStr := stakeKernelHash(header.Previous, header.Miner, slot)
+	if !mining.MeetsTarget(hashStr, stakeTarget(stake)) {
+		return false
+	}
+
+	fmt.Printf("  🎯 权益出块: 时隙 %d, 权益 %.2f\n", slot, stake)
+	header.Hash, header.WorkProof = hashStr, fmt.Sprintf("%s%d", StakeWorkProofPrefix, slot)
+	return true
+}
+
+// stakeKernelHash 时隙的抽签哈希 (区块哈希，可由前序哈希、矿工与时隙重算)
+func stakeKernelHash(prev, miner string, slot int64) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d", prev, miner, slot)))
+	return hex.EncodeToString(hash[:])
+}
+
+// validateStakeBlock 校验权益区块相对链历史的出块权: 时隙须晚于前一区块的时隙 (每个时隙至多一个区块)，
+// 抽签哈希须命中矿工链上权益 (chainStake) 对应的目标
+func validateStakeBlock(b Block, prior []Block) error {
+	if !strings.HasPrefix(b.WorkProof, StakeWorkProofPrefix) || b.effectiveVersion() < PoWHashVersion {
+		return nil
+	}
+	slot := stakeSlot(b.Timestamp)
+	if len(prior) > 0 && slot <= stakeSlot(prior[len(prior)-1].Timestamp) {
+		return fmt.Errorf("区块 #%d 时隙 %d 未晚于前一区块的时隙", b.Index, slot)
+	}
+	stake := chainStake(prior, b.Miner)
+	if !mining.MeetsTarget(b.Hash, stakeTarget(stake)) {
+		return fmt.Errorf("区块 #%d 抽签哈希未命中矿工权益 %.4f 的目标", b.Index, stake)
+	}
+	return nil
+}
+
+// SetMode 设置出块模式
+func (m *Miner) SetMode(mode string) error {
+	switch mode {
+	case MiningModePoW, MiningModeStake:
+		m.mode = mode
+		return nil
+	}
+	return fmt.Errorf("未知出块模式: %s (可选: %s/%s)", mode, MiningModePoW, MiningModeStake)
+}
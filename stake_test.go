@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"oaw/mining"
+)
+
+// stakeBlock 以 s 在 timestamp 所在时隙的抽签哈希构造并签名权益区块 (不检查是否命中)
+func stakeBlock(t *testing.T, prior []Block, timestamp int64, s testSigner) Block {
+	t.Helper()
+	slot := stakeSlot(timestamp)
+	b := Block{Version: CurrentBlockVersion, Index: len(prior), Timestamp: timestamp, Miner: s.Address,
+		Value: BaseBlockReward, WorkProof: fmt.Sprintf("%s%d", StakeWorkProofPrefix, slot)}
+	if len(prior) > 0 {
+		b.Previous = prior[len(prior)-1].Hash
+	}
+	b.Hash = stakeKernelHash(b.Previous, b.Miner, slot)
+	if err := SignBlock(&b, s.Private); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// findStakeBlock 从 from 起逐个时隙抽签，返回第一个命中 (hit 为 true) 或未命中的权益区块
+func findStakeBlock(t *testing.T, prior []Block, from int64, s testSigner, hit bool) Block {
+	t.Helper()
+	target := stakeTarget(chainStake(prior, s.Address))
+	for ts := from; ts < from+100_000*StakeSlotSeconds; ts += StakeSlotSeconds {
+		b := stakeBlock(t, prior, ts, s)
+		if mining.MeetsTarget(b.Hash, target) == hit {
+			return b
+		}
+	}
+	t.Fatalf("未找到 hit=%v 的时隙", hit)
+	return Block{}
+}
+
+func TestChainStake(t *testing.T) {
+	s, other := newTestSigner(t), newTestSigner(t)
+	chain := testChain(t, s, 1_700_000_000, 10, DefaultConfirmations+2)
+
+	tests := []struct {
+		name  string
+		prior []Block
+		miner string
+		want  float64
+	}{
+		{"空链", nil, s.Address, 0},
+		{"未达到确认数的奖励不计入", chain[:DefaultConfirmations-1], s.Address, 0},
+		{"只计入已确认的奖励", chain, s.Address, 3 * BaseBlockReward},
+		{"其他矿工没有权益", chain, other.Address, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chainStake(tt.prior, tt.miner); got != tt.want {
+				t.Fatalf("权益 %.2f，期望 %.2f", got, tt.want)
+			}
+		})
+	}
+	if stakeTarget(0).Sign() != 0 {
+		t.Fatal("没有权益时目标应为 0")
+	}
+}
+
+func TestValidateStakeBlock(t *testing.T) {
+	s, other := newTestSigner(t), newTestSigner(t)
+	chain := testChain(t, s, 1_700_000_000, 10, DefaultConfirmations+4)
+	next := chain[len(chain)-1].Timestamp + StakeSlotSeconds
+	good := findStakeBlock(t, chain, next, s, true)
+
+	tests := []struct {
+		name    string
+		block   func() Block
+		wantErr string
+	}{
+		{"命中权益目标", func() Block { return good }, ""},
+		{"时隙与时间戳不符", func() Block {
+			b := good
+			b.WorkProof = fmt.Sprintf("%s%d", StakeWorkProofPrefix, stakeSlot(b.Timestamp)+1)
+			return b
+		}, "与区块时间戳不符"},
+		{"抽签哈希不匹配", func() Block {
+			b := good
+			b.Hash = stakeKernelHash(b.Previous, other.Address, stakeSlot(b.Timestamp))
+			return b
+		}, "抽签哈希不匹配"},
+		{"时隙未晚于前一区块", func() Block {
+			return stakeBlock(t, chain, chain[len(chain)-1].Timestamp, s)
+		}, "未晚于前一区块的时隙"},
+		{"未命中权益目标", func() Block { return findStakeBlock(t, chain, next, s, false) }, "未命中"},
+		{"没有链上权益的矿工", func() Block { return stakeBlock(t, chain, next, other) }, "未命中"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.block()
+			err := validateBlockWork(b, true)
+			if err == nil {
+				err = validateStakeBlock(b, chain)
+			}
+			checkErr(t, err, tt.wantErr)
+		})
+	}
+
+	// 对等节点的零权益区块被拒绝，命中的区块被接收
+	m := &Miner{blocks: append([]Block(nil), chain...), dataDir: t.TempDir()}
+	checkErr(t, m.AcceptBlock(stakeBlock(t, chain, next, other)), "未命中")
+	checkErr(t, m.AcceptBlock(good), "")
+	checkErr(t, m.VerifyChain(), "")
+}
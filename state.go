@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// CommunityPoolAddress 社区池账户地址 (创世时创建)
+const CommunityPoolAddress = "0x0000000000000000000000000000000000000002"
+
+// Transaction 链上转账交易
+type Transaction struct {
+	Hash      string  `json:"hash"`
+	From      string  `json:"from"`
+	To        string  `json:"to"`
+	Amount    float64 `json:"amount"`
+	Memo      string  `json:"memo"`
+	Timestamp int64   `json:"timestamp"`
+	Nonce     uint64  `json:"nonce"`     // 发起者账户序号，防止双花
+	Signature string  `json:"signature"` // 发起者签名
+}
+
+// ChainState 链状态 (账户与交易历史)
+type ChainState struct {
+	Genesis      int64              `json:"genesis"`
+	Accounts     map[string]float64 `json:"accounts"` // 账户转账净额 (不含挖矿奖励)
+	Nonces       map[string]uint64  `json:"nonces"`   // 账户下一个交易序号
+	Transactions []Transaction      `json:"transactions"`
+
+	path string
+}
+
+// LoadChainState 加载链状态，不存在时创建创世状态
+func LoadChainState(dir string) (*ChainState, error) {
+	s := &ChainState{
+		Accounts: map[string]float64{},
+		Nonces:   map[string]uint64{},
+		path:     filepath.Join(dir, "state.json"),
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		// 创世: 创建社区池账户
+		s.Genesis = time.Now().Unix()
+		s.Accounts[CommunityPoolAddress] = 0
+		return s, s.Save()
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("解析链状态失败: %w", err)
+	}
+	if s.Accounts == nil {
+		s.Accounts = map[string]float64{}
+	}
+	if s.Nonces == nil {
+		s.Nonces = map[string]uint64{}
+	}
+	if _, ok := s.Accounts[CommunityPoolAddress]; !ok {
+		s.Accounts[CommunityPoolAddress] = 0
+	}
+	return s, nil
+}
+
+// Save 保存链状态 (先写临时文件再重命名: 中途失败不会丢失防双花的 nonce 与交易记录)
+func (s *ChainState) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+// BalanceOf 计算账户余额 = 挖矿奖励 + 转账净额
+func (s *ChainState) BalanceOf(address string, blocks []Block) float64 {
+	snapshot := loadSnapshot(filepath.Dir(s.path))
+	total := snapshot.Balances[address]
+	for _, b := range blocks {
+		if strings.EqualFold(b.Payee(), address) && !snapshot.covers(b) {
+			total += b.Value
+		}
+	}
+	return total + s.Accounts[address]
+}
+
+// Transfer 创建签名转账交易并应用到链状态
+// 交易使用转出账户的下一个 nonce，余额不足时拒绝
+func (s *ChainState) Transfer(from, to string, amount float64, memo, privateKeyHex string) (*Transaction, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("转账金额必须大于 0")
+	}
+
+	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("私钥解析失败: %w", err)
+	}
+	if signer := crypto.PubkeyToAddress(privateKey.PublicKey).Hex(); !strings.EqualFold(signer, from) {
+		return nil, fmt.Errorf("签名钱包 %s 与转出地址 %s 不一致", signer, from)
+	}
+
+	balance := s.BalanceOf(from, loadChainBlocks(filepath.Dir(s.path)))
+	if balance+balanceEpsilon < amount {
+		return nil, fmt.Errorf("余额不足: %.4f < %.4f", balance, amount)
+	}
+
+	tx := Transaction{
+		From:      from,
+		To:        to,
+		Amount:    amount,
+		Memo:      memo,
+		Timestamp: time.Now().Unix(),
+		Nonce:     s.Nonces[from],
+	}
+	hash := txSigningHash(tx)
+	tx.Hash = "0x" + hex.EncodeToString(hash)
+
+	sig, err := crypto.Sign(hash, privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("签名失败: %w", err)
+	}
+	tx.Signature = "0x" + hex.EncodeToString(sig)
+
+	s.apply(tx)
+	return &tx, s.Save()
+}
+
+// ApplyRemote 应用对等区块中的交易 (已存在则忽略)
+func (s *ChainState) ApplyRemote(txs []Transaction) error {
+	changed := false
+	for _, tx := range txs {
+		if s.HasTx(tx.Hash) {
+			continue
+		}
+		s.apply(tx)
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return s.Save()
+}
+
+// HasTx 交易是否已在本地链状态中
+func (s *ChainState) HasTx(hash string) bool {
+	for _, tx := range s.Transactions {
+		if tx.Hash == hash {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ChainState) apply(tx Transaction) {
+	s.Accounts[tx.From] -= tx.Amount
+	s.Accounts[tx.To] += tx.Amount
+	if tx.Nonce+1 > s.Nonces[tx.From] {
+		s.Nonces[tx.From] = tx.Nonce + 1
+	}
+	s.Transactions = append(s.Transactions, tx)
+}
+
+// History 获取与账户相关的交易 (按时间顺序)
+func (s *ChainState) History(address string) []Transaction {
+	var txs []Transaction
+	for _, tx := range s.Transactions {
+		if strings.EqualFold(tx.From, address) || strings.EqualFold(tx.To, address) {
+			txs = append(txs, tx)
+		}
+	}
+	return txs
+}
+
+// loadChainBlocks 读取本地区块
+func loadChainBlocks(dir string) []Block {
+	var blocks []Block
+	data, err := os.ReadFile(filepath.Join(dir, "blocks.json"))
+	if err == nil {
+		json.Unmarshal(data, &blocks)
+	}
+	return blocks
+}
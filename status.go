@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"oaw/mining"
+	"oaw/openclaw"
+)
+
+// MineStatus 挖矿状态 (供 mine status --json 输出)
+type MineStatus struct {
+	Running            bool    `json:"running"`
+	Mode               string  `json:"mode"`
+	Difficulty         int     `json:"difficulty"`
+	MinDifficulty      int     `json:"min_difficulty"`
+	MaxDifficulty      int     `json:"max_difficulty"`
+	Target             string  `json:"target"`                       // 难度目标 (十六进制)
+	HashRate           float64 `json:"hash_rate"`                    // 本机哈希率 (H/s)
+	EstimatedBlockTime float64 `json:"estimated_block_time_seconds"` // 预计出块时间
+	Blocks             int     `json:"blocks"`
+	Balance            float64 `json:"balance"`
+
+	OpenClaw *openclaw.Health `json:"openclaw,omitempty"` // OpenClaw 连接状态 (后台同步或集成器轮询写入)
+}
+
+// Status 汇总挖矿状态，哈希率通过短时基准测试估算。
+// 状态在锁内复制，基准测试 (200ms) 在锁外进行，不阻塞挖矿与接收对等区块
+func (m *Miner) Status() MineStatus {
+	m.mu.Lock()
+	st := MineStatus{
+		Running:       m.working,
+		Mode:          m.mode,
+		Difficulty:    expectedDifficulty(m.blocks, time.Now().Unix()),
+		MinDifficulty: m.minDifficulty,
+		MaxDifficulty: m.maxDifficulty,
+		Blocks:        len(m.blocks),
+		Balance:       m.Balance(),
+	}
+	m.mu.Unlock()
+
+	target := mining.DifficultyTarget(st.Difficulty)
+	rate := measureHashRate(200 * time.Millisecond)
+
+	// 期望尝试次数 = 2^256 / target
+	expected := math.Pow(2, 256) / float64FromBig(target)
+	var eta float64
+	if rate > 0 {
+		eta = expected / rate
+	}
+
+	st.Target = fmt.Sprintf("0x%064x", target)
+	st.HashRate = rate
+	st.EstimatedBlockTime = eta
+	st.OpenClaw, _ = openclaw.LoadHealth(m.dataDir)
+	return st
+}
+
+// measureHashRate 在 d 时间内测量 SHA256 哈希率
+func measureHashRate(d time.Duration) float64 {
+	start := time.Now()
+	var n uint64
+	for time.Since(start) < d {
+		for i := 0; i < 1000; i++ {
+			sha256.Sum256([]byte(fmt.Sprintf("%d", n)))
+			n++
+		}
+	}
+	return float64(n) / time.Since(start).Seconds()
+}
+
+func float64FromBig(x *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(x).Float64()
+	return f
+}
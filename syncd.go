@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"oaw/openclaw"
+	worktracker "oaw/tracker"
+)
+
+// syncOptions OpenClaw 同步参数
+type syncOptions struct {
+	Valuator    worktracker.Valuator
+	Filter      openclaw.AgentFilter
+	Transcripts bool
+}
+
+// defaultSyncOptions 默认参数: 默认价值策略、config.json 中的 Agent 筛选、按消息同步
+func defaultSyncOptions() syncOptions {
+	opts := syncOptions{Valuator: worktracker.DefaultValuator, Transcripts: true}
+	if cfg, err := LoadConfig(dataDir); err == nil && cfg.OpenClawAgents != nil {
+		opts.Filter = *cfg.OpenClawAgents
+	}
+	return opts
+}
+
+// syncOnce 执行一次同步并记录 OpenClaw 连接状态
+func syncOnce(opts syncOptions) error {
+	err := openclaw.SyncFromSessions(dataDir, opts.Valuator, opts.Filter, opts.Transcripts)
+	recordSyncHealth(err)
+	return err
+}
+
+// recordSyncHealth 更新连接状态文件 (oaw mine status 显示)，不可达超过 openclaw.UnreachableWarn 时警告
+func recordSyncHealth(syncErr error) {
+	h, err := openclaw.LoadHealth(dataDir)
+	if err != nil || h == nil {
+		h = &openclaw.Health{}
+	}
+	now := time.Now()
+	wasDown := !h.Healthy && !h.DownSince.IsZero()
+	if h.Observe(syncErr, now) {
+		fmt.Printf("⚠️ OpenClaw 已持续 %s 不可达: %v\n", h.Downtime(now).Round(time.Second), syncErr)
+	} else if wasDown && syncErr == nil {
+		fmt.Println("✅ OpenClaw 已恢复")
+	}
+	h.Source = "sync"
+	if err := h.Save(dataDir); err != nil {
+		fmt.Printf("⚠️ 保存连接状态失败: %v\n", err)
+	}
+}
+
+// 后台同步的退避参数
+const (
+	syncJitter     = 0.1       // 间隔随机浮动 ±10%，避免多个节点同时同步
+	maxSyncBackoff = time.Hour // 连续失败时的最长间隔
+)
+
+// nextSyncDelay 下一次同步前的等待时间: 每连续失败一次间隔加倍 (间隔 × 2^n)，最长 1 小时 (正常间隔已超过 1 小时时保持不变)
+func nextSyncDelay(interval time.Duration, failures int) time.Duration {
+	delay := interval
+	for i := 0; i < failures && delay < maxSyncBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxSyncBackoff && interval < maxSyncBackoff {
+		delay = maxSyncBackoff
+	}
+	jitter := (rand.Float64()*2 - 1) * syncJitter * float64(delay)
+	return delay + time.Duration(jitter)
+}
+
+// runSyncLoop 立即同步一次，之后按间隔定时同步，直到 ctx 取消
+func runSyncLoop(ctx context.Context, interval time.Duration, sync func() error) {
+	failures := 0
+	for {
+		if err := sync(); err != nil {
+			failures++
+			fmt.Printf("⚠️ [%s] 同步失败 (连续 %d 次): %v\n", time.Now().Format("15:04:05"), failures, err)
+		} else {
+			failures = 0
+		}
+
+		delay := nextSyncDelay(interval, failures)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
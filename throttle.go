@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cpuThrottleInterval 每计算多少次哈希检查一次占空比
+const cpuThrottleInterval = 10000
+
+// cpuThrottle 基于占空比的 CPU 限速：忙碌 busy 后休眠 busy×(100-p)/p
+type cpuThrottle struct {
+	percent   int
+	busyStart time.Time
+}
+
+func newCPUThrottle(percent int) *cpuThrottle {
+	return &cpuThrottle{percent: percent, busyStart: time.Now()}
+}
+
+// tick 在挖矿循环中周期调用
+func (t *cpuThrottle) tick() {
+	if t == nil || t.percent <= 0 || t.percent >= 100 {
+		return
+	}
+	busy := time.Since(t.busyStart)
+	time.Sleep(busy * time.Duration(100-t.percent) / time.Duration(t.percent))
+	t.busyStart = time.Now()
+}
+
+// ParseCPUPercent 解析 "50%" 或 "50"
+func ParseCPUPercent(s string) (int, error) {
+	p, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(s), "%"))
+	if err != nil || p < 1 || p > 100 {
+		return 0, fmt.Errorf("无效 CPU 占用: %s (范围 1%%-100%%)", s)
+	}
+	return p, nil
+}
+
+// SetMaxCPU 设置挖矿最大 CPU 占用百分比
+func (m *Miner) SetMaxCPU(percent int) {
+	m.maxCPU = percent
+}
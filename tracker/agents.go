@@ -0,0 +1,108 @@
+package worktracker
+
+import (
+	"sort"
+	"time"
+)
+
+// ============ 按 Agent 统计 ============
+
+// AgentStats 单个 Agent 的累计统计
+type AgentStats struct {
+	AgentID         string  `json:"agent_id"`
+	Tasks           int     `json:"tasks"`
+	CompletedTasks  int     `json:"completed_tasks"`
+	FailedTasks     int     `json:"failed_tasks"`
+	TotalTokens     int64   `json:"total_tokens"`
+	TotalValue      float64 `json:"total_value"`
+	TotalDurationMs int64   `json:"total_duration_ms"` // 任务总耗时 (毫秒)
+	TotalCostUSD    float64 `json:"total_cost_usd"`    // 估算 token 成本 (美元)
+	Flagged         int     `json:"flagged"`           // 被隔离的可疑记录数
+}
+
+// SuccessRate 成功率 = 完成 / (完成 + 失败)，尚无结束任务时为 0
+func (a AgentStats) SuccessRate() float64 {
+	if done := a.CompletedTasks + a.FailedTasks; done > 0 {
+		return float64(a.CompletedTasks) / float64(done)
+	}
+	return 0
+}
+
+// AvgDuration 已结束任务的平均耗时
+func (a AgentStats) AvgDuration() time.Duration {
+	if done := a.CompletedTasks + a.FailedTasks; done > 0 {
+		return time.Duration(a.TotalDurationMs/int64(done)) * time.Millisecond
+	}
+	return 0
+}
+
+// addAgent 计入 (n=1) 或撤销 (n=-1) 记录到所属 Agent
+func (s *Stats) addAgent(r *WorkRecord, n int) {
+	if s.ByAgent == nil {
+		s.ByAgent = make(map[string]AgentStats)
+	}
+	a := s.ByAgent[r.AgentID]
+	a.AgentID = r.AgentID
+	if r.Flagged() {
+		a.Flagged += n
+		s.putAgent(a)
+		return
+	}
+	a.Tasks += n
+	switch r.Status {
+	case "completed":
+		a.CompletedTasks += n
+	case "failed":
+		a.FailedTasks += n
+	}
+	a.TotalTokens += int64(n) * (r.TokensInput + r.TokensOutput)
+	a.TotalValue += float64(n) * r.CalculateValue()
+	a.TotalDurationMs += int64(n) * r.Duration().Milliseconds()
+	a.TotalCostUSD += float64(n) * r.CostUSD
+	s.putAgent(a)
+}
+
+// putAgent 保存 Agent 统计，已无任何记录时移除
+func (s *Stats) putAgent(a AgentStats) {
+	if a.Tasks == 0 && a.Flagged == 0 {
+		delete(s.ByAgent, a.AgentID)
+		return
+	}
+	s.ByAgent[a.AgentID] = a
+}
+
+// mergeAgent 合并另一份 Agent 统计 (如归档摘要)
+func (s *Stats) mergeAgent(o AgentStats) {
+	if s.ByAgent == nil {
+		s.ByAgent = make(map[string]AgentStats)
+	}
+	a := s.ByAgent[o.AgentID]
+	a.AgentID = o.AgentID
+	a.Tasks += o.Tasks
+	a.CompletedTasks += o.CompletedTasks
+	a.FailedTasks += o.FailedTasks
+	a.TotalTokens += o.TotalTokens
+	a.TotalValue += o.TotalValue
+	a.TotalDurationMs += o.TotalDurationMs
+	a.TotalCostUSD += o.TotalCostUSD
+	a.Flagged += o.Flagged
+	s.ByAgent[o.AgentID] = a
+}
+
+// AgentBreakdown 各 Agent 的累计统计 (含已归档记录)，按价值降序
+func (t *Tracker) AgentBreakdown() []AgentStats {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	list := make([]AgentStats, 0, len(t.stats.ByAgent))
+	for _, a := range t.stats.ByAgent {
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].TotalValue != list[j].TotalValue {
+			return list[i].TotalValue > list[j].TotalValue
+		}
+		return list[i].AgentID < list[j].AgentID
+	})
+	return list
+}
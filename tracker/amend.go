@@ -0,0 +1,146 @@
+package worktracker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ============ 记录修正 ============
+
+// superseded 记录是否已被修正 (调用方需持有锁)
+func (t *Tracker) superseded(r *WorkRecord) bool {
+	return t.amendedBy[r.ID] != ""
+}
+
+// Amend 修正已结束的记录: 生成引用原记录的修正记录 (保留原工作证明哈希)，
+// 统计改为计入修正后的数值；原记录与其工作证明保持不变
+func (t *Tracker) Amend(id string, result TaskResult, reason string) (*WorkRecord, error) {
+	return t.AmendAs(id, "", result, reason)
+}
+
+// AmendAs 同 Amend，taskType 不为空时同时修正任务类型 (如分类规则变化后重新分类)
+func (t *Tracker) AmendAs(id string, taskType TaskType, result TaskResult, reason string) (*WorkRecord, error) {
+	t.ensureLoaded()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	orig, ok := t.records[id]
+	if !ok {
+		return nil, fmt.Errorf("记录不存在: %s", id)
+	}
+	if latest := t.amendedBy[id]; latest != "" {
+		return nil, fmt.Errorf("记录已被 %s 修正，请修正最新版本", latest)
+	}
+	if orig.Status != "completed" && orig.Status != "failed" {
+		return nil, fmt.Errorf("只能修正已结束的记录 (当前状态: %s)", orig.Status)
+	}
+
+	record := &WorkRecord{
+		ID:           t.newRecordID(),
+		AgentID:      orig.AgentID,
+		TaskType:     orig.TaskType,
+		TaskDesc:     orig.TaskDesc,
+		ParentID:     orig.ParentID,
+		Tags:         append([]string(nil), orig.Tags...),
+		Status:       orig.Status,
+		StartedAt:    orig.StartedAt,
+		CompletedAt:  orig.CompletedAt, // 保持原完成时间，统计仍归入原周期
+		Seq:          t.nextSeq(),
+		TokensInput:  result.TokensInput,
+		TokensOutput: result.TokensOutput,
+		TokensCache:  result.TokensCache,
+		CodeLines:    result.CodeLines,
+		CodeFiles:    result.CodeFiles,
+		WordsWritten: result.WordsWritten,
+		BugsFixed:    result.BugsFixed,
+		APICalls:     result.APICalls,
+		ErrorsFixed:  result.ErrorsFixed,
+		LinesRemoved: result.LinesRemoved,
+		Commits:      append([]string(nil), result.Commits...),
+		Repo:         result.Repo,
+		Model:        orig.Model,
+		Amends:       orig.ID,
+		AmendedProof: orig.ProofHash,
+		AmendReason:  reason,
+		AmendedAt:    time.Now().UnixMilli(),
+	}
+	if taskType != "" {
+		record.TaskType = taskType
+	}
+	if result.Model != "" {
+		record.Model = result.Model
+	}
+	record.priceCost()
+	record.AddTags(result.Tags...)
+	record.GenerateProof()
+	t.sign(record)
+	if err := t.save(record); err != nil {
+		return nil, fmt.Errorf("保存修正记录失败: %w", err)
+	}
+
+	t.records[record.ID] = record
+	t.proofs[record.ProofHash] = record.ID
+	t.amendedBy[orig.ID] = record.ID
+	t.indexInsert(record)
+
+	t.removeStats(orig)
+	t.updateStats(record)
+	t.invalidateRollups(record.recordTime())
+	t.saveStats()
+	t.publish(RecordAmended, record)
+	return record, nil
+}
+
+// Amendments 记录的修正历史 (从最初记录到最新版本)
+func (t *Tracker) Amendments(id string) []*WorkRecord {
+	t.ensureLoaded()
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	r, ok := t.records[id]
+	if !ok {
+		return nil
+	}
+	for r.Amends != "" && t.records[r.Amends] != nil {
+		r = t.records[r.Amends]
+	}
+
+	chain := []*WorkRecord{r}
+	for next := t.amendedBy[r.ID]; next != "" && t.records[next] != nil; next = t.amendedBy[next] {
+		chain = append(chain, t.records[next])
+		if len(chain) > len(t.records) {
+			break // 防止损坏数据中的循环引用
+		}
+	}
+	return chain
+}
+
+// invalidateRollups 删除包含这些时间的周期快照，下次统计时重算；
+// 其他时区的快照整个删除 (调用方需持有锁)
+func (t *Tracker) invalidateRollups(ms ...int64) {
+	if len(ms) == 0 {
+		return
+	}
+	for _, period := range []Period{PeriodDay, PeriodWeek, PeriodMonth} {
+		snapshots := t.loadRollups(period)
+		changed := false
+		for _, at := range ms {
+			key := period.start(time.UnixMilli(at)).Format("2006-01-02")
+			if _, ok := snapshots[key]; ok {
+				delete(snapshots, key)
+				changed = true
+			}
+		}
+		if changed {
+			t.saveRollups(period, snapshots)
+		}
+		others, _ := filepath.Glob(filepath.Join(t.dataDir, "rollups", string(period)+"*.json"))
+		for _, path := range others {
+			if path != t.rollupPath(period) {
+				os.Remove(path)
+			}
+		}
+	}
+}
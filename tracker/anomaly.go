@@ -0,0 +1,73 @@
+package worktracker
+
+import "fmt"
+
+// ============ 异常检测 ============
+
+// AnomalyThresholds 工作量指标的合理范围，超出即视为可疑记录
+type AnomalyThresholds struct {
+	MaxBugsPerTask   int     // 单任务修复 bug / 错误数上限
+	MaxLinesPerToken float64 // 每个输出 token 最多对应的代码行
+	LineAllowance    int     // 代码行的固定余量 (token 统计缺失时的容差)
+	MaxWordsPerToken float64 // 每个输出 token 最多对应的文字数
+	WordAllowance    int     // 文字的固定余量
+}
+
+// Anomaly 当前使用的阈值
+var Anomaly = AnomalyThresholds{
+	MaxBugsPerTask:   50,
+	MaxLinesPerToken: 0.5, // 一行代码至少约 2 个 token
+	LineAllowance:    50,
+	MaxWordsPerToken: 2,
+	WordAllowance:    200,
+}
+
+// Anomalies 记录的可疑之处，为空表示正常。
+// 可疑记录被隔离: 价值为 0，不计入统计 (只计入 Flagged)，可通过修正记录更正
+func (w *WorkRecord) Anomalies() []string {
+	// 聚合记录包含多个任务，按任务数放宽上限与余量
+	tasks := 1
+	if w.Aggregated > 1 {
+		tasks = w.Aggregated
+	}
+	var reasons []string
+	if maxBugs := Anomaly.MaxBugsPerTask * tasks; w.BugsFixed > maxBugs {
+		reasons = append(reasons, fmt.Sprintf("修复 bug %d 个，超过上限 %d", w.BugsFixed, maxBugs))
+	}
+	if maxBugs := Anomaly.MaxBugsPerTask * tasks; w.ErrorsFixed > maxBugs {
+		reasons = append(reasons, fmt.Sprintf("修复错误 %d 个，超过上限 %d", w.ErrorsFixed, maxBugs))
+	}
+	if limit := float64(w.TokensOutput)*Anomaly.MaxLinesPerToken + float64(Anomaly.LineAllowance*tasks); float64(w.CodeLines) > limit {
+		reasons = append(reasons, fmt.Sprintf("代码 %d 行与输出 token %d 不符 (上限 %.0f 行)", w.CodeLines, w.TokensOutput, limit))
+	}
+	if limit := float64(w.TokensOutput)*Anomaly.MaxWordsPerToken + float64(Anomaly.WordAllowance*tasks); float64(w.WordsWritten) > limit {
+		reasons = append(reasons, fmt.Sprintf("文字 %d 与输出 token %d 不符 (上限 %.0f)", w.WordsWritten, w.TokensOutput, limit))
+	}
+	if w.StartedAt > 0 && w.CompletedAt > 0 && w.CompletedAt < w.StartedAt {
+		reasons = append(reasons, "完成时间早于开始时间")
+	}
+	if w.TokensInput < 0 || w.TokensOutput < 0 || w.TokensCache < 0 || w.CodeLines < 0 || w.WordsWritten < 0 || w.BugsFixed < 0 {
+		reasons = append(reasons, "工作量指标为负")
+	}
+	return reasons
+}
+
+// Flagged 记录是否被标记为可疑
+func (w *WorkRecord) Flagged() bool {
+	return len(w.Anomalies()) > 0
+}
+
+// FlaggedRecords 被标记为可疑的记录 (最新版本，按时间倒序)
+func (t *Tracker) FlaggedRecords() []*WorkRecord {
+	t.ensureLoaded()
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var flagged []*WorkRecord
+	for i := len(t.order) - 1; i >= 0; i-- {
+		if r := t.order[i]; !t.superseded(r) && r.Flagged() {
+			flagged = append(flagged, r)
+		}
+	}
+	return flagged
+}
@@ -0,0 +1,200 @@
+package worktracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ============ 记录保留与归档 ============
+
+// DefaultRetentionMonths 默认保留的原始记录月数 (不含当月)
+const DefaultRetentionMonths = 12
+
+// MonthlyArchive 月度归档 (<dataDir>/archive/<2006-01>.json):
+// 原始记录被删除，只保留统计摘要与全部工作证明哈希的 Merkle 根
+type MonthlyArchive struct {
+	Month      string   `json:"month"`              // 2006-01
+	Summary    Stats    `json:"summary"`            // 只统计最新版本 (与实时统计一致)
+	Root       string   `json:"root"`               // Leaves 的 Merkle 根
+	Leaves     []string `json:"leaves"`             // 工作证明哈希 (含被修正的原记录)
+	RecordIDs  []string `json:"record_ids"`         // 与 Leaves 一一对应
+	LastSeq    uint64   `json:"last_seq,omitempty"` // 归档记录中最大的写入序号，见 clock.go
+	ArchivedAt int64    `json:"archived_at"`
+}
+
+// ArchiveResult 一次归档的结果
+type ArchiveResult struct {
+	Cutoff   time.Time         `json:"cutoff"` // 早于此时间的已结束记录被归档
+	Records  int               `json:"records"`
+	Archives []*MonthlyArchive `json:"archives"` // 本次写入的月份 (含合并后的内容)
+}
+
+// Archive 将 keepMonths 个月之前 (按月对齐) 的已结束记录压缩为月度归档，
+// 归档前保存相关周期快照，随后删除原始记录文件；总统计保持不变
+func (t *Tracker) Archive(keepMonths int) (*ArchiveResult, error) {
+	if keepMonths < 0 {
+		return nil, fmt.Errorf("保留月数不能为负: %d", keepMonths)
+	}
+
+	t.ensureLoaded()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	cutoff := PeriodMonth.start(now).AddDate(0, -keepMonths, 0)
+	result := &ArchiveResult{Cutoff: cutoff, Archives: []*MonthlyArchive{}}
+
+	hi := timeBound(t.order, cutoff.UnixMilli())
+	var expired []*WorkRecord
+	for _, r := range t.order[:hi] {
+		if r.Status == "completed" || r.Status == "failed" {
+			expired = append(expired, r)
+		}
+	}
+	if len(expired) == 0 {
+		return result, nil
+	}
+
+	// 先保存归档范围内已结束周期的快照，删除原始记录后统计仍可查询
+	from := time.UnixMilli(expired[0].recordTime())
+	for _, period := range []Period{PeriodDay, PeriodWeek, PeriodMonth} {
+		if _, err := t.aggregateLocked(period, from, cutoff, now); err != nil {
+			return nil, fmt.Errorf("保存统计快照失败: %w", err)
+		}
+	}
+
+	months := map[string]*MonthlyArchive{}
+	var keys []string
+	for _, r := range expired {
+		key := time.UnixMilli(r.recordTime()).Format("2006-01")
+		a, ok := months[key]
+		if !ok {
+			if a, ok = t.loadArchive(key); !ok {
+				a = &MonthlyArchive{Month: key, Summary: Stats{ByTaskType: map[string]int{}}}
+			}
+			months[key] = a
+			keys = append(keys, key)
+		}
+		if !t.superseded(r) {
+			a.Summary.add(r, 1)
+		}
+		if r.Seq > a.LastSeq {
+			a.LastSeq = r.Seq
+		}
+		if r.ProofHash != "" {
+			a.Leaves = append(a.Leaves, r.ProofHash)
+			a.RecordIDs = append(a.RecordIDs, r.ID)
+		}
+	}
+
+	for _, key := range keys {
+		a := months[key]
+		a.ArchivedAt = now.Unix()
+		if len(a.Leaves) > 0 {
+			root, err := MerkleRoot(a.Leaves)
+			if err != nil {
+				return nil, err
+			}
+			a.Root = root
+		}
+		if err := t.saveArchive(a); err != nil {
+			return nil, fmt.Errorf("保存归档 %s 失败: %w", key, err)
+		}
+		result.Archives = append(result.Archives, a)
+	}
+
+	// 归档已落盘，再删除原始记录 (单独文件与分段中的记录)
+	segmented := map[string]bool{}
+	for _, r := range expired {
+		if t.segmentOf[r.ID] != "" {
+			segmented[r.ID] = true
+		}
+	}
+	if err := t.dropFromSegments(segmented); err != nil {
+		return result, fmt.Errorf("删除分段中的记录失败: %w", err)
+	}
+	for _, r := range expired {
+		switch err := os.Remove(filepath.Join(t.dataDir, r.ID+".json")); {
+		case err == nil:
+			t.files--
+		case !os.IsNotExist(err):
+			return result, fmt.Errorf("删除记录 %s 失败: %w", r.ID, err)
+		}
+		t.indexRemove(r)
+		delete(t.records, r.ID)
+		delete(t.amendedBy, r.ID) // 工作证明保留在 t.proofs 中，继续参与去重
+		result.Records++
+	}
+	for agent, list := range t.byAgent {
+		if len(list) == 0 {
+			delete(t.byAgent, agent)
+		}
+	}
+	t.saveStats()
+	if start, err := time.ParseInLocation("2006-01", keys[0], time.Local); err == nil {
+		if t.archivedFrom.IsZero() || start.Before(t.archivedFrom) {
+			t.archivedFrom = start
+		}
+	}
+	return result, nil
+}
+
+// Archives 读取全部月度归档 (按月份排序)
+func (t *Tracker) Archives() ([]*MonthlyArchive, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.loadArchives()
+}
+
+func (t *Tracker) archiveDir() string {
+	return filepath.Join(t.dataDir, "archive")
+}
+
+func (t *Tracker) loadArchive(month string) (*MonthlyArchive, bool) {
+	data, err := os.ReadFile(filepath.Join(t.archiveDir(), month+".json"))
+	if err != nil {
+		return nil, false
+	}
+	var a MonthlyArchive
+	if json.Unmarshal(data, &a) != nil {
+		return nil, false
+	}
+	if a.Summary.ByTaskType == nil {
+		a.Summary.ByTaskType = map[string]int{}
+	}
+	return &a, true
+}
+
+func (t *Tracker) loadArchives() ([]*MonthlyArchive, error) {
+	entries, err := os.ReadDir(t.archiveDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var archives []*MonthlyArchive
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		if a, ok := t.loadArchive(strings.TrimSuffix(e.Name(), ".json")); ok {
+			archives = append(archives, a)
+		}
+	}
+	sort.Slice(archives, func(i, j int) bool { return archives[i].Month < archives[j].Month })
+	return archives, nil
+}
+
+func (t *Tracker) saveArchive(a *MonthlyArchive) error {
+	if err := os.MkdirAll(t.archiveDir(), 0755); err != nil {
+		return err
+	}
+	return writeJSONAtomic(filepath.Join(t.archiveDir(), a.Month+".json"), a)
+}
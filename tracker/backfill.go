@@ -0,0 +1,91 @@
+package worktracker
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ============ 补全工作证明与签名 ============
+
+// BackfillResult 一次补全的结果
+type BackfillResult struct {
+	Proofs     int      `json:"proofs"`     // 补全工作证明的记录数
+	Signatures int      `json:"signatures"` // 补签名的记录数
+	Skipped    []string `json:"skipped,omitempty"`
+}
+
+// Backfill 为缺少工作证明或签名的已结束记录 (如早期版本的失败任务、未配置钱包时的记录)
+// 计算工作证明并用当前钱包签名，写回时附带补全说明。已有但与字段不符的工作证明不会被覆盖。
+// dryRun 时只统计不写入
+func (t *Tracker) Backfill(dryRun bool) (*BackfillResult, error) {
+	t.ensureLoaded()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := &BackfillResult{}
+	now := time.Now()
+	for _, r := range t.order {
+		if r.Status != "completed" && r.Status != "failed" {
+			continue
+		}
+		needProof := r.ProofHash == ""
+		key := t.signerFor(r.AgentID)
+		needSign := !r.IsSigned() && key != nil
+		if !needProof && !needSign {
+			continue
+		}
+		if !needProof && r.ComputeProof() != r.ProofHash {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: 工作证明与字段不符，不予签名", r.ID))
+			continue
+		}
+
+		next := *r
+		next.Tags = append([]string(nil), r.Tags...)
+		var notes []string
+		if needProof {
+			next.GenerateProof()
+			if id, dup := t.proofs[next.ProofHash]; dup && id != r.ID {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("%s: 工作证明与记录 %s 重复", r.ID, id))
+				continue
+			}
+			notes = append(notes, "工作证明")
+		}
+		if key != nil {
+			if err := next.Sign(key); err != nil {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("%s: %v", r.ID, err))
+				continue
+			}
+			notes = append(notes, "签名")
+		}
+
+		if needProof {
+			result.Proofs++
+		}
+		if next.IsSigned() && !r.IsSigned() {
+			result.Signatures++
+		}
+		if dryRun {
+			continue
+		}
+
+		next.BackfillNote = appendNote(r.BackfillNote, fmt.Sprintf("%s 补全%s", now.Format("2006-01-02"), strings.Join(notes, "与")))
+		next.BackfilledAt = now.UnixMilli()
+		if err := t.save(&next); err != nil {
+			return result, fmt.Errorf("保存记录 %s 失败: %w", r.ID, err)
+		}
+		*r = next
+		if needProof {
+			t.proofs[r.ProofHash] = r.ID
+		}
+	}
+	return result, nil
+}
+
+// appendNote 追加补全说明 (多次补全时保留历史)
+func appendNote(note, add string) string {
+	if note == "" {
+		return add
+	}
+	return note + "; " + add
+}
@@ -0,0 +1,375 @@
+package worktracker
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ============ 审计证明包 ============
+
+// 证明包是一个 zip 文件，供第三方离线审计:
+//   manifest.json          清单: 记录数、Merkle 根、各文件 sha256，可由导出节点钱包签名
+//   merkle.json            全部记录工作证明哈希构成的 Merkle 树 (叶子与根)
+//   records/<id>.json      已签名的记录 (含签名)
+//   inclusion/<id>.json    已进入证明批次的记录的包含证明 (关联此前公布的批次根)
+
+// BundleVersion 证明包格式版本
+const BundleVersion = 1
+
+const (
+	bundleManifest = "manifest.json"
+	bundleMerkle   = "merkle.json"
+)
+
+// BundleManifest 证明包清单
+type BundleManifest struct {
+	Version   int               `json:"version"`
+	CreatedAt int64             `json:"created_at"`
+	Since     int64             `json:"since,omitempty"` // 只含此时间 (毫秒) 之后结束的记录
+	Records   int               `json:"records"`
+	Root      string            `json:"root"`  // merkle.json 的根
+	Files     map[string]string `json:"files"` // 文件名 -> sha256 (不含清单自身)
+	Signer    string            `json:"signer,omitempty"`
+	Signature string            `json:"signature,omitempty"` // 对清单 (签名字段为空时) 的 sha256 签名
+}
+
+// BundleTree 证明包中的 Merkle 树
+type BundleTree struct {
+	Root      string   `json:"root"`
+	Leaves    []string `json:"leaves"`     // 工作证明哈希 (按记录时间排序)
+	RecordIDs []string `json:"record_ids"` // 与 Leaves 一一对应
+}
+
+// BundleReport 证明包校验结果，Problems 为空表示全部通过
+type BundleReport struct {
+	Manifest   *BundleManifest `json:"manifest"`
+	Records    int             `json:"records"`
+	Verified   int             `json:"verified"`   // 工作证明与签名均有效的记录
+	Inclusions int             `json:"inclusions"` // 校验通过的批次包含证明
+	Signers    []string        `json:"signers"`    // 记录的签名钱包
+	Problems   []string        `json:"problems,omitempty"`
+}
+
+// OK 是否全部校验通过
+func (r *BundleReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+func (r *BundleReport) problem(format string, args ...interface{}) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+// digest 清单签名的摘要 (签名字段置空后的 JSON)
+func (m BundleManifest) digest() []byte {
+	m.Signer, m.Signature = "", ""
+	data, _ := json.Marshal(m)
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+// ExportBundle 将 since 之后结束的已签名记录导出为证明包 (写入 out)，
+// proofsDir 中的批次用于附带包含证明；设置了签名钱包时签名清单。
+// 返回清单与因未签名而跳过的记录数
+func (t *Tracker) ExportBundle(out, proofsDir string, since time.Time) (*BundleManifest, int, error) {
+	t.ensureLoaded()
+	t.mu.RLock()
+	var records []*WorkRecord
+	skipped := 0
+	lo := 0
+	if !since.IsZero() {
+		lo = timeBound(t.order, since.UnixMilli())
+	}
+	for _, r := range t.order[lo:] {
+		if r.Status != "completed" && r.Status != "failed" {
+			continue
+		}
+		if !r.IsSigned() {
+			skipped++
+			continue
+		}
+		c := *r
+		records = append(records, &c)
+	}
+	signer := t.signer
+	t.mu.RUnlock()
+
+	if len(records) == 0 {
+		return nil, skipped, fmt.Errorf("没有可导出的已签名记录")
+	}
+
+	tree := BundleTree{}
+	for _, r := range records {
+		tree.Leaves = append(tree.Leaves, r.ProofHash)
+		tree.RecordIDs = append(tree.RecordIDs, r.ID)
+	}
+	root, err := MerkleRoot(tree.Leaves)
+	if err != nil {
+		return nil, skipped, err
+	}
+	tree.Root = root
+
+	now := time.Now()
+	manifest := &BundleManifest{
+		Version:   BundleVersion,
+		CreatedAt: now.Unix(),
+		Records:   len(records),
+		Root:      root,
+		Files:     map[string]string{},
+	}
+	if !since.IsZero() {
+		manifest.Since = since.UnixMilli()
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	add := func(name string, v interface{}) error {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		if name != bundleManifest {
+			sum := sha256.Sum256(data)
+			manifest.Files[name] = hex.EncodeToString(sum[:])
+		}
+		return nil
+	}
+
+	if err := add(bundleMerkle, tree); err != nil {
+		return nil, skipped, err
+	}
+	inclusion, err := inclusionProofs(proofsDir)
+	if err != nil {
+		return nil, skipped, fmt.Errorf("读取证明批次失败: %w", err)
+	}
+	for _, r := range records {
+		if err := add("records/"+r.ID+".json", r); err != nil {
+			return nil, skipped, err
+		}
+		if proof := inclusion(r.ID); proof != nil && proof.ProofHash == r.ProofHash {
+			if err := add("inclusion/"+r.ID+".json", proof); err != nil {
+				return nil, skipped, err
+			}
+		}
+	}
+
+	if signer != nil {
+		sig, err := crypto.Sign(manifest.digest(), signer)
+		if err != nil {
+			return nil, skipped, fmt.Errorf("签名清单失败: %w", err)
+		}
+		manifest.Signer = crypto.PubkeyToAddress(signer.PublicKey).Hex()
+		manifest.Signature = "0x" + hex.EncodeToString(sig)
+	}
+	if err := add(bundleManifest, manifest); err != nil {
+		return nil, skipped, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, skipped, err
+	}
+	if err := writeFileAtomic(out, buf.Bytes(), 0644); err != nil {
+		return nil, skipped, err
+	}
+	return manifest, skipped, nil
+}
+
+// inclusionProofs 读取全部批次，返回按记录 ID 生成包含证明的函数 (每个批次只建一次树)
+func inclusionProofs(dir string) (func(id string) *InclusionProof, error) {
+	batches, err := LoadProofBatches(dir)
+	if err != nil {
+		return nil, err
+	}
+	type position struct {
+		batch *ProofBatch
+		index int
+	}
+	where := map[string]position{}
+	for _, b := range batches {
+		for i, id := range b.RecordIDs {
+			if _, ok := where[id]; !ok && i < len(b.Leaves) {
+				where[id] = position{b, i}
+			}
+		}
+	}
+	levels := map[int][][][]byte{}
+	return func(id string) *InclusionProof {
+		p, ok := where[id]
+		if !ok {
+			return nil
+		}
+		tree, ok := levels[p.batch.Index]
+		if !ok {
+			if tree, err = merkleLevels(p.batch.Leaves); err != nil {
+				return nil
+			}
+			levels[p.batch.Index] = tree
+		}
+		return &InclusionProof{
+			RecordID:  id,
+			ProofHash: p.batch.Leaves[p.index],
+			Batch:     p.batch.Index,
+			Root:      p.batch.Root,
+			Proof:     levelsProof(tree, p.index),
+		}
+	}, nil
+}
+
+// VerifyBundle 离线校验证明包: 文件哈希、清单签名、每条记录的工作证明与签名、
+// Merkle 根以及批次包含证明。格式错误返回 error，校验问题记录在 Problems 中
+func VerifyBundle(file string) (*BundleReport, error) {
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("打开证明包失败: %w", err)
+	}
+	defer zr.Close()
+
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败: %w", f.Name, err)
+		}
+		files[f.Name] = data
+	}
+
+	var manifest BundleManifest
+	if err := json.Unmarshal(files[bundleManifest], &manifest); err != nil {
+		return nil, fmt.Errorf("清单缺失或无效: %w", err)
+	}
+	if manifest.Version > BundleVersion {
+		return nil, fmt.Errorf("证明包版本 %d 高于支持的版本 %d", manifest.Version, BundleVersion)
+	}
+	report := &BundleReport{Manifest: &manifest}
+
+	// 文件完整性: 清单列出的文件必须存在且哈希一致，不允许清单外的文件
+	for name, want := range manifest.Files {
+		data, ok := files[name]
+		if !ok {
+			report.problem("缺少文件 %s", name)
+			continue
+		}
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != want {
+			report.problem("文件 %s 的哈希与清单不符", name)
+		}
+	}
+	for name := range files {
+		if _, ok := manifest.Files[name]; !ok && name != bundleManifest {
+			report.problem("清单未列出的文件 %s", name)
+		}
+	}
+
+	if manifest.Signature != "" {
+		if err := verifyManifestSignature(&manifest); err != nil {
+			report.problem("清单签名无效: %v", err)
+		}
+	}
+
+	var tree BundleTree
+	if err := json.Unmarshal(files[bundleMerkle], &tree); err != nil {
+		return nil, fmt.Errorf("Merkle 树缺失或无效: %w", err)
+	}
+	if len(tree.Leaves) != len(tree.RecordIDs) {
+		report.problem("Merkle 树叶子与记录 ID 数量不一致")
+	}
+	if root, err := MerkleRoot(tree.Leaves); err != nil || root != tree.Root || root != manifest.Root {
+		report.problem("Merkle 根与叶子或清单不符")
+	}
+	if len(tree.Leaves) != manifest.Records {
+		report.problem("清单记录数 %d 与 Merkle 叶子数 %d 不符", manifest.Records, len(tree.Leaves))
+	}
+
+	signers := map[string]bool{}
+	for i, id := range tree.RecordIDs {
+		report.Records++
+		data, ok := files["records/"+id+".json"]
+		if !ok {
+			report.problem("缺少记录 %s", id)
+			continue
+		}
+		r, _, err := decodeRecord(data)
+		if err != nil {
+			report.problem("记录 %s 无法解析: %v", id, err)
+			continue
+		}
+		if r.ID != id || i >= len(tree.Leaves) || r.ProofHash != tree.Leaves[i] {
+			report.problem("记录 %s 与 Merkle 叶子不符", id)
+			continue
+		}
+		if err := VerifyRecord(r); err != nil {
+			report.problem("记录 %s: %v", id, err)
+			continue
+		}
+		if manifest.Since > 0 && r.CompletedAt < manifest.Since {
+			report.problem("记录 %s 早于清单声明的起始时间", id)
+		}
+		report.Verified++
+		signers[r.Signer] = true
+
+		if data, ok := files["inclusion/"+id+".json"]; ok {
+			var proof InclusionProof
+			if json.Unmarshal(data, &proof) != nil || proof.RecordID != id || proof.ProofHash != r.ProofHash || !proof.Verify() {
+				report.problem("记录 %s 的批次包含证明无效", id)
+				continue
+			}
+			report.Inclusions++
+		}
+	}
+	inTree := make(map[string]bool, len(tree.RecordIDs))
+	for _, id := range tree.RecordIDs {
+		inTree[id] = true
+	}
+	for name := range files {
+		if strings.HasPrefix(name, "records/") {
+			id := strings.TrimSuffix(path.Base(name), ".json")
+			if !inTree[id] {
+				report.problem("记录 %s 不在 Merkle 树中", id)
+			}
+		}
+	}
+
+	for s := range signers {
+		report.Signers = append(report.Signers, s)
+	}
+	sort.Strings(report.Signers)
+	return report, nil
+}
+
+func verifyManifestSignature(m *BundleManifest) error {
+	sig, err := hex.DecodeString(strings.TrimPrefix(m.Signature, "0x"))
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return fmt.Errorf("签名格式无效")
+	}
+	pub, err := crypto.SigToPub(m.digest(), sig)
+	if err != nil {
+		return err
+	}
+	if signer := crypto.PubkeyToAddress(*pub).Hex(); !strings.EqualFold(signer, m.Signer) {
+		return fmt.Errorf("签名者 %s 与清单声明的 %s 不一致", signer, m.Signer)
+	}
+	return nil
+}
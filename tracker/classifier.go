@@ -0,0 +1,202 @@
+package worktracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// ============ 任务类型分类器 ============
+
+// ClassifyInput 分类依据: 事件内容与调用的工具
+type ClassifyInput struct {
+	Content string   `json:"content"`
+	Tools   []string `json:"tools,omitempty"` // 工具名称
+}
+
+// Classifier 任务类型分类器，ok=false 表示无法判断 (交由下一个分类器)
+type Classifier interface {
+	Classify(in ClassifyInput) (t TaskType, ok bool)
+}
+
+// ClassifierFunc 函数形式的分类器
+type ClassifierFunc func(in ClassifyInput) (TaskType, bool)
+
+func (f ClassifierFunc) Classify(in ClassifyInput) (TaskType, bool) { return f(in) }
+
+// ChainClassifier 依次尝试，取第一个能判断的结果
+type ChainClassifier []Classifier
+
+func (c ChainClassifier) Classify(in ClassifyInput) (TaskType, bool) {
+	for _, cl := range c {
+		if cl == nil {
+			continue
+		}
+		if t, ok := cl.Classify(in); ok {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// keywordClassifier 按 config.json task_types 中注册的关键词分类，见 MatchTaskType
+var keywordClassifier = ClassifierFunc(func(in ClassifyInput) (TaskType, bool) {
+	return MatchTaskType(in.Content)
+})
+
+// ClassifyRule 分类规则: 内容匹配 Pattern 或任一工具名匹配 Tool (均为正则，不区分大小写) 时归为 Type；
+// 两者都设置时需同时满足
+type ClassifyRule struct {
+	Pattern string   `json:"pattern,omitempty"`
+	Tool    string   `json:"tool,omitempty"`
+	Type    TaskType `json:"type"`
+
+	content *regexp.Regexp
+	tool    *regexp.Regexp
+}
+
+// compile 编译规则中的正则
+func (r *ClassifyRule) compile() error {
+	if r.Pattern == "" && r.Tool == "" {
+		return fmt.Errorf("分类规则 %s 缺少 pattern 或 tool", r.Type)
+	}
+	if !IsTaskType(r.Type) {
+		return fmt.Errorf("分类规则的任务类型未知: %s", r.Type)
+	}
+	var err error
+	if r.Pattern != "" {
+		if r.content, err = regexp.Compile("(?i)" + r.Pattern); err != nil {
+			return fmt.Errorf("分类规则 %q 无效: %w", r.Pattern, err)
+		}
+	}
+	if r.Tool != "" {
+		if r.tool, err = regexp.Compile("(?i)" + r.Tool); err != nil {
+			return fmt.Errorf("分类规则 %q 无效: %w", r.Tool, err)
+		}
+	}
+	return nil
+}
+
+func (r *ClassifyRule) match(in ClassifyInput) bool {
+	if r.content != nil && !r.content.MatchString(in.Content) {
+		return false
+	}
+	if r.tool == nil {
+		return true
+	}
+	for _, name := range in.Tools {
+		if r.tool.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// RuleClassifier 规则引擎: 按顺序匹配，第一条命中的规则生效
+type RuleClassifier struct {
+	rules []ClassifyRule
+}
+
+// NewRuleClassifier 编译规则并创建规则引擎
+func NewRuleClassifier(rules []ClassifyRule) (*RuleClassifier, error) {
+	c := &RuleClassifier{rules: make([]ClassifyRule, len(rules))}
+	copy(c.rules, rules)
+	for i := range c.rules {
+		if err := c.rules[i].compile(); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// LoadRuleClassifier 从规则文件 (JSON 数组: [{"pattern":"terraform|helm","type":"deploy"}]) 创建规则引擎
+func LoadRuleClassifier(path string) (*RuleClassifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []ClassifyRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("解析规则文件 %s 失败: %w", path, err)
+	}
+	return NewRuleClassifier(rules)
+}
+
+func (c *RuleClassifier) Classify(in ClassifyInput) (TaskType, bool) {
+	for i := range c.rules {
+		if c.rules[i].match(in) {
+			return c.rules[i].Type, true
+		}
+	}
+	return "", false
+}
+
+// DefaultRules 内置规则: 代码、调试、部署、文档关键词，之后按工具判断
+var DefaultRules = []ClassifyRule{
+	{Pattern: `func |def |class |const |let |import |package `, Type: TaskCoding},
+	{Pattern: `error|bug|fix|debug|exception|traceback`, Type: TaskDebug},
+	{Pattern: `deploy|docker|kubernetes|kubectl|npm run|build|serve`, Type: TaskDeploy},
+	{Pattern: `readme|document|comment|explain|describe`, Type: TaskDoc},
+	{Tool: `^(exec|bash)`, Type: TaskCoding},
+	{Tool: `^(write|edit).*\.(go|js|ts|py|rs|java|cpp|c|h|cs)$`, Type: TaskCoding},
+	{Tool: `^(write|edit)`, Type: TaskWriting},
+}
+
+var defaultRuleClassifier = mustRuleClassifier(DefaultRules)
+
+func mustRuleClassifier(rules []ClassifyRule) *RuleClassifier {
+	c, err := NewRuleClassifier(rules)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// DefaultTaskType 所有分类器都无法判断时的类型
+const DefaultTaskType = TaskResearch
+
+// classifier 当前分类器 (调用方可用 SetClassifier 替换)
+var classifier Classifier = ChainClassifier{keywordClassifier, defaultRuleClassifier}
+
+// SetClassifier 替换分类器 (为 nil 时恢复默认: 注册类型的关键词 → 内置规则)
+func SetClassifier(c Classifier) {
+	if c == nil {
+		c = ChainClassifier{keywordClassifier, defaultRuleClassifier}
+	}
+	classifier = c
+}
+
+// Classify 为事件分类，无法判断时返回 DefaultTaskType
+func Classify(in ClassifyInput) TaskType {
+	if t, ok := classifier.Classify(in); ok {
+		return t
+	}
+	return DefaultTaskType
+}
+
+// ClassifierConfig 分类器配置 (config.json 的 classifier)
+type ClassifierConfig struct {
+	Rules string         `json:"rules,omitempty"` // 规则文件，优先于内置规则
+	LLM   *LLMClassifier `json:"llm,omitempty"`   // 规则都未命中时询问大模型 (可选)
+}
+
+// ConfigureClassifier 按配置组装分类器: 注册类型的关键词 → 规则文件 → 大模型 → 内置规则
+func ConfigureClassifier(cfg ClassifierConfig) error {
+	chain := ChainClassifier{keywordClassifier}
+	if cfg.Rules != "" {
+		rules, err := LoadRuleClassifier(cfg.Rules)
+		if err != nil {
+			return err
+		}
+		chain = append(chain, rules)
+	}
+	if cfg.LLM != nil {
+		if err := cfg.LLM.validate(); err != nil {
+			return err
+		}
+		chain = append(chain, cfg.LLM)
+	}
+	SetClassifier(append(chain, defaultRuleClassifier))
+	return nil
+}
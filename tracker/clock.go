@@ -0,0 +1,79 @@
+package worktracker
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ============ 时钟偏差防护 ============
+
+// 墙上时钟可被回拨或伪造，记录另外带有追踪器分配的单调递增写入序号 (Seq)，
+// 序号参与工作证明，记录的先后顺序以序号为准，时间戳只作参考
+
+// MaxClockSkew 允许的时钟偏差: 完成时间超出当前时间、或序号靠后的记录早于前一条记录超过此值时视为异常
+var MaxClockSkew = 5 * time.Minute
+
+// nextSeq 分配下一个写入序号 (调用方需持有写锁)
+func (t *Tracker) nextSeq() uint64 {
+	t.seq++
+	return t.seq
+}
+
+// observeSeq 登记已有记录的序号，保证之后分配的序号更大 (调用方需持有写锁)
+func (t *Tracker) observeSeq(seq uint64) {
+	if seq > t.seq {
+		t.seq = seq
+	}
+}
+
+// checkClock 校验记录时间: 完成时间不能早于开始时间，也不能超出当前时间 MaxClockSkew 以上
+func checkClock(r *WorkRecord, now time.Time) error {
+	if r.StartedAt > 0 && r.CompletedAt > 0 && r.CompletedAt < r.StartedAt {
+		return fmt.Errorf("完成时间 %d 早于开始时间 %d", r.CompletedAt, r.StartedAt)
+	}
+	if limit := now.Add(MaxClockSkew).UnixMilli(); r.CompletedAt > limit || r.StartedAt > limit {
+		return fmt.Errorf("记录时间超出当前时间 (允许偏差 %s)", MaxClockSkew)
+	}
+	return nil
+}
+
+// ClockIssue 时间异常的记录
+type ClockIssue struct {
+	Record *WorkRecord `json:"record"`
+	Reason string      `json:"reason"`
+}
+
+// ClockIssues 检查时间戳可疑的记录: 完成时间在未来或早于开始时间，
+// 以及按写入序号排列时时间明显倒退 (写入时时钟被回拨)
+func (t *Tracker) ClockIssues() []ClockIssue {
+	t.ensureLoaded()
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	now := time.Now()
+	var issues []ClockIssue
+	var sequenced []*WorkRecord
+	for _, r := range t.order {
+		if err := checkClock(r, now); err != nil {
+			issues = append(issues, ClockIssue{r, err.Error()})
+		}
+		if r.Seq > 0 {
+			sequenced = append(sequenced, r)
+		}
+	}
+
+	sort.Slice(sequenced, func(i, j int) bool { return sequenced[i].Seq < sequenced[j].Seq })
+	skew := MaxClockSkew.Milliseconds()
+	var latest *WorkRecord
+	for _, r := range sequenced {
+		if latest != nil && r.CompletedAt < latest.CompletedAt-skew {
+			issues = append(issues, ClockIssue{r, fmt.Sprintf("序号 %d 晚于记录 %s (序号 %d)，完成时间却早 %s",
+				r.Seq, latest.ID, latest.Seq, time.Duration(latest.CompletedAt-r.CompletedAt)*time.Millisecond)})
+		}
+		if latest == nil || r.CompletedAt > latest.CompletedAt {
+			latest = r
+		}
+	}
+	return issues
+}
@@ -0,0 +1,190 @@
+package worktracker
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ============ 记录字段加密 ============
+
+// 任务描述与修正原因可能包含专有的提示词或代码。设置 RecordKey 后，保存到磁盘的记录中
+// 这些字段以 AES-256-GCM 加密 ("enc:v1:<base64(nonce|密文)>"，以记录 ID 作为附加数据)，
+// 加载时透明解密；内存中的记录、统计、Webhook 与工作证明均使用明文。
+// 未设置密钥时加密字段保持原样 (不可读，但重新保存时不会损坏)
+
+const encPrefix = "enc:v1:"
+
+// RecordKey 记录字段加密密钥 (32 字节)，为空时不加密
+var RecordKey []byte
+
+// SetRecordKey 设置记录字段加密密钥
+func SetRecordKey(key []byte) error {
+	if len(key) != 32 {
+		return fmt.Errorf("加密密钥须为 32 字节 (当前 %d 字节)", len(key))
+	}
+	RecordKey = append([]byte(nil), key...)
+	return nil
+}
+
+// DeriveRecordKey 由钱包私钥派生记录加密密钥 (与签名用途分离)
+func DeriveRecordKey(privateKeyHex string) ([]byte, error) {
+	priv, err := hex.DecodeString(strings.TrimPrefix(privateKeyHex, "0x"))
+	if err != nil || len(priv) == 0 {
+		return nil, fmt.Errorf("私钥格式无效")
+	}
+	sum := sha256.Sum256(append([]byte("oaw-record-encryption/v1|"), priv...))
+	return sum[:], nil
+}
+
+// LoadRecordKeyFile 读取数据密钥文件 (64 位十六进制)
+func LoadRecordKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("密钥文件 %s 格式无效: %w", path, err)
+	}
+	return key, nil
+}
+
+// GenerateRecordKeyFile 生成随机数据密钥并写入 path (仅所有者可读)，已存在时不覆盖
+func GenerateRecordKeyFile(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("密钥文件已存在: %s", path)
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, []byte(hex.EncodeToString(key)+"\n"), 0600)
+}
+
+func recordCipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(RecordKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func sealField(gcm cipher.AEAD, id, plain string) (string, error) {
+	if plain == "" || strings.HasPrefix(plain, encPrefix) {
+		return plain, nil
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), []byte(id))
+	return encPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func openField(gcm cipher.AEAD, id, value string) (string, error) {
+	if !strings.HasPrefix(value, encPrefix) {
+		return value, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encPrefix))
+	if err != nil || len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("密文格式无效")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], []byte(id))
+	if err != nil {
+		return "", fmt.Errorf("解密失败 (密钥不匹配?)")
+	}
+	return string(plain), nil
+}
+
+// sealed 写入磁盘的副本: 设置密钥时加密敏感字段
+func (w *WorkRecord) sealed() (*WorkRecord, error) {
+	if RecordKey == nil {
+		return w, nil
+	}
+	gcm, err := recordCipher()
+	if err != nil {
+		return nil, err
+	}
+	c := *w
+	if c.TaskDesc, err = sealField(gcm, w.ID, w.TaskDesc); err != nil {
+		return nil, err
+	}
+	if c.AmendReason, err = sealField(gcm, w.ID, w.AmendReason); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// writeRecordAtomic 原子写入记录文件 (敏感字段按需加密)
+func writeRecordAtomic(path string, r *WorkRecord) error {
+	disk, err := r.sealed()
+	if err != nil {
+		return err
+	}
+	return writeJSONAtomic(path, disk)
+}
+
+// openFields 解密从磁盘读取的敏感字段 (未设置密钥时保持密文)
+func (w *WorkRecord) openFields() error {
+	if RecordKey == nil || !w.Encrypted() {
+		return nil
+	}
+	gcm, err := recordCipher()
+	if err != nil {
+		return err
+	}
+	if w.TaskDesc, err = openField(gcm, w.ID, w.TaskDesc); err != nil {
+		return fmt.Errorf("记录 %s 的任务描述%w", w.ID, err)
+	}
+	if w.AmendReason, err = openField(gcm, w.ID, w.AmendReason); err != nil {
+		return fmt.Errorf("记录 %s 的修正原因%w", w.ID, err)
+	}
+	return nil
+}
+
+// Encrypted 记录是否含未解密的字段
+func (w *WorkRecord) Encrypted() bool {
+	return strings.HasPrefix(w.TaskDesc, encPrefix) || strings.HasPrefix(w.AmendReason, encPrefix)
+}
+
+// EncryptAll 按当前密钥重写全部记录文件与分段 (加密已有的明文记录)，返回重写的记录数
+func (t *Tracker) EncryptAll() (int, error) {
+	if RecordKey == nil {
+		return 0, fmt.Errorf("未设置加密密钥")
+	}
+	t.ensureLoaded()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entries, err := os.ReadDir(t.dataDir)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, e := range entries {
+		if e.IsDir() || !isRecordFile(e.Name()) {
+			continue
+		}
+		r := t.records[strings.TrimSuffix(e.Name(), ".json")]
+		if r == nil {
+			continue // 重复或无法解析的文件保持原样
+		}
+		if err := t.save(r); err != nil {
+			return n, fmt.Errorf("重写记录 %s 失败: %w", r.ID, err)
+		}
+		n++
+	}
+	m, err := t.rewriteSegments()
+	return n + m, err
+}
@@ -0,0 +1,67 @@
+package worktracker
+
+import "time"
+
+// ============ 记录事件订阅 ============
+
+// RecordEventType 记录生命周期事件类型
+type RecordEventType string
+
+const (
+	RecordStarted   RecordEventType = "started"
+	RecordCompleted RecordEventType = "completed"
+	RecordFailed    RecordEventType = "failed"
+	RecordAmended   RecordEventType = "amended"
+	RecordReviewed  RecordEventType = "reviewed" // 设置质量评分
+)
+
+// subscriberBuffer 每个订阅者的事件缓冲，消费过慢时丢弃新事件而不阻塞追踪器
+const subscriberBuffer = 256
+
+// RecordEvent 记录事件 (Record 为事件发生时的副本)
+type RecordEvent struct {
+	Type   RecordEventType `json:"type"`
+	Record WorkRecord      `json:"record"`
+	Time   int64           `json:"time"`
+}
+
+// Subscribe 订阅记录事件，返回事件通道与取消函数 (取消后通道关闭)
+func (t *Tracker) Subscribe() (<-chan RecordEvent, func()) {
+	ch := make(chan RecordEvent, subscriberBuffer)
+
+	t.mu.Lock()
+	if t.subscribers == nil {
+		t.subscribers = make(map[chan RecordEvent]struct{})
+	}
+	t.subscribers[ch] = struct{}{}
+	t.mu.Unlock()
+
+	cancel := func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if _, ok := t.subscribers[ch]; ok {
+			delete(t.subscribers, ch)
+			close(ch)
+		}
+	}
+	return ch, cancel
+}
+
+// publish 向订阅者发送事件 (调用方需持有写锁)
+func (t *Tracker) publish(typ RecordEventType, r *WorkRecord) {
+	if len(t.subscribers) == 0 {
+		return
+	}
+	event := RecordEvent{Type: typ, Record: *r, Time: time.Now().UnixMilli()}
+	event.Record.Tags = append([]string(nil), r.Tags...)
+	if r.QualityScore != nil {
+		score := *r.QualityScore
+		event.Record.QualityScore = &score
+	}
+	for ch := range t.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package worktracker
+
+import (
+	"crypto/rand"
+	"sync"
+	"time"
+)
+
+// ============ 记录 ID ============
+
+// 记录 ID 采用 ULID 格式: 48 位毫秒时间戳 + 80 位随机数，Crockford Base32 编码为 26 个字符，
+// 按字典序即按生成时间排序。同一毫秒内 (或时钟回拨时) 沿用上一时间戳并将随机部分加一，
+// 保证同一进程内生成的 ID 严格递增
+
+const idAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var idGen struct {
+	mu      sync.Mutex
+	lastMs  uint64
+	entropy [10]byte
+}
+
+// generateID 生成单调递增的记录 ID
+func generateID() string {
+	idGen.mu.Lock()
+	defer idGen.mu.Unlock()
+
+	ms := uint64(time.Now().UnixMilli())
+	if ms > idGen.lastMs {
+		idGen.lastMs = ms
+		if _, err := rand.Read(idGen.entropy[:]); err != nil {
+			panic("读取随机数失败: " + err.Error())
+		}
+	} else if !incEntropy(&idGen.entropy) {
+		// 随机部分溢出时借用下一毫秒
+		idGen.lastMs++
+		rand.Read(idGen.entropy[:])
+	}
+
+	var b [16]byte
+	for i := 0; i < 6; i++ {
+		b[i] = byte(idGen.lastMs >> (40 - 8*i))
+	}
+	copy(b[6:], idGen.entropy[:])
+	return encodeID(b)
+}
+
+// incEntropy 随机部分加一，溢出时返回 false
+func incEntropy(e *[10]byte) bool {
+	for i := len(e) - 1; i >= 0; i-- {
+		e[i]++
+		if e[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeID 将 128 位 ID 编码为 26 个 Base32 字符 (首字符只用 3 位)
+func encodeID(b [16]byte) string {
+	out := make([]byte, 26)
+	// 从低位开始每次取 5 位
+	var acc uint32
+	bits := 0
+	pos := 25
+	for i := 15; i >= 0; i-- {
+		acc |= uint32(b[i]) << bits
+		bits += 8
+		for bits >= 5 && pos >= 0 {
+			out[pos] = idAlphabet[acc&31]
+			acc >>= 5
+			bits -= 5
+			pos--
+		}
+	}
+	if pos >= 0 {
+		out[pos] = idAlphabet[acc&31]
+	}
+	return string(out)
+}
+
+// newRecordID 生成未被占用的记录 ID (调用方需持有写锁)，与已有记录冲突时重新生成
+func (t *Tracker) newRecordID() string {
+	for {
+		id := generateID()
+		if !t.idTaken(id) {
+			return id
+		}
+	}
+}
+
+// idTaken ID 是否已被内存或分段中的记录占用 (调用方需持有锁)
+func (t *Tracker) idTaken(id string) bool {
+	if _, ok := t.records[id]; ok {
+		return true
+	}
+	_, ok := t.segmentOf[id]
+	return ok
+}
@@ -0,0 +1,159 @@
+package worktracker
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ============ 批量导入 ============
+
+// maxImportLine 单行记录的最大长度
+const maxImportLine = 1 << 20
+
+// ImportError 无效行
+type ImportError struct {
+	Line int    `json:"line"`
+	Err  string `json:"error"`
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	Imported    int           `json:"imported"`
+	Duplicates  int           `json:"duplicates"`   // 工作证明已存在
+	ProofsFixed int           `json:"proofs_fixed"` // 工作证明与字段不符，已重算
+	Unsigned    int           `json:"unsigned"`     // 签名无效已移除 (含重算证明的记录)
+	Invalid     []ImportError `json:"invalid"`
+}
+
+// Import 从 JSONL 导入外部工作记录 (每行一条 WorkRecord):
+// 校验字段、按字段重算工作证明、按工作证明去重；签名仅在校验通过时保留
+func (t *Tracker) Import(in io.Reader) (*ImportResult, error) {
+	t.ensureLoaded()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := &ImportResult{Invalid: []ImportError{}}
+	imported := map[string]*WorkRecord{}
+	var order []*WorkRecord
+	var replaced []*WorkRecord // 被导入的修正记录取代、此前已计入统计的记录
+	var importErr error        // 写入失败时停止导入，已导入的记录仍计入统计
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLine)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		decoded, _, err := decodeRecord([]byte(text))
+		if err != nil {
+			result.Invalid = append(result.Invalid, ImportError{line, fmt.Sprintf("解析失败: %v", err)})
+			continue
+		}
+		rec := *decoded
+		if err := validateImport(&rec); err != nil {
+			result.Invalid = append(result.Invalid, ImportError{line, err.Error()})
+			continue
+		}
+		if err := checkClock(&rec, time.Now()); err != nil {
+			result.Invalid = append(result.Invalid, ImportError{line, err.Error()})
+			continue
+		}
+		if rec.ID == "" {
+			rec.ID = t.newRecordID()
+		}
+		rec.Tags = normalizeTags(rec.Tags)
+
+		if proof := rec.ComputeProof(); proof != rec.ProofHash {
+			if rec.ProofHash != "" {
+				result.ProofsFixed++
+			}
+			rec.ProofHash = proof
+		}
+		if rec.Signature != "" || rec.Signer != "" {
+			if VerifyRecord(&rec) != nil {
+				rec.Signature, rec.Signer = "", ""
+				result.Unsigned++
+			}
+		}
+
+		if _, dup := t.proofs[rec.ProofHash]; dup {
+			result.Duplicates++
+			continue
+		}
+		if t.idTaken(rec.ID) {
+			result.Invalid = append(result.Invalid, ImportError{line, fmt.Sprintf("记录 ID 已存在: %s", rec.ID)})
+			continue
+		}
+		if latest := t.amendedBy[rec.Amends]; rec.Amends != "" && latest != "" {
+			result.Invalid = append(result.Invalid, ImportError{line, fmt.Sprintf("记录 %s 已被 %s 修正", rec.Amends, latest)})
+			continue
+		}
+
+		r := &rec
+		if err := t.save(r); err != nil {
+			importErr = fmt.Errorf("第 %d 行保存失败: %w", line, err)
+			break
+		}
+		if r.Amends != "" {
+			if orig, ok := t.records[r.Amends]; ok && imported[orig.ID] == nil && !t.superseded(orig) {
+				replaced = append(replaced, orig)
+			}
+			t.amendedBy[r.Amends] = r.ID
+		}
+		t.records[r.ID] = r
+		t.proofs[r.ProofHash] = r.ID
+		t.indexEvents(r)
+		t.observeSeq(r.Seq)
+		t.indexInsert(r)
+		imported[r.ID] = r
+		order = append(order, r)
+		result.Imported++
+	}
+	if err := scanner.Err(); err != nil && importErr == nil {
+		importErr = fmt.Errorf("读取导入文件失败: %w", err)
+	}
+
+	// 全部插入后再统计，修正记录先于原记录出现时也只计入最新版本
+	var touched []int64
+	for _, r := range replaced {
+		t.removeStats(r)
+		touched = append(touched, r.recordTime())
+	}
+	for _, r := range order {
+		if !t.superseded(r) {
+			t.updateStats(r)
+		}
+		touched = append(touched, r.recordTime())
+	}
+	t.invalidateRollups(touched...)
+	if len(order) > 0 {
+		t.saveStats()
+	}
+	return result, importErr
+}
+
+// validateImport 校验导入记录的字段
+func validateImport(r *WorkRecord) error {
+	switch {
+	case r.AgentID == "":
+		return fmt.Errorf("缺少 agent_id")
+	case r.TaskType == "":
+		return fmt.Errorf("缺少 task_type")
+	case r.Status != "completed" && r.Status != "failed":
+		return fmt.Errorf("只能导入已结束的记录 (状态: %s)", r.Status)
+	case r.CompletedAt <= 0 || r.CompletedAt < r.StartedAt:
+		return fmt.Errorf("完成时间无效: %d", r.CompletedAt)
+	case r.TokensInput < 0 || r.TokensOutput < 0 || r.TokensCache < 0 || r.CodeLines < 0 || r.CodeFiles < 0 ||
+		r.WordsWritten < 0 || r.BugsFixed < 0 || r.APICalls < 0 || r.ErrorsFixed < 0 || r.CostUSD < 0 || r.Aggregated < 0:
+		return fmt.Errorf("工作量指标不能为负")
+	case r.QualityScore != nil && (*r.QualityScore < 0 || *r.QualityScore > 1):
+		return fmt.Errorf("质量评分须在 0~1 之间")
+	case r.Amends != "" && r.Amends == r.ID:
+		return fmt.Errorf("记录不能修正自身")
+	}
+	return nil
+}
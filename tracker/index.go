@@ -0,0 +1,68 @@
+package worktracker
+
+import "sort"
+
+// ============ 索引 ============
+
+// 索引按记录时间升序保存记录 (最新的在末尾)，写入时维护，查询按倒序遍历无需排序:
+//   t.order   全部记录
+//   t.byAgent 按 Agent 分组的记录
+
+// olderThan 升序比较: a 排在 b 之前 (与 recordBefore 相反)
+func olderThan(a, b *WorkRecord) bool {
+	return recordBefore(b, a)
+}
+
+// insertSorted 按时间插入，新记录通常直接追加在末尾
+func insertSorted(s []*WorkRecord, r *WorkRecord) []*WorkRecord {
+	n := len(s)
+	if n == 0 || olderThan(s[n-1], r) {
+		return append(s, r)
+	}
+	pos := sort.Search(n, func(i int) bool { return olderThan(r, s[i]) })
+	s = append(s, nil)
+	copy(s[pos+1:], s[pos:])
+	s[pos] = r
+	return s
+}
+
+// removeSorted 移除记录，须在修改记录时间之前调用
+func removeSorted(s []*WorkRecord, r *WorkRecord) []*WorkRecord {
+	pos := sort.Search(len(s), func(i int) bool { return !olderThan(s[i], r) })
+	for i := pos; i < len(s) && !olderThan(r, s[i]); i++ {
+		if s[i] == r {
+			return append(s[:i], s[i+1:]...)
+		}
+	}
+	return s
+}
+
+// indexInsert 将记录加入索引 (调用方需持有写锁)
+func (t *Tracker) indexInsert(r *WorkRecord) {
+	t.order = insertSorted(t.order, r)
+	t.byAgent[r.AgentID] = insertSorted(t.byAgent[r.AgentID], r)
+}
+
+// indexRemove 将记录移出索引 (调用方需持有写锁)
+func (t *Tracker) indexRemove(r *WorkRecord) {
+	t.order = removeSorted(t.order, r)
+	t.byAgent[r.AgentID] = removeSorted(t.byAgent[r.AgentID], r)
+}
+
+// indexRebuild 重建索引 (加载历史记录后调用)
+func (t *Tracker) indexRebuild() {
+	t.order = make([]*WorkRecord, 0, len(t.records))
+	t.byAgent = make(map[string][]*WorkRecord)
+	for _, r := range t.records {
+		t.order = append(t.order, r)
+	}
+	sort.Slice(t.order, func(i, j int) bool { return olderThan(t.order[i], t.order[j]) })
+	for _, r := range t.order {
+		t.byAgent[r.AgentID] = append(t.byAgent[r.AgentID], r)
+	}
+}
+
+// timeBound 第一条记录时间 >= ms 的位置
+func timeBound(s []*WorkRecord, ms int64) int {
+	return sort.Search(len(s), func(i int) bool { return s[i].recordTime() >= ms })
+}
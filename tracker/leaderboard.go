@@ -0,0 +1,106 @@
+package worktracker
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============ Agent 排行榜 ============
+
+// LeaderboardEntry 排行榜条目
+type LeaderboardEntry struct {
+	Rank        int     `json:"rank"`
+	AgentID     string  `json:"agent_id"`
+	Signer      string  `json:"signer"` // 最近一条记录的签名钱包
+	Tasks       int     `json:"tasks"`
+	TotalTokens int64   `json:"total_tokens"`
+	Value       float64 `json:"value"` // 已验证价值
+}
+
+// ParseLookback 解析回溯时长: 30d / 2w / 12h / 90m，all 或空表示全部
+func ParseLookback(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "all" {
+		return 0, nil
+	}
+	unit := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}[s[len(s)-1]]
+	if unit > 0 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("无效的时长: %s (如 30d、2w、12h)", s)
+		}
+		return time.Duration(n) * unit, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("无效的时长: %s (如 30d、2w、12h)", s)
+	}
+	return d, nil
+}
+
+// Leaderboard 按已验证价值排名 Agent: 只计入 since 之后结束、签名校验通过、
+// 未被修正且未被标记为可疑的记录；since 为零时统计全部记录，limit <= 0 不限条数
+func (t *Tracker) Leaderboard(since time.Time, limit int) []LeaderboardEntry {
+	t.ensureLoaded()
+	t.mu.Lock() // 校验结果写入缓存
+	defer t.mu.Unlock()
+
+	lo := 0
+	if !since.IsZero() {
+		lo = timeBound(t.order, since.UnixMilli())
+	}
+
+	byAgent := map[string]*LeaderboardEntry{}
+	for _, r := range t.order[lo:] {
+		if r.Status != "completed" && r.Status != "failed" || t.superseded(r) || !t.verified(r) || r.Flagged() {
+			continue
+		}
+		e, ok := byAgent[r.AgentID]
+		if !ok {
+			e = &LeaderboardEntry{AgentID: r.AgentID}
+			byAgent[r.AgentID] = e
+		}
+		e.Signer = r.Signer // 按时间升序遍历，保留最近的签名钱包
+		e.Tasks++
+		e.TotalTokens += r.TokensInput + r.TokensOutput
+		e.Value += r.CalculateValue()
+	}
+
+	board := make([]LeaderboardEntry, 0, len(byAgent))
+	for _, e := range byAgent {
+		board = append(board, *e)
+	}
+	sort.Slice(board, func(i, j int) bool {
+		if board[i].Value != board[j].Value {
+			return board[i].Value > board[j].Value
+		}
+		return board[i].AgentID < board[j].AgentID
+	})
+	if limit > 0 && len(board) > limit {
+		board = board[:limit]
+	}
+	for i := range board {
+		board[i].Rank = i + 1
+	}
+	return board
+}
+
+// verified 记录签名是否校验通过，结果按记录缓存 (已结束的记录不可变，调用方需持有写锁)
+func (t *Tracker) verified(r *WorkRecord) bool {
+	if !r.IsSigned() {
+		return false
+	}
+	key := r.ID + "|" + r.Signature
+	if ok, cached := t.verifiedCache[key]; cached {
+		return ok
+	}
+	if t.verifiedCache == nil {
+		t.verifiedCache = make(map[string]bool)
+	}
+	ok := VerifyRecord(r) == nil
+	t.verifiedCache[key] = ok
+	return ok
+}
@@ -0,0 +1,112 @@
+package worktracker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ============ 大模型分类器 ============
+
+// LLMClassifier 调用 OpenAI 兼容的 chat/completions 接口分类，请求失败或回答不是已知类型时交由下一个分类器
+type LLMClassifier struct {
+	URL       string `json:"url"`                   // 接口地址，如 https://api.openai.com/v1/chat/completions
+	Model     string `json:"model"`                 // 模型名称
+	APIKeyEnv string `json:"api_key_env,omitempty"` // 读取 API 密钥的环境变量 (密钥不写入配置文件)
+	Timeout   string `json:"timeout,omitempty"`     // 请求超时 (默认 10s)
+	MaxChars  int    `json:"max_chars,omitempty"`   // 发送的内容上限 (默认 2000 字符)
+}
+
+const (
+	llmDefaultTimeout  = 10 * time.Second
+	llmDefaultMaxChars = 2000
+)
+
+func (c *LLMClassifier) validate() error {
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("无效的分类模型地址: %s", c.URL)
+	}
+	if c.Model == "" {
+		return fmt.Errorf("分类模型未指定 model")
+	}
+	if c.Timeout != "" {
+		if _, err := time.ParseDuration(c.Timeout); err != nil {
+			return fmt.Errorf("分类模型超时无效: %q", c.Timeout)
+		}
+	}
+	return nil
+}
+
+// prompt 分类提示: 列出全部已知类型，要求只回答类型名
+func (c *LLMClassifier) prompt(in ClassifyInput) string {
+	var b strings.Builder
+	b.WriteString("将以下 AI Agent 的工作归入一个任务类型，只回答类型名。可选类型:\n")
+	for _, def := range TaskTypes() {
+		fmt.Fprintf(&b, "- %s: %s\n", def.Name, def.Desc)
+	}
+	content := in.Content
+	max := c.MaxChars
+	if max <= 0 {
+		max = llmDefaultMaxChars
+	}
+	if r := []rune(content); len(r) > max {
+		content = string(r[:max])
+	}
+	fmt.Fprintf(&b, "\n工具: %s\n内容:\n%s", strings.Join(in.Tools, ", "), content)
+	return b.String()
+}
+
+func (c *LLMClassifier) Classify(in ClassifyInput) (TaskType, bool) {
+	if strings.TrimSpace(in.Content) == "" && len(in.Tools) == 0 {
+		return "", false
+	}
+	body, _ := json.Marshal(map[string]any{
+		"model":       c.Model,
+		"temperature": 0,
+		"messages":    []map[string]string{{"role": "user", "content": c.prompt(in)}},
+	})
+	req, err := http.NewRequest(http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKeyEnv != "" {
+		if key := os.Getenv(c.APIKeyEnv); key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+	}
+	timeout := llmDefaultTimeout
+	if d, err := time.ParseDuration(c.Timeout); err == nil && d > 0 {
+		timeout = d
+	}
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return "", false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", false
+	}
+
+	var out struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if json.NewDecoder(resp.Body).Decode(&out) != nil || len(out.Choices) == 0 {
+		return "", false
+	}
+	answer := strings.ToLower(strings.Trim(strings.TrimSpace(out.Choices[0].Message.Content), "`\"'.。"))
+	if t := TaskType(answer); IsTaskType(t) {
+		return t, true
+	}
+	return "", false
+}
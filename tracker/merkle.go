@@ -0,0 +1,252 @@
+package worktracker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ============ Merkle 批量证明 ============
+
+// MerkleStep 包含证明中的一步
+type MerkleStep struct {
+	Hash string `json:"hash"`
+	Left bool   `json:"left"` // 兄弟节点在左侧
+}
+
+// merkleParent 父节点 = sha256(左 || 右)
+func merkleParent(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// merkleLevels 由叶子构建整棵树 (奇数个节点时复制最后一个)
+func merkleLevels(leaves []string) ([][][]byte, error) {
+	if len(leaves) == 0 {
+		return nil, fmt.Errorf("没有叶子节点")
+	}
+	level := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		b, err := hex.DecodeString(leaf)
+		if err != nil {
+			return nil, fmt.Errorf("无效的叶子哈希: %s", leaf)
+		}
+		level[i] = b
+	}
+
+	levels := [][][]byte{level}
+	for len(level) > 1 {
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+			levels[len(levels)-1] = level
+		}
+		next := make([][]byte, len(level)/2)
+		for i := range next {
+			next[i] = merkleParent(level[2*i], level[2*i+1])
+		}
+		levels = append(levels, next)
+		level = next
+	}
+	return levels, nil
+}
+
+// MerkleRoot 计算叶子 (十六进制哈希) 的 Merkle 根
+func MerkleRoot(leaves []string) (string, error) {
+	levels, err := merkleLevels(leaves)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(levels[len(levels)-1][0]), nil
+}
+
+// MerkleProof 生成第 index 个叶子的包含证明
+func MerkleProof(leaves []string, index int) ([]MerkleStep, error) {
+	if index < 0 || index >= len(leaves) {
+		return nil, fmt.Errorf("叶子序号越界: %d", index)
+	}
+	levels, err := merkleLevels(leaves)
+	if err != nil {
+		return nil, err
+	}
+	return levelsProof(levels, index), nil
+}
+
+// levelsProof 由已构建的树生成包含证明 (同一棵树生成多个证明时避免重复建树)
+func levelsProof(levels [][][]byte, index int) []MerkleStep {
+	var proof []MerkleStep
+	for _, level := range levels[:len(levels)-1] {
+		sibling := index ^ 1
+		proof = append(proof, MerkleStep{Hash: hex.EncodeToString(level[sibling]), Left: sibling < index})
+		index /= 2
+	}
+	return proof
+}
+
+// VerifyMerkleProof 校验叶子是否包含在根中
+func VerifyMerkleProof(leaf string, proof []MerkleStep, root string) bool {
+	node, err := hex.DecodeString(leaf)
+	if err != nil {
+		return false
+	}
+	for _, step := range proof {
+		sibling, err := hex.DecodeString(step.Hash)
+		if err != nil {
+			return false
+		}
+		if step.Left {
+			node = merkleParent(sibling, node)
+		} else {
+			node = merkleParent(node, sibling)
+		}
+	}
+	return hex.EncodeToString(node) == root
+}
+
+// ProofBatch 一批记录工作证明的 Merkle 承诺 (proofs/batch-<序号>.json)
+type ProofBatch struct {
+	Index     int      `json:"index"`
+	Root      string   `json:"root"`
+	Leaves    []string `json:"leaves"`     // 记录工作证明哈希 (按记录时间排序)
+	RecordIDs []string `json:"record_ids"` // 与 Leaves 一一对应
+	CreatedAt int64    `json:"created_at"`
+}
+
+// InclusionProof 记录的包含证明
+type InclusionProof struct {
+	RecordID  string       `json:"record_id"`
+	ProofHash string       `json:"proof_hash"`
+	Batch     int          `json:"batch"`
+	Root      string       `json:"root"`
+	Proof     []MerkleStep `json:"proof"`
+}
+
+// Verify 校验包含证明
+func (p *InclusionProof) Verify() bool {
+	return VerifyMerkleProof(p.ProofHash, p.Proof, p.Root)
+}
+
+// LoadProofBatches 读取目录中的全部批次 (按序号排序)
+func LoadProofBatches(dir string) ([]*ProofBatch, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var batches []*ProofBatch
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), "batch-") || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var b ProofBatch
+		if err := json.Unmarshal(data, &b); err != nil {
+			return nil, fmt.Errorf("解析批次 %s 失败: %w", e.Name(), err)
+		}
+		batches = append(batches, &b)
+	}
+	sort.Slice(batches, func(i, j int) bool { return batches[i].Index < batches[j].Index })
+	return batches, nil
+}
+
+// BuildProofBatch 将尚未进入任何批次的已签名记录打包为新批次并写入 dir，
+// 没有新记录时返回 nil
+func (t *Tracker) BuildProofBatch(dir string) (*ProofBatch, error) {
+	batches, err := LoadProofBatches(dir)
+	if err != nil {
+		return nil, err
+	}
+	batched := map[string]bool{}
+	for _, b := range batches {
+		for _, leaf := range b.Leaves {
+			batched[leaf] = true
+		}
+	}
+
+	t.ensureLoaded()
+	t.mu.RLock()
+	batch := &ProofBatch{Index: 1, CreatedAt: time.Now().Unix()}
+	if len(batches) > 0 {
+		batch.Index = batches[len(batches)-1].Index + 1
+	}
+	for _, r := range t.order {
+		if r.ProofHash == "" || !r.IsSigned() || batched[r.ProofHash] {
+			continue
+		}
+		batched[r.ProofHash] = true
+		batch.Leaves = append(batch.Leaves, r.ProofHash)
+		batch.RecordIDs = append(batch.RecordIDs, r.ID)
+	}
+	t.mu.RUnlock()
+
+	if len(batch.Leaves) == 0 {
+		return nil, nil
+	}
+	if batch.Root, err = MerkleRoot(batch.Leaves); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("batch-%06d.json", batch.Index))
+	return batch, writeJSONAtomic(path, batch)
+}
+
+// StartProofBatching 定期打包新记录，直到 stop 关闭
+func (t *Tracker) StartProofBatching(dir string, interval time.Duration, stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if _, err := t.BuildProofBatch(dir); err != nil {
+					fmt.Printf("⚠️ 打包工作证明失败: %v\n", err)
+				}
+			}
+		}
+	}()
+}
+
+// FindInclusionProof 在 dir 的批次中查找记录并生成包含证明
+func FindInclusionProof(dir, recordID string) (*InclusionProof, error) {
+	batches, err := LoadProofBatches(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range batches {
+		for i, id := range b.RecordIDs {
+			if id != recordID {
+				continue
+			}
+			proof, err := MerkleProof(b.Leaves, i)
+			if err != nil {
+				return nil, err
+			}
+			return &InclusionProof{
+				RecordID:  id,
+				ProofHash: b.Leaves[i],
+				Batch:     b.Index,
+				Root:      b.Root,
+				Proof:     proof,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("记录 %s 尚未进入任何批次", recordID)
+}
@@ -0,0 +1,148 @@
+package worktracker
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ============ Prometheus 指标 ============
+
+// 计数器记录本进程启动以来的事件 (重启后归零，由 Prometheus 处理)，
+// 仪表取自累计统计 (含历史记录与归档)
+
+// agentCounters 单个 Agent 的事件计数
+type agentCounters struct {
+	started   int64
+	completed int64
+	failed    int64
+	tokens    int64
+	value     float64
+}
+
+// metricCounters 追踪器事件计数 (由 t.mu 保护)
+type metricCounters struct {
+	byAgent     map[string]*agentCounters
+	recordSaves int64 // 记录写入失败次数
+	statsSaves  int64 // 统计写入失败次数
+}
+
+func (m *metricCounters) agent(id string) *agentCounters {
+	if m.byAgent == nil {
+		m.byAgent = make(map[string]*agentCounters)
+	}
+	c := m.byAgent[id]
+	if c == nil {
+		c = &agentCounters{}
+		m.byAgent[id] = c
+	}
+	return c
+}
+
+// countStarted 计入开始的任务 (调用方需持有写锁)
+func (t *Tracker) countStarted(r *WorkRecord) {
+	t.metrics.agent(r.AgentID).started++
+}
+
+// countFinished 计入结束的任务 (调用方需持有写锁)
+func (t *Tracker) countFinished(r *WorkRecord) {
+	c := t.metrics.agent(r.AgentID)
+	if r.Status == "failed" {
+		c.failed++
+		return
+	}
+	c.completed++
+	c.tokens += r.TokensInput + r.TokensOutput
+	c.value += r.CalculateValue()
+}
+
+// WriteMetrics 以 Prometheus 文本格式输出指标
+func (t *Tracker) WriteMetrics(out io.Writer) error {
+	t.ensureLoaded()
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	w := bufio.NewWriter(out)
+	agents := make([]string, 0, len(t.metrics.byAgent))
+	for id := range t.metrics.byAgent {
+		agents = append(agents, id)
+	}
+	sort.Strings(agents)
+
+	perAgent := func(name, help, typ string, get func(*agentCounters) string) {
+		writeMetricHeader(w, name, help, typ)
+		for _, id := range agents {
+			fmt.Fprintf(w, "%s{agent=%s} %s\n", name, quoteLabel(id), get(t.metrics.byAgent[id]))
+		}
+	}
+	perAgent("oaw_tasks_started_total", "Tasks started since process start.", "counter",
+		func(c *agentCounters) string { return strconv.FormatInt(c.started, 10) })
+	perAgent("oaw_tasks_completed_total", "Tasks completed since process start.", "counter",
+		func(c *agentCounters) string { return strconv.FormatInt(c.completed, 10) })
+	perAgent("oaw_tasks_failed_total", "Tasks failed since process start.", "counter",
+		func(c *agentCounters) string { return strconv.FormatInt(c.failed, 10) })
+	perAgent("oaw_tokens_total", "Input and output tokens of completed tasks since process start.", "counter",
+		func(c *agentCounters) string { return strconv.FormatInt(c.tokens, 10) })
+	perAgent("oaw_value_total", "Work value of completed tasks since process start.", "counter",
+		func(c *agentCounters) string { return formatFloat(c.value) })
+
+	writeMetricHeader(w, "oaw_save_errors_total", "Failed writes since process start.", "counter")
+	fmt.Fprintf(w, "oaw_save_errors_total{kind=\"record\"} %d\n", t.metrics.recordSaves)
+	fmt.Fprintf(w, "oaw_save_errors_total{kind=\"stats\"} %d\n", t.metrics.statsSaves)
+
+	pending := 0
+	for _, r := range t.records {
+		if r.Status == "pending" {
+			pending++
+		}
+	}
+	s := t.stats
+	gauges := []struct {
+		name, help string
+		value      string
+	}{
+		{"oaw_tasks_pending", "Tasks started but not yet finished.", strconv.Itoa(pending)},
+		{"oaw_records_tasks", "Finished tasks in the tracker statistics.", strconv.Itoa(s.TotalTasks)},
+		{"oaw_records_completed", "Completed tasks in the tracker statistics.", strconv.Itoa(s.CompletedTasks)},
+		{"oaw_records_failed", "Failed tasks in the tracker statistics.", strconv.Itoa(s.FailedTasks)},
+		{"oaw_records_flagged", "Quarantined suspicious records.", strconv.Itoa(s.Flagged)},
+		{"oaw_records_tokens", "Tokens in the tracker statistics.", strconv.FormatInt(s.TotalTokens, 10)},
+		{"oaw_records_value", "Work value in the tracker statistics.", formatFloat(s.TotalValue)},
+		{"oaw_records_cost_usd", "Estimated token cost in USD.", formatFloat(s.TotalCostUSD)},
+	}
+	for _, g := range gauges {
+		writeMetricHeader(w, g.name, g.help, "gauge")
+		fmt.Fprintf(w, "%s %s\n", g.name, g.value)
+	}
+
+	ids := make([]string, 0, len(s.ByAgent))
+	for id := range s.ByAgent {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	writeMetricHeader(w, "oaw_agent_value", "Work value per agent in the tracker statistics.", "gauge")
+	for _, id := range ids {
+		fmt.Fprintf(w, "oaw_agent_value{agent=%s} %s\n", quoteLabel(id), formatFloat(s.ByAgent[id].TotalValue))
+	}
+	writeMetricHeader(w, "oaw_agent_tasks", "Finished tasks per agent in the tracker statistics.", "gauge")
+	for _, id := range ids {
+		fmt.Fprintf(w, "oaw_agent_tasks{agent=%s} %d\n", quoteLabel(id), s.ByAgent[id].Tasks)
+	}
+	return w.Flush()
+}
+
+func writeMetricHeader(w io.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// quoteLabel 转义标签值 (反斜杠、双引号与换行)
+func quoteLabel(v string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v) + `"`
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
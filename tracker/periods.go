@@ -0,0 +1,168 @@
+package worktracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ============ 时间序列统计 ============
+
+// Period 统计周期
+type Period string
+
+const (
+	PeriodDay   Period = "day"
+	PeriodWeek  Period = "week" // 周一为一周开始
+	PeriodMonth Period = "month"
+)
+
+// ParsePeriod 解析统计周期
+func ParsePeriod(s string) (Period, error) {
+	switch Period(s) {
+	case PeriodDay, PeriodWeek, PeriodMonth:
+		return Period(s), nil
+	}
+	return "", fmt.Errorf("无效的统计周期: %s (可选: day/week/month)", s)
+}
+
+// start 时间所在周期的开始时间 (统计时区，见 Location)
+func (p Period) start(t time.Time) time.Time {
+	t = t.In(Location)
+	y, m, d := t.Date()
+	switch p {
+	case PeriodWeek:
+		day := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case PeriodMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// next 下一个周期的开始时间
+func (p Period) next(start time.Time) time.Time {
+	switch p {
+	case PeriodWeek:
+		return start.AddDate(0, 0, 7)
+	case PeriodMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// PeriodStats 单个周期的统计
+type PeriodStats struct {
+	Start           string  `json:"start"` // 周期开始日期 (2006-01-02)
+	Tasks           int     `json:"tasks"`
+	CompletedTasks  int     `json:"completed_tasks"`
+	FailedTasks     int     `json:"failed_tasks"`
+	TotalTokens     int64   `json:"total_tokens"`
+	TotalValue      float64 `json:"total_value"`
+	TotalDurationMs int64   `json:"total_duration_ms"` // 任务总耗时 (毫秒)
+	TotalCostUSD    float64 `json:"total_cost_usd"`    // 估算 token 成本 (美元)
+}
+
+// Aggregate 按周期汇总 [from, to) 内已结束任务的 token、价值与任务数
+// from 向前对齐到周期开始，为零时从最早记录开始；to 为零时到当前时间；
+// 已结束的周期保存为快照 (<dataDir>/rollups/<period>.json，非本地时区为 <period>.<时区>.json)，之后直接读取
+func (t *Tracker) Aggregate(period Period, from, to time.Time) ([]PeriodStats, error) {
+	t.ensureLoaded()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if to.IsZero() || to.After(now) {
+		to = now
+	}
+	if from.IsZero() {
+		from = t.archivedFrom // 已归档的周期从快照读取
+		if len(t.order) > 0 && (from.IsZero() || time.UnixMilli(t.order[0].recordTime()).Before(from)) {
+			from = time.UnixMilli(t.order[0].recordTime())
+		}
+		if from.IsZero() {
+			return []PeriodStats{}, nil
+		}
+	}
+	return t.aggregateLocked(period, from, to, now)
+}
+
+// aggregateLocked 按周期汇总并保存已结束周期的快照 (调用方需持有写锁)
+func (t *Tracker) aggregateLocked(period Period, from, to, now time.Time) ([]PeriodStats, error) {
+	snapshots := t.loadRollups(period)
+	current := period.start(now)
+	changed := false
+
+	result := []PeriodStats{}
+	for start := period.start(from); start.Before(to); start = period.next(start) {
+		key := start.Format("2006-01-02")
+		closed := start.Before(current)
+		if s, ok := snapshots[key]; ok && closed {
+			result = append(result, s)
+			continue
+		}
+
+		s := t.aggregateRange(key, start, period.next(start))
+		if closed {
+			snapshots[key] = s
+			changed = true
+		}
+		result = append(result, s)
+	}
+
+	if changed {
+		if err := t.saveRollups(period, snapshots); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// aggregateRange 统计 [start, end) 内已结束的任务 (调用方需持有锁)
+func (t *Tracker) aggregateRange(key string, start, end time.Time) PeriodStats {
+	s := PeriodStats{Start: key}
+	lo := timeBound(t.order, start.UnixMilli())
+	hi := timeBound(t.order, end.UnixMilli())
+	for _, r := range t.order[lo:hi] {
+		if r.Status != "completed" && r.Status != "failed" || t.superseded(r) || r.Flagged() {
+			continue
+		}
+		s.Tasks++
+		if r.Status == "completed" {
+			s.CompletedTasks++
+		} else {
+			s.FailedTasks++
+		}
+		s.TotalTokens += r.TokensInput + r.TokensOutput
+		s.TotalValue += r.CalculateValue()
+		s.TotalDurationMs += r.Duration().Milliseconds()
+		s.TotalCostUSD += r.CostUSD
+	}
+	return s
+}
+
+func (t *Tracker) rollupPath(period Period) string {
+	name := string(period)
+	if zone := rollupZone(); zone != "" {
+		name += "." + zone
+	}
+	return filepath.Join(t.dataDir, "rollups", name+".json")
+}
+
+func (t *Tracker) loadRollups(period Period) map[string]PeriodStats {
+	snapshots := map[string]PeriodStats{}
+	if data, err := os.ReadFile(t.rollupPath(period)); err == nil {
+		json.Unmarshal(data, &snapshots)
+	}
+	return snapshots
+}
+
+func (t *Tracker) saveRollups(period Period, snapshots map[string]PeriodStats) error {
+	path := t.rollupPath(period)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeJSONAtomic(path, snapshots)
+}
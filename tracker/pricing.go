@@ -0,0 +1,76 @@
+package worktracker
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ============ 模型成本 ============
+
+// ModelPrice 模型 token 单价 (美元 / 百万 token)
+type ModelPrice struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+	Cache  float64 `json:"cache,omitempty"` // 缓存读写 token 单价 (未设置时不计)
+}
+
+// DefaultModelPrice 价格表中的通配项，未匹配的模型按此估算
+const DefaultModelPrice = "*"
+
+// Pricing 按模型名的价格表 (可在 config.json 的 pricing 中配置)，
+// 模型名按完全匹配、最长前缀 (如 claude-3-5-sonnet 匹配 claude-3-5-sonnet-20241022)、"*" 的顺序查找
+var Pricing = map[string]ModelPrice{}
+
+// SetModelPrice 设置模型单价
+func SetModelPrice(model string, p ModelPrice) error {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if model == "" {
+		return fmt.Errorf("模型名不能为空")
+	}
+	for _, v := range []float64{p.Input, p.Output, p.Cache} {
+		if v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("模型 %s 的单价无效: %v", model, v)
+		}
+	}
+	Pricing[model] = p
+	return nil
+}
+
+// LookupPrice 查找模型单价
+func LookupPrice(model string) (ModelPrice, bool) {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if model != "" {
+		if p, ok := Pricing[model]; ok {
+			return p, true
+		}
+		best := ""
+		for name := range Pricing {
+			if name != DefaultModelPrice && strings.HasPrefix(model, name) && len(name) > len(best) {
+				best = name
+			}
+		}
+		if best != "" {
+			return Pricing[best], true
+		}
+	}
+	p, ok := Pricing[DefaultModelPrice]
+	return p, ok
+}
+
+// EstimateCost 按价格表估算 token 成本 (美元)，模型无价格时返回 false
+func EstimateCost(model string, tokensInput, tokensOutput int64) (float64, bool) {
+	p, ok := LookupPrice(model)
+	if !ok {
+		return 0, false
+	}
+	return (float64(tokensInput)*p.Input + float64(tokensOutput)*p.Output) / 1e6, true
+}
+
+// priceCost 结束时按当时的价格表记录成本 (之后调价不影响已有记录)
+func (w *WorkRecord) priceCost() {
+	w.CostUSD, _ = EstimateCost(w.Model, w.TokensInput, w.TokensOutput)
+	if p, ok := LookupPrice(w.Model); ok {
+		w.CostUSD += float64(w.TokensCache) * p.Cache / 1e6
+	}
+}
@@ -0,0 +1,70 @@
+package worktracker
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ============ 质量评分 ============
+
+// QualityWeight 质量评分对价值的影响: 价值 × (1 + QualityWeight × (评分 - 0.5))，
+// 默认评分 1 为 1.5 倍、评分 0 为 0.5 倍；未评审的记录不受影响
+var QualityWeight = 1.0
+
+// qualityMultiplier 质量系数
+func (w *WorkRecord) qualityMultiplier() float64 {
+	if w.QualityScore == nil {
+		return 1
+	}
+	m := 1 + QualityWeight*(*w.QualityScore-0.5)
+	if m < 0 {
+		return 0
+	}
+	return m
+}
+
+// valuationKey 价值计算配置的描述 (变更后持久化统计需重建)
+func valuationKey() string {
+	return DefaultValuator.String() + "|quality:" + strconv.FormatFloat(QualityWeight, 'f', -1, 64) +
+		"|types:" + taskTypesKey()
+}
+
+// SetQuality 评审已结束的记录: 设置 0~1 的质量评分，价值与统计随之更新。
+// 评分不参与工作证明，可重复评审 (覆盖上次评分)
+func (t *Tracker) SetQuality(id string, score float64, reviewer string) (*WorkRecord, error) {
+	if score < 0 || score > 1 {
+		return nil, fmt.Errorf("质量评分须在 0~1 之间: %g", score)
+	}
+
+	t.ensureLoaded()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r, ok := t.records[id]
+	if !ok {
+		return nil, fmt.Errorf("记录不存在: %s", id)
+	}
+	if latest := t.amendedBy[id]; latest != "" {
+		return nil, fmt.Errorf("记录已被 %s 修正，请评审最新版本", latest)
+	}
+	if r.Status != "completed" && r.Status != "failed" {
+		return nil, fmt.Errorf("只能评审已结束的记录 (当前状态: %s)", r.Status)
+	}
+
+	next := *r
+	next.QualityScore = &score
+	next.ReviewedBy = reviewer
+	next.ReviewedAt = time.Now().UnixMilli()
+	if err := t.save(&next); err != nil {
+		return nil, fmt.Errorf("保存评审结果失败: %w", err)
+	}
+
+	t.removeStats(r)
+	*r = next
+	t.updateStats(r)
+	t.invalidateRollups(r.recordTime())
+	t.saveStats()
+	t.publish(RecordReviewed, r)
+	return r, nil
+}
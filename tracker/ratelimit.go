@@ -0,0 +1,164 @@
+package worktracker
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ============ 高频 Agent 限流 ============
+
+// RateLimit 单个 Agent 的记录频率限制: 每分钟前 PerMinute 个完成的任务单独记录，
+// 超出部分按任务类型合并到该分钟的聚合记录 (工作量指标累加)，避免记录数爆炸。
+// 失败任务与子任务始终单独记录
+type RateLimit struct {
+	PerMinute int `json:"per_minute"` // 0 为不限
+}
+
+// DefaultRateLimit 限流表中的通配项，未单独配置的 Agent 使用此限制
+const DefaultRateLimit = "*"
+
+// RateLimits 按 Agent ID 的限流表 (可在 config.json 的 rate_limits 中配置)
+var RateLimits = map[string]RateLimit{}
+
+// SetRateLimit 设置 Agent 的限流 ("*" 为默认)
+func SetRateLimit(agentID string, l RateLimit) error {
+	agentID = strings.TrimSpace(agentID)
+	if agentID == "" {
+		return fmt.Errorf("Agent ID 不能为空")
+	}
+	if l.PerMinute < 0 {
+		return fmt.Errorf("Agent %s 的每分钟上限不能为负: %d", agentID, l.PerMinute)
+	}
+	RateLimits[agentID] = l
+	return nil
+}
+
+func rateLimitFor(agentID string) RateLimit {
+	if l, ok := RateLimits[agentID]; ok {
+		return l
+	}
+	return RateLimits[DefaultRateLimit]
+}
+
+// rateWindow Agent 当前一分钟的计数与聚合记录
+type rateWindow struct {
+	minute int64
+	count  int
+	aggs   map[TaskType]*WorkRecord
+}
+
+// throttled 计入一次完成，超出限制时返回 true (调用方需持有写锁)
+func (t *Tracker) throttled(record *WorkRecord, at int64) bool {
+	limit := rateLimitFor(record.AgentID).PerMinute
+	if limit <= 0 || record.ParentID != "" || t.hasSubtasks(record) {
+		return false
+	}
+	if t.rates == nil {
+		t.rates = make(map[string]*rateWindow)
+	}
+	minute := at / int64(time.Minute/time.Millisecond)
+	w := t.rates[record.AgentID]
+	if w == nil || w.minute != minute {
+		w = &rateWindow{minute: minute, aggs: make(map[TaskType]*WorkRecord)}
+		t.rates[record.AgentID] = w
+	}
+	w.count++
+	return w.count > limit
+}
+
+// hasSubtasks 任务是否有子任务 (子任务晚于父任务开始，只需查看索引末尾)
+func (t *Tracker) hasSubtasks(record *WorkRecord) bool {
+	list := t.byAgent[record.AgentID]
+	for i := len(list) - 1; i >= 0 && list[i].recordTime() >= record.StartedAt; i-- {
+		if list[i].ParentID == record.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// aggregate 将已完成的任务合并到当前分钟的聚合记录，原任务不单独保存 (调用方需持有写锁)
+func (t *Tracker) aggregate(record, done *WorkRecord) error {
+	w := t.rates[record.AgentID]
+	agg := w.aggs[done.TaskType]
+	if agg != nil && (t.records[agg.ID] != agg || t.superseded(agg)) {
+		agg = nil // 已被修正或归档，另起一条
+	}
+
+	var next WorkRecord
+	if agg == nil {
+		next = *done
+		next.ID = t.newRecordID()
+		next.Tags = append([]string(nil), done.Tags...)
+		next.QualityScore, next.ReviewedBy, next.ReviewedAt = nil, "", 0
+		next.Aggregated = 1
+	} else {
+		next = *agg
+		next.Tags = append([]string(nil), agg.Tags...)
+		next.AddTags(done.Tags...)
+		if done.StartedAt < next.StartedAt {
+			next.StartedAt = done.StartedAt
+		}
+		if done.CompletedAt > next.CompletedAt {
+			next.CompletedAt = done.CompletedAt
+		}
+		next.TokensInput += done.TokensInput
+		next.TokensOutput += done.TokensOutput
+		next.TokensCache += done.TokensCache
+		next.CodeLines += done.CodeLines
+		next.CodeFiles += done.CodeFiles
+		next.WordsWritten += done.WordsWritten
+		next.BugsFixed += done.BugsFixed
+		next.APICalls += done.APICalls
+		next.ErrorsFixed += done.ErrorsFixed
+		next.LinesRemoved += done.LinesRemoved
+		next.Commits = append(append([]string(nil), agg.Commits...), done.Commits...)
+		if next.Repo == "" {
+			next.Repo = done.Repo
+		}
+		next.CostUSD += done.CostUSD
+		if next.Model == "" {
+			next.Model = done.Model
+		}
+		next.EventKeys = append(append([]string(nil), agg.EventKeys...), done.EventKeys...)
+		next.Aggregated++
+	}
+	next.Seq = t.nextSeq()
+	next.TaskDesc = fmt.Sprintf("[聚合] %s 的 %d 个 %s 任务 (%s)",
+		next.AgentID, next.Aggregated, next.TaskType, time.UnixMilli(next.CompletedAt).Format("2006-01-02 15:04"))
+	next.GenerateProof()
+	t.sign(&next)
+	if err := t.save(&next); err != nil {
+		return fmt.Errorf("保存聚合记录失败: %w", err)
+	}
+
+	// 原任务只存在于内存，移除即可
+	t.indexRemove(record)
+	delete(t.records, record.ID)
+	*record = *done
+	record.Status = "aggregated"
+
+	if agg == nil {
+		agg = &next
+		t.records[agg.ID] = agg
+		w.aggs[done.TaskType] = agg
+	} else {
+		t.indexRemove(agg)
+		t.removeStats(agg)
+		if t.proofs[agg.ProofHash] == agg.ID {
+			delete(t.proofs, agg.ProofHash)
+		}
+		*agg = next
+	}
+	t.proofs[agg.ProofHash] = agg.ID
+	t.indexEvents(agg)
+	t.indexInsert(agg)
+	t.updateStats(agg)
+	t.saveStats()
+	t.countFinished(done)
+	if agg.Aggregated == 1 {
+		t.publish(RecordCompleted, agg)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package worktracker
+
+import "fmt"
+
+// ============ 单条记录 ============
+
+// RecordDetail 记录详情 (含计算得出的字段)
+type RecordDetail struct {
+	Record         *WorkRecord `json:"record"`
+	Value          float64     `json:"value"`
+	DurationMs     int64       `json:"duration_ms"`
+	ProofValid     bool        `json:"proof_valid"`     // 由字段重算的工作证明与记录一致
+	SignatureValid bool        `json:"signature_valid"` // 签名可恢复出声明的签名钱包
+	AmendedBy      string      `json:"amended_by,omitempty"`
+	Anomalies      []string    `json:"anomalies,omitempty"`
+}
+
+// GetRecord 按 ID 获取记录
+func (t *Tracker) GetRecord(id string) (*WorkRecord, error) {
+	t.ensureLoaded()
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	r, ok := t.records[id]
+	if !ok {
+		return nil, fmt.Errorf("记录不存在: %s", id)
+	}
+	return r, nil
+}
+
+// GetRecordDetail 按 ID 获取记录详情
+func (t *Tracker) GetRecordDetail(id string) (*RecordDetail, error) {
+	r, err := t.GetRecord(id)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.RLock()
+	amendedBy := t.amendedBy[id]
+	t.mu.RUnlock()
+
+	return &RecordDetail{
+		Record:         r,
+		Value:          r.CalculateValue(),
+		DurationMs:     r.Duration().Milliseconds(),
+		ProofValid:     r.ProofHash != "" && r.ComputeProof() == r.ProofHash,
+		SignatureValid: r.IsSigned() && VerifyRecord(r) == nil,
+		AmendedBy:      amendedBy,
+		Anomalies:      r.Anomalies(),
+	}, nil
+}
@@ -0,0 +1,99 @@
+package worktracker
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ============ 记录版本与迁移 ============
+
+// SchemaVersion 当前记录格式版本。修改记录字段 (改名、拆分、改变单位等) 时
+// 递增版本并在 migrations 中追加一步迁移；只新增可选字段时无需迁移
+const SchemaVersion = 2
+
+// migration 将记录从 version-1 升级到 version，直接操作原始 JSON 字段
+type migration struct {
+	version int
+	desc    string
+	apply   func(fields map[string]json.RawMessage) error
+}
+
+// migrations 按版本顺序排列
+var migrations = []migration{
+	{
+		// 版本 1: 未带 schema_version 的历史记录，字段与版本 2 相同
+		version: 2,
+		desc:    "增加 schema_version 字段",
+		apply:   func(fields map[string]json.RawMessage) error { return nil },
+	},
+}
+
+// recordVersion 记录的格式版本 (历史记录没有该字段，视为版本 1)
+func recordVersion(fields map[string]json.RawMessage) (int, error) {
+	raw, ok := fields["schema_version"]
+	if !ok {
+		return 1, nil
+	}
+	var v int
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return 0, fmt.Errorf("无效的 schema_version: %s", raw)
+	}
+	if v == 0 {
+		v = 1
+	}
+	return v, nil
+}
+
+// decodeRecord 解析记录并迁移到当前版本，migrated 表示发生了升级 (需写回磁盘)
+func decodeRecord(data []byte) (*WorkRecord, bool, error) {
+	r, migrated, err := migrateRecord(data)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := r.openFields(); err != nil {
+		return nil, false, err
+	}
+	return r, migrated, nil
+}
+
+// migrateRecord 解析记录并迁移到当前版本
+func migrateRecord(data []byte) (r *WorkRecord, migrated bool, err error) {
+	r = &WorkRecord{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, false, err
+	}
+	if r.SchemaVersion == SchemaVersion {
+		return r, false, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, false, err
+	}
+	version, err := recordVersion(fields)
+	if err != nil {
+		return nil, false, err
+	}
+	if version > SchemaVersion {
+		return nil, false, fmt.Errorf("记录版本 %d 高于当前支持的版本 %d，请升级 oaw", version, SchemaVersion)
+	}
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		if err := m.apply(fields); err != nil {
+			return nil, false, fmt.Errorf("迁移到版本 %d (%s) 失败: %w", m.version, m.desc, err)
+		}
+	}
+	fields["schema_version"] = json.RawMessage(fmt.Sprint(SchemaVersion))
+
+	upgraded, err := json.Marshal(fields)
+	if err != nil {
+		return nil, false, err
+	}
+	r = &WorkRecord{}
+	if err := json.Unmarshal(upgraded, r); err != nil {
+		return nil, false, err
+	}
+	return r, true, nil
+}
@@ -0,0 +1,364 @@
+package worktracker
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ============ 记录分段存储 ============
+
+// 每条记录一个文件的目录在记录数达到数万后 (尤其 Windows 文件系统) 列目录与备份都很慢。
+// Compact 将已结束的记录合并为分段文件 <dataDir>/segments/seg-NNNNNN.jsonl (每行一条记录)，
+// index.json 记录各分段的记录数与时间范围。之后修改的记录 (如评审) 仍写为单独文件并覆盖分段中的旧版本，
+// 下次 Compact 时再合并
+
+// SegmentSize 每个分段文件的最大记录数
+const SegmentSize = 10000
+
+const segmentIndexFile = "index.json"
+
+// segmentInfo 分段索引项
+type segmentInfo struct {
+	Name    string `json:"name"`
+	Records int    `json:"records"`
+	Bytes   int64  `json:"bytes"`
+	From    int64  `json:"from"` // 最早记录时间 (毫秒)
+	To      int64  `json:"to"`   // 最晚记录时间 (毫秒)
+}
+
+type segmentIndex struct {
+	Segments []segmentInfo `json:"segments"`
+}
+
+func (x *segmentIndex) total() int {
+	n := 0
+	for _, s := range x.Segments {
+		n += s.Records
+	}
+	return n
+}
+
+// CompactResult 一次合并的结果
+type CompactResult struct {
+	Records  int `json:"records"`  // 合并的记录数
+	Segments int `json:"segments"` // 合并后的分段文件数
+	Loose    int `json:"loose"`    // 仍为单独文件的记录 (未结束、重复或无法解析)
+}
+
+func (t *Tracker) segmentDir() string {
+	return filepath.Join(t.dataDir, "segments")
+}
+
+func (t *Tracker) loadSegmentIndex() *segmentIndex {
+	x := &segmentIndex{}
+	if data, err := os.ReadFile(filepath.Join(t.segmentDir(), segmentIndexFile)); err == nil {
+		json.Unmarshal(data, x)
+	}
+	return x
+}
+
+func (t *Tracker) saveSegmentIndex(x *segmentIndex) error {
+	return writeJSONAtomic(filepath.Join(t.segmentDir(), segmentIndexFile), x)
+}
+
+// readSegment 逐行解析分段文件；无法解析的行以 r 为 nil 回调，由调用方决定是否保留原文
+func (t *Tracker) readSegment(name string, fn func(line []byte, r *WorkRecord, migrated bool)) error {
+	f, err := os.Open(filepath.Join(t.segmentDir(), name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		r, migrated, err := decodeRecord(line)
+		if err != nil {
+			fmt.Printf("⚠️ 跳过分段 %s 中的记录: %v\n", name, err)
+			fn(line, nil, false)
+			continue
+		}
+		fn(line, r, migrated)
+	}
+	return scanner.Err()
+}
+
+// loadSegments 加载分段中的记录 (在单独文件之前加载，由 load 调用)；
+// 索引中的记录数与实际不符时 (写入中断) 按实际修正
+func (t *Tracker) loadSegments() {
+	x := t.loadSegmentIndex()
+	repaired := false
+	for i := range x.Segments {
+		s := &x.Segments[i]
+		n := 0
+		err := t.readSegment(s.Name, func(_ []byte, r *WorkRecord, migrated bool) {
+			n++
+			if r == nil {
+				return
+			}
+			t.segmentOf[r.ID] = s.Name
+			if migrated {
+				// 升级后写为单独文件覆盖分段中的旧版本，下次合并时写回分段
+				if err := t.save(r); err != nil {
+					fmt.Printf("⚠️ 记录 %s 升级后写回失败: %v\n", r.ID, err)
+				}
+			}
+			t.addLoaded(r)
+		})
+		if err != nil {
+			fmt.Printf("⚠️ 读取分段 %s 失败: %v\n", s.Name, err)
+		}
+		if n != s.Records {
+			s.Records = n
+			repaired = true
+		}
+		t.files += n
+	}
+	if repaired {
+		t.saveSegmentIndex(x)
+	}
+}
+
+// Compact 将已结束记录的单独文件合并到分段文件，合并后删除原文件；
+// 被单独文件覆盖的分段记录以新版本写回。内存中的记录与统计不变
+func (t *Tracker) Compact() (*CompactResult, error) {
+	t.ensureLoaded()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entries, err := os.ReadDir(t.dataDir)
+	if err != nil {
+		return nil, err
+	}
+	result := &CompactResult{}
+	var batch []*WorkRecord
+	for _, e := range entries {
+		if e.IsDir() || !isRecordFile(e.Name()) {
+			continue
+		}
+		r := t.records[strings.TrimSuffix(e.Name(), ".json")]
+		if r == nil || r.Status != "completed" && r.Status != "failed" {
+			result.Loose++
+			continue
+		}
+		batch = append(batch, r)
+	}
+	if len(batch) == 0 {
+		result.Segments = len(t.loadSegmentIndex().Segments)
+		return result, nil
+	}
+
+	// 分段中的旧版本先移除，避免同一记录存两份
+	stale := map[string]bool{}
+	for _, r := range batch {
+		if t.segmentOf[r.ID] != "" {
+			stale[r.ID] = true
+		}
+	}
+	if err := t.dropFromSegments(stale); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(batch, func(i, j int) bool { return batch[i].recordTime() < batch[j].recordTime() })
+	x, err := t.appendSegments(batch)
+	if err != nil {
+		return nil, err
+	}
+
+	// 分段与索引已落盘，再删除单独文件 (中途崩溃时单独文件覆盖分段中的相同记录，不影响数据)
+	for _, r := range batch {
+		switch err := os.Remove(filepath.Join(t.dataDir, r.ID+".json")); {
+		case err == nil:
+			t.files--
+		case !os.IsNotExist(err):
+			return result, fmt.Errorf("删除记录文件 %s 失败: %w", r.ID, err)
+		}
+		result.Records++
+	}
+	t.saveStats()
+	result.Segments = len(x.Segments)
+	return result, nil
+}
+
+// appendSegments 追加记录: 先填满最后一个分段，其余写入新分段 (调用方需持有写锁)
+func (t *Tracker) appendSegments(records []*WorkRecord) (*segmentIndex, error) {
+	if err := os.MkdirAll(t.segmentDir(), 0755); err != nil {
+		return nil, err
+	}
+	x := t.loadSegmentIndex()
+
+	for len(records) > 0 {
+		var s *segmentInfo
+		var buf []byte
+		if n := len(x.Segments); n > 0 && x.Segments[n-1].Records < SegmentSize {
+			s = &x.Segments[n-1]
+			data, err := os.ReadFile(filepath.Join(t.segmentDir(), s.Name))
+			if err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+			buf = data
+		} else {
+			x.Segments = append(x.Segments, segmentInfo{Name: fmt.Sprintf("seg-%06d.jsonl", t.nextSegment(x))})
+			s = &x.Segments[len(x.Segments)-1]
+		}
+
+		before := s.Records
+		for len(records) > 0 && s.Records < SegmentSize {
+			r := records[0]
+			records = records[1:]
+			r.SchemaVersion = SchemaVersion
+			disk, err := r.sealed()
+			if err != nil {
+				return nil, err
+			}
+			line, err := json.Marshal(disk)
+			if err != nil {
+				return nil, err
+			}
+			buf = append(append(buf, line...), '\n')
+			s.Records++
+			s.widen(r.recordTime())
+			t.segmentOf[r.ID] = s.Name
+		}
+		s.Bytes = int64(len(buf))
+		if err := writeFileAtomic(filepath.Join(t.segmentDir(), s.Name), buf, 0644); err != nil {
+			return nil, fmt.Errorf("写入分段 %s 失败: %w", s.Name, err)
+		}
+		t.files += s.Records - before
+	}
+	if err := t.saveSegmentIndex(x); err != nil {
+		return nil, fmt.Errorf("保存分段索引失败: %w", err)
+	}
+	return x, nil
+}
+
+// dropFromSegments 从分段中删除记录 (归档、被新版本替换)，整段删除或重写受影响的分段 (调用方需持有写锁)
+func (t *Tracker) dropFromSegments(ids map[string]bool) error {
+	affected := map[string]int{}
+	for id := range ids {
+		if name := t.segmentOf[id]; name != "" {
+			affected[name]++
+		}
+	}
+	if len(affected) == 0 {
+		return nil
+	}
+
+	x := t.loadSegmentIndex()
+	kept := x.Segments[:0]
+	for _, s := range x.Segments {
+		if affected[s.Name] == 0 {
+			kept = append(kept, s)
+			continue
+		}
+
+		path := filepath.Join(t.segmentDir(), s.Name)
+		if affected[s.Name] >= s.Records {
+			// 整段删除，无需读取
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			t.files -= s.Records
+			continue
+		}
+		var buf []byte
+		next := segmentInfo{Name: s.Name}
+		err := t.readSegment(s.Name, func(line []byte, r *WorkRecord, _ bool) {
+			if r != nil && ids[r.ID] {
+				return
+			}
+			buf = append(append(buf, line...), '\n') // 无法解析的行原样保留
+			next.Records++
+			if r != nil {
+				next.widen(r.recordTime())
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("读取分段 %s 失败: %w", s.Name, err)
+		}
+		if next.Records == 0 {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		} else {
+			next.Bytes = int64(len(buf))
+			if err := writeFileAtomic(path, buf, 0644); err != nil {
+				return fmt.Errorf("重写分段 %s 失败: %w", s.Name, err)
+			}
+			kept = append(kept, next)
+		}
+		t.files -= s.Records - next.Records
+	}
+	x.Segments = kept
+	for id := range ids {
+		delete(t.segmentOf, id)
+	}
+	return t.saveSegmentIndex(x)
+}
+
+func (t *Tracker) nextSegment(x *segmentIndex) int {
+	max := 0
+	for _, s := range x.Segments {
+		var n int
+		if _, err := fmt.Sscanf(s.Name, "seg-%06d.jsonl", &n); err == nil && n > max {
+			max = n
+		}
+	}
+	return max + 1
+}
+
+func (s *segmentInfo) widen(at int64) {
+	if s.From == 0 || at < s.From {
+		s.From = at
+	}
+	if at > s.To {
+		s.To = at
+	}
+}
+
+// rewriteSegments 按当前密钥重写全部分段 (调用方需持有写锁)，无法解析的行原样保留
+func (t *Tracker) rewriteSegments() (int, error) {
+	x := t.loadSegmentIndex()
+	n := 0
+	for _, s := range x.Segments {
+		var buf []byte
+		var failed error
+		err := t.readSegment(s.Name, func(line []byte, r *WorkRecord, _ bool) {
+			if r != nil && failed == nil {
+				if mem := t.records[r.ID]; mem != nil && t.segmentOf[r.ID] == s.Name {
+					r = mem // 内存中为最新版本
+				}
+				disk, err := r.sealed()
+				if err == nil {
+					line, err = json.Marshal(disk)
+				}
+				if err != nil {
+					failed = err
+					return
+				}
+				n++
+			}
+			buf = append(append(buf, line...), '\n')
+		})
+		if err == nil {
+			err = failed
+		}
+		if err != nil {
+			return n, fmt.Errorf("重写分段 %s 失败: %w", s.Name, err)
+		}
+		if err := writeFileAtomic(filepath.Join(t.segmentDir(), s.Name), buf, 0644); err != nil {
+			return n, fmt.Errorf("重写分段 %s 失败: %w", s.Name, err)
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,134 @@
+package worktracker
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ============ 记录签名 ============
+
+// SetAgentSigner 为指定 Agent 设置独立的钱包私钥，该 Agent 的记录用其签名 (agentID 可为通配符；
+// 未匹配的 Agent 使用 SetSigner 的私钥)
+func (t *Tracker) SetAgentSigner(agentID, privateKeyHex string) error {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+	if err != nil {
+		return fmt.Errorf("Agent %s 私钥解析失败: %w", agentID, err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.agentSigners == nil {
+		t.agentSigners = map[string]*ecdsa.PrivateKey{}
+	}
+	t.agentSigners[agentID] = key
+	return nil
+}
+
+// signerFor 记录使用的签名私钥: 精确匹配优先，其次通配符 (调用方需持有锁)
+func (t *Tracker) signerFor(agentID string) *ecdsa.PrivateKey {
+	if key, ok := t.agentSigners[agentID]; ok {
+		return key
+	}
+	var best string
+	for pattern := range t.agentSigners {
+		if ok, _ := path.Match(pattern, agentID); ok && (best == "" || len(pattern) > len(best)) {
+			best = pattern
+		}
+	}
+	if best != "" {
+		return t.agentSigners[best]
+	}
+	return t.signer
+}
+
+// SetSigner 设置 Agent 钱包私钥，完成的任务将用其签名工作证明
+func (t *Tracker) SetSigner(privateKeyHex string) error {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+	if err != nil {
+		return fmt.Errorf("私钥解析失败: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.signer = key
+	return nil
+}
+
+// Sign 用钱包私钥对工作证明哈希签名
+func (w *WorkRecord) Sign(key *ecdsa.PrivateKey) error {
+	signer, sig, err := SignProof(w.ProofHash, key)
+	if err != nil {
+		return fmt.Errorf("记录 %s: %w", w.ID, err)
+	}
+	w.Signer, w.Signature = signer, sig
+	return nil
+}
+
+// SignProof 用钱包私钥对工作证明哈希 (十六进制 sha256) 签名，返回钱包地址与签名
+func SignProof(proofHash string, key *ecdsa.PrivateKey) (signer, signature string, err error) {
+	hash, err := hex.DecodeString(proofHash)
+	if err != nil || len(hash) != 32 {
+		return "", "", fmt.Errorf("缺少有效的工作证明")
+	}
+	sig, err := crypto.Sign(hash, key)
+	if err != nil {
+		return "", "", fmt.Errorf("签名失败: %w", err)
+	}
+	return crypto.PubkeyToAddress(key.PublicKey).Hex(), "0x" + hex.EncodeToString(sig), nil
+}
+
+// VerifyProofSignature 从签名恢复公钥，确认与声明的签名钱包一致
+func VerifyProofSignature(proofHash, signature, signer string) error {
+	hash, err := hex.DecodeString(proofHash)
+	if err != nil || len(hash) != 32 {
+		return fmt.Errorf("工作证明格式无效")
+	}
+	sig, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return fmt.Errorf("签名格式无效")
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return fmt.Errorf("签名无效: %w", err)
+	}
+	if recovered := crypto.PubkeyToAddress(*pub).Hex(); !strings.EqualFold(recovered, signer) {
+		return fmt.Errorf("签名者 %s 与声明的 %s 不一致", recovered, signer)
+	}
+	return nil
+}
+
+// IsSigned 记录是否已签名
+func (w *WorkRecord) IsSigned() bool {
+	return w.Signature != "" && w.Signer != ""
+}
+
+// VerifyRecord 离线校验记录: 由字段重算工作证明哈希，
+// 并从签名恢复公钥，确认与记录声明的签名钱包一致
+func VerifyRecord(w *WorkRecord) error {
+	if proof := w.ComputeProof(); proof != w.ProofHash {
+		return fmt.Errorf("工作证明不匹配: 记录 %s, 重算 %s", w.ProofHash, proof)
+	}
+	if !w.IsSigned() {
+		return fmt.Errorf("记录未签名")
+	}
+	return VerifyProofSignature(w.ProofHash, w.Signature, w.Signer)
+}
+
+// LoadRecordFile 读取单条记录文件
+func LoadRecordFile(path string) (*WorkRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r, _, err := decodeRecord(data)
+	if err != nil {
+		return nil, fmt.Errorf("解析记录失败: %w", err)
+	}
+	return r, nil
+}
@@ -0,0 +1,60 @@
+package worktracker
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ============ 任务状态机 ============
+
+// 任务状态只能从 pending 结束为 completed 或 failed，结束后不可再变更
+// (数值更正走 Amend，生成新的修正记录)
+var transitions = map[string][]string{
+	"pending": {"completed", "failed"},
+}
+
+// canTransition 状态转换是否合法
+func canTransition(from, to string) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTransition 校验结束任务的状态转换 (调用方需持有锁)，
+// 返回 done=true 表示重复调用且结果一致，应直接忽略
+func (t *Tracker) checkTransition(record *WorkRecord, to string, same bool) (done bool, err error) {
+	if cur, ok := t.records[record.ID]; !ok || cur != record {
+		return false, fmt.Errorf("记录不存在或不属于此追踪器: %s", record.ID)
+	}
+	if record.Status == to {
+		if same {
+			return true, nil
+		}
+		return false, fmt.Errorf("记录 %s 已是 %s 状态，结果不一致，如需更正请使用修正记录 (Amend)", record.ID, to)
+	}
+	if !canTransition(record.Status, to) {
+		return false, fmt.Errorf("记录 %s 状态不能从 %s 变为 %s", record.ID, record.Status, to)
+	}
+	return false, nil
+}
+
+// sameResult 记录是否已包含该结果 (用于识别重复的完成调用)
+func (w *WorkRecord) sameResult(result TaskResult) bool {
+	return w.TokensInput == result.TokensInput &&
+		w.TokensOutput == result.TokensOutput &&
+		w.TokensCache == result.TokensCache &&
+		w.CodeLines == result.CodeLines &&
+		w.CodeFiles == result.CodeFiles &&
+		w.WordsWritten == result.WordsWritten &&
+		w.BugsFixed == result.BugsFixed &&
+		w.APICalls == result.APICalls &&
+		w.ErrorsFixed == result.ErrorsFixed &&
+		w.LinesRemoved == result.LinesRemoved &&
+		slices.Equal(w.Commits, result.Commits) &&
+		w.Repo == result.Repo &&
+		(result.Model == "" || w.Model == result.Model) &&
+		w.HasTags(normalizeTags(result.Tags)...)
+}
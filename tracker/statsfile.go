@@ -0,0 +1,154 @@
+package worktracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ============ 统计持久化 ============
+
+// 统计在每次写入记录后保存到 <dataDir>/stats.json，启动时只需读取该文件，
+// 记录文件在首次查询或写入时才加载。崩溃恢复依赖记录文件数:
+// 记录先于统计落盘，两次写入之间崩溃会导致文件数不一致，启动时即重建统计
+
+const (
+	statsFile    = "stats.json"
+	statsVersion = 4
+)
+
+// statsSnapshot 持久化的统计
+type statsSnapshot struct {
+	Version   int    `json:"version"`
+	Records   int    `json:"records"`  // 写入统计时的记录文件数
+	Valuator  string `json:"valuator"` // 计算价值使用的策略，变更后需重建
+	Stats     Stats  `json:"stats"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// isRecordFile 是否为记录文件 (排除统计、Webhook 配置与写入中断残留的临时文件)
+func isRecordFile(name string) bool {
+	return filepath.Ext(name) == ".json" && name != statsFile && name != webhookFile && !strings.HasPrefix(name, ".")
+}
+
+// countRecordFiles 统计记录文件数 (只列目录，不读取内容)，分段中的记录按索引计数
+func (t *Tracker) countRecordFiles() (int, error) {
+	entries, err := os.ReadDir(t.dataDir)
+	if err != nil {
+		return 0, err
+	}
+	n := t.loadSegmentIndex().total()
+	for _, e := range entries {
+		if !e.IsDir() && isRecordFile(e.Name()) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// loadStatsSnapshot 读取持久化统计，记录文件数一致时采用并推迟加载记录
+func (t *Tracker) loadStatsSnapshot() bool {
+	data, err := os.ReadFile(filepath.Join(t.dataDir, statsFile))
+	if err != nil {
+		return false
+	}
+	var snap statsSnapshot
+	if json.Unmarshal(data, &snap) != nil || snap.Version != statsVersion || snap.Valuator != valuationKey() {
+		return false
+	}
+	n, err := t.countRecordFiles()
+	if err != nil || n != snap.Records {
+		return false
+	}
+	if snap.Stats.ByTaskType == nil {
+		snap.Stats.ByTaskType = make(map[string]int)
+	}
+	t.stats = &snap.Stats
+	t.files = n
+	t.fromSnapshot = true
+	return true
+}
+
+// saveStats 保存统计 (调用方需持有写锁)；失败时仅警告，下次启动会因文件数不一致而重建
+func (t *Tracker) saveStats() {
+	snap := statsSnapshot{
+		Version:   statsVersion,
+		Records:   t.files,
+		Valuator:  valuationKey(),
+		Stats:     *t.stats,
+		UpdatedAt: time.Now().UnixMilli(),
+	}
+	if err := writeJSONAtomic(filepath.Join(t.dataDir, statsFile), snap); err != nil {
+		t.metrics.statsSaves++
+		fmt.Printf("⚠️ 保存统计失败: %v\n", err)
+	}
+}
+
+// ensureLoaded 首次需要记录时加载全部记录文件，并与持久化统计核对
+func (t *Tracker) ensureLoaded() {
+	t.loadOnce.Do(func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		persisted := t.stats
+		t.stats = &Stats{ByTaskType: make(map[string]int)}
+		t.load()
+
+		switch {
+		case !t.fromSnapshot:
+			t.saveStats()
+		case !statsEqual(*persisted, *t.stats):
+			fmt.Println("⚠️ 持久化统计与记录不一致，已按记录重建")
+			t.saveStats()
+		}
+	})
+}
+
+// statsEqual 比较两份统计 (价值按相对误差比较，避免浮点累加顺序的影响)
+func statsEqual(a, b Stats) bool {
+	if a.TotalTasks != b.TotalTasks || a.CompletedTasks != b.CompletedTasks || a.FailedTasks != b.FailedTasks ||
+		a.TotalTokens != b.TotalTokens || a.TotalCodeLines != b.TotalCodeLines || a.TotalWords != b.TotalWords ||
+		a.BugsFixed != b.BugsFixed || a.Subtasks != b.Subtasks || a.TotalDurationMs != b.TotalDurationMs || a.Flagged != b.Flagged ||
+		!floatEqual(a.TotalValue, b.TotalValue) || !floatEqual(a.TotalCostUSD, b.TotalCostUSD) {
+		return false
+	}
+	if len(nonZero(a.ByTaskType)) != len(nonZero(b.ByTaskType)) {
+		return false
+	}
+	for k, v := range nonZero(a.ByTaskType) {
+		if b.ByTaskType[k] != v {
+			return false
+		}
+	}
+	if len(a.ByAgent) != len(b.ByAgent) {
+		return false
+	}
+	for k, x := range a.ByAgent {
+		y, ok := b.ByAgent[k]
+		if !ok || x.Tasks != y.Tasks || x.CompletedTasks != y.CompletedTasks || x.FailedTasks != y.FailedTasks ||
+			x.TotalTokens != y.TotalTokens || x.TotalDurationMs != y.TotalDurationMs || x.Flagged != y.Flagged ||
+			!floatEqual(x.TotalValue, y.TotalValue) || !floatEqual(x.TotalCostUSD, y.TotalCostUSD) {
+			return false
+		}
+	}
+	return true
+}
+
+func floatEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-6*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
+
+// nonZero 去除计数为 0 的项 (撤销统计后会留下 0 值)
+func nonZero(m map[string]int) map[string]int {
+	out := make(map[string]int, len(m))
+	for k, v := range m {
+		if v != 0 {
+			out[k] = v
+		}
+	}
+	return out
+}
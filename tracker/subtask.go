@@ -0,0 +1,104 @@
+package worktracker
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ============ 子任务 ============
+
+// StartSubtask 在父任务下开始子任务 (继承父任务的 Agent 与标签)
+func (t *Tracker) StartSubtask(parentID, taskDesc string, taskType TaskType, tags ...string) (*WorkRecord, error) {
+	t.ensureLoaded()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	parent, ok := t.records[parentID]
+	if !ok {
+		return nil, fmt.Errorf("父任务不存在: %s", parentID)
+	}
+
+	record := &WorkRecord{
+		ID:        t.newRecordID(),
+		AgentID:   parent.AgentID,
+		TaskType:  taskType,
+		TaskDesc:  taskDesc,
+		ParentID:  parentID,
+		Tags:      normalizeTags(append(append([]string{}, parent.Tags...), tags...)),
+		Status:    "pending",
+		StartedAt: time.Now().UnixMilli(),
+	}
+
+	t.records[record.ID] = record
+	t.indexInsert(record)
+	t.countStarted(record)
+	t.publish(RecordStarted, record)
+	return record, nil
+}
+
+// TaskRollup 任务汇总: 任务自身 + 所有子任务
+type TaskRollup struct {
+	Record   *WorkRecord   `json:"record"`
+	Subtasks []*TaskRollup `json:"subtasks,omitempty"`
+
+	Tasks        int     `json:"tasks"` // 含自身
+	TotalTokens  int64   `json:"total_tokens"`
+	CodeLines    int     `json:"code_lines"`
+	WordsWritten int     `json:"words_written"`
+	BugsFixed    int     `json:"bugs_fixed"`
+	OwnValue     float64 `json:"own_value"` // 任务自身价值
+	Value        float64 `json:"value"`     // 汇总价值
+}
+
+// Rollup 汇总任务及其所有子任务的工作量与价值
+func (t *Tracker) Rollup(id string) (*TaskRollup, error) {
+	t.ensureLoaded()
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if _, ok := t.records[id]; !ok {
+		return nil, fmt.Errorf("任务不存在: %s", id)
+	}
+
+	children := map[string][]*WorkRecord{}
+	for _, r := range t.records {
+		if r.ParentID != "" && !t.superseded(r) {
+			children[r.ParentID] = append(children[r.ParentID], r)
+		}
+	}
+	return rollup(t.records[id], children, map[string]bool{}), nil
+}
+
+func rollup(r *WorkRecord, children map[string][]*WorkRecord, visited map[string]bool) *TaskRollup {
+	visited[r.ID] = true // 防止损坏数据中的循环引用
+
+	value := r.CalculateValue()
+	node := &TaskRollup{
+		Record:       r,
+		Tasks:        1,
+		TotalTokens:  r.TokensInput + r.TokensOutput,
+		CodeLines:    r.CodeLines,
+		WordsWritten: r.WordsWritten,
+		BugsFixed:    r.BugsFixed,
+		OwnValue:     value,
+		Value:        value,
+	}
+
+	subs := children[r.ID]
+	sort.Slice(subs, func(i, j int) bool { return subs[i].StartedAt < subs[j].StartedAt })
+	for _, c := range subs {
+		if visited[c.ID] {
+			continue
+		}
+		sub := rollup(c, children, visited)
+		node.Subtasks = append(node.Subtasks, sub)
+		node.Tasks += sub.Tasks
+		node.TotalTokens += sub.TotalTokens
+		node.CodeLines += sub.CodeLines
+		node.WordsWritten += sub.WordsWritten
+		node.BugsFixed += sub.BugsFixed
+		node.Value += sub.Value
+	}
+	return node
+}
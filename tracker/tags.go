@@ -0,0 +1,42 @@
+package worktracker
+
+import "strings"
+
+// ============ 标签 ============
+
+// normalizeTags 去除空白、空标签与重复标签 (保持顺序)
+func normalizeTags(tags []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+// AddTags 追加标签 (忽略已有标签)
+func (w *WorkRecord) AddTags(tags ...string) {
+	w.Tags = normalizeTags(append(w.Tags, tags...))
+}
+
+// HasTags 记录是否包含全部标签
+func (w *WorkRecord) HasTags(tags ...string) bool {
+	for _, want := range tags {
+		found := false
+		for _, tag := range w.Tags {
+			if tag == strings.TrimSpace(want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
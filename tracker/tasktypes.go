@@ -0,0 +1,125 @@
+package worktracker
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ============ 任务类型注册表 ============
+
+// TaskTypeDef 任务类型定义。内置类型之外的类型可在 config.json 的 task_types 中声明，
+// 同名声明覆盖内置类型的权重
+type TaskTypeDef struct {
+	Name     TaskType `json:"name"`
+	Weight   float64  `json:"weight"`             // 价值权重，见 Weights
+	Keywords []string `json:"keywords,omitempty"` // 分类器匹配的内容关键词 (不区分大小写)
+	Desc     string   `json:"desc,omitempty"`
+	Builtin  bool     `json:"-"`
+}
+
+// builtinTaskTypes 内置类型 (按展示顺序)
+var builtinTaskTypes = []TaskTypeDef{
+	{Name: TaskCoding, Desc: "代码生成"},
+	{Name: TaskWriting, Desc: "文字创作"},
+	{Name: TaskResearch, Desc: "调研分析"},
+	{Name: TaskDebug, Desc: "调试修复"},
+	{Name: TaskDeploy, Desc: "部署运维"},
+	{Name: TaskReview, Desc: "代码审查"},
+	{Name: TaskDoc, Desc: "文档编写"},
+	{Name: TaskAnalysis, Desc: "数据分析"},
+}
+
+// registeredTaskTypes 运行时注册的类型 (按注册顺序，分类器按此顺序匹配关键词)
+var registeredTaskTypes []TaskTypeDef
+
+var taskTypeName = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// RegisterTaskType 注册任务类型 (或覆盖已有类型的权重/关键词)，
+// 注册后即可用于 StartTask、分类器与价值计算。需在创建 Tracker 前调用
+func RegisterTaskType(def TaskTypeDef) error {
+	name := TaskType(strings.ToLower(strings.TrimSpace(string(def.Name))))
+	if !taskTypeName.MatchString(string(name)) {
+		return fmt.Errorf("任务类型名无效: %q (小写字母开头，仅含字母、数字、- 和 _)", def.Name)
+	}
+	if def.Weight < 0 || math.IsNaN(def.Weight) || math.IsInf(def.Weight, 0) {
+		return fmt.Errorf("任务类型 %s 的权重无效: %v", name, def.Weight)
+	}
+
+	keywords := make([]string, 0, len(def.Keywords))
+	for _, kw := range def.Keywords {
+		if kw = strings.ToLower(strings.TrimSpace(kw)); kw != "" {
+			keywords = append(keywords, kw)
+		}
+	}
+	def.Name, def.Keywords, def.Builtin = name, keywords, false
+
+	Weights[name] = def.Weight
+	for i, r := range registeredTaskTypes {
+		if r.Name == name {
+			registeredTaskTypes[i] = def
+			return nil
+		}
+	}
+	registeredTaskTypes = append(registeredTaskTypes, def)
+	return nil
+}
+
+// IsTaskType 类型是否已知 (内置或已注册)
+func IsTaskType(t TaskType) bool {
+	_, ok := Weights[t]
+	return ok
+}
+
+// TaskTypes 列出全部已知类型: 内置类型在前，注册类型按注册顺序
+func TaskTypes() []TaskTypeDef {
+	var list []TaskTypeDef
+	seen := map[TaskType]bool{}
+	for _, b := range builtinTaskTypes {
+		def := b
+		def.Weight, def.Builtin = Weights[b.Name], true
+		for _, r := range registeredTaskTypes {
+			if r.Name == b.Name {
+				def.Keywords = r.Keywords
+				if r.Desc != "" {
+					def.Desc = r.Desc
+				}
+			}
+		}
+		list = append(list, def)
+		seen[b.Name] = true
+	}
+	for _, r := range registeredTaskTypes {
+		if !seen[r.Name] {
+			list = append(list, r)
+		}
+	}
+	return list
+}
+
+// MatchTaskType 按注册类型的关键词为内容分类，先注册者优先；
+// 未命中时由调用方回退到内置规则
+func MatchTaskType(content string) (TaskType, bool) {
+	lower := strings.ToLower(content)
+	for _, r := range registeredTaskTypes {
+		for _, kw := range r.Keywords {
+			if strings.Contains(lower, kw) {
+				return r.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// taskTypesKey 类型权重的描述，参与 valuationKey
+func taskTypesKey() string {
+	parts := make([]string, 0, len(Weights))
+	for name, w := range Weights {
+		parts = append(parts, string(name)+"="+strconv.FormatFloat(w, 'f', -1, 64))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,67 @@
+package worktracker
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ============ 统计时区 ============
+
+// 记录时间保存为 UnixMilli，与时区无关；按日/周/月统计、"今天" 与日期参数 (2006-01-02)
+// 按 Location 划分，使统计与操作者的日历一致。周期快照按时区分别保存，切换时区不会沿用其他时区的快照
+
+// Location 统计使用的时区 (config.json 的 timezone 或 --tz，默认本地时区)
+var Location = time.Local
+
+// SetTimezone 设置统计时区: IANA 名称 (如 Asia/Shanghai)、Local、UTC 或 UTC 偏移 (如 +08:00、-0530)
+func SetTimezone(name string) error {
+	loc, err := ParseTimezone(name)
+	if err != nil {
+		return err
+	}
+	Location = loc
+	return nil
+}
+
+// ParseTimezone 解析时区名称，空字符串与 Local 为本地时区
+func ParseTimezone(name string) (*time.Location, error) {
+	switch strings.TrimSpace(name) {
+	case "", "Local", "local":
+		return time.Local, nil
+	}
+	if loc, err := time.LoadLocation(name); err == nil {
+		return loc, nil
+	}
+	for _, layout := range []string{"-07:00", "-0700", "-07"} {
+		if t, err := time.Parse(layout, strings.TrimPrefix(name, "UTC")); err == nil {
+			_, offset := t.Zone()
+			return time.FixedZone(name, offset), nil
+		}
+	}
+	return nil, fmt.Errorf("无效的时区: %q (IANA 名称如 Asia/Shanghai，或 UTC 偏移如 +08:00)", name)
+}
+
+// TimezoneName 当前统计时区的名称
+func TimezoneName() string {
+	return Location.String()
+}
+
+// Today 统计时区中今天的开始时间
+func Today() time.Time {
+	return PeriodDay.start(time.Now())
+}
+
+// PeriodBounds 时间所在周期的 [开始, 结束) (统计时区)
+func PeriodBounds(period Period, t time.Time) (time.Time, time.Time) {
+	start := period.start(t)
+	return start, period.next(start)
+}
+
+// rollupZone 周期快照文件名中的时区部分，本地时区为空 (沿用原有文件名)
+func rollupZone() string {
+	if Location == time.Local {
+		return ""
+	}
+	return strings.NewReplacer("/", "_", ":", "", "+", "p", "-", "m").Replace(Location.String())
+}
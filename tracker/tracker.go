@@ -1,12 +1,16 @@
 package worktracker
 
 import (
+	"crypto/ecdsa"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -28,32 +32,60 @@ const (
 
 // WorkRecord 工作记录
 type WorkRecord struct {
+	SchemaVersion int      `json:"schema_version"` // 记录格式版本，见 schema.go
 	ID           string    `json:"id"`            // 唯一ID
 	AgentID      string    `json:"agent_id"`      // Agent ID
 	TaskType     TaskType  `json:"task_type"`    // 任务类型
 	TaskDesc     string    `json:"task_desc"`     // 任务描述
+	ParentID     string    `json:"parent_id,omitempty"` // 父任务 ID (子任务)
+	Tags         []string  `json:"tags,omitempty"`      // 标签 (项目/客户等，不参与工作证明)
+	Aggregated   int       `json:"aggregated,omitempty"` // 聚合记录合并的任务数，见 ratelimit.go
+
+	// 修正: 修正记录引用原记录并保留其工作证明，原记录保持不变
+	Amends       string    `json:"amends,omitempty"`        // 被修正的记录 ID
+	AmendedProof string    `json:"amended_proof,omitempty"` // 被修正记录的工作证明哈希
+	AmendReason  string    `json:"amend_reason,omitempty"`  // 修正原因
+	AmendedAt    int64     `json:"amended_at,omitempty"`    // 修正时间
 	Status       string    `json:"status"`         // pending/completed/failed
 	StartedAt    int64     `json:"started_at"`   // 开始时间
 	CompletedAt  int64     `json:"completed_at"`  // 完成时间
+	Seq          uint64    `json:"seq,omitempty"` // 写入序号 (单调递增)，见 clock.go
+	EventKeys    []string  `json:"event_keys,omitempty"` // 产生记录的事件的去重键 (见 eventKey，不参与工作证明)
 	
 	// 工作量指标
 	TokensInput   int64     `json:"tokens_input"`   // 输入 token
 	TokensOutput  int64     `json:"tokens_output"`  // 输出 token
+	TokensCache   int64     `json:"tokens_cache,omitempty"` // 缓存读写 token (按低单价计入成本)
 	CodeLines     int       `json:"code_lines"`     // 生成代码行
 	CodeFiles     int       `json:"code_files"`     // 生成文件数
 	WordsWritten  int       `json:"words_written"`  // 文字产出
 	BugsFixed     int       `json:"bugs_fixed"`    // 修复 bug 数
 	APICalls      int       `json:"api_calls"`      // API 调用次数
 	ErrorsFixed   int       `json:"errors_fixed"`  // 错误修复数
+	LinesRemoved  int       `json:"lines_removed,omitempty"` // 删除的代码行 (按 git 提交统计时)
+	Commits       []string  `json:"commits,omitempty"`       // 归属于该任务的 git 提交 (SHA)，此时 CodeLines 为提交新增的行数
+	Repo          string    `json:"repo,omitempty"`          // 提交所在的仓库 (远程地址，无远程时为本地路径)
+	
+	// 成本 (不参与工作证明)，见 pricing.go
+	Model         string    `json:"model,omitempty"`    // 使用的模型
+	CostUSD       float64   `json:"cost_usd,omitempty"` // 结束时按价格表估算的 token 成本 (美元)
+	
+	// 质量评审 (不参与工作证明)，见 quality.go
+	QualityScore  *float64  `json:"quality_score,omitempty"` // 0~1，未评审时为空
+	ReviewedBy    string    `json:"reviewed_by,omitempty"`
+	ReviewedAt    int64     `json:"reviewed_at,omitempty"`
 	
 	// 验证
 	ProofHash    string    `json:"proof_hash"`    // 工作证明哈希
 	Signature    string    `json:"signature"`      // 签名
+	Signer       string    `json:"signer,omitempty"` // 签名钱包地址
+	BackfillNote string    `json:"backfill_note,omitempty"` // 事后补全工作证明/签名的说明，见 backfill.go
+	BackfilledAt int64     `json:"backfilled_at,omitempty"`
 }
 
 // ============ 工作量计算 ============
 
-// Weights 权重配置
+// Weights 权重配置 (可通过 RegisterTaskType 扩展，见 tasktypes.go)
 var Weights = map[TaskType]float64{
 	TaskCoding:   1.5,   // 代码生成价值高
 	TaskDebug:    2.0,   // 调试修复价值最高
@@ -65,39 +97,60 @@ var Weights = map[TaskType]float64{
 	TaskAnalysis: 1.4,   // 数据分析
 }
 
-// CalculateValue 计算工作价值
+// CalculateValue 按默认策略计算工作价值
 func (w *WorkRecord) CalculateValue() float64 {
-	// 基础价值 = 任务类型权重 × 完成状态
-	statusMultiplier := 1.0
-	if w.Status == "failed" {
-		statusMultiplier = 0.3
+	if w.Flagged() {
+		return 0 // 可疑记录被隔离，见 anomaly.go
 	}
-	
-	baseValue := Weights[w.TaskType] * statusMultiplier
-	
-	// 代码贡献
-	codeValue := float64(w.CodeLines) * 0.01
-	if w.BugsFixed > 0 {
-		codeValue += float64(w.BugsFixed) * 5.0 // 修复 bug 价值高
+	return DefaultValuator.Value(w.Metrics()) * w.qualityMultiplier()
+}
+
+// Metrics 价值计算输入
+func (w *WorkRecord) Metrics() WorkMetrics {
+	return WorkMetrics{
+		TaskType:     w.TaskType,
+		Status:       w.Status,
+		TokensInput:  w.TokensInput,
+		TokensOutput: w.TokensOutput,
+		TokensCache:  w.TokensCache,
+		CodeLines:    w.CodeLines,
+		WordsWritten: w.WordsWritten,
+		BugsFixed:    w.BugsFixed,
+		APICalls:     w.APICalls,
+		Duration:     w.Duration(),
 	}
-	
-	// 文字贡献
-	wordValue := float64(w.WordsWritten) * 0.001
-	
-	// API 调用效率
-	apiEfficiency := 0.0
-	if w.APICalls > 0 && w.Status == "completed" {
-		apiEfficiency = 1.0 / float64(w.APICalls) * 10
+}
+
+// Duration 任务耗时 (未结束或时间缺失时为 0)
+func (w *WorkRecord) Duration() time.Duration {
+	if w.StartedAt <= 0 || w.CompletedAt < w.StartedAt {
+		return 0
 	}
-	
-	// Token 消耗成本
-	tokenCost := float64(w.TokensInput+w.TokensOutput) * 0.0001
-	
-	return (baseValue + codeValue + wordValue + apiEfficiency) - tokenCost
+	return time.Duration(w.CompletedAt-w.StartedAt) * time.Millisecond
+}
+
+// HasCommit 记录是否关联该 git 提交 (sha 可为至少 4 位的前缀，不区分大小写)
+func (w *WorkRecord) HasCommit(sha string) bool {
+	sha = strings.ToLower(sha)
+	if len(sha) < 4 {
+		return false
+	}
+	for _, c := range w.Commits {
+		if strings.HasPrefix(strings.ToLower(c), sha) {
+			return true
+		}
+	}
+	return false
 }
 
 // GenerateProof 生成工作证明
 func (w *WorkRecord) GenerateProof() string {
+	w.ProofHash = w.ComputeProof()
+	return w.ProofHash
+}
+
+// ComputeProof 由记录字段计算工作证明哈希 (不修改记录)
+func (w *WorkRecord) ComputeProof() string {
 	data := fmt.Sprintf("%s|%s|%s|%s|%d|%d|%d|%d|%d|%d",
 		w.AgentID,
 		w.TaskType,
@@ -110,11 +163,30 @@ func (w *WorkRecord) GenerateProof() string {
 		w.BugsFixed,
 		w.CompletedAt,
 	)
+	if w.ParentID != "" {
+		data += "|" + w.ParentID // 仅子任务追加，已有证明保持不变
+	}
+	if w.Aggregated > 0 {
+		data += fmt.Sprintf("|aggregated:%d", w.Aggregated)
+	}
+	if w.TokensCache > 0 {
+		data += fmt.Sprintf("|cache:%d", w.TokensCache)
+	}
+	if w.Seq > 0 {
+		data += fmt.Sprintf("|seq:%d", w.Seq)
+	}
+	if w.Amends != "" {
+		data += fmt.Sprintf("|amends:%s|%s|%d", w.Amends, w.AmendedProof, w.AmendedAt)
+	}
+	if len(w.Commits) > 0 {
+		data += fmt.Sprintf("|commits:%s|removed:%d", strings.Join(w.Commits, ","), w.LinesRemoved)
+		if w.Repo != "" {
+			data += "|repo:" + w.Repo
+		}
+	}
 	
 	hash := sha256.Sum256([]byte(data))
-	w.ProofHash = hex.EncodeToString(hash[:])
-	
-	return w.ProofHash
+	return hex.EncodeToString(hash[:])
 }
 
 // ============ 工作量追踪器 ============
@@ -123,8 +195,35 @@ func (w *WorkRecord) GenerateProof() string {
 type Tracker struct {
 	mu         sync.RWMutex
 	records    map[string]*WorkRecord
+	order      []*WorkRecord            // 按时间升序的索引，见 index.go
+	byAgent    map[string][]*WorkRecord // 按 Agent 分组的时间索引
+	amendedBy  map[string]string        // 记录 ID -> 修正记录 ID
+
+	subscribers map[chan RecordEvent]struct{} // 记录事件订阅者，见 events.go
+	archivedFrom time.Time                    // 最早归档月份，见 archive.go
 	stats      *Stats
 	dataDir    string
+	signer     *ecdsa.PrivateKey // Agent 钱包私钥 (为空时记录不签名)
+	agentSigners map[string]*ecdsa.PrivateKey // 按 Agent 的钱包私钥，见 SetAgentSigner
+	proofs     map[string]string // 去重索引: 工作证明哈希 -> 记录 ID
+	events     map[string]string // 去重索引: 事件键 -> 记录 ID
+
+	// 统计持久化，见 statsfile.go
+	loadOnce     sync.Once // 记录文件延迟加载
+	files        int       // 记录文件数 (含分段中的记录)
+	segmentOf    map[string]string // 记录 ID -> 所在分段文件，见 segments.go
+	fromSnapshot bool      // 启动时统计取自 stats.json
+
+	webhooks    []Webhook // 任务事件 Webhook，见 webhook.go
+	webhookOnce sync.Once
+
+	verifiedCache map[string]bool // 签名校验结果，见 leaderboard.go
+
+	rates map[string]*rateWindow // 各 Agent 当前一分钟的限流状态，见 ratelimit.go
+
+	seq uint64 // 已分配的最大写入序号，见 clock.go
+
+	metrics metricCounters // Prometheus 计数，见 metrics.go
 }
 
 // Stats 统计数据
@@ -137,13 +236,23 @@ type Stats struct {
 	TotalWords     int            `json:"total_words"`
 	BugsFixed      int            `json:"bugs_fixed"`
 	TotalValue     float64        `json:"total_value"`
+	Subtasks       int            `json:"subtasks"`     // 其中子任务数
+	TotalDurationMs int64         `json:"total_duration_ms"` // 任务总耗时 (毫秒)
+	TotalCostUSD   float64        `json:"total_cost_usd"` // 估算 token 成本 (美元)
+	Flagged        int            `json:"flagged"`      // 被隔离的可疑记录数 (不计入以上统计)
 	ByTaskType     map[string]int `json:"by_task_type"`
+	ByAgent        map[string]AgentStats `json:"by_agent,omitempty"` // 按 Agent 分组，见 agents.go
 }
 
 // NewTracker 创建追踪器
 func NewTracker(dataDir string) (*Tracker, error) {
 	t := &Tracker{
 		records: make(map[string]*WorkRecord),
+		proofs:  make(map[string]string),
+		events:  make(map[string]string),
+		byAgent: make(map[string][]*WorkRecord),
+		amendedBy: make(map[string]string),
+		segmentOf: make(map[string]string),
 		stats: &Stats{
 			ByTaskType: make(map[string]int),
 		},
@@ -155,69 +264,172 @@ func NewTracker(dataDir string) (*Tracker, error) {
 		return nil, err
 	}
 	
-	// 加载历史记录
-	t.load()
+	// 统计快照有效时推迟加载记录，否则立即加载并重建统计
+	if !t.loadStatsSnapshot() {
+		t.ensureLoaded()
+	}
+	
+	t.loadWebhooks()
+	t.startWebhooks()
 	
 	return t, nil
 }
 
-// StartTask 开始任务
-func (t *Tracker) StartTask(agentID, taskDesc string, taskType TaskType) *WorkRecord {
+// StartTask 开始任务，可附带标签
+func (t *Tracker) StartTask(agentID, taskDesc string, taskType TaskType, tags ...string) *WorkRecord {
+	t.ensureLoaded()
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	
 	record := &WorkRecord{
-		ID:        generateID(),
+		ID:        t.newRecordID(),
 		AgentID:   agentID,
 		TaskType:  taskType,
 		TaskDesc:  taskDesc,
+		Tags:      normalizeTags(tags),
 		Status:    "pending",
 		StartedAt: time.Now().UnixMilli(),
 	}
 	
 	t.records[record.ID] = record
+	t.indexInsert(record)
+	t.countStarted(record)
+	t.publish(RecordStarted, record)
 	return record
 }
 
-// CompleteTask 完成任务
-func (t *Tracker) CompleteTask(record *WorkRecord, result TaskResult) {
+// CompleteTask 完成任务；重复完成且结果一致时忽略，其余非法状态转换返回错误
+func (t *Tracker) CompleteTask(record *WorkRecord, result TaskResult) error {
+	t.ensureLoaded()
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	
-	record.Status = "completed"
-	record.CompletedAt = time.Now().UnixMilli()
-	record.TokensInput = result.TokensInput
-	record.TokensOutput = result.TokensOutput
-	record.CodeLines = result.CodeLines
-	record.CodeFiles = result.CodeFiles
-	record.WordsWritten = result.WordsWritten
-	record.BugsFixed = result.BugsFixed
-	record.APICalls = result.APICalls
-	record.ErrorsFixed = result.ErrorsFixed
-	
-	// 生成证明
-	record.GenerateProof()
+	if done, err := t.checkTransition(record, "completed", record.sameResult(result)); done || err != nil {
+		return err
+	}
 	
-	// 更新统计
-	t.updateStats(record)
+	next := *record
+	next.Tags = append([]string(nil), record.Tags...)
+	next.Status = "completed"
+	next.CompletedAt = time.Now().UnixMilli()
+	next.TokensInput = result.TokensInput
+	next.TokensOutput = result.TokensOutput
+	next.TokensCache = result.TokensCache
+	next.CodeLines = result.CodeLines
+	next.CodeFiles = result.CodeFiles
+	next.WordsWritten = result.WordsWritten
+	next.BugsFixed = result.BugsFixed
+	next.APICalls = result.APICalls
+	next.ErrorsFixed = result.ErrorsFixed
+	next.LinesRemoved = result.LinesRemoved
+	next.Commits = append([]string(nil), result.Commits...)
+	next.Repo = result.Repo
+	if result.Model != "" {
+		next.Model = result.Model
+	}
+	next.priceCost()
+	next.AddTags(result.Tags...)
 	
-	// 持久化
-	t.save(record)
+	// 重复处理同一事件时丢弃，不重复计入统计
+	next.EventKeys = nil
+	if key := eventKey(record, result); key != "" {
+		if id, ok := t.events[key]; ok && id != record.ID {
+			t.indexRemove(record)
+			*record = next
+			record.Status = "duplicate"
+			delete(t.records, record.ID)
+			return nil
+		}
+		next.EventKeys = []string{key}
+	}
+	// 超出限流的任务合并到聚合记录
+	if t.throttled(record, next.CompletedAt) {
+		return t.aggregate(record, &next)
+	}
+	if err := t.finish(record, &next); err != nil {
+		return err
+	}
+	t.publish(RecordCompleted, record)
+	return nil
 }
 
-// FailTask 任务失败
-func (t *Tracker) FailTask(record *WorkRecord, errMsg string) {
+// FailTask 任务失败；重复标记失败时忽略，已完成的任务返回错误
+func (t *Tracker) FailTask(record *WorkRecord, errMsg string) error {
+	t.ensureLoaded()
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	
-	record.Status = "failed"
-	record.CompletedAt = time.Now().UnixMilli()
-	record.TaskDesc = record.TaskDesc + " [ERROR: " + errMsg + "]"
+	if done, err := t.checkTransition(record, "failed", true); done || err != nil {
+		return err
+	}
 	
-	t.stats.FailedTasks++
-	t.stats.TotalTasks++
+	next := *record
+	next.Status = "failed"
+	next.CompletedAt = time.Now().UnixMilli()
+	next.TaskDesc = record.TaskDesc + " [ERROR: " + errMsg + "]"
+	next.GenerateProof()
+	if err := t.finish(record, &next); err != nil {
+		return err
+	}
+	t.publish(RecordFailed, record)
+	return nil
+}
+
+// finish 分配写入序号、签名并持久化结束后的记录，写入成功后才替换内存中的记录并更新索引与统计，
+// 写入失败时记录保持原状态 (调用方需持有写锁)
+func (t *Tracker) finish(record, next *WorkRecord) error {
+	next.Seq = t.nextSeq()
+	next.GenerateProof()
+	t.sign(next)
+	if err := t.save(next); err != nil {
+		return fmt.Errorf("保存记录 %s 失败: %w", record.ID, err)
+	}
 	
-	t.save(record)
+	t.indexRemove(record)
+	*record = *next
+	t.proofs[record.ProofHash] = record.ID
+	t.indexEvents(record)
+	t.indexInsert(record)
+	t.updateStats(record)
+	t.saveStats()
+	t.countFinished(record)
+	return nil
+}
+
+// eventKey 事件的去重键: 只取事件本身的内容 (Agent、父任务、会话/任务描述、任务类型、事件时间与工具产出的工作量)，
+// 不含写入序号与完成时间，同一事件重复处理时相同。事件时间未知 (EventAt 为 0) 时无法区分相同内容的事件，不去重
+func eventKey(record *WorkRecord, result TaskResult) string {
+	if result.EventAt == 0 {
+		return ""
+	}
+	data := fmt.Sprintf("%s|%s|%s|%s|%d|%d|%d|%d|%d|%d|%d|%d|%d|%d|%d|%s|%s",
+		record.AgentID,
+		record.ParentID,
+		record.TaskDesc,
+		record.TaskType,
+		result.EventAt,
+		result.TokensInput,
+		result.TokensOutput,
+		result.TokensCache,
+		result.CodeLines,
+		result.CodeFiles,
+		result.WordsWritten,
+		result.BugsFixed,
+		result.APICalls,
+		result.ErrorsFixed,
+		result.LinesRemoved,
+		strings.Join(result.Commits, ","),
+		result.Repo,
+	)
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
+
+// indexEvents 登记记录的事件去重键 (调用方需持有写锁)
+func (t *Tracker) indexEvents(r *WorkRecord) {
+	for _, key := range r.EventKeys {
+		t.events[key] = r.ID
+	}
 }
 
 // GetStats 获取统计
@@ -225,85 +437,438 @@ func (t *Tracker) GetStats() Stats {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 	
-	return *t.stats
+	s := *t.stats
+	s.ByTaskType = make(map[string]int, len(t.stats.ByTaskType))
+	for k, v := range t.stats.ByTaskType {
+		s.ByTaskType[k] = v
+	}
+	s.ByAgent = make(map[string]AgentStats, len(t.stats.ByAgent))
+	for k, v := range t.stats.ByAgent {
+		s.ByAgent[k] = v
+	}
+	return s
 }
 
 // GetRecords 获取记录
 func (t *Tracker) GetRecords(limit int) []*WorkRecord {
+	return t.Query(RecordFilter{Limit: limit})
+}
+
+// RecordFilter 记录查询条件 (零值字段不过滤)
+type RecordFilter struct {
+	AgentID  string
+	TaskType TaskType
+	Status   string
+	ParentID string   // 仅返回该任务的直接子任务
+	Tags     []string // 须包含全部标签
+	Since    time.Time // 记录时间 >= Since
+	Until    time.Time // 记录时间 < Until
+	Limit    int
+	Signed   bool // 仅返回已签名记录
+	Latest   bool // 不返回已被修正的记录
+	Commit   string // 关联该 git 提交的记录 (SHA 或其前缀)
+
+	// 分页: Cursor 为上一页返回的 NextCursor，Offset 在游标之后再跳过的条数
+	Cursor string
+	Offset int
+}
+
+// RecordPage 分页查询结果
+type RecordPage struct {
+	Records    []*WorkRecord `json:"records"`
+	Total      int           `json:"total"`                 // 满足条件的记录总数
+	NextCursor string        `json:"next_cursor,omitempty"` // 下一页游标，为空表示没有更多
+}
+
+// Match 记录是否满足查询条件
+// 注: Latest 条件由 Tracker 判断，Match 不处理
+func (f *RecordFilter) Match(r *WorkRecord) bool {
+	if f.AgentID != "" && r.AgentID != f.AgentID {
+		return false
+	}
+	if f.TaskType != "" && r.TaskType != f.TaskType {
+		return false
+	}
+	if f.Status != "" && r.Status != f.Status {
+		return false
+	}
+	if f.ParentID != "" && r.ParentID != f.ParentID {
+		return false
+	}
+	if len(f.Tags) > 0 && !r.HasTags(f.Tags...) {
+		return false
+	}
+	if f.Signed && !r.IsSigned() {
+		return false
+	}
+	if f.Commit != "" && !r.HasCommit(f.Commit) {
+		return false
+	}
+	at := r.recordTime()
+	if !f.Since.IsZero() && at < f.Since.UnixMilli() {
+		return false
+	}
+	if !f.Until.IsZero() && at >= f.Until.UnixMilli() {
+		return false
+	}
+	return true
+}
+
+// recordTime 记录时间 (毫秒)，未完成的记录取开始时间
+func (w *WorkRecord) recordTime() int64 {
+	if w.CompletedAt > 0 {
+		return w.CompletedAt
+	}
+	return w.StartedAt
+}
+
+// recordBefore 排序: 时间倒序，同一时间按 ID 升序，保证游标稳定
+func recordBefore(a, b *WorkRecord) bool {
+	if at, bt := a.recordTime(), b.recordTime(); at != bt {
+		return at > bt
+	}
+	return a.ID < b.ID
+}
+
+// cursorOf 记录的分页游标 (<时间>-<ID>)
+func cursorOf(r *WorkRecord) string {
+	return fmt.Sprintf("%d-%s", r.recordTime(), r.ID)
+}
+
+func parseCursor(cursor string) (*WorkRecord, error) {
+	ts, id, ok := strings.Cut(cursor, "-")
+	at, err := strconv.ParseInt(ts, 10, 64)
+	if !ok || err != nil || id == "" {
+		return nil, fmt.Errorf("无效的分页游标: %s", cursor)
+	}
+	return &WorkRecord{ID: id, CompletedAt: at}, nil
+}
+
+// Query 按条件查询记录 (按时间倒序)，游标无效时返回空
+func (t *Tracker) Query(filter RecordFilter) []*WorkRecord {
+	page, err := t.QueryPage(filter)
+	if err != nil {
+		return nil
+	}
+	return page.Records
+}
+
+// QueryPage 按条件分页查询记录 (按时间倒序)
+// 游标定位到上一页最后一条记录之后，期间新增的记录不会导致重复或遗漏
+func (t *Tracker) QueryPage(filter RecordFilter) (RecordPage, error) {
+	t.ensureLoaded()
+	var page RecordPage
+	var after *WorkRecord
+	if filter.Cursor != "" {
+		var err error
+		if after, err = parseCursor(filter.Cursor); err != nil {
+			return page, err
+		}
+	}
+	if filter.Offset < 0 {
+		return page, fmt.Errorf("无效的 offset: %d", filter.Offset)
+	}
+
 	t.mu.RLock()
 	defer t.mu.RUnlock()
-	
-	records := make([]*WorkRecord, 0, len(t.records))
-	for _, r := range t.records {
-		records = append(records, r)
+
+	// 按 Agent 与时间范围缩小遍历区间 [lo, hi)
+	base := t.order
+	if filter.AgentID != "" {
+		base = t.byAgent[filter.AgentID]
 	}
-	
-	// 按时间排序
-	for i := 0; i < len(records)-1; i++ {
-		for j := i + 1; j < len(records); j++ {
-			if records[j].CompletedAt > records[i].CompletedAt {
-				records[i], records[j] = records[j], records[i]
+	lo, hi := 0, len(base)
+	if !filter.Since.IsZero() {
+		lo = timeBound(base, filter.Since.UnixMilli())
+	}
+	if !filter.Until.IsZero() {
+		hi = timeBound(base, filter.Until.UnixMilli())
+	}
+	if hi < lo {
+		hi = lo
+	}
+
+	// 游标之后的记录位于 [lo, end)
+	end := hi
+	if after != nil {
+		end = lo + sort.Search(hi-lo, func(i int) bool { return !olderThan(base[lo+i], after) })
+	}
+
+	// 区间内无其他过滤条件时总数即区间长度，只需从游标处遍历当前页；
+	// 否则需遍历整个区间统计总数
+	unfiltered := filter.isRangeOnly()
+	start := hi - 1
+	if unfiltered {
+		page.Total = hi - lo
+		start = end - 1
+	}
+
+	skip := filter.Offset
+	records := []*WorkRecord{}
+	for i := start; i >= lo; i-- {
+		r := base[i]
+		if !unfiltered {
+			if !filter.Match(r) || (filter.Latest && t.superseded(r)) {
+				continue
 			}
+			page.Total++
+			if i >= end {
+				continue
+			}
+		}
+		if skip > 0 {
+			skip--
+			continue
 		}
+		if filter.Limit > 0 && len(records) == filter.Limit {
+			if page.NextCursor == "" {
+				page.NextCursor = cursorOf(records[len(records)-1])
+			}
+			if unfiltered {
+				break
+			}
+			continue
+		}
+		records = append(records, r)
 	}
-	
-	if limit > 0 && len(records) > limit {
-		records = records[:limit]
+
+	page.Records = records
+	return page, nil
+}
+
+// isRangeOnly 是否只有索引可直接处理的条件 (Agent、时间范围与分页)
+func (f RecordFilter) isRangeOnly() bool {
+	return f.TaskType == "" && f.Status == "" && f.ParentID == "" && len(f.Tags) == 0 && !f.Signed && !f.Latest && f.Commit == ""
+}
+
+// ParseFilterTime 解析查询时间，支持 2006-01-02 (统计时区的当天开始) 与 RFC3339
+func ParseFilterTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, Location); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无法解析时间 %q (格式: 2006-01-02 或 RFC3339)", s)
+	}
+	return t, nil
+}
+
+// sign 用 Agent 钱包签名记录 (调用方需持有锁)
+func (t *Tracker) sign(r *WorkRecord) {
+	key := t.signerFor(r.AgentID)
+	if key == nil {
+		return
+	}
+	if err := r.Sign(key); err != nil {
+		fmt.Printf("⚠️ 记录 %s 签名失败: %v\n", r.ID, err)
 	}
-	
-	return records
 }
 
 func (t *Tracker) updateStats(r *WorkRecord) {
-	t.stats.TotalTasks++
-	if r.Status == "completed" {
-		t.stats.CompletedTasks++
+	t.addStats(r, 1)
+}
+
+// removeStats 撤销记录对统计的贡献 (记录被修正时)
+func (t *Tracker) removeStats(r *WorkRecord) {
+	t.addStats(r, -1)
+}
+
+func (t *Tracker) addStats(r *WorkRecord, n int) {
+	t.stats.add(r, n)
+}
+
+// add 计入 (n=1) 或撤销 (n=-1) 一条记录
+func (s *Stats) add(r *WorkRecord, n int) {
+	if r.Flagged() {
+		s.Flagged += n
+		s.addAgent(r, n)
+		return
+	}
+	s.TotalTasks += n
+	switch r.Status {
+	case "completed":
+		s.CompletedTasks += n
+	case "failed":
+		s.FailedTasks += n
+	}
+	s.TotalTokens += int64(n) * (r.TokensInput + r.TokensOutput)
+	s.TotalCodeLines += n * r.CodeLines
+	s.TotalWords += n * r.WordsWritten
+	s.BugsFixed += n * r.BugsFixed
+	s.TotalValue += float64(n) * r.CalculateValue()
+	s.TotalDurationMs += int64(n) * r.Duration().Milliseconds()
+	s.TotalCostUSD += float64(n) * r.CostUSD
+	s.ByTaskType[string(r.TaskType)] += n
+	if r.ParentID != "" {
+		s.Subtasks += n
+	}
+	s.addAgent(r, n)
+}
+
+// merge 合并另一份统计 (如归档摘要)
+func (s *Stats) merge(o Stats) {
+	s.TotalTasks += o.TotalTasks
+	s.CompletedTasks += o.CompletedTasks
+	s.FailedTasks += o.FailedTasks
+	s.TotalTokens += o.TotalTokens
+	s.TotalCodeLines += o.TotalCodeLines
+	s.TotalWords += o.TotalWords
+	s.BugsFixed += o.BugsFixed
+	s.TotalValue += o.TotalValue
+	s.Subtasks += o.Subtasks
+	s.TotalDurationMs += o.TotalDurationMs
+	s.TotalCostUSD += o.TotalCostUSD
+	s.Flagged += o.Flagged
+	for k, v := range o.ByTaskType {
+		s.ByTaskType[k] += v
+	}
+	for _, a := range o.ByAgent {
+		s.mergeAgent(a)
 	}
-	t.stats.TotalTokens += r.TokensInput + r.TokensOutput
-	t.stats.TotalCodeLines += r.CodeLines
-	t.stats.TotalWords += r.WordsWritten
-	t.stats.BugsFixed += r.BugsFixed
-	t.stats.TotalValue += r.CalculateValue()
-	t.stats.ByTaskType[string(r.TaskType)]++
 }
 
-func (t *Tracker) save(r *WorkRecord) {
+func (t *Tracker) save(r *WorkRecord) error {
 	filename := filepath.Join(t.dataDir, fmt.Sprintf("%s.json", r.ID))
-	data, _ := json.MarshalIndent(r, "", "  ")
-	os.WriteFile(filename, data, 0644)
+	r.SchemaVersion = SchemaVersion
+	_, statErr := os.Stat(filename)
+	if err := writeRecordAtomic(filename, r); err != nil {
+		t.metrics.recordSaves++
+		return err
+	}
+	if os.IsNotExist(statErr) {
+		t.files++
+	}
+	return nil
 }
 
 func (t *Tracker) load() {
+	// 已归档记录以月度摘要计入，其工作证明仍参与去重
+	archives, _ := t.loadArchives()
+	for _, a := range archives {
+		t.stats.merge(a.Summary)
+		t.observeSeq(a.LastSeq)
+		for i := 0; i < len(a.Leaves) && i < len(a.RecordIDs); i++ {
+			t.proofs[a.Leaves[i]] = a.RecordIDs[i]
+		}
+		if start, err := time.ParseInLocation("2006-01", a.Month, time.Local); err == nil {
+			if t.archivedFrom.IsZero() || start.Before(t.archivedFrom) {
+				t.archivedFrom = start
+			}
+		}
+	}
+
+	// 分段中的记录先加载，之后的单独文件是更新的版本
+	t.loadSegments()
 	files, _ := os.ReadDir(t.dataDir)
 	for _, f := range files {
-		if f.IsDir() || len(f.Name()) < 5 {
+		if f.IsDir() || !isRecordFile(f.Name()) {
 			continue
 		}
-		data, err := os.ReadFile(filepath.Join(t.dataDir, f.Name()))
+		t.files++
+		path := filepath.Join(t.dataDir, f.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		r, migrated, err := decodeRecord(data)
+		if err != nil {
+			fmt.Printf("⚠️ 跳过记录 %s: %v\n", f.Name(), err)
+			continue
+		}
+		if migrated {
+			// 升级后写回，失败时下次加载重新迁移
+			if err := writeRecordAtomic(path, r); err != nil {
+				fmt.Printf("⚠️ 记录 %s 升级后写回失败: %v\n", f.Name(), err)
+			}
+		}
+		t.addLoaded(r)
+	}
+	t.indexRebuild()
+
+	// 只统计最新版本，被修正的记录不计入
+	for _, r := range t.records {
+		if !t.superseded(r) {
+			t.updateStats(r)
+		}
+	}
+}
+
+// addLoaded 登记加载的记录 (调用方需持有写锁)；同一 ID 后加载的版本覆盖先前的版本
+func (t *Tracker) addLoaded(r *WorkRecord) {
+	if old := t.records[r.ID]; old != nil && old.ProofHash != r.ProofHash && t.proofs[old.ProofHash] == r.ID {
+		delete(t.proofs, old.ProofHash)
+	}
+	if r.ProofHash != "" {
+		if id, dup := t.proofs[r.ProofHash]; dup && id != r.ID {
+			return // 重复记录不计入统计，可用 Dedup 清理
+		}
+		t.proofs[r.ProofHash] = r.ID
+	}
+	t.records[r.ID] = r
+	t.indexEvents(r)
+	t.observeSeq(r.Seq)
+	if r.Amends != "" {
+		t.amendedBy[r.Amends] = r.ID
+	}
+}
+
+// Dedup 删除磁盘上与已索引记录工作证明重复的记录文件，返回删除数量
+func (t *Tracker) Dedup() (int, error) {
+	t.ensureLoaded()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	files, err := os.ReadDir(t.dataDir)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, f := range files {
+		if f.IsDir() || !isRecordFile(f.Name()) {
+			continue
+		}
+		path := filepath.Join(t.dataDir, f.Name())
+		data, err := os.ReadFile(path)
 		if err != nil {
 			continue
 		}
 		var r WorkRecord
-		if json.Unmarshal(data, &r) == nil {
-			t.records[r.ID] = &r
-			t.updateStats(&r)
+		if json.Unmarshal(data, &r) != nil || r.ProofHash == "" {
+			continue
 		}
+		if id := t.proofs[r.ProofHash]; id != "" && (id != r.ID || f.Name() != r.ID+".json") {
+			if err := os.Remove(path); err != nil {
+				return removed, err
+			}
+			t.files--
+			removed++
+		}
+	}
+	if removed > 0 {
+		t.saveStats()
 	}
+	return removed, nil
 }
 
 // TaskResult 任务结果
 type TaskResult struct {
 	TokensInput   int64
 	TokensOutput  int64
+	TokensCache   int64 // 缓存读写 token
 	CodeLines     int
 	CodeFiles     int
 	WordsWritten  int
 	BugsFixed     int
 	APICalls     int
 	ErrorsFixed   int
-}
-
-func generateID() string {
-	hash := sha256.Sum256([]byte(time.Now().String()))
-	return hex.EncodeToString(hash[:])[:16]
+	LinesRemoved  int      // 删除的代码行 (按 git 提交统计时)
+	Commits       []string // 归属于该任务的 git 提交
+	Repo          string   // 提交所在的仓库
+	Model         string   // 使用的模型 (用于估算成本)
+	Tags          []string // 完成时追加的标签
+	EventAt       int64    // 事件时间 (毫秒)，非 0 时同一事件重复完成只记录一次，见 eventKey
 }
@@ -0,0 +1,50 @@
+package worktracker
+
+import "testing"
+
+// 同一事件重复完成时只记录一次 (重启后同样去重)，事件时间不同的相同内容分别记录
+func TestCompleteTaskDeduplicatesEvent(t *testing.T) {
+	dir := t.TempDir()
+	tr, err := NewTracker(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := TaskResult{TokensInput: 1200, TokensOutput: 300, CodeLines: 40, APICalls: 2, EventAt: 1700000000000}
+
+	complete := func(tr *Tracker, result TaskResult) *WorkRecord {
+		t.Helper()
+		record := tr.StartTask("main", "Session: s1", TaskCoding)
+		if err := tr.CompleteTask(record, result); err != nil {
+			t.Fatal(err)
+		}
+		return record
+	}
+
+	first := complete(tr, result)
+	if first.Status != "completed" {
+		t.Fatalf("首次完成状态为 %q，期望 completed", first.Status)
+	}
+	if second := complete(tr, result); second.Status != "duplicate" {
+		t.Fatalf("重复完成状态为 %q，期望 duplicate", second.Status)
+	}
+	if got := tr.GetStats().CompletedTasks; got != 1 {
+		t.Fatalf("完成任务数为 %d，期望 1", got)
+	}
+
+	later := result
+	later.EventAt += 1000
+	if r := complete(tr, later); r.Status != "completed" {
+		t.Fatalf("不同事件的状态为 %q，期望 completed", r.Status)
+	}
+
+	reloaded, err := NewTracker(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := complete(reloaded, result); r.Status != "duplicate" {
+		t.Fatalf("重新加载后重复完成状态为 %q，期望 duplicate", r.Status)
+	}
+	if got := reloaded.GetStats().CompletedTasks; got != 2 {
+		t.Fatalf("重新加载后完成任务数为 %d，期望 2", got)
+	}
+}
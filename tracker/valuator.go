@@ -0,0 +1,267 @@
+package worktracker
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============ 价值计算策略 ============
+
+// WorkMetrics 价值计算输入 (追踪器记录与 OpenClaw 会话记录统一转换为此结构)
+type WorkMetrics struct {
+	TaskType     TaskType
+	Kind         string // OpenClaw 会话类型 (direct/interactive/cron/subagent/webhook)
+	Status       string
+	TokensInput  int64
+	TokensOutput int64
+	TokensCache  int64 // 缓存读写 token
+	CodeLines    int
+	WordsWritten int
+	BugsFixed    int
+	APICalls     int
+	Duration     time.Duration // 任务耗时 (未知时为 0)
+}
+
+// Valuator 工作价值计算策略
+type Valuator interface {
+	Value(m WorkMetrics) float64
+	String() string // 策略描述，可由 ParseValuator 解析
+}
+
+// DefaultValuator 默认策略: token 价值 + 代码/文字产出价值
+// OpenClaw 会话记录没有代码产出指标，结果与 token 策略一致
+var DefaultValuator Valuator = CompositeValuator{
+	{Weight: 1, Valuator: TokenValuator{}},
+	{Weight: 1, Valuator: LineValuator{}},
+}
+
+// statusMultiplier 失败任务按 30% 计价
+func statusMultiplier(status string) float64 {
+	if status == "failed" {
+		return 0.3
+	}
+	return 1.0
+}
+
+// 默认 token 单价
+const (
+	DefaultOutputTokenValue = 0.1    // 每个输出 token 的价值
+	DefaultInputTokenCost   = 0.001  // 每个输入 token 的成本
+	DefaultCacheTokenCost   = 0.0001 // 每个缓存读写 token 的成本 (远低于普通输入)
+)
+
+// KindBonuses 按会话类型的价值加成 (config.json 的 kind_bonuses)，未配置的类型不加成
+var KindBonuses = map[string]float64{"cron": 1.5}
+
+// SetKindBonus 设置会话类型的价值加成 (倍数，1 为不加成)
+func SetKindBonus(kind string, bonus float64) error {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if kind == "" {
+		return fmt.Errorf("会话类型不能为空")
+	}
+	if bonus < 0 || math.IsNaN(bonus) || math.IsInf(bonus, 0) {
+		return fmt.Errorf("会话类型 %s 的价值加成无效: %v", kind, bonus)
+	}
+	KindBonuses[kind] = bonus
+	return nil
+}
+
+// kindBonus 会话类型的价值加成
+func kindBonus(kind string) float64 {
+	if bonus, ok := KindBonuses[kind]; ok {
+		return bonus
+	}
+	return 1.0
+}
+
+// TokenValuator 按 token 计价:
+// 输出 token = AI 创造的价值，输入 token 与缓存 token = 消耗的成本，按会话类型加成 (见 KindBonuses，默认定时任务 1.5 倍)。
+// 单价为 0 时使用默认单价 (按模型覆盖单价见 openclaw.ModelValues)
+type TokenValuator struct {
+	OutputRate float64
+	InputRate  float64
+	CacheRate  float64
+}
+
+func (t TokenValuator) Value(m WorkMetrics) float64 {
+	outRate, inRate, cacheRate := t.OutputRate, t.InputRate, t.CacheRate
+	if outRate == 0 {
+		outRate = DefaultOutputTokenValue
+	}
+	if inRate == 0 {
+		inRate = DefaultInputTokenCost
+	}
+	if cacheRate == 0 {
+		cacheRate = DefaultCacheTokenCost
+	}
+	outputValue := float64(m.TokensOutput) * outRate
+	inputCost := float64(m.TokensInput)*inRate + float64(m.TokensCache)*cacheRate
+
+	return (outputValue - inputCost) * kindBonus(m.Kind)
+}
+
+// String 单价不体现在描述中 (由模型配置决定，不随记录保存)
+func (TokenValuator) String() string { return "token" }
+
+// WithTokenRates 返回将策略中的 token 计价替换为指定单价的策略 (组合策略逐项替换)
+func WithTokenRates(v Valuator, rates TokenValuator) Valuator {
+	switch x := v.(type) {
+	case TokenValuator:
+		return rates
+	case CompositeValuator:
+		c := make(CompositeValuator, len(x))
+		for i, p := range x {
+			c[i] = WeightedValuator{Weight: p.Weight, Valuator: WithTokenRates(p.Valuator, rates)}
+		}
+		return c
+	}
+	return v
+}
+
+// LineValuator 按产出计价: 任务类型权重 + 代码行 + 修复 bug + 文字 + API 调用效率
+type LineValuator struct{}
+
+func (LineValuator) Value(m WorkMetrics) float64 {
+	baseValue := Weights[m.TaskType] * statusMultiplier(m.Status)
+
+	codeValue := float64(m.CodeLines) * 0.01
+	codeValue += float64(m.BugsFixed) * 5.0 // 修复 bug 价值高
+
+	wordValue := float64(m.WordsWritten) * 0.001
+
+	apiEfficiency := 0.0
+	if m.APICalls > 0 && m.Status == "completed" {
+		apiEfficiency = 1.0 / float64(m.APICalls) * 10
+	}
+
+	return baseValue + codeValue + wordValue + apiEfficiency
+}
+
+func (LineValuator) String() string { return "lines" }
+
+// FlatValuator 每个任务固定价值
+type FlatValuator struct {
+	Rate float64
+}
+
+func (f FlatValuator) Value(m WorkMetrics) float64 {
+	return f.Rate * statusMultiplier(m.Status)
+}
+
+func (f FlatValuator) String() string {
+	return "flat:" + strconv.FormatFloat(f.Rate, 'f', -1, 64)
+}
+
+// maxValuedDuration 耗时计价上限，避免遗忘结束的任务获得过高价值
+const maxValuedDuration = 8 * time.Hour
+
+// DurationValuator 按耗时计价: 每小时 Rate (上限 8 小时)，
+// 通常与其他策略组合使用，如 "token+lines+duration:0.5"
+type DurationValuator struct {
+	Rate float64
+}
+
+func (d DurationValuator) Value(m WorkMetrics) float64 {
+	duration := m.Duration
+	if duration > maxValuedDuration {
+		duration = maxValuedDuration
+	}
+	return d.Rate * duration.Hours() * statusMultiplier(m.Status)
+}
+
+func (d DurationValuator) String() string {
+	return "duration:" + strconv.FormatFloat(d.Rate, 'f', -1, 64)
+}
+
+// WeightedValuator 组合策略中的一项
+type WeightedValuator struct {
+	Weight   float64
+	Valuator Valuator
+}
+
+// CompositeValuator 组合策略: 各策略结果加权求和
+type CompositeValuator []WeightedValuator
+
+func (c CompositeValuator) Value(m WorkMetrics) float64 {
+	var total float64
+	for _, p := range c {
+		total += p.Weight * p.Valuator.Value(m)
+	}
+	return total
+}
+
+func (c CompositeValuator) String() string {
+	parts := make([]string, len(c))
+	for i, p := range c {
+		if p.Weight == 1 {
+			parts[i] = p.Valuator.String()
+		} else {
+			parts[i] = strconv.FormatFloat(p.Weight, 'f', -1, 64) + "*" + p.Valuator.String()
+		}
+	}
+	return strings.Join(parts, "+")
+}
+
+// ParseValuator 解析策略描述:
+//
+//	token | lines | flat[:<每任务价值>] | duration[:<每小时价值>] | composite (默认组合)
+//	组合: 用 + 连接，可带权重，如 "0.5*token+lines+2*flat:1"
+func ParseValuator(spec string) (Valuator, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "composite" {
+		return DefaultValuator, nil
+	}
+
+	if strings.Contains(spec, "+") || strings.Contains(spec, "*") {
+		var c CompositeValuator
+		for _, part := range strings.Split(spec, "+") {
+			weight := 1.0
+			if w, name, ok := strings.Cut(part, "*"); ok {
+				var err error
+				if weight, err = strconv.ParseFloat(strings.TrimSpace(w), 64); err != nil {
+					return nil, fmt.Errorf("无效的策略权重: %s", w)
+				}
+				part = name
+			}
+			v, err := parseSingleValuator(strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, WeightedValuator{Weight: weight, Valuator: v})
+		}
+		return c, nil
+	}
+	return parseSingleValuator(spec)
+}
+
+func parseSingleValuator(spec string) (Valuator, error) {
+	name, arg, _ := strings.Cut(spec, ":")
+	switch name {
+	case "token":
+		return TokenValuator{}, nil
+	case "lines":
+		return LineValuator{}, nil
+	case "flat":
+		rate := 1.0
+		if arg != "" {
+			var err error
+			if rate, err = strconv.ParseFloat(arg, 64); err != nil {
+				return nil, fmt.Errorf("无效的固定价值: %s", arg)
+			}
+		}
+		return FlatValuator{Rate: rate}, nil
+	case "duration":
+		rate := 1.0
+		if arg != "" {
+			var err error
+			if rate, err = strconv.ParseFloat(arg, 64); err != nil {
+				return nil, fmt.Errorf("无效的每小时价值: %s", arg)
+			}
+		}
+		return DurationValuator{Rate: rate}, nil
+	}
+	return nil, fmt.Errorf("未知的价值策略: %s (可选: token/lines/flat/duration/composite)", spec)
+}
@@ -0,0 +1,183 @@
+package worktracker
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ============ 任务事件 Webhook ============
+
+const webhookFile = "webhooks.json"
+
+// WebhookRetries 投递失败 (网络错误、5xx、429) 后的重试次数，间隔 1s、2s、4s...
+var WebhookRetries = 3
+
+const (
+	webhookBackoff = time.Second
+	webhookTimeout = 10 * time.Second
+)
+
+// Webhook 任务事件回调: 以 POST 发送 RecordEvent JSON
+// 设置 Secret 时附带 X-OAW-Signature: sha256=<HMAC-SHA256(body)>
+type Webhook struct {
+	URL    string            `json:"url"`
+	Secret string            `json:"secret,omitempty"`
+	Events []RecordEventType `json:"events,omitempty"` // 为空时为 completed 与 failed
+}
+
+// wants 是否订阅该事件
+func (h Webhook) wants(e RecordEventType) bool {
+	if len(h.Events) == 0 {
+		return e == RecordCompleted || e == RecordFailed
+	}
+	for _, want := range h.Events {
+		if want == e {
+			return true
+		}
+	}
+	return false
+}
+
+// SignWebhook 计算 Webhook 签名 (接收方用相同密钥校验 X-OAW-Signature)
+func SignWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Webhooks 已配置的 Webhook
+func (t *Tracker) Webhooks() []Webhook {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return append([]Webhook(nil), t.webhooks...)
+}
+
+// AddWebhook 添加 Webhook 并开始投递
+func (t *Tracker) AddWebhook(h Webhook) error {
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("无效的 webhook 地址: %s", h.URL)
+	}
+	for _, e := range h.Events {
+		switch e {
+		case RecordStarted, RecordCompleted, RecordFailed, RecordAmended, RecordReviewed:
+		default:
+			return fmt.Errorf("未知的事件类型: %s (可选: started/completed/failed/amended/reviewed)", e)
+		}
+	}
+
+	t.mu.Lock()
+	t.webhooks = append(t.webhooks, h)
+	err = t.saveWebhooks()
+	t.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	t.startWebhooks()
+	return nil
+}
+
+// RemoveWebhook 按序号移除 Webhook
+func (t *Tracker) RemoveWebhook(i int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if i < 0 || i >= len(t.webhooks) {
+		return fmt.Errorf("序号超出范围: %d", i)
+	}
+	t.webhooks = append(t.webhooks[:i], t.webhooks[i+1:]...)
+	return t.saveWebhooks()
+}
+
+func (t *Tracker) loadWebhooks() {
+	data, err := os.ReadFile(filepath.Join(t.dataDir, webhookFile))
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &t.webhooks); err != nil {
+		fmt.Printf("⚠️ 解析 %s 失败: %v\n", webhookFile, err)
+	}
+}
+
+// saveWebhooks 保存配置 (调用方需持有写锁)
+func (t *Tracker) saveWebhooks() error {
+	return writeJSONAtomic(filepath.Join(t.dataDir, webhookFile), t.webhooks)
+}
+
+// startWebhooks 订阅记录事件并投递到 Webhook (只启动一次，未配置时不启动)
+func (t *Tracker) startWebhooks() {
+	if len(t.Webhooks()) == 0 {
+		return
+	}
+	t.webhookOnce.Do(func() {
+		events, _ := t.Subscribe()
+		go func() {
+			for event := range events {
+				for _, h := range t.Webhooks() {
+					if h.wants(event.Type) {
+						go deliverWebhook(h, event)
+					}
+				}
+			}
+		}()
+	})
+}
+
+// deliverWebhook 投递事件，失败时按指数退避重试
+func deliverWebhook(h Webhook, event RecordEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	delay := webhookBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := postWebhook(h, event, body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= WebhookRetries {
+			fmt.Printf("⚠️ webhook %s 投递失败 (记录 %s): %v\n", h.URL, event.Record.ID, err)
+			return
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// postWebhook 发送一次，返回失败时是否值得重试
+func postWebhook(h Webhook, event RecordEvent, body []byte) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-OAW-Event", string(event.Type))
+	req.Header.Set("X-OAW-Delivery", fmt.Sprintf("%s-%s", event.Record.ID, event.Type))
+	if h.Secret != "" {
+		req.Header.Set("X-OAW-Signature", SignWebhook(h.Secret, body))
+	}
+
+	client := &http.Client{Timeout: webhookTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return true, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return false, fmt.Errorf("HTTP %d", resp.StatusCode)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// balanceEpsilon 余额比较的浮点容差
+const balanceEpsilon = 1e-9
+
+// txSigningHash 交易签名摘要 (包含 nonce)
+func txSigningHash(tx Transaction) []byte {
+	data := fmt.Sprintf("%s|%s|%f|%s|%d|%d", tx.From, tx.To, tx.Amount, tx.Memo, tx.Timestamp, tx.Nonce)
+	hash := sha256.Sum256([]byte(data))
+	return hash[:]
+}
+
+// VerifyTransaction 校验交易哈希与签名者为转出地址
+func VerifyTransaction(tx Transaction) error {
+	hash := txSigningHash(tx)
+	if tx.Hash != "0x"+hex.EncodeToString(hash) {
+		return fmt.Errorf("交易 %s 哈希不匹配", tx.Hash)
+	}
+
+	sig, err := hex.DecodeString(strings.TrimPrefix(tx.Signature, "0x"))
+	if err != nil {
+		return fmt.Errorf("交易 %s 签名格式错误: %w", tx.Hash, err)
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return fmt.Errorf("交易 %s 签名恢复失败: %w", tx.Hash, err)
+	}
+	if signer := crypto.PubkeyToAddress(*pub).Hex(); !strings.EqualFold(signer, tx.From) {
+		return fmt.Errorf("交易 %s 签名者 %s 与转出地址 %s 不一致", tx.Hash, signer, tx.From)
+	}
+	return nil
+}
+
+// txLedger 由区块链推导的账户余额与 nonce，用于校验区块交易
+type txLedger struct {
+	balances map[string]float64
+	nonces   map[string]uint64
+	seen     map[string]bool
+}
+
+// newTxLedger 从快照与区块重放账本
+func newTxLedger(blocks []Block, snapshot *ChainSnapshot) *txLedger {
+	l := &txLedger{
+		balances: map[string]float64{},
+		nonces:   map[string]uint64{},
+		seen:     map[string]bool{},
+	}
+	if snapshot != nil {
+		for addr, v := range snapshot.Balances {
+			l.balances[addr] += v
+		}
+		for addr, v := range snapshot.Ledger {
+			l.balances[addr] += v
+		}
+		for addr, n := range snapshot.Nonces {
+			l.nonces[addr] = n
+		}
+	}
+
+	for _, b := range blocks {
+		if snapshot.covers(b) {
+			// 已计入快照，只登记交易哈希防止重放
+			for _, tx := range b.Transactions {
+				l.seen[tx.Hash] = true
+			}
+			continue
+		}
+		if payee := b.Payee(); payee != "" {
+			l.balances[payee] += b.Value
+		}
+		for _, tx := range b.Transactions {
+			l.balances[tx.From] -= tx.Amount
+			l.balances[tx.To] += tx.Amount
+			l.nonces[tx.From] = tx.Nonce + 1
+			l.seen[tx.Hash] = true
+		}
+	}
+	return l
+}
+
+// apply 校验交易 (签名、重复、nonce、余额) 并记入账本
+func (l *txLedger) apply(tx Transaction) error {
+	if err := VerifyTransaction(tx); err != nil {
+		return err
+	}
+	if l.seen[tx.Hash] {
+		return fmt.Errorf("交易 %s 重复", tx.Hash)
+	}
+	if tx.Amount <= 0 {
+		return fmt.Errorf("交易 %s 金额无效", tx.Hash)
+	}
+	if want := l.nonces[tx.From]; tx.Nonce != want {
+		return fmt.Errorf("交易 %s nonce 错误 (期望 %d, 实际 %d)，可能为双花", tx.Hash, want, tx.Nonce)
+	}
+	if l.balances[tx.From]+balanceEpsilon < tx.Amount {
+		return fmt.Errorf("交易 %s 余额不足", tx.Hash)
+	}
+
+	l.balances[tx.From] -= tx.Amount
+	l.balances[tx.To] += tx.Amount
+	l.nonces[tx.From] = tx.Nonce + 1
+	l.seen[tx.Hash] = true
+	return nil
+}
+
+// validateBlockTxs 校验区块内交易可在当前链上依次执行
+func (m *Miner) validateBlockTxs(b Block) error {
+	l := newTxLedger(m.blocks, m.snapshot)
+	for _, tx := range b.Transactions {
+		if err := l.apply(tx); err != nil {
+			return fmt.Errorf("区块 #%d: %w", b.Index, err)
+		}
+	}
+	return nil
+}
+
+// pendingTransactions 选出本地链状态中尚未上链且可执行的交易 (调用方需持有锁)
+func (m *Miner) pendingTransactions() []Transaction {
+	state, err := LoadChainState(m.dataDir)
+	if err != nil {
+		return nil
+	}
+
+	l := newTxLedger(m.blocks, m.snapshot)
+	var txs []Transaction
+	for _, tx := range state.Transactions {
+		if l.seen[tx.Hash] {
+			continue
+		}
+		if l.apply(tx) == nil {
+			txs = append(txs, tx)
+		}
+	}
+	return txs
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// signTestTx 构造并签名 from 转给 to 的交易
+func signTestTx(t *testing.T, from testSigner, to string, amount float64, nonce uint64) Transaction {
+	t.Helper()
+	tx := Transaction{From: from.Address, To: to, Amount: amount, Memo: "test", Timestamp: 1_700_000_000, Nonce: nonce}
+	hash := txSigningHash(tx)
+	tx.Hash = "0x" + hex.EncodeToString(hash)
+	key, err := crypto.HexToECDSA(from.Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := crypto.Sign(hash, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Signature = "0x" + hex.EncodeToString(sig)
+	return tx
+}
+
+func TestValidateBlockTxs(t *testing.T) {
+	s, a, b := newTestSigner(t), newTestSigner(t), newTestSigner(t)
+	chain := testChain(t, s, 1_700_000_000, 10, 3) // s 的奖励 30
+	spent := signTestTx(t, s, a.Address, 5, 0)
+	withSpent := append(append([]Block(nil), chain...), Block{Index: len(chain), Transactions: []Transaction{spent}})
+
+	forged := signTestTx(t, a, b.Address, 1, 0)
+	forged.From = s.Address
+	impostor := signTestTx(t, testSigner{Address: s.Address, Private: a.Private}, b.Address, 1, 0)
+	tampered := signTestTx(t, s, a.Address, 1, 0)
+	tampered.Amount = 20
+
+	tests := []struct {
+		name    string
+		prior   []Block
+		txs     []Transaction
+		wantErr string
+	}{
+		{"有效转账", chain, []Transaction{signTestTx(t, s, a.Address, 10, 0)}, ""},
+		{"连续 nonce", chain, []Transaction{signTestTx(t, s, a.Address, 10, 0), signTestTx(t, s, b.Address, 10, 1)}, ""},
+		{"同一 nonce 转给两个地址 (双花)", chain,
+			[]Transaction{signTestTx(t, s, a.Address, 20, 0), signTestTx(t, s, b.Address, 20, 0)}, "nonce 错误"},
+		{"区块内重复交易", chain, []Transaction{spent, spent}, "重复"},
+		{"重放已上链的交易", withSpent, []Transaction{spent}, "重复"},
+		{"复用已上链的 nonce", withSpent, []Transaction{signTestTx(t, s, b.Address, 5, 0)}, "nonce 错误"},
+		{"已上链交易之后的 nonce", withSpent, []Transaction{signTestTx(t, s, b.Address, 5, 1)}, ""},
+		{"跳过 nonce", chain, []Transaction{signTestTx(t, s, a.Address, 1, 1)}, "nonce 错误"},
+		{"余额不足", chain, []Transaction{signTestTx(t, s, a.Address, 31, 0)}, "余额不足"},
+		{"花费已转出的余额", withSpent, []Transaction{signTestTx(t, s, b.Address, 26, 1)}, "余额不足"},
+		{"没有余额的账户", chain, []Transaction{signTestTx(t, a, b.Address, 1, 0)}, "余额不足"},
+		{"改写转出地址", chain, []Transaction{forged}, "哈希不匹配"},
+		{"他人私钥签名", chain, []Transaction{impostor}, "签名者"},
+		{"篡改金额", chain, []Transaction{tampered}, "哈希不匹配"},
+		{"金额为 0", chain, []Transaction{signTestTx(t, s, a.Address, 0, 0)}, "金额无效"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Miner{blocks: tt.prior}
+			checkErr(t, m.validateBlockTxs(Block{Index: len(tt.prior), Transactions: tt.txs}), tt.wantErr)
+		})
+	}
+}
+
+func TestAcceptBlockRejectsDoubleSpend(t *testing.T) {
+	s, a, b := newTestSigner(t), newTestSigner(t), newTestSigner(t)
+	chain := testChain(t, s, 1_700_000_000, 10, 3)
+	m := &Miner{blocks: append([]Block(nil), chain...), dataDir: t.TempDir()}
+
+	withTxs := func(prior []Block, timestamp int64, txs ...Transaction) Block {
+		blk := minePoWBlock(t, prior, timestamp, s)
+		blk.Transactions = txs
+		if err := SignBlock(&blk, s.Private); err != nil {
+			t.Fatal(err)
+		}
+		return blk
+	}
+
+	first := withTxs(m.blocks, 1_700_000_030, signTestTx(t, s, a.Address, 25, 0))
+	checkErr(t, m.AcceptBlock(first), "")
+
+	// 同一 nonce 的另一笔转账与超出余额的转账都被拒绝
+	checkErr(t, m.AcceptBlock(withTxs(m.blocks, 1_700_000_040, signTestTx(t, s, b.Address, 25, 0))), "nonce 错误")
+	checkErr(t, m.AcceptBlock(withTxs(m.blocks, 1_700_000_040, signTestTx(t, s, b.Address, 25, 1))), "余额不足")
+	checkErr(t, m.AcceptBlock(withTxs(m.blocks, 1_700_000_040, first.Transactions[0])), "重复")
+	if len(m.blocks) != len(chain)+1 {
+		t.Fatalf("链高度 %d，期望 %d", len(m.blocks), len(chain)+1)
+	}
+
+	// 接收的交易写入链状态，nonce 推进
+	state, err := LoadChainState(m.dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Nonces[s.Address] != 1 || !state.HasTx(first.Transactions[0].Hash) {
+		t.Fatalf("链状态 nonce %d，期望 1", state.Nonces[s.Address])
+	}
+}
+
+func TestChainStateSaveAtomic(t *testing.T) {
+	dir := t.TempDir()
+	s, a := newTestSigner(t), newTestSigner(t)
+	state, err := LoadChainState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := state.ApplyRemote([]Transaction{signTestTx(t, s, a.Address, 1, 0), signTestTx(t, s, a.Address, 1, 1)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "state.json.tmp")); !os.IsNotExist(err) {
+		t.Fatalf("临时文件未重命名: %v", err)
+	}
+
+	// 残留的不完整临时文件不影响已保存的状态
+	os.WriteFile(filepath.Join(dir, "state.json.tmp"), []byte(`{"nonces":`), 0644)
+	loaded, err := LoadChainState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Nonces[s.Address] != 2 || len(loaded.Transactions) != 2 {
+		t.Fatalf("重新读取的 nonce %d、交易 %d 条，期望 2 与 2", loaded.Nonces[s.Address], len(loaded.Transactions))
+	}
+}
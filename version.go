@@ -0,0 +1,66 @@
+package main
+
+import "fmt"
+
+// CurrentBlockVersion 本节点出块使用的区块头版本
+const CurrentBlockVersion = 2
+
+// PoWHashVersion 区块哈希可由区块头重算的最低版本 (版本 2 起 PoW 哈希覆盖区块头与随机数，见 blockPoWHash)。
+// 更早的区块无法验证工作量，只在本地链中保留，不再从对等节点接收
+const PoWHashVersion = 2
+
+// blockVersionActivation 区块头版本激活表：高度 ≥ Height 的区块须使用 ≥ Version 的版本
+// 新增区块头字段 (如 Merkle 根) 时追加一项并提升 CurrentBlockVersion，旧区块不受影响
+var blockVersionActivation = []struct {
+	Version int
+	Height  int
+}{
+	{Version: 1, Height: 0}, // 矿工签名区块
+}
+
+// blockVersionRules 各版本的附加校验规则
+var blockVersionRules = map[int]func(b Block) error{
+	1: func(b Block) error {
+		if b.Hash == "" {
+			return fmt.Errorf("区块 #%d 缺少哈希", b.Index)
+		}
+		return nil
+	},
+}
+
+// effectiveVersion 未标注版本的旧区块视为版本 1
+func (b Block) effectiveVersion() int {
+	if b.Version == 0 {
+		return 1
+	}
+	return b.Version
+}
+
+// requiredBlockVersion 指定高度要求的最低区块头版本
+func requiredBlockVersion(height int) int {
+	required := 1
+	for _, a := range blockVersionActivation {
+		if height >= a.Height && a.Version > required {
+			required = a.Version
+		}
+	}
+	return required
+}
+
+// validateBlockVersion 校验区块头版本满足激活高度，并执行该版本及以下的规则；
+// 高于本节点已知的版本视为软升级，只校验已知规则
+func validateBlockVersion(b Block) error {
+	v := b.effectiveVersion()
+	if required := requiredBlockVersion(b.Index); v < required {
+		return fmt.Errorf("区块 #%d 版本 %d 低于激活要求 %d", b.Index, v, required)
+	}
+	for version, rule := range blockVersionRules {
+		if version > v {
+			continue
+		}
+		if err := rule(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	worktracker "oaw/tracker"
+)
+
+// ============ 实时工作面板 (oaw watch) ============
+
+// 面板参数
+const (
+	watchRecent  = 12               // 显示的最近事件数
+	watchWindow  = time.Minute      // 滚动统计窗口
+	watchRefresh = time.Second      // 刷新间隔
+	watchIdle    = 10 * time.Minute // Agent 行在无事件超过该时长后变暗
+)
+
+// watchSample 窗口内一次完成记录的用量
+type watchSample struct {
+	at     time.Time
+	agent  string
+	tokens int64
+	value  float64
+}
+
+// watchAgent 面板中一个 Agent 的累计
+type watchAgent struct {
+	ID       string
+	Tasks    int
+	Failed   int
+	Tokens   int64
+	Value    float64
+	LastSeen time.Time
+}
+
+// dashboard 面板状态，由记录事件累积
+type dashboard struct {
+	started time.Time
+	source  string
+	recent  []worktracker.RecordEvent
+	window  []watchSample
+	agents  map[string]*watchAgent
+	tokens  int64
+	value   float64
+	pending int
+}
+
+func newDashboard(source string) *dashboard {
+	return &dashboard{started: time.Now(), source: source, agents: map[string]*watchAgent{}}
+}
+
+// add 累积一条记录事件
+func (d *dashboard) add(e worktracker.RecordEvent, now time.Time) {
+	d.recent = append(d.recent, e)
+	if len(d.recent) > watchRecent {
+		d.recent = d.recent[len(d.recent)-watchRecent:]
+	}
+
+	r := &e.Record
+	a, ok := d.agents[r.AgentID]
+	if !ok {
+		a = &watchAgent{ID: r.AgentID}
+		d.agents[r.AgentID] = a
+	}
+	a.LastSeen = now
+
+	switch e.Type {
+	case worktracker.RecordStarted:
+		d.pending++
+	case worktracker.RecordCompleted, worktracker.RecordFailed:
+		if d.pending > 0 {
+			d.pending--
+		}
+		tokens := r.TokensInput + r.TokensOutput
+		value := r.CalculateValue()
+		a.Tasks++
+		if e.Type == worktracker.RecordFailed {
+			a.Failed++
+		}
+		a.Tokens += tokens
+		a.Value += value
+		d.tokens += tokens
+		d.value += value
+		d.window = append(d.window, watchSample{at: now, agent: r.AgentID, tokens: tokens, value: value})
+	}
+}
+
+// rolling 窗口内的 token 与价值 (agent 为空时为全部)，同时丢弃过期样本
+func (d *dashboard) rolling(now time.Time, agent string) (tokens int64, value float64) {
+	cut := 0
+	for cut < len(d.window) && now.Sub(d.window[cut].at) > watchWindow {
+		cut++
+	}
+	d.window = d.window[cut:]
+	for _, s := range d.window {
+		if agent == "" || s.agent == agent {
+			tokens += s.tokens
+			value += s.value
+		}
+	}
+	return tokens, value
+}
+
+// render 重绘面板 (ANSI 清屏后整体输出)
+func (d *dashboard) render(w io.Writer, now time.Time) {
+	var b strings.Builder
+	b.WriteString("\033[H\033[2J")
+	fmt.Fprintf(&b, "OAW 实时工作面板  %s  来源: %s  运行 %s  (Ctrl+C 退出)\n\n",
+		now.Format("15:04:05"), d.source, now.Sub(d.started).Round(time.Second))
+
+	tokens, value := d.rolling(now, "")
+	fmt.Fprintf(&b, "近 1 分钟: %d token/分钟  %.2f OAW/分钟    累计: %d token  %.2f OAW    进行中: %d\n\n",
+		tokens, value, d.tokens, d.value, d.pending)
+
+	agents := make([]*watchAgent, 0, len(d.agents))
+	for _, a := range d.agents {
+		agents = append(agents, a)
+	}
+	sort.Slice(agents, func(i, j int) bool {
+		if agents[i].Value != agents[j].Value {
+			return agents[i].Value > agents[j].Value
+		}
+		return agents[i].ID < agents[j].ID
+	})
+	fmt.Fprintf(&b, "%-24s %6s %6s %12s %10s %12s %10s\n", "Agent", "任务", "失败", "token", "价值", "token/分钟", "最近")
+	for _, a := range agents {
+		t, _ := d.rolling(now, a.ID)
+		line := fmt.Sprintf("%-24s %6d %6d %12d %10.2f %12d %10s",
+			truncate(a.ID, 24), a.Tasks, a.Failed, a.Tokens, a.Value, t, a.LastSeen.Format("15:04:05"))
+		if now.Sub(a.LastSeen) > watchIdle {
+			line = "\033[2m" + line + "\033[0m"
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString("\n最近事件:\n")
+	if len(d.recent) == 0 {
+		b.WriteString("  (等待事件...)\n")
+	}
+	for i := len(d.recent) - 1; i >= 0; i-- {
+		e := d.recent[i]
+		r := e.Record
+		fmt.Fprintf(&b, "  %s  %-9s %-16s %-10s %8d token  %s\n",
+			time.UnixMilli(e.Time).Format("15:04:05"), e.Type, truncate(r.AgentID, 16), r.TaskType,
+			r.TokensInput+r.TokensOutput, truncate(r.TaskDesc, 40))
+	}
+	io.WriteString(w, b.String())
+}
+
+// truncate 截断过长的字段
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+// runDashboard 消费事件并定时重绘，直到 ctx 取消或事件通道关闭
+func runDashboard(ctx context.Context, w io.Writer, source string, events <-chan worktracker.RecordEvent) {
+	d := newDashboard(source)
+	ticker := time.NewTicker(watchRefresh)
+	defer ticker.Stop()
+	d.render(w, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				d.render(w, time.Now())
+				return
+			}
+			d.add(e, time.Now())
+		case <-ticker.C:
+			d.render(w, time.Now())
+		}
+	}
+}
+
+// streamEvents 订阅 API 服务的 /api/events (SSE)，将记录事件写入通道，连接断开时关闭通道
+func streamEvents(ctx context.Context, baseURL string) (<-chan worktracker.RecordEvent, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(baseURL, "/")+"/api/events", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s 返回 %s", req.URL, resp.Status)
+	}
+
+	ch := make(chan worktracker.RecordEvent, 64)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1<<20)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			var e worktracker.RecordEvent
+			if json.Unmarshal([]byte(data), &e) != nil {
+				continue
+			}
+			select {
+			case ch <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}